@@ -1,16 +1,14 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/skbhati199/go-web-build/internal/cmd"
 )
 
 func main() {
+	// Execute reports errors itself, in the format selected by --output.
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
 }
@@ -0,0 +1,346 @@
+// Package analyze attributes the bytes of a build's scripts and stylesheets
+// to the original sources and npm packages they came from, using the
+// build's source maps.
+package analyze
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
+)
+
+// Pseudo packages for bytes that do not come from node_modules.
+const (
+	// ProjectPackage groups the project's own sources.
+	ProjectPackage = "(project)"
+	// UnmappedSource holds bytes no mapping covers, such as the module
+	// runtime, or whole files without a source map.
+	UnmappedSource = "(unmapped)"
+)
+
+// Report is the size breakdown of a build. Gzip sizes of sources are their
+// standalone compressed sizes, scaled so that the sources of a chunk add up
+// to the compressed size of the chunk.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Dir         string    `json:"dir"`
+	Bytes       int64     `json:"bytes"`
+	Gzip        int64     `json:"gzip"`
+	Chunks      []Chunk   `json:"chunks"`
+	// Packages is sorted by Bytes, largest first.
+	Packages []Package `json:"packages"`
+}
+
+// Chunk is one script or stylesheet of the build. Error explains why its
+// source map could not be used; all its bytes are then unmapped.
+type Chunk struct {
+	File    string   `json:"file"`
+	Map     string   `json:"map,omitempty"`
+	Bytes   int64    `json:"bytes"`
+	Gzip    int64    `json:"gzip"`
+	Sources []Source `json:"sources"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Source is the share of a chunk that came from one original file.
+type Source struct {
+	Path    string `json:"path"`
+	Package string `json:"package"`
+	Bytes   int64  `json:"bytes"`
+	Gzip    int64  `json:"gzip"`
+}
+
+// Package totals the sources of one npm package across all chunks.
+type Package struct {
+	Name    string   `json:"name"`
+	Bytes   int64    `json:"bytes"`
+	Gzip    int64    `json:"gzip"`
+	Sources int      `json:"sources"`
+	Chunks  []string `json:"chunks"`
+}
+
+// analyzedExtensions are the output files attributed to sources.
+var analyzedExtensions = map[string]bool{".js": true, ".mjs": true, ".css": true}
+
+// Analyze reads every script and stylesheet below dir together with the
+// source map named by its sourceMappingURL comment.
+func Analyze(dir string) (*Report, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && analyzedExtensions[filepath.Ext(p)] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no scripts or stylesheets found in %s", dir)
+	}
+	sort.Strings(files)
+
+	report := &Report{GeneratedAt: time.Now(), Dir: dir}
+	for _, file := range files {
+		chunk, err := analyzeFile(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		report.Bytes += chunk.Bytes
+		report.Gzip += chunk.Gzip
+		report.Chunks = append(report.Chunks, *chunk)
+	}
+	report.Packages = packages(report.Chunks)
+	return report, nil
+}
+
+func analyzeFile(dir, file string) (*Chunk, error) {
+	code, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	chunk := &Chunk{
+		File:  filepath.ToSlash(rel),
+		Bytes: int64(len(code)),
+		Gzip:  gzipSize(code),
+	}
+
+	content := map[string]*bytes.Buffer{}
+	consumer, mapName, err := loadMap(file, code)
+	if err != nil {
+		chunk.Error = err.Error()
+	}
+	chunk.Map = mapName
+	if consumer == nil {
+		content[UnmappedSource] = bytes.NewBuffer(code)
+	} else {
+		attribute(code, consumer, content)
+	}
+
+	var gzipTotal int64
+	for name, buf := range content {
+		src := Source{Path: name, Package: packageName(name), Bytes: int64(buf.Len()), Gzip: gzipSize(buf.Bytes())}
+		gzipTotal += src.Gzip
+		chunk.Sources = append(chunk.Sources, src)
+	}
+	sort.Slice(chunk.Sources, func(i, j int) bool {
+		a, b := chunk.Sources[i], chunk.Sources[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Path < b.Path
+	})
+	scaleGzip(chunk.Sources, gzipTotal, chunk.Gzip)
+	return chunk, nil
+}
+
+// loadMap returns a consumer for the map referenced by code, and the map's
+// name. A nil consumer without an error means the file has no map.
+func loadMap(file string, code []byte) (*sourcemap.Consumer, string, error) {
+	url := sourcemap.MappingURL(string(code))
+	if url == "" {
+		return nil, "", nil
+	}
+
+	var data []byte
+	name := url
+	if strings.HasPrefix(url, "data:") {
+		name = "(inline)"
+		comma := strings.IndexByte(url, ',')
+		if comma < 0 || !strings.HasSuffix(url[:comma], ";base64") {
+			return nil, name, fmt.Errorf("unsupported inline source map")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(url[comma+1:])
+		if err != nil {
+			return nil, name, fmt.Errorf("invalid inline source map: %w", err)
+		}
+		data = decoded
+	} else {
+		var err error
+		data, err = os.ReadFile(filepath.Join(filepath.Dir(file), filepath.FromSlash(url)))
+		if err != nil {
+			return nil, name, fmt.Errorf("failed to read source map: %w", err)
+		}
+	}
+
+	var sm sourcemap.SourceMap
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return nil, name, fmt.Errorf("invalid source map: %w", err)
+	}
+	consumer, err := sourcemap.NewConsumer(&sm)
+	if err != nil {
+		return nil, name, err
+	}
+	return consumer, name, nil
+}
+
+// attribute splits code into the byte ranges covered by each mapping
+// segment. A segment covers its line up to the next segment; line breaks
+// belong to the last segment of their line.
+func attribute(code []byte, c *sourcemap.Consumer, content map[string]*bytes.Buffer) {
+	add := func(source string, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		buf, ok := content[source]
+		if !ok {
+			buf = &bytes.Buffer{}
+			content[source] = buf
+		}
+		buf.Write(data)
+	}
+
+	for lineNo, start := 0, 0; start < len(code); lineNo++ {
+		end := bytes.IndexByte(code[start:], '\n')
+		if end < 0 {
+			end = len(code)
+		} else {
+			end += start + 1
+		}
+		line := code[start:end]
+		start = end
+
+		source, offset := UnmappedSource, 0
+		cursor := columnCursor{line: line}
+		for _, seg := range c.Segments(lineNo) {
+			pos := cursor.offset(seg.GeneratedColumn)
+			if pos > offset {
+				add(source, line[offset:pos])
+				offset = pos
+			}
+			source = UnmappedSource
+			if seg.Source >= 0 {
+				source = cleanSource(c.SourcePath(seg.Source))
+			}
+		}
+		add(source, line[offset:])
+	}
+}
+
+// columnCursor converts source map columns, counted in UTF-16 code units,
+// to byte offsets in a line. Columns must be queried in increasing order.
+type columnCursor struct {
+	line  []byte
+	pos   int
+	units int
+}
+
+func (c *columnCursor) offset(column int) int {
+	for c.pos < len(c.line) && c.units < column {
+		r, size := utf8.DecodeRune(c.line[c.pos:])
+		c.units++
+		if r >= 0x10000 {
+			c.units++
+		}
+		c.pos += size
+	}
+	return c.pos
+}
+
+// cleanSource strips URL schemes and relative prefixes that bundlers add to
+// source paths, such as "webpack:///./src/app.js".
+func cleanSource(source string) string {
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+	}
+	source = path.Clean("/" + source)[1:]
+	if source == "" {
+		return UnmappedSource
+	}
+	return source
+}
+
+// packageName returns the npm package a source belongs to, including the
+// scope, or ProjectPackage.
+func packageName(source string) string {
+	if source == UnmappedSource {
+		return UnmappedSource
+	}
+	i := strings.LastIndex(source, "node_modules/")
+	if i < 0 {
+		return ProjectPackage
+	}
+	parts := strings.SplitN(source[i+len("node_modules/"):], "/", 3)
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+func gzipSize(data []byte) int64 {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	return int64(buf.Len())
+}
+
+// scaleGzip distributes total over sources in proportion to their standalone
+// gzip sizes, keeping the rounded values summing to total.
+func scaleGzip(sources []Source, standalone, total int64) {
+	if standalone == 0 {
+		return
+	}
+	var assigned int64
+	for i := range sources {
+		if i == len(sources)-1 {
+			sources[i].Gzip = total - assigned
+			break
+		}
+		sources[i].Gzip = sources[i].Gzip * total / standalone
+		assigned += sources[i].Gzip
+	}
+}
+
+func packages(chunks []Chunk) []Package {
+	byName := map[string]*Package{}
+	files := map[string]map[string]bool{}
+	for _, chunk := range chunks {
+		for _, src := range chunk.Sources {
+			pkg, ok := byName[src.Package]
+			if !ok {
+				pkg = &Package{Name: src.Package}
+				byName[src.Package] = pkg
+				files[src.Package] = map[string]bool{}
+			}
+			pkg.Bytes += src.Bytes
+			pkg.Gzip += src.Gzip
+			files[src.Package][src.Path] = true
+			if n := len(pkg.Chunks); n == 0 || pkg.Chunks[n-1] != chunk.File {
+				pkg.Chunks = append(pkg.Chunks, chunk.File)
+			}
+		}
+	}
+
+	result := make([]Package, 0, len(byName))
+	for name, pkg := range byName {
+		pkg.Sources = len(files[name])
+		result = append(result, *pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
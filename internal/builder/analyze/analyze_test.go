@@ -0,0 +1,88 @@
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
+)
+
+func TestAnalyze(t *testing.T) {
+	// Line 1 is runtime, line 2 is "app();" from src/app.js followed by
+	// "lib();" from a scoped package.
+	code := "var r={};\napp();lib();\n"
+	gen := sourcemap.NewGenerator("main.js")
+	app := gen.AddSource("webpack:///./src/app.js", "app();")
+	lib := gen.AddSource("node_modules/@scope/lib/index.js", "lib();")
+	gen.AddMapping(sourcemap.Mapping{GeneratedLine: 1, GeneratedColumn: 0, Source: app, Name: -1})
+	gen.AddMapping(sourcemap.Mapping{GeneratedLine: 1, GeneratedColumn: 6, Source: lib, Name: -1})
+	data, err := json.Marshal(gen.SourceMap(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "assets"), 0755)
+	os.WriteFile(filepath.Join(dir, "assets", "main.js"), []byte(code+"//# sourceMappingURL=main.js.map\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "assets", "main.js.map"), data, 0644)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("a{color:red}"), 0644)
+
+	report, err := Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(report.Chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %+v", report.Chunks)
+	}
+
+	sizes := map[string]int64{}
+	var total, gzipTotal int64
+	for _, src := range report.Chunks[0].Sources {
+		sizes[src.Path] = src.Bytes
+		total += src.Bytes
+		gzipTotal += src.Gzip
+	}
+	if sizes["src/app.js"] != 6 || sizes["node_modules/@scope/lib/index.js"] != 7 {
+		t.Errorf("Unexpected attribution: %v", sizes)
+	}
+	if total != report.Chunks[0].Bytes || gzipTotal != report.Chunks[0].Gzip {
+		t.Errorf("Sources add up to %d/%d bytes, chunk has %d/%d", total, gzipTotal, report.Chunks[0].Bytes, report.Chunks[0].Gzip)
+	}
+
+	names := map[string]bool{}
+	for _, p := range report.Packages {
+		names[p.Name] = true
+	}
+	for _, want := range []string{"@scope/lib", ProjectPackage, UnmappedSource} {
+		if !names[want] {
+			t.Errorf("Missing package %s in %+v", want, report.Packages)
+		}
+	}
+
+	var html bytes.Buffer
+	if err := WriteHTML(&html, report); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	if !strings.Contains(html.String(), `"name":"@scope/lib"`) {
+		t.Error("Treemap data missing from HTML report")
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	tests := map[string]string{
+		"src/index.js":                          ProjectPackage,
+		"node_modules/react/index.js":           "react",
+		"node_modules/@babel/runtime/helper.js": "@babel/runtime",
+		"node_modules/a/node_modules/b/x.js":    "b",
+		UnmappedSource:                          UnmappedSource,
+	}
+	for source, want := range tests {
+		if got := packageName(source); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// WriteJSON writes r as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// node is an entry of the treemap: the build, a chunk, a package or a
+// source file.
+type node struct {
+	Name     string  `json:"name"`
+	Bytes    int64   `json:"bytes"`
+	Gzip     int64   `json:"gzip"`
+	Children []*node `json:"children,omitempty"`
+}
+
+// tree groups the sources of every chunk by package.
+func tree(r *Report) *node {
+	root := &node{Name: r.Dir, Bytes: r.Bytes, Gzip: r.Gzip}
+	for _, chunk := range r.Chunks {
+		c := &node{Name: chunk.File, Bytes: chunk.Bytes, Gzip: chunk.Gzip}
+		byPackage := map[string]*node{}
+		for _, src := range chunk.Sources {
+			pkg, ok := byPackage[src.Package]
+			if !ok {
+				pkg = &node{Name: src.Package}
+				byPackage[src.Package] = pkg
+				c.Children = append(c.Children, pkg)
+			}
+			pkg.Bytes += src.Bytes
+			pkg.Gzip += src.Gzip
+			pkg.Children = append(pkg.Children, &node{Name: src.Path, Bytes: src.Bytes, Gzip: src.Gzip})
+		}
+		sort.SliceStable(c.Children, func(i, j int) bool { return c.Children[i].Bytes > c.Children[j].Bytes })
+		root.Children = append(root.Children, c)
+	}
+	return root
+}
+
+// WriteHTML writes a self-contained page with a zoomable treemap of r and
+// the package table.
+func WriteHTML(w io.Writer, r *Report) error {
+	data, err := json.Marshal(tree(r))
+	if err != nil {
+		return err
+	}
+	// json.Marshal escapes <, > and &, so the data cannot close the script.
+	return htmlReport.Execute(w, struct {
+		*Report
+		Tree template.JS
+	}{r, template.JS(data)})
+}
+
+var htmlReport = template.Must(template.New("analyze").Funcs(template.FuncMap{
+	"size": formatSize,
+	"date": func(r *Report) string { return r.GeneratedAt.Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Bundle analysis</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 1.5rem; color: #222; }
+#controls { margin: .5rem 0; }
+#crumbs a { cursor: pointer; color: #0b5cad; }
+#map { position: relative; height: 70vh; border: 1px solid #ccc; overflow: hidden; }
+.box { position: absolute; box-sizing: border-box; border: 1px solid #fff; overflow: hidden; cursor: pointer; font-size: 12px; }
+.box > span { display: block; padding: 2px 4px; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+.box .box { border-color: rgba(255,255,255,.6); }
+table { border-collapse: collapse; margin-top: 1.5rem; }
+th, td { border: 1px solid #ddd; padding: .3rem .6rem; text-align: left; }
+td.num, th.num { text-align: right; }
+th { background: #f5f5f5; }
+</style>
+</head>
+<body>
+<h1>Bundle analysis</h1>
+<p>{{.Dir}}: {{size .Bytes}} minified, {{size .Gzip}} gzip, {{len .Chunks}} file(s). Generated {{date .Report}}.</p>
+<div id="controls">
+Size: <label><input type="radio" name="metric" value="bytes" checked> minified</label>
+<label><input type="radio" name="metric" value="gzip"> gzip</label>
+&nbsp; <span id="crumbs"></span>
+</div>
+<div id="map"></div>
+<h2>Packages</h2>
+<table>
+<tr><th>Package</th><th class="num">Minified</th><th class="num">Gzip</th><th class="num">Files</th><th>Chunks</th></tr>
+{{- range .Packages}}
+<tr><td>{{.Name}}</td><td class="num">{{size .Bytes}}</td><td class="num">{{size .Gzip}}</td><td class="num">{{.Sources}}</td><td>{{range $i, $c := .Chunks}}{{if $i}}, {{end}}{{$c}}{{end}}</td></tr>
+{{- end}}
+</table>
+<script>
+(function () {
+  var root = {{.Tree}};
+  var metric = "bytes", path = [root];
+  var map = document.getElementById("map"), crumbs = document.getElementById("crumbs");
+
+  function size(n) {
+    if (n < 1024) return n + " B";
+    var units = ["KB", "MB", "GB"], i = -1;
+    do { n /= 1024; i++; } while (n >= 1024 && i < units.length - 1);
+    return n.toFixed(1) + " " + units[i];
+  }
+
+  function color(name) {
+    var h = 0;
+    for (var i = 0; i < name.length; i++) h = (h * 31 + name.charCodeAt(i)) % 360;
+    return "hsl(" + h + ", 55%, 72%)";
+  }
+
+  function worst(row, sum, side) {
+    var max = Math.max.apply(null, row), min = Math.min.apply(null, row);
+    return Math.max(side * side * max / (sum * sum), (sum * sum) / (side * side * min));
+  }
+
+  // squarify lays items out in rect with the squarified treemap algorithm.
+  function squarify(items, rect) {
+    var total = 0, out = [];
+    items.forEach(function (n) { total += n[metric]; });
+    var r = {x: rect.x, y: rect.y, w: rect.w, h: rect.h}, i = 0;
+    while (i < items.length && total > 0 && r.w > 0 && r.h > 0) {
+      var scale = r.w * r.h / total, side = Math.min(r.w, r.h);
+      var row = [], areas = [], sum = 0;
+      while (i < items.length) {
+        var a = items[i][metric] * scale;
+        if (row.length && worst(areas.concat(a), sum + a, side) > worst(areas, sum, side)) break;
+        row.push(items[i]); areas.push(a); sum += a; i++;
+      }
+      var wide = r.w >= r.h, t = sum / (wide ? r.h : r.w), offset = 0;
+      row.forEach(function (n, k) {
+        var len = areas[k] / t;
+        out.push({node: n, x: wide ? r.x : r.x + offset, y: wide ? r.y + offset : r.y, w: wide ? t : len, h: wide ? len : t});
+        offset += len;
+      });
+      row.forEach(function (n) { total -= n[metric]; });
+      if (wide) { r.x += t; r.w -= t; } else { r.y += t; r.h -= t; }
+    }
+    return out;
+  }
+
+  function visible(n) {
+    return (n.children || []).filter(function (c) { return c[metric] > 0; })
+      .sort(function (a, b) { return b[metric] - a[metric]; });
+  }
+
+  function draw(parent, n, rect, depth) {
+    squarify(visible(n), rect).forEach(function (b) {
+      var el = document.createElement("div");
+      el.className = "box";
+      el.style.left = b.x + "px"; el.style.top = b.y + "px";
+      el.style.width = b.w + "px"; el.style.height = b.h + "px";
+      el.style.background = color(depth ? b.node.name : n.name + "/" + b.node.name);
+      el.title = b.node.name + "\n" + size(b.node.bytes) + " minified, " + size(b.node.gzip) + " gzip";
+      var label = document.createElement("span");
+      label.textContent = b.node.name + " " + size(b.node[metric]);
+      el.appendChild(label);
+      el.onclick = function (e) {
+        e.stopPropagation();
+        if (b.node.children) { path = path.concat(depth ? [n, b.node] : [b.node]); render(); }
+      };
+      parent.appendChild(el);
+      if (depth === 0 && b.node.children && b.w > 40 && b.h > 40) {
+        draw(el, b.node, {x: 0, y: 18, w: b.w - 2, h: b.h - 20}, 1);
+      }
+    });
+  }
+
+  function render() {
+    var current = path[path.length - 1];
+    map.innerHTML = "";
+    draw(map, current, {x: 0, y: 0, w: map.clientWidth, h: map.clientHeight}, 0);
+    crumbs.innerHTML = "";
+    path.forEach(function (n, i) {
+      if (i) crumbs.appendChild(document.createTextNode(" / "));
+      var a = document.createElement("a");
+      a.textContent = n.name;
+      a.onclick = function () { path = path.slice(0, i + 1); render(); };
+      crumbs.appendChild(a);
+    });
+  }
+
+  document.querySelectorAll("input[name=metric]").forEach(function (input) {
+    input.onchange = function () { metric = input.value; render(); };
+  });
+  window.onresize = render;
+  render();
+})();
+</script>
+</body>
+</html>
+`))
+
+// formatSize renders a byte count with binary units.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,50 +2,713 @@ package builder
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
+	"github.com/skbhati199/go-web-build/internal/builder/bundler"
+	"github.com/skbhati199/go-web-build/internal/builder/compress"
+	"github.com/skbhati199/go-web-build/internal/builder/env"
+	"github.com/skbhati199/go-web-build/internal/builder/images"
+	"github.com/skbhati199/go-web-build/internal/builder/naming"
+	"github.com/skbhati199/go-web-build/internal/builder/parallel"
+	"github.com/skbhati199/go-web-build/internal/pkg/cache"
+	"github.com/skbhati199/go-web-build/pkg/utils"
 )
 
-type Builder struct {
-	sourceMapBuilder *sourcemap.SourceMapBuilder
-}
+type Builder struct{}
 
 type Options struct {
 	Mode    string
 	OutDir  string
 	Config  string
 	BaseDir string
+
+	Minify    bool
+	SourceMap bool
+	// PublicPath is the URL prefix the output is served under. Defaults to
+	// "/".
+	PublicPath string
+	// Entries overrides entry point discovery. Paths are relative to BaseDir.
+	Entries []string
+	// HTML lists the HTML entries, relative to BaseDir. Empty uses
+	// public/index.html when it exists.
+	HTML []string
+	// MinifyHTML minifies the HTML entries.
+	MinifyHTML bool
+	// Environment selects the .env.<environment> file layered over .env
+	// (see package env).
+	Environment string
+	// EnvPrefixes mark the environment variables exposed to client code.
+	// Empty uses env.DefaultPrefixes.
+	EnvPrefixes []string
+	// EnvDeclaration is where TypeScript projects get a declaration of the
+	// exposed variables, relative to BaseDir. Empty disables it.
+	EnvDeclaration string
+	// Naming holds the output name patterns of production builds.
+	Naming Naming
+	// Jobs bounds how many files are processed at once. Zero uses one job
+	// per CPU.
+	Jobs int
+	// CacheDir is where module analysis and minified code are kept between
+	// builds. Relative paths are resolved against BaseDir; empty disables
+	// caching.
+	CacheDir string
+	// Images, when set, optimizes imported PNG and JPEG images (see package
+	// images). Results are cached in CacheDir.
+	Images *images.Options
+	// Compression, when set, writes gzip and Brotli siblings of the outputs
+	// (see package compress).
+	Compression *compress.Options
+	// Split, when set, splits the scripts at import() expressions and moves
+	// modules shared by several chunks to chunks of their own.
+	Split *bundler.SplitOptions
+	// TreeShake leaves unused exports and the modules nothing needs out of
+	// the bundle.
+	TreeShake bool
 }
 
-func New() *Builder {
-	return &Builder{
-		sourceMapBuilder: sourcemap.NewSourceMapBuilder(&sourcemap.SourceMapConfig{
-			Type:           sourcemap.ExternalType,
-			Mode:           sourcemap.ProductionMode,
-			IncludeContent: true,
-			SourceRoot:     "/src",
-		}),
+// Naming holds output name patterns, relative to the output directory, for
+// the placeholders described in package naming. Empty patterns use
+// DefaultNaming.
+type Naming struct {
+	Entry string
+	// Chunk names the async and shared chunks of code splitting.
+	Chunk string
+	CSS   string
+	Asset string
+}
+
+// DefaultNaming is the content hashed layout of production builds.
+var DefaultNaming = Naming{
+	Entry: scriptDir + "/[name].[hash:8][ext]",
+	Chunk: scriptDir + "/[name].[hash:8][ext]",
+	CSS:   styleDir + "/[name].[hash:8][ext]",
+	Asset: mediaDir + "/[name].[hash:8][ext]",
+}
+
+// withDefaults fills in empty patterns and validates the result.
+func (n Naming) withDefaults() (Naming, error) {
+	for _, p := range []struct {
+		pattern *string
+		def     string
+	}{{&n.Entry, DefaultNaming.Entry}, {&n.Chunk, DefaultNaming.Chunk}, {&n.CSS, DefaultNaming.CSS}, {&n.Asset, DefaultNaming.Asset}} {
+		if *p.pattern == "" {
+			*p.pattern = p.def
+		}
+		if err := naming.Validate(*p.pattern); err != nil {
+			return n, err
+		}
 	}
+	return n, nil
+}
+
+// Result summarises a finished build.
+type Result struct {
+	Mode    string
+	OutDir  string
+	Modules int
+	// Cache reports how much work was reused from CacheDir.
+	Cache   bundler.CacheStats
+	Outputs []OutputFile
+	// Images summarises image optimization.
+	Images ImageStats
+	// Compressed lists the precompressed siblings written for Outputs.
+	Compressed []compress.Result
+	// Removed lists what tree shaking left out.
+	Removed []bundler.Removal
+	// Manifest is set for production builds, which use hashed names.
+	Manifest Manifest
+	Duration time.Duration
+}
+
+// OutputFile is a file written by the build. Path is relative to OutDir.
+type OutputFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Output layout, relative to the output directory.
+const (
+	scriptDir    = "assets"
+	styleDir     = "static/css"
+	mediaDir     = "static/media"
+	sourceMapDir = "sourcemaps"
+	publicDir    = "public"
+)
+
+// entryCandidates are tried in order when no entries are configured.
+var entryCandidates = []string{"src/index", "src/main", "index"}
+
+var entryExtensions = []string{".tsx", ".ts", ".jsx", ".js", ".mjs"}
+
+func New() *Builder {
+	return &Builder{}
 }
 
 func (b *Builder) Build(ctx context.Context, opts Options) error {
+	_, err := b.Run(ctx, opts)
+	return err
+}
+
+// Run builds the project and reports what was written.
+func (b *Builder) Run(ctx context.Context, opts Options) (*Result, error) {
+	start := time.Now()
+
 	// Setup build directory
-	buildDir := filepath.Join(opts.BaseDir, opts.OutDir)
+	buildDir := opts.OutDir
+	if !filepath.IsAbs(buildDir) {
+		buildDir = filepath.Join(opts.BaseDir, opts.OutDir)
+	}
 
 	// Configure build based on mode
+	var result *Result
+	var err error
+	if opts.Mode == "production" {
+		result, err = b.buildProduction(ctx, buildDir, opts)
+	} else {
+		result, err = b.buildDevelopment(ctx, buildDir, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+func (b *Builder) buildDevelopment(ctx context.Context, buildDir string, opts Options) (*Result, error) {
+	opts.Mode = "development"
+	return b.bundle(ctx, buildDir, opts)
+}
+
+func (b *Builder) buildProduction(ctx context.Context, buildDir string, opts Options) (*Result, error) {
+	return b.bundle(ctx, buildDir, opts)
+}
+
+func (b *Builder) bundle(ctx context.Context, buildDir string, opts Options) (*Result, error) {
+	baseDir, err := filepath.Abs(opts.BaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	if opts.PublicPath == "" {
+		opts.PublicPath = "/"
+	}
+	pages, err := loadPages(baseDir, opts.HTML)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := pageEntries(pages, func() ([]bundler.Entry, error) {
+		return discoverEntries(baseDir, opts.Entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vars, err := env.Load(baseDir, opts.Environment, opts.EnvPrefixes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment files: %w", err)
+	}
+	if err := writeEnvDeclaration(baseDir, opts.EnvDeclaration, vars); err != nil {
+		return nil, err
+	}
+
+	// Production output is content hashed and described by a manifest.
+	var names *Naming
 	if opts.Mode == "production" {
-		return b.buildProduction(ctx, buildDir, opts)
+		n, err := opts.Naming.withDefaults()
+		if err != nil {
+			return nil, err
+		}
+		names = &n
+	}
+
+	bundlerOpts := bundler.Options{
+		BaseDir:    baseDir,
+		Mode:       opts.Mode,
+		Minify:     opts.Minify,
+		SourceMap:  opts.SourceMap,
+		PublicPath: opts.PublicPath,
+		MediaDir:   mediaDir,
+		Define:     env.Defines(vars, opts.Mode, opts.PublicPath),
+		Jobs:       opts.Jobs,
+		Split:      opts.Split,
+		TreeShake:  opts.TreeShake,
+	}
+	if names != nil {
+		bundlerOpts.AssetNames = names.Asset
+	}
+	var buildCache bundler.Cache
+	if opts.CacheDir != "" {
+		dir := opts.CacheDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		c, err := cache.OpenDiskCache(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open build cache (run gobuild cache clear to reset it): %w", err)
+		}
+		defer c.Close()
+		buildCache = c
+		bundlerOpts.Cache = c
+	}
+	bundled, err := bundler.New(bundlerOpts).Bundle(ctx, entries)
+	if err != nil {
+		return nil, fmt.Errorf("bundling failed: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := emptyOutDir(buildDir, baseDir); err != nil {
+		return nil, fmt.Errorf("failed to clean output directory: %w", err)
+	}
+	if err := utils.EnsureDir(buildDir); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	result := &Result{Mode: opts.Mode, OutDir: buildDir, Modules: len(bundled.Modules), Cache: bundled.Cache, Removed: bundled.Removed}
+	w := &outputWriter{
+		dir:        buildDir,
+		result:     result,
+		names:      names,
+		publicPath: opts.PublicPath,
+		refs:       urlRewriter{},
+		chunks:     make(map[string]chunkFiles),
+		images:     opts.Images,
+		cache:      buildCache,
+	}
+	if names != nil {
+		result.Manifest = Manifest{}
+	}
+
+	public := filepath.Join(baseDir, publicDir)
+	if err := w.copyPublic(public); err != nil {
+		return nil, err
+	}
+	// Chunks are written after all media, since their styles refer to the
+	// hashed media names.
+	err = parallel.Run(ctx, opts.Jobs, len(bundled.Media), func(ctx context.Context, i int) error {
+		return w.writeMedia(bundled.Media[i], baseDir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Entry chunks are written last, since they embed the URLs of the
+	// chunks they load.
+	var chunks, entryChunks []*bundler.Chunk
+	for _, chunk := range bundled.Chunks {
+		if chunk.Kind == bundler.ChunkEntry {
+			entryChunks = append(entryChunks, chunk)
+		} else {
+			chunks = append(chunks, chunk)
+		}
+	}
+	for _, phase := range [][]*bundler.Chunk{chunks, entryChunks} {
+		err = parallel.Run(ctx, opts.Jobs, len(phase), func(ctx context.Context, i int) error {
+			return w.writeChunk(phase[i], opts.Minify)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if result.Manifest != nil {
+		if err := w.rewritePublic(public); err != nil {
+			return nil, err
+		}
+	}
+	values := pagePlaceholders(opts, vars)
+	for _, page := range pages {
+		if err := w.writePage(page, values, opts.MinifyHTML); err != nil {
+			return nil, err
+		}
+	}
+	if result.Manifest != nil {
+		if err := w.writeManifest(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(result.Outputs, func(i, j int) bool { return result.Outputs[i].Path < result.Outputs[j].Path })
+	if opts.Compression != nil {
+		files := make([]string, len(result.Outputs))
+		for i, out := range result.Outputs {
+			files[i] = out.Path
+		}
+		c := *opts.Compression
+		if c.Jobs == 0 {
+			c.Jobs = opts.Jobs
+		}
+		result.Compressed, err = compress.Files(ctx, buildDir, files, c)
+		if err != nil {
+			return nil, fmt.Errorf("compression failed: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// emptyOutDir removes the previous build from dir, so hashed files of
+// earlier builds, their source maps and precompressed siblings do not pile
+// up. A directory containing the project is left alone.
+func emptyOutDir(dir, baseDir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(dir, baseDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
 	}
-	return b.buildDevelopment(ctx, buildDir, opts)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEnvDeclaration writes the TypeScript declaration of the exposed
+// environment variables to file, if the project has a tsconfig.json. The
+// file is only rewritten when its content changes, so watchers are not
+// triggered by every build.
+func writeEnvDeclaration(baseDir, file string, vars map[string]string) error {
+	if file == "" || !utils.FileExists(filepath.Join(baseDir, "tsconfig.json")) {
+		return nil
+	}
+	path := filepath.Join(baseDir, filepath.FromSlash(file))
+	content := []byte(env.Declaration(vars))
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == string(content) {
+		return nil
+	}
+	if err := utils.EnsureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", file, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
+
+// discoverEntries resolves configured entries, or finds the conventional
+// src/index, src/main or index module. A discovered entry is named "main".
+func discoverEntries(baseDir string, configured []string) ([]bundler.Entry, error) {
+	if len(configured) > 0 {
+		entries := make([]bundler.Entry, 0, len(configured))
+		for _, path := range configured {
+			full := filepath.Join(baseDir, path)
+			if !utils.FileExists(full) {
+				return nil, fmt.Errorf("entry point %s does not exist", path)
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			entries = append(entries, bundler.Entry{Name: name, Path: full})
+		}
+		return entries, nil
+	}
+
+	for _, candidate := range entryCandidates {
+		for _, ext := range entryExtensions {
+			full := filepath.Join(baseDir, filepath.FromSlash(candidate)+ext)
+			if utils.FileExists(full) {
+				return []bundler.Entry{{Name: "main", Path: full}}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no entry point found in %s (expected src/index.js or similar)", baseDir)
+}
+
+// outputWriter writes build artifacts below dir and records them in result.
+// With names set, outputs get hashed names and are added to the manifest.
+// Its methods may run concurrently; mu guards the result, refs and chunks.
+type outputWriter struct {
+	dir        string
+	result     *Result
+	names      *Naming
+	publicPath string
+	mu         sync.Mutex
+	refs       urlRewriter
+	chunks     map[string]chunkFiles
+	images     *images.Options
+	cache      bundler.Cache
 }
 
-func (b *Builder) buildDevelopment(ctx context.Context, buildDir string, opts Options) error {
-	// Development build implementation
+func (w *outputWriter) writeFile(rel string, data []byte) error {
+	path := filepath.Join(w.dir, filepath.FromSlash(rel))
+	if err := utils.EnsureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	w.mu.Lock()
+	w.result.Outputs = append(w.result.Outputs, OutputFile{Path: rel, Size: int64(len(data))})
+	w.mu.Unlock()
 	return nil
 }
 
-func (b *Builder) buildProduction(ctx context.Context, buildDir string, opts Options) error {
-	// Production build implementation
+// copyPublic copies the public directory verbatim, if the project has one.
+func (w *outputWriter) copyPublic(dir string) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+	if err := utils.CopyDir(dir, w.dir); err != nil {
+		return fmt.Errorf("failed to copy public directory: %w", err)
+	}
+	return nil
+}
+
+// writeMedia copies an imported asset, optimizing images. The bundler has
+// already chosen its output path, since module code refers to it.
+func (w *outputWriter) writeMedia(media *bundler.Media, baseDir string) error {
+	data, err := os.ReadFile(media.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", media.Source, err)
+	}
+	var variants []imageVariant
+	if w.images != nil && images.Supported(media.Source) {
+		if data, variants, err = w.optimizeImage(media, data); err != nil {
+			return err
+		}
+	}
+	if err := w.writeFile(media.Path, data); err != nil {
+		return err
+	}
+	if w.names != nil {
+		ext := filepath.Ext(media.Source)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.refs.add(naming.Unhashed(w.names.Asset, strings.TrimSuffix(filepath.Base(media.Source), ext), ext), media.Path)
+		w.result.Manifest[sourceID(baseDir, media.Source)] = &ManifestEntry{
+			File:      media.Path,
+			Integrity: Integrity(data),
+			Size:      int64(len(data)),
+			Srcset:    srcset(w.publicPath, variants),
+		}
+	}
+	return nil
+}
+
+// writeChunk writes the script, styles and source maps of a chunk. Entry
+// chunks get their start code, which refers to the chunks they load, so
+// those must have been written first. Without hashing the layout is:
+//
+//	assets/<name>[.min].js
+//	static/css/<name>[.min].css
+//	sourcemaps/<name>.js.map
+//	sourcemaps/<name>.css.map
+//
+// With hashing the script and styles follow the naming patterns and the
+// source maps are named after them; async and shared chunks use the Chunk
+// pattern.
+func (w *outputWriter) writeChunk(chunk *bundler.Chunk, minify bool) error {
+	suffix := ""
+	if minify {
+		suffix = ".min"
+	}
+	script := scriptDir + "/" + chunk.Name + suffix + ".js"
+	style := styleDir + "/" + chunk.Name + suffix + ".css"
+	mapPath := sourceMapDir + "/" + chunk.Name + ".js.map"
+	styleMapPath := sourceMapDir + "/" + chunk.Name + ".css.map"
+	code, css := chunk.Code+chunk.Start(w.chunkURLs), chunk.CSS
+
+	var entry *ManifestEntry
+	var styleName string
+	if w.names != nil {
+		// CSS references are rewritten before hashing so the hash covers
+		// the final content.
+		styleName = naming.Unhashed(w.names.CSS, chunk.Name, ".css")
+		w.mu.Lock()
+		css = w.refs.rewriteCSS(styleName, css, w.publicPath)
+		w.mu.Unlock()
+		style = naming.Expand(w.names.CSS, chunk.Name, ".css", []byte(css))
+		script = naming.Expand(w.scriptPattern(chunk), chunk.Name, ".js", []byte(code))
+		mapPath = sourceMapDir + "/" + path.Base(script) + ".map"
+		styleMapPath = sourceMapDir + "/" + path.Base(style) + ".map"
+		entry = &ManifestEntry{File: script, IsEntry: chunk.Kind == bundler.ChunkEntry}
+		if chunk.Entry != nil {
+			entry.Src = chunk.Entry.ID
+		}
+		for _, dep := range chunk.Imports {
+			entry.Imports = append(entry.Imports, w.chunkName(dep))
+		}
+		for _, dep := range chunk.DynamicImports {
+			entry.DynamicImports = append(entry.DynamicImports, w.chunkName(dep))
+		}
+	}
+
+	if chunk.Map != nil {
+		chunk.Map.File = filepath.Base(script)
+		data, err := json.Marshal(chunk.Map)
+		if err != nil {
+			return fmt.Errorf("failed to encode source map for %s: %w", chunk.Name, err)
+		}
+		if err := w.writeFile(mapPath, data); err != nil {
+			return err
+		}
+		code += "//# sourceMappingURL=" + relativeURL(script, mapPath) + "\n"
+		if entry != nil {
+			entry.Map = mapPath
+		}
+	}
+
+	var styleMap string
+	if css != "" && chunk.CSSMap != nil {
+		chunk.CSSMap.File = filepath.Base(style)
+		data, err := json.Marshal(chunk.CSSMap)
+		if err != nil {
+			return fmt.Errorf("failed to encode style source map for %s: %w", chunk.Name, err)
+		}
+		if err := w.writeFile(styleMapPath, data); err != nil {
+			return err
+		}
+		css += "/*# sourceMappingURL=" + relativeURL(style, styleMapPath) + " */\n"
+		styleMap = styleMapPath
+	}
+
+	if err := w.writeFile(script, []byte(code)); err != nil {
+		return err
+	}
+	if css != "" {
+		if err := w.writeFile(style, []byte(css)); err != nil {
+			return err
+		}
+	}
+	files := chunkFiles{script: script, scriptIntegrity: Integrity([]byte(code)), imports: chunk.Imports}
+	if css != "" {
+		files.style, files.styleIntegrity = style, Integrity([]byte(css))
+	}
+	w.mu.Lock()
+	w.chunks[chunk.Name] = files
+	w.mu.Unlock()
+	if entry == nil {
+		return nil
+	}
+
+	entry.Integrity = files.scriptIntegrity
+	entry.Size = int64(len(code))
+	for _, m := range chunk.Modules {
+		if m.Kind == bundler.KindAsset {
+			entry.Assets = append(entry.Assets, m.ID)
+		}
+	}
+	sort.Strings(entry.Assets)
+	name := w.chunkName(chunk)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if css != "" {
+		w.result.Manifest[styleName] = &ManifestEntry{
+			File:      style,
+			Integrity: files.styleIntegrity,
+			Size:      int64(len(css)),
+			Map:       styleMap,
+		}
+		entry.CSS = []string{styleName}
+	}
+	w.result.Manifest[name] = entry
+	w.refs.add(name, script)
+	if css != "" {
+		w.refs.add(styleName, style)
+	}
 	return nil
 }
+
+// scriptPattern returns the name pattern of the script of chunk.
+func (w *outputWriter) scriptPattern(chunk *bundler.Chunk) string {
+	if chunk.Kind == bundler.ChunkEntry {
+		return w.names.Entry
+	}
+	return w.names.Chunk
+}
+
+// chunkName returns the logical manifest name of the script of chunk.
+func (w *outputWriter) chunkName(chunk *bundler.Chunk) string {
+	return naming.Unhashed(w.scriptPattern(chunk), chunk.Name, ".js")
+}
+
+// chunkURLs returns the URLs of the script and styles of a written chunk,
+// for the start code of entry chunks.
+func (w *outputWriter) chunkURLs(chunk *bundler.Chunk) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	files := w.chunks[chunk.Name]
+	urls := []string{w.publicPath + files.script}
+	if files.style != "" {
+		urls = append(urls, w.publicPath+files.style)
+	}
+	return urls
+}
+
+// rewritePublic points references in the HTML and CSS files copied from the
+// public directory at hashed outputs.
+func (w *outputWriter) rewritePublic(public string) error {
+	if info, err := os.Stat(public); err != nil || !info.IsDir() {
+		return nil
+	}
+	return filepath.WalkDir(public, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(file))
+		if ext != ".html" && ext != ".htm" && ext != ".css" {
+			return nil
+		}
+		rel, err := filepath.Rel(public, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		out := filepath.Join(w.dir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		var rewritten string
+		if ext == ".css" {
+			rewritten = w.refs.rewriteCSS(rel, string(data), w.publicPath)
+		} else {
+			rewritten = w.refs.rewriteHTML(rel, string(data), w.publicPath)
+		}
+		if rewritten == string(data) {
+			return nil
+		}
+		return os.WriteFile(out, []byte(rewritten), 0644)
+	})
+}
+
+func (w *outputWriter) writeManifest() error {
+	data, err := json.MarshalIndent(w.result.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return w.writeFile(ManifestFile, append(data, '\n'))
+}
+
+// sourceID returns the module ID style name of a project file.
+func sourceID(baseDir, file string) string {
+	if rel, err := filepath.Rel(baseDir, file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(file)
+}
+
+// relativeURL returns the URL of target relative to the file from. Both are
+// slash-separated paths relative to the output directory.
+func relativeURL(from, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
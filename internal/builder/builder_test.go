@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunProductionManifest(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/index.js":      "import logo from './logo.png';\nimport './app.css';\nconsole.log(logo);\n",
+		"src/app.css":       "body { background: url(/static/media/logo.png); }\n",
+		"src/logo.png":      "\x89PNG",
+		"public/index.html": `<link rel="stylesheet" href="/static/css/main.css"><script src="assets/main.js"></script><a href="/about">`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := New().Run(context.Background(), Options{Mode: "production", BaseDir: dir, OutDir: "dist", SourceMap: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	script, style, logo := manifest["assets/main.js"], manifest["static/css/main.css"], manifest["src/logo.png"]
+	if script == nil || style == nil || logo == nil {
+		t.Fatalf("Manifest is missing entries: %s", data)
+	}
+	if !script.IsEntry || script.Src != "src/index.js" || len(script.CSS) != 1 || len(script.Assets) != 1 {
+		t.Errorf("Unexpected script entry: %+v", script)
+	}
+	if !strings.HasPrefix(script.Integrity, "sha384-") || script.Map == "" {
+		t.Errorf("Script entry lacks integrity or map: %+v", script)
+	}
+	for _, entry := range []*ManifestEntry{script, style, logo} {
+		if _, err := os.Stat(filepath.Join(result.OutDir, entry.File)); err != nil {
+			t.Errorf("%s was not written", entry.File)
+		}
+	}
+
+	css, _ := os.ReadFile(filepath.Join(result.OutDir, style.File))
+	if !strings.Contains(string(css), "/"+logo.File) {
+		t.Errorf("CSS reference not rewritten: %s", css)
+	}
+	html, _ := os.ReadFile(filepath.Join(result.OutDir, "index.html"))
+	want := `<link rel="stylesheet" href="/` + style.File + `"><script src="` + script.File + `"></script><a href="/about">`
+	if string(html) != want {
+		t.Errorf("index.html = %s, want %s", html, want)
+	}
+
+	// Rebuilding after a change replaces the previous hashed outputs.
+	if err := os.WriteFile(filepath.Join(dir, "src/index.js"), []byte("import logo from './logo.png';\nimport './app.css';\nconsole.log('logo', logo);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New().Run(context.Background(), Options{Mode: "production", BaseDir: dir, OutDir: "dist", SourceMap: true}); err != nil {
+		t.Fatalf("Second Run() error = %v", err)
+	}
+	for _, stale := range []string{script.File, script.Map} {
+		if _, err := os.Stat(filepath.Join(result.OutDir, stale)); !os.IsNotExist(err) {
+			t.Errorf("Stale output %s was not removed", stale)
+		}
+	}
+}
+
+func TestRunDevelopmentUnhashed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src/index.js"), []byte("console.log(1);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().Run(context.Background(), Options{Mode: "development", BaseDir: dir, OutDir: "dist"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Manifest != nil || len(result.Outputs) != 1 || result.Outputs[0].Path != "assets/main.js" {
+		t.Errorf("Unexpected development outputs: %+v", result.Outputs)
+	}
+}
+
+func TestRunHTMLEntries(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/index.js":      "import './app.css';\nconsole.log('main');\n",
+		"src/app.css":       "body { margin: 0; }\n",
+		"src/admin.js":      "console.log('admin');\n",
+		"public/index.html": "<!DOCTYPE html>\n<html>\n  <head>\n    <link rel=\"icon\" href=\"%PUBLIC_URL%/favicon.ico\">\n  </head>\n  <body>\n    <div id=\"root\"></div>\n  </body>\n</html>\n",
+		"public/admin.html": "<html><head><script type=\"module\" src=\"/src/admin.js\"></script></head><body></body></html>",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := New().Run(context.Background(), Options{
+		Mode:       "production",
+		BaseDir:    dir,
+		OutDir:     "dist",
+		PublicPath: "/app/",
+		HTML:       []string{"public/index.html", "public/admin.html"},
+		MinifyHTML: true,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	main, style, admin := result.Manifest["assets/main.js"], result.Manifest["static/css/main.css"], result.Manifest["assets/admin.js"]
+	if main == nil || style == nil || admin == nil {
+		t.Fatalf("Manifest is missing entries: %+v", result.Manifest)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(result.OutDir, "index.html"))
+	want := `<!DOCTYPE html><html><head><link rel="icon" href="/app/favicon.ico">` +
+		`<link rel="stylesheet" href="/app/` + style.File + `" integrity="` + style.Integrity + `" crossorigin="anonymous">` +
+		`<script type="module" src="/app/` + main.File + `" integrity="` + main.Integrity + `" crossorigin="anonymous"></script>` +
+		`</head><body><div id="root"></div></body></html>`
+	if string(index) != want {
+		t.Errorf("index.html = %s, want %s", index, want)
+	}
+
+	page, _ := os.ReadFile(filepath.Join(result.OutDir, "admin.html"))
+	want = `<html><head><script type="module" src="/app/` + admin.File + `" integrity="` + admin.Integrity + `" crossorigin="anonymous"></script></head><body></body></html>`
+	if string(page) != want {
+		t.Errorf("admin.html = %s, want %s", page, want)
+	}
+}
@@ -0,0 +1,821 @@
+package bundler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+)
+
+// analyzer finds the dependencies of a JavaScript module and records the
+// edits that turn its ES module syntax into calls on the bundle runtime.
+type analyzer struct {
+	m       *Module
+	toks    []js.Token
+	defines []define
+	// imported maps the local names of default and named imports to the
+	// expressions that read them from the imported module.
+	imported map[string]string
+	// importedFrom lists the same bindings in source order with the import
+	// record they come from, so only the ones used end up in its Names.
+	importedFrom []importedName
+}
+
+// importedName is a default or named import bound to local.
+type importedName struct {
+	local  string
+	record int
+	name   string
+}
+
+// define is a compile-time substitution of a dotted expression such as
+// process.env.NODE_ENV.
+type define struct {
+	parts []string
+	value string
+}
+
+func parseDefines(defines map[string]string) []define {
+	result := make([]define, 0, len(defines))
+	for key, value := range defines {
+		result = append(result, define{parts: strings.Split(key, "."), value: value})
+	}
+	return result
+}
+
+// binding is an import or export specifier such as "a as b".
+type binding struct {
+	imported string
+	local    string
+}
+
+func analyzeModule(m *Module, defines []define) error {
+	toks, err := js.Tokenize(m.code)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.ID, err)
+	}
+
+	a := &analyzer{m: m, toks: toks, defines: defines, imported: make(map[string]string)}
+	if err := a.run(); err != nil {
+		return err
+	}
+	return a.rewriteImported()
+}
+
+func (a *analyzer) run() error {
+	depth := 0
+	for i := 0; i < len(a.toks); i++ {
+		t := a.toks[i]
+		switch {
+		case t.IsPunct("{") || t.IsPunct("(") || t.IsPunct("["):
+			depth++
+		case t.IsPunct("}") || t.IsPunct(")") || t.IsPunct("]"):
+			depth--
+		case t.IsName("import") && !a.afterDot(i):
+			next := a.tok(i + 1)
+			switch {
+			case next.IsPunct("("):
+				a.dynamicImport(i)
+			case next.IsPunct("."):
+				// import.meta is left to defines.
+			case depth == 0:
+				end, err := a.importDecl(i)
+				if err != nil {
+					return err
+				}
+				i = end
+				continue
+			}
+		case t.IsName("export") && depth == 0:
+			end, err := a.exportDecl(i)
+			if err != nil {
+				return err
+			}
+			i = end
+			continue
+		case t.Is(js.TokenIdentifier, "require") && !a.afterDot(i):
+			a.requireCall(i)
+		}
+
+		if end, ok := a.matchDefine(i); ok {
+			i = end
+		}
+	}
+	return nil
+}
+
+func (a *analyzer) tok(i int) js.Token {
+	if i < 0 || i >= len(a.toks) {
+		return js.Token{Kind: js.TokenEOF}
+	}
+	return a.toks[i]
+}
+
+func (a *analyzer) afterDot(i int) bool {
+	prev := a.tok(i - 1)
+	return prev.IsPunct(".") || prev.IsPunct("?.")
+}
+
+func (a *analyzer) errorAt(i int, format string, args ...interface{}) error {
+	t := a.tok(i)
+	if t.Kind == js.TokenEOF && len(a.toks) > 0 {
+		t = a.toks[len(a.toks)-1]
+	}
+	return fmt.Errorf("%s:%d:%d: %s", a.m.ID, t.Line+1, t.Col+1, fmt.Sprintf(format, args...))
+}
+
+func (a *analyzer) addRecord(specifier string, kind ImportKind) int {
+	a.m.Imports = append(a.m.Imports, &ImportRecord{Specifier: specifier, Kind: kind})
+	return len(a.m.Imports) - 1
+}
+
+func (a *analyzer) replace(start, end int, text string) {
+	a.m.edits = append(a.m.edits, edit{start: start, end: end, text: text})
+}
+
+// replaceTokens replaces the source spanned by tokens from..to inclusive.
+func (a *analyzer) replaceTokens(from, to int, text string) {
+	a.replace(a.toks[from].Start, a.toks[to].End, text)
+}
+
+// replaceStatement is replaceTokens for a statement that only loads the
+// module of import record idx.
+func (a *analyzer) replaceStatement(from, to int, text string, idx int) {
+	a.m.edits = append(a.m.edits, edit{start: a.toks[from].Start, end: a.toks[to].End, text: text, statement: idx + 1})
+}
+
+// export adds an export of local under name and returns its index.
+func (a *analyzer) export(name, local string) int {
+	a.m.exports = append(a.m.exports, exportEntry{name: name, local: local})
+	return len(a.m.exports) - 1
+}
+
+// declares records that tokens from..to inclusive declare the export at
+// index e and nothing else.
+func (a *analyzer) declares(e, from, to int, pure bool) {
+	entry := &a.m.exports[e]
+	entry.start, entry.end, entry.pure = a.toks[from].Start, a.toks[to].End, pure
+}
+
+// dynamicImport handles import("specifier"). Non-literal arguments are left
+// to the browser's native import().
+func (a *analyzer) dynamicImport(i int) {
+	arg, closing := a.tok(i+2), a.tok(i+3)
+	if arg.Kind != js.TokenString || !closing.IsPunct(")") {
+		return
+	}
+	idx := a.addRecord(js.Unquote(arg.Value), ImportDynamic)
+	a.m.Imports[idx].Namespace = true
+	a.replaceTokens(i, i+3, "require.dynamic("+recordRef(idx)+")")
+}
+
+// requireCall handles require("specifier").
+func (a *analyzer) requireCall(i int) {
+	open, arg, closing := a.tok(i+1), a.tok(i+2), a.tok(i+3)
+	if !open.IsPunct("(") || arg.Kind != js.TokenString || !closing.IsPunct(")") {
+		return
+	}
+	idx := a.addRecord(js.Unquote(arg.Value), ImportRequire)
+	a.m.Imports[idx].Namespace = true
+	a.replace(arg.Start, arg.End, recordRef(idx))
+}
+
+// matchDefine substitutes the longest compile-time define starting at token
+// i and returns the index of the last replaced token.
+func (a *analyzer) matchDefine(i int) (int, bool) {
+	t := a.tok(i)
+	if (t.Kind != js.TokenIdentifier && t.Kind != js.TokenKeyword) || a.afterDot(i) {
+		return i, false
+	}
+
+	best, bestEnd := -1, i
+	for n, d := range a.defines {
+		if d.parts[0] != t.Value || (best >= 0 && len(d.parts) <= len(a.defines[best].parts)) {
+			continue
+		}
+		end, ok := i, true
+		for _, part := range d.parts[1:] {
+			if !a.tok(end+1).IsPunct(".") || a.tok(end+2).Value != part {
+				ok = false
+				break
+			}
+			end += 2
+		}
+		if ok {
+			best, bestEnd = n, end
+		}
+	}
+	if best < 0 || a.tok(bestEnd+1).IsPunct("=") {
+		return i, false
+	}
+
+	a.replaceTokens(i, bestEnd, "("+a.defines[best].value+")")
+	return bestEnd, true
+}
+
+// importDecl rewrites an import declaration starting at token i and returns
+// the index of its last token.
+func (a *analyzer) importDecl(i int) (int, error) {
+	a.m.esm = true
+	j := i + 1
+
+	if a.tok(j).Kind == js.TokenString {
+		idx := a.addRecord(js.Unquote(a.tok(j).Value), ImportStatic)
+		end := a.skipImportAttributes(j)
+		a.replaceStatement(i, end, "require("+recordRef(idx)+");", idx)
+		return end, nil
+	}
+
+	var defaultName, namespace string
+	var named []binding
+
+	if t := a.tok(j); t.Kind == js.TokenIdentifier && !(t.Value == "from" && a.tok(j+1).Kind == js.TokenString) {
+		defaultName = t.Value
+		j++
+		if a.tok(j).IsPunct(",") {
+			j++
+		}
+	}
+	switch {
+	case a.tok(j).IsPunct("*"):
+		if !a.tok(j+1).IsName("as") || a.tok(j+2).Kind != js.TokenIdentifier {
+			return 0, a.errorAt(j, "malformed namespace import")
+		}
+		namespace = a.tok(j + 2).Value
+		j += 3
+	case a.tok(j).IsPunct("{"):
+		var err error
+		named, j, err = a.specifiers(j)
+		if err != nil {
+			return 0, err
+		}
+		j++
+	}
+
+	if !a.tok(j).IsName("from") || a.tok(j+1).Kind != js.TokenString {
+		return 0, a.errorAt(j, "expected 'from' clause in import declaration")
+	}
+	idx := a.addRecord(js.Unquote(a.tok(j+1).Value), ImportStatic)
+	record := a.m.Imports[idx]
+	end := a.skipImportAttributes(j + 1)
+
+	// Default and named imports are read from the module wherever they are
+	// used, so they stay live bindings and a cycle does not read them
+	// before the module defining them has run.
+	module := fmt.Sprintf("__gb_m%d", idx)
+	decls := []string{module + " = require(" + recordRef(idx) + ")"}
+	if defaultName != "" {
+		a.importedFrom = append(a.importedFrom, importedName{local: defaultName, record: idx, name: "default"})
+		a.imported[defaultName] = "require.interop(" + module + ")"
+	}
+	if namespace != "" {
+		record.Namespace = true
+		decls = append(decls, namespace+" = "+module)
+	}
+	for _, b := range named {
+		a.importedFrom = append(a.importedFrom, importedName{local: b.local, record: idx, name: b.imported})
+		if b.imported == "default" {
+			a.imported[b.local] = "require.interop(" + module + ")"
+		} else {
+			a.imported[b.local] = memberExpr(module, b.imported)
+		}
+	}
+
+	a.replaceStatement(i, end, "var "+strings.Join(decls, ", ")+";", idx)
+	return end, nil
+}
+
+// rewriteImported replaces the references to default and named imports
+// with reads from the imported module, and points local exports of them at
+// the same reads. References inside other edits, such as the import
+// declarations themselves, are left to those edits. The names of the
+// imports that are referenced or exported are added to their records.
+func (a *analyzer) rewriteImported() error {
+	if len(a.imported) == 0 {
+		return nil
+	}
+
+	refs, err := js.FreeReferences(a.toks)
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.m.ID, err)
+	}
+	edited := func(pos int) bool {
+		for _, e := range a.m.edits {
+			if pos >= e.start && pos < e.end {
+				return true
+			}
+		}
+		return false
+	}
+	used := make(map[string]bool)
+	for _, e := range a.m.exports {
+		if e.reexport == 0 {
+			used[e.local] = true
+		}
+	}
+	for name, list := range refs {
+		for _, ref := range list {
+			if !edited(a.toks[ref.Index].Start) {
+				used[name] = true
+			}
+		}
+	}
+	for _, b := range a.importedFrom {
+		if used[b.local] {
+			record := a.m.Imports[b.record]
+			record.Names = append(record.Names, b.name)
+		}
+	}
+
+	for i, e := range a.m.exports {
+		if expr, ok := a.imported[e.local]; ok && e.reexport == 0 {
+			a.m.exports[i].local = expr
+		}
+	}
+	names := make([]string, 0, len(a.imported))
+	for name := range a.imported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expr := a.imported[name]
+		for _, ref := range refs[name] {
+			t := a.toks[ref.Index]
+			if edited(t.Start) {
+				continue
+			}
+			if ref.Shorthand {
+				a.replace(t.Start, t.End, name+": "+expr)
+			} else {
+				a.replace(t.Start, t.End, expr)
+			}
+		}
+	}
+	return nil
+}
+
+// skipImportAttributes skips an optional "assert { ... }" or "with { ... }"
+// clause and a trailing semicolon following the specifier at index j, and
+// returns the index of the last token belonging to the declaration.
+func (a *analyzer) skipImportAttributes(j int) int {
+	next := a.tok(j + 1)
+	if (next.IsName("assert") || next.IsName("with")) && !next.NewlineBefore && a.tok(j+2).IsPunct("{") {
+		k := j + 3
+		for k < len(a.toks) && !a.tok(k).IsPunct("}") {
+			k++
+		}
+		j = k
+	}
+	if a.tok(j + 1).IsPunct(";") {
+		j++
+	}
+	return j
+}
+
+// specifiers parses "{ a, b as c, 'd' as e }" starting at the opening brace
+// and returns the bindings and the index of the closing brace.
+func (a *analyzer) specifiers(j int) ([]binding, int, error) {
+	var result []binding
+	k := j + 1
+	for !a.tok(k).IsPunct("}") {
+		t := a.tok(k)
+		if t.Kind == js.TokenEOF {
+			return nil, 0, a.errorAt(j, "unterminated specifier list")
+		}
+		name := t.Value
+		if t.Kind == js.TokenString {
+			name = js.Unquote(t.Value)
+		} else if t.Kind != js.TokenIdentifier && t.Kind != js.TokenKeyword {
+			return nil, 0, a.errorAt(k, "unexpected %q in specifier list", t.Value)
+		}
+		b := binding{imported: name, local: name}
+		k++
+		if a.tok(k).IsName("as") {
+			alias := a.tok(k + 1)
+			b.local = alias.Value
+			if alias.Kind == js.TokenString {
+				b.local = js.Unquote(alias.Value)
+			}
+			k += 2
+		}
+		result = append(result, b)
+		if a.tok(k).IsPunct(",") {
+			k++
+		}
+	}
+	return result, k, nil
+}
+
+// exportDecl rewrites an export declaration starting at token i and returns
+// the index of the last token it consumed. Declarations such as
+// "export const" only consume the export keyword so that the main loop still
+// scans their bodies.
+func (a *analyzer) exportDecl(i int) (int, error) {
+	a.m.esm = true
+	j := i + 1
+	t := a.tok(j)
+
+	switch {
+	case t.IsPunct("*"):
+		return a.exportStar(i)
+
+	case t.IsPunct("{"):
+		named, closing, err := a.specifiers(j)
+		if err != nil {
+			return 0, err
+		}
+		if a.tok(closing+1).IsName("from") && a.tok(closing+2).Kind == js.TokenString {
+			idx := a.addRecord(js.Unquote(a.tok(closing+2).Value), ImportReexport)
+			record := a.m.Imports[idx]
+			end := a.skipImportAttributes(closing + 2)
+			module := fmt.Sprintf("__gb_m%d", idx)
+			for _, b := range named {
+				// In a re-export "imported" is the dependency's name and "local"
+				// the name this module exports it under.
+				record.Names = append(record.Names, b.imported)
+				local := memberExpr(module, b.imported)
+				if b.imported == "default" {
+					local = "require.interop(" + module + ")"
+				}
+				e := a.export(b.local, local)
+				a.m.exports[e].reexport, a.m.exports[e].imported = idx+1, b.imported
+			}
+			a.replaceStatement(i, end, "var "+module+" = require("+recordRef(idx)+");", idx)
+			return end, nil
+		}
+		for _, b := range named {
+			a.export(b.local, b.imported)
+		}
+		end := closing
+		if a.tok(end + 1).IsPunct(";") {
+			end++
+		}
+		a.replaceTokens(i, end, "")
+		return end, nil
+
+	case t.IsName("default"):
+		return a.exportDefault(i)
+
+	case t.IsName("var") || t.IsName("let") || t.IsName("const"):
+		names, err := a.declarationNames(j)
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range names {
+			a.export(name, name)
+		}
+		if len(names) == 1 && a.tok(j+1).Value == names[0] && a.tok(j+2).IsPunct("=") {
+			end := a.skipExpression(j + 3)
+			if a.tok(end).IsPunct(";") {
+				a.declares(len(a.m.exports)-1, i, end, a.pureExpression(j+3, end))
+			} else if !a.tok(end).IsPunct(",") {
+				a.declares(len(a.m.exports)-1, i, end-1, a.pureExpression(j+3, end))
+			}
+		}
+		a.replaceTokens(i, i, "")
+		return i, nil
+
+	case t.IsName("function") || t.IsName("class") || (t.IsName("async") && a.tok(j+1).IsName("function")):
+		k := j
+		if t.IsName("async") {
+			k++
+		}
+		k++
+		if a.tok(k).IsPunct("*") {
+			k++
+		}
+		name := a.tok(k)
+		if name.Kind != js.TokenIdentifier {
+			return 0, a.errorAt(k, "exported declaration requires a name")
+		}
+		e := a.export(name.Value, name.Value)
+		if body, err := a.declarationBody(k); err == nil {
+			a.declares(e, i, body, !t.IsName("class"))
+		}
+		a.replaceTokens(i, i, "")
+		return i, nil
+	}
+
+	return 0, a.errorAt(j, "unsupported export syntax %q", t.Value)
+}
+
+func (a *analyzer) exportStar(i int) (int, error) {
+	j := i + 2
+	var alias string
+	if a.tok(j).IsName("as") {
+		aliasTok := a.tok(j + 1)
+		alias = aliasTok.Value
+		if aliasTok.Kind == js.TokenString {
+			alias = js.Unquote(aliasTok.Value)
+		}
+		j += 2
+	}
+	if !a.tok(j).IsName("from") || a.tok(j+1).Kind != js.TokenString {
+		return 0, a.errorAt(j, "expected 'from' clause in export declaration")
+	}
+
+	idx := a.addRecord(js.Unquote(a.tok(j+1).Value), ImportReexport)
+	a.m.Imports[idx].Namespace = true
+	end := a.skipImportAttributes(j + 1)
+
+	if alias != "" {
+		module := fmt.Sprintf("__gb_m%d", idx)
+		e := a.export(alias, module)
+		a.m.exports[e].reexport, a.m.exports[e].imported = idx+1, "*"
+		a.replaceStatement(i, end, "var "+module+" = require("+recordRef(idx)+");", idx)
+	} else {
+		a.replaceStatement(i, end, "require.star(exports, require("+recordRef(idx)+"));", idx)
+	}
+	return end, nil
+}
+
+func (a *analyzer) exportDefault(i int) (int, error) {
+	j := i + 2
+	t := a.tok(j)
+
+	isFunction := t.IsName("function") || (t.IsName("async") && a.tok(j+1).IsName("function") && !a.tok(j+1).NewlineBefore)
+	if isFunction || t.IsName("class") {
+		k := j
+		if t.IsName("async") {
+			k++
+		}
+		k++
+		if a.tok(k).IsPunct("*") {
+			k++
+		}
+		if name := a.tok(k); name.Kind == js.TokenIdentifier && !(t.IsName("class") && name.Value == "extends") {
+			e := a.export("default", name.Value)
+			if body, err := a.declarationBody(k); err == nil {
+				a.declares(e, i, body, isFunction)
+			}
+			a.replaceTokens(i, i+1, "")
+			return i + 1, nil
+		}
+
+		// Anonymous declarations become an assignment, terminated explicitly
+		// so that a following line cannot continue the expression.
+		body, err := a.declarationBody(k)
+		if err != nil {
+			return 0, err
+		}
+		e := a.export("default", "__gb_default")
+		a.declares(e, i, body, isFunction)
+		a.replaceTokens(i, i+1, "var __gb_default =")
+		a.replace(a.toks[body].End, a.toks[body].End, ";")
+		return i + 1, nil
+	}
+
+	e := a.export("default", "__gb_default")
+	if end := a.skipExpression(j); a.tok(end).IsPunct(";") {
+		a.declares(e, i, end, a.pureExpression(j, end))
+	}
+	a.replaceTokens(i, i+1, "var __gb_default =")
+	return i + 1, nil
+}
+
+// pureExpression reports whether evaluating tokens from up to end has no
+// side effects: whether they are a function, an arrow function or a single
+// literal.
+func (a *analyzer) pureExpression(from, end int) bool {
+	t := a.tok(from)
+	switch {
+	case end == from+1:
+		return t.Kind == js.TokenString || t.Kind == js.TokenNumber ||
+			t.IsName("true") || t.IsName("false") || t.IsName("null") ||
+			(t.Kind == js.TokenTemplate && strings.HasSuffix(t.Value, "`") && !strings.Contains(t.Value, "${"))
+	case t.IsName("function"):
+		return true
+	case t.IsName("async") && a.tok(from+1).IsName("function"):
+		return true
+	case t.IsName("async"):
+		from++
+		t = a.tok(from)
+	}
+	if t.Kind == js.TokenIdentifier {
+		return a.tok(from + 1).IsPunct("=>")
+	}
+	if t.IsPunct("(") {
+		closing, err := a.matching(from)
+		return err == nil && a.tok(closing+1).IsPunct("=>")
+	}
+	return false
+}
+
+// declarationBody returns the index of the brace closing the body of the
+// function or class whose header starts at k.
+func (a *analyzer) declarationBody(k int) (int, error) {
+	depth := 0
+	for ; k < len(a.toks); k++ {
+		t := a.toks[k]
+		switch {
+		case t.IsPunct("(") || t.IsPunct("["):
+			depth++
+		case t.IsPunct(")") || t.IsPunct("]"):
+			depth--
+		case t.IsPunct("{") && depth == 0:
+			return a.matching(k)
+		}
+	}
+	return 0, a.errorAt(k, "missing declaration body")
+}
+
+// matching returns the index of the bracket closing the one at k.
+func (a *analyzer) matching(k int) (int, error) {
+	depth := 0
+	for i := k; i < len(a.toks); i++ {
+		t := a.toks[i]
+		switch {
+		case t.IsPunct("{") || t.IsPunct("(") || t.IsPunct("["):
+			depth++
+		case t.IsPunct("}") || t.IsPunct(")") || t.IsPunct("]"):
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, a.errorAt(k, "unbalanced %q", a.toks[k].Value)
+}
+
+// declarationNames returns the names bound by the var/let/const declaration
+// whose keyword is at index j.
+func (a *analyzer) declarationNames(j int) ([]string, error) {
+	var names []string
+	k := j + 1
+	for {
+		bound, next, err := a.bindingPattern(k)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, bound...)
+		k = next
+		if a.tok(k).IsPunct("=") {
+			k = a.skipExpression(k + 1)
+		}
+		if !a.tok(k).IsPunct(",") {
+			return names, nil
+		}
+		k++
+	}
+}
+
+// bindingPattern collects the names bound by an identifier, object pattern or
+// array pattern starting at k and returns the index after it.
+func (a *analyzer) bindingPattern(k int) ([]string, int, error) {
+	t := a.tok(k)
+	switch {
+	case t.Kind == js.TokenIdentifier || (t.Kind == js.TokenKeyword && !js.IsKeyword(t.Value)):
+		return []string{t.Value}, k + 1, nil
+
+	case t.IsPunct("{"):
+		var names []string
+		k++
+		for !a.tok(k).IsPunct("}") {
+			if a.tok(k).Kind == js.TokenEOF {
+				return nil, 0, a.errorAt(k, "unterminated object pattern")
+			}
+			if a.tok(k).IsPunct("...") {
+				bound, next, err := a.bindingPattern(k + 1)
+				if err != nil {
+					return nil, 0, err
+				}
+				names = append(names, bound...)
+				k = next
+			} else {
+				key := a.tok(k)
+				if key.IsPunct("[") {
+					end, err := a.matching(k)
+					if err != nil {
+						return nil, 0, err
+					}
+					k = end
+				}
+				if a.tok(k + 1).IsPunct(":") {
+					bound, next, err := a.bindingPattern(k + 2)
+					if err != nil {
+						return nil, 0, err
+					}
+					names = append(names, bound...)
+					k = next
+				} else {
+					names = append(names, key.Value)
+					k++
+				}
+				if a.tok(k).IsPunct("=") {
+					k = a.skipExpression(k + 1)
+				}
+			}
+			if a.tok(k).IsPunct(",") {
+				k++
+			}
+		}
+		return names, k + 1, nil
+
+	case t.IsPunct("["):
+		var names []string
+		k++
+		for !a.tok(k).IsPunct("]") {
+			if a.tok(k).Kind == js.TokenEOF {
+				return nil, 0, a.errorAt(k, "unterminated array pattern")
+			}
+			if a.tok(k).IsPunct(",") {
+				k++
+				continue
+			}
+			if a.tok(k).IsPunct("...") {
+				k++
+			}
+			bound, next, err := a.bindingPattern(k)
+			if err != nil {
+				return nil, 0, err
+			}
+			names = append(names, bound...)
+			k = next
+			if a.tok(k).IsPunct("=") {
+				k = a.skipExpression(k + 1)
+			}
+			if a.tok(k).IsPunct(",") {
+				k++
+			}
+		}
+		return names, k + 1, nil
+	}
+
+	return nil, 0, a.errorAt(k, "unexpected %q in declaration", t.Value)
+}
+
+// skipExpression advances past an expression starting at k and returns the
+// index of the first token after it: a comma or closing bracket at the
+// starting depth, a semicolon, or a line break that ends the statement.
+func (a *analyzer) skipExpression(k int) int {
+	depth := 0
+	start := k
+	for ; k < len(a.toks); k++ {
+		t := a.toks[k]
+		if depth == 0 {
+			if t.IsPunct(",") || t.IsPunct(";") || t.IsPunct(")") || t.IsPunct("]") || t.IsPunct("}") {
+				return k
+			}
+			if k > start && t.NewlineBefore && endsStatement(a.toks[k-1], t) {
+				return k
+			}
+		}
+		switch {
+		case t.IsPunct("{") || t.IsPunct("(") || t.IsPunct("["):
+			depth++
+		case t.IsPunct("}") || t.IsPunct(")") || t.IsPunct("]"):
+			depth--
+		}
+	}
+	return k
+}
+
+// endsStatement reports whether a line break between prev and next
+// terminates the statement under automatic semicolon insertion.
+func endsStatement(prev, next js.Token) bool {
+	if prev.Kind == js.TokenPunctuator && prev.Value != ")" && prev.Value != "]" && prev.Value != "}" &&
+		prev.Value != "++" && prev.Value != "--" {
+		return false
+	}
+	if prev.Kind == js.TokenKeyword && !(prev.Value == "this" || prev.Value == "super" ||
+		prev.Value == "true" || prev.Value == "false" || prev.Value == "null") {
+		return false
+	}
+	if next.Kind == js.TokenPunctuator {
+		switch next.Value {
+		case "(", "[", "`", ".", "?.", "+", "-", "*", "/", "%", "**", "=", "==", "===", "!=", "!==",
+			"<", ">", "<=", ">=", "&&", "||", "??", "&", "|", "^", "?", ":", ",", "<<", ">>", ">>>",
+			"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "=>":
+			return false
+		}
+	}
+	if next.Kind == js.TokenTemplate || next.IsName("in") || next.IsName("instanceof") {
+		return false
+	}
+	return true
+}
+
+// memberExpr renders module.name, falling back to bracket notation for names
+// that are not valid identifiers.
+func memberExpr(object, name string) string {
+	if isIdentifierName(name) {
+		return object + "." + name
+	}
+	return object + "[" + js.Quote(name) + "]"
+}
+
+func isIdentifierName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r >= 0x80:
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
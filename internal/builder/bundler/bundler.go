@@ -0,0 +1,335 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+	"github.com/skbhati199/go-web-build/internal/builder/naming"
+	"github.com/skbhati199/go-web-build/internal/builder/parallel"
+)
+
+// Options configures a bundling run.
+type Options struct {
+	// BaseDir is the project root. Module IDs are relative to it.
+	BaseDir string
+	// Mode is "development" or "production".
+	Mode      string
+	Minify    bool
+	SourceMap bool
+	// PublicPath is the URL prefix the output directory is served under.
+	PublicPath string
+	// MediaDir is where imported assets are emitted, relative to the output
+	// directory.
+	MediaDir string
+	// AssetNames is the naming pattern of imported assets (see package
+	// naming). Defaults to "<MediaDir>/[name].[hash:8][ext]".
+	AssetNames string
+	// Define maps dotted expressions to the JavaScript source they are
+	// replaced with at build time.
+	Define map[string]string
+	// Jobs bounds how many modules are processed at once. Zero uses one
+	// job per CPU.
+	Jobs int
+	// TreeShake leaves out unused exports and the modules nothing needs
+	// (see Removal).
+	TreeShake bool
+	// Split, when set, splits chunks at import() expressions and moves
+	// shared modules to chunks of their own.
+	Split *SplitOptions
+	// Cache, when set, keeps module analysis and minified code between
+	// builds so unchanged modules are not processed again.
+	Cache Cache
+}
+
+// Entry is a named entry point.
+type Entry struct {
+	Name string
+	Path string
+}
+
+type Bundler struct {
+	opts     Options
+	resolver *Resolver
+	defines  []define
+	modules  map[string]*Module
+	// optionsKey is folded into every cache key.
+	optionsKey string
+	statsMu    sync.Mutex
+	stats      CacheStats
+}
+
+// Result is the output of a bundling run. It holds the generated code but
+// writes nothing to disk.
+type Result struct {
+	Chunks  []*Chunk
+	Media   []*Media
+	Modules []*Module
+	Cache   CacheStats
+	// Removed lists what tree shaking left out, in module order.
+	Removed []Removal
+}
+
+// Media is an asset imported from JavaScript that must be copied to the
+// output directory.
+type Media struct {
+	Source string
+	Path   string
+}
+
+func New(opts Options) *Bundler {
+	if opts.Mode == "" {
+		opts.Mode = "development"
+	}
+	if opts.PublicPath == "" {
+		opts.PublicPath = "/"
+	}
+	if opts.MediaDir == "" {
+		opts.MediaDir = "static/media"
+	}
+	if opts.AssetNames == "" {
+		opts.AssetNames = opts.MediaDir + "/[name].[hash:8][ext]"
+	}
+
+	defines := map[string]string{
+		"process.env.NODE_ENV": strconv.Quote(opts.Mode),
+	}
+	for key, value := range opts.Define {
+		defines[key] = value
+	}
+
+	return &Bundler{
+		opts:       opts,
+		resolver:   NewResolver(opts.Mode),
+		defines:    parseDefines(defines),
+		modules:    make(map[string]*Module),
+		optionsKey: optionsKey(opts, defines),
+	}
+}
+
+// Bundle loads the module graph reachable from entries and links one chunk
+// per entry, or with Split the entry, async and shared chunks.
+func (b *Bundler) Bundle(ctx context.Context, entries []Entry) (*Result, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entry points")
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		path, err := filepath.Abs(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if err := b.load(ctx, paths); err != nil {
+		return nil, err
+	}
+	roots := make([]*Module, 0, len(paths))
+	for _, path := range paths {
+		roots = append(roots, b.modules[path])
+	}
+
+	order := b.order(roots)
+	var removed []Removal
+	if b.opts.TreeShake {
+		removed = b.shake(roots, order)
+		order = b.order(roots)
+	}
+	b.assignRefs(order)
+	if err := b.prepare(ctx, order); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Modules: order, Removed: removed}
+	for _, m := range order {
+		if m.Kind == KindAsset {
+			result.Media = append(result.Media, &Media{Source: m.Path, Path: m.mediaPath})
+		}
+	}
+
+	if b.opts.Split != nil {
+		result.Chunks = b.split(entries, roots)
+	} else {
+		for i, entry := range entries {
+			result.Chunks = append(result.Chunks, b.entryChunk(entry.Name, roots[i]))
+		}
+	}
+	err := parallel.Run(ctx, b.opts.Jobs, len(result.Chunks), func(ctx context.Context, i int) error {
+		return b.link(result.Chunks[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Cache = b.stats
+	return result, nil
+}
+
+// load reads, analyses and resolves every module reachable from paths. Each
+// round processes the modules discovered by the previous one in parallel;
+// import records are linked between rounds, so the graph does not depend on
+// scheduling.
+func (b *Bundler) load(ctx context.Context, paths []string) error {
+	pending := b.register(paths)
+	for len(pending) > 0 {
+		resolved := make([][]string, len(pending))
+		err := parallel.Run(ctx, b.opts.Jobs, len(pending), func(ctx context.Context, i int) error {
+			var err error
+			resolved[i], err = b.process(pending[i])
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		var next []*Module
+		for i, m := range pending {
+			next = append(next, b.register(resolved[i])...)
+			for j, record := range m.Imports {
+				record.Module = b.modules[resolved[i][j]]
+			}
+		}
+		pending = next
+	}
+	return nil
+}
+
+// register creates modules for the paths that have not been seen yet.
+func (b *Bundler) register(paths []string) []*Module {
+	var added []*Module
+	for _, path := range paths {
+		if _, ok := b.modules[path]; ok {
+			continue
+		}
+		m := &Module{Path: path, Kind: kindForPath(path), ID: b.moduleID(path)}
+		b.modules[path] = m
+		added = append(added, m)
+	}
+	return added
+}
+
+// process reads and analyses m and returns the resolved paths of its
+// imports, in the order of m.Imports.
+func (b *Bundler) process(m *Module) ([]string, error) {
+	if m.Kind != KindEmpty {
+		data, err := os.ReadFile(m.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read module %s: %w", m.ID, err)
+		}
+		m.Source = string(data)
+		m.code = m.Source
+	}
+
+	switch m.Kind {
+	case KindJS:
+		code, err := js.Transform(m.Source, transformOptions(m.Path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.ID, err)
+		}
+		m.code = code
+		if err := b.analyze(m); err != nil {
+			return nil, err
+		}
+	case KindCSS:
+		if err := analyzeStyle(m); err != nil {
+			return nil, err
+		}
+	case KindAsset:
+		m.mediaPath = mediaName(b.opts.AssetNames, m.Path, m.Source)
+	}
+
+	resolved := make([]string, len(m.Imports))
+	for i, record := range m.Imports {
+		path, err := b.resolver.Resolve(record.Specifier, m.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.ID, err)
+		}
+		resolved[i] = path
+	}
+	return resolved, nil
+}
+
+// prepare generates the code of every JavaScript module in parallel, so
+// linking only has to concatenate it.
+func (b *Bundler) prepare(ctx context.Context, modules []*Module) error {
+	return parallel.Run(ctx, b.opts.Jobs, len(modules), func(ctx context.Context, i int) error {
+		m := modules[i]
+		if m.Kind != KindJS {
+			return nil
+		}
+		code, err := m.applyEdits(registryRef)
+		if err != nil {
+			return err
+		}
+		m.transformed = code
+		if b.opts.Minify {
+			m.minified, m.segments, err = b.compact(m, code)
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (b *Bundler) moduleID(path string) string {
+	if path == emptyModule {
+		return path
+	}
+	if rel, err := filepath.Rel(b.opts.BaseDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// order returns every module reachable from roots with dependencies before
+// the modules that import them. The order only depends on import order in
+// the sources, so output is deterministic.
+func (b *Bundler) order(roots []*Module) []*Module {
+	var order []*Module
+	visited := make(map[*Module]bool)
+	var visit func(m *Module)
+	visit = func(m *Module) {
+		if visited[m] || m.removed {
+			return
+		}
+		visited[m] = true
+		for _, record := range m.Imports {
+			visit(record.Module)
+		}
+		order = append(order, m)
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return order
+}
+
+// assignRefs chooses registry keys: readable module IDs in development and
+// short numeric keys in production.
+func (b *Bundler) assignRefs(order []*Module) {
+	sorted := append([]*Module{}, order...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for i, m := range sorted {
+		if b.opts.Mode == "production" {
+			m.ref = strconv.Itoa(i)
+		} else {
+			m.ref = strconv.Quote(m.ID)
+		}
+	}
+}
+
+// mediaName expands the asset naming pattern for file. The content hash in
+// the default pattern keeps files with the same name in different
+// directories apart.
+func mediaName(pattern, file, content string) string {
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(filepath.Base(file), ext)
+	return naming.Expand(pattern, base, ext, []byte(content))
+}
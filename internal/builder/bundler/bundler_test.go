@@ -0,0 +1,423 @@
+package bundler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestBundle(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"src/index.js": `import greet, { name as who } from './greet';
+import './app.css';
+export const answer = 42;
+console.log(greet(who), process.env.NODE_ENV);
+`,
+		"src/greet.js": `export default function greet(n) { return 'hi ' + n }
+export const name = 'gobuild';
+`,
+		"src/app.css":                  "body { margin: 0; }\n",
+		"node_modules/unused/index.js": "module.exports = 1;\n",
+	})
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "development", opts: Options{Mode: "development", SourceMap: true}},
+		{name: "production", opts: Options{Mode: "production", Minify: true, SourceMap: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.BaseDir = dir
+			result, err := New(tt.opts).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+			if err != nil {
+				t.Fatalf("Bundle failed: %v", err)
+			}
+
+			if len(result.Modules) != 3 {
+				t.Errorf("Expected 3 modules, got %d", len(result.Modules))
+			}
+			if got := result.Modules[len(result.Modules)-1].ExportNames(); len(got) != 1 || got[0] != "answer" {
+				t.Errorf("Unexpected entry exports: %v", got)
+			}
+
+			chunk := result.Chunks[0]
+			if strings.Contains(chunk.Code, "import ") || strings.Contains(chunk.Code, "export ") {
+				t.Errorf("Module syntax left in output:\n%s", chunk.Code)
+			}
+			if !strings.Contains(chunk.Code, `"`+tt.opts.Mode+`"`) {
+				t.Errorf("process.env.NODE_ENV was not replaced")
+			}
+			if !strings.Contains(chunk.CSS, "margin") {
+				t.Errorf("Expected CSS to be collected, got %q", chunk.CSS)
+			}
+			if chunk.Map == nil || len(chunk.Map.Sources) != 2 || chunk.Map.Mappings == "" {
+				t.Errorf("Unexpected source map: %+v", chunk.Map)
+			}
+		})
+	}
+}
+
+func TestBundleLiveBindings(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	dir := writeProject(t, map[string]string{
+		"src/index.js": `import { count, inc } from './counter';
+import { a } from './a';
+inc();
+inc();
+console.log(count, a());
+`,
+		"src/counter.js": "export let count = 0;\nexport function inc() { count++; }\n",
+		// a and b import each other, and b is evaluated before a's const
+		// is initialized.
+		"src/a.js": "import { b } from './b';\nexport const a = () => 'a:' + b();\n",
+		"src/b.js": "import { a } from './a';\nexport const b = () => typeof a;\n",
+	})
+
+	for _, minify := range []bool{false, true} {
+		result, err := New(Options{BaseDir: dir, Minify: minify}).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+		if err != nil {
+			t.Fatalf("Bundle failed: %v", err)
+		}
+		chunk := result.Chunks[0]
+		out, err := exec.Command(node, "-e", chunk.Code+chunk.Start(nil)).CombinedOutput()
+		if err != nil {
+			t.Fatalf("Bundle (minify %v) failed to run: %v\n%s", minify, err, out)
+		}
+		if got := strings.TrimSpace(string(out)); got != "2 a:function" {
+			t.Errorf("Bundle (minify %v) printed %q, want %q", minify, got, "2 a:function")
+		}
+	}
+}
+
+func TestBundleTypeScript(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	dir := writeProject(t, map[string]string{
+		"src/index.tsx": `import { Color, type Props } from './types';
+import { Shape } from './shape';
+
+const Label = ({ text }: Props) => <b title="label">{text}</b>;
+console.log(<Label text={Color[Color.Red] + new Shape(2).area()} />);
+`,
+		"src/types.ts": "export enum Color { Red, Green }\nexport interface Props { text: string }\n",
+		"src/shape.ts": "export class Shape {\n  constructor(private readonly side: number) {}\n  area(): number { return this.side ** 2 }\n}\n",
+		// A stand-in for React that renders elements as markup.
+		"node_modules/react/package.json": `{"main": "index.js"}`,
+		"node_modules/react/index.js": `exports.createElement = (type, props, ...children) =>
+  typeof type === "function" ? type({ ...props, children }) : "<" + type + " title=" + props.title + ">" + children.join("") + "</" + type + ">";
+`,
+	})
+
+	result, err := New(Options{BaseDir: dir}).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.tsx")}})
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	chunk := result.Chunks[0]
+	out, err := exec.Command(node, "-e", chunk.Code+chunk.Start(nil)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Bundle failed to run: %v\n%s", err, out)
+	}
+	if got, want := strings.TrimSpace(string(out)), "<b title=label>Red4</b>"; got != want {
+		t.Errorf("Bundle printed %q, want %q", got, want)
+	}
+}
+
+func TestBundleUnresolvedImport(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"src/index.js": "import missing from './missing';\n",
+	})
+
+	_, err := New(Options{BaseDir: dir}).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+	if err == nil || !strings.Contains(err.Error(), "./missing") {
+		t.Fatalf("Expected resolution error, got %v", err)
+	}
+}
+
+func TestResolveExportsPatterns(t *testing.T) {
+	exports := map[string]interface{}{
+		"./*":              "./dist/*.js",
+		"./features/*":     "./dist/features/*.js",
+		"./features/*.css": "./dist/features/*.css",
+		"./features/x/*":   "./dist/x/*.js",
+	}
+	tests := map[string]string{
+		"./util":             "./dist/util.js",
+		"./features/a":       "./dist/features/a.js",
+		"./features/a.css":   "./dist/features/a.css",
+		"./features/x/deep":  "./dist/x/deep.js",
+		"./features/x/y.css": "./dist/x/y.css.js",
+	}
+	for subpath, want := range tests {
+		// Map order is random, so repeat to catch order dependence.
+		for i := 0; i < 20; i++ {
+			got, ok := resolveExports(exports, subpath, []string{"default"})
+			if !ok || got != want {
+				t.Fatalf("resolveExports(%q) = %q, %v, want %q", subpath, got, ok, want)
+			}
+		}
+	}
+}
+
+// memoryCache is an in-memory Cache.
+type memoryCache struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.objects[key]
+	return data, ok
+}
+
+func (c *memoryCache) Put(key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[key] = data
+	return nil
+}
+
+func TestBundleCache(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"src/index.js": "import { name } from './name';\nimport { greet } from './greet';\nconsole.log(greet(name));\n",
+		"src/greet.js": "export function greet(n) { return 'hi ' + n }\n",
+		"src/name.js":  "export const name = 'gobuild';\n",
+	})
+	c := &memoryCache{objects: map[string][]byte{}}
+	bundle := func() *Result {
+		t.Helper()
+		opts := Options{BaseDir: dir, Mode: "production", Minify: true, SourceMap: true, Cache: c}
+		result, err := New(opts).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+		if err != nil {
+			t.Fatalf("Bundle failed: %v", err)
+		}
+		return result
+	}
+
+	cold := bundle()
+	if cold.Cache.Reused != 0 || cold.Cache.Analyzed != 3 {
+		t.Errorf("Cold build: unexpected cache stats %+v", cold.Cache)
+	}
+
+	warm := bundle()
+	if warm.Cache.Reused != 3 || warm.Cache.Analyzed != 0 || warm.Cache.ReusedCode != 3 {
+		t.Errorf("Warm build: unexpected cache stats %+v", warm.Cache)
+	}
+	if warm.Chunks[0].Code != cold.Chunks[0].Code {
+		t.Errorf("Warm build differs from cold build:\n%s\n%s", cold.Chunks[0].Code, warm.Chunks[0].Code)
+	}
+
+	// A new module shifts the production refs of its dependents, so their
+	// code is generated again while their analysis is still reused.
+	if err := os.WriteFile(filepath.Join(dir, "src/name.js"), []byte("import { a } from './a';\nexport const name = a;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src/a.js"), []byte("export const a = 'changed';\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed := bundle()
+	if changed.Cache.Analyzed != 2 || changed.Cache.Reused != 2 {
+		t.Errorf("Changed build: unexpected cache stats %+v", changed.Cache)
+	}
+	if !strings.Contains(changed.Chunks[0].Code, "changed") {
+		t.Errorf("Changed module missing from output:\n%s", changed.Chunks[0].Code)
+	}
+}
+
+func TestBundleStyles(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"src/index.js": "import './app.css';\n",
+		"src/app.css": `@charset "UTF-8";
+@import "./base.css";
+@import url(print.css) print;
+@import url("https://example.com/font.css");
+.logo { background : url(img/logo.png#mark) }
+`,
+		"src/base.css":     ":root { --gap : 4px ; }\n",
+		"src/print.css":    "body { color : black }\n",
+		"src/img/logo.png": "png",
+	})
+
+	opts := Options{BaseDir: dir, Mode: "production", Minify: true, SourceMap: true}
+	result, err := New(opts).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+
+	chunk := result.Chunks[0]
+	if len(result.Media) != 1 {
+		t.Fatalf("Expected the url() target to be emitted, got %+v", result.Media)
+	}
+	want := `@import url("https://example.com/font.css");:root{--gap:4px}@media print{body{color:black}}.logo{background:url(/` + result.Media[0].Path + `#mark)}`
+	if chunk.CSS != want {
+		t.Errorf("Unexpected styles:\n got: %s\nwant: %s", chunk.CSS, want)
+	}
+	if strings.Contains(chunk.Code, "logo") {
+		t.Errorf("Assets only used by styles should not be registered in the script:\n%s", chunk.Code)
+	}
+	if chunk.CSSMap == nil || len(chunk.CSSMap.Sources) != 3 {
+		t.Errorf("Unexpected style source map: %+v", chunk.CSSMap)
+	}
+}
+
+func TestBundleSplit(t *testing.T) {
+	shared := `export const words = [` + strings.Repeat(`"shared words",`, 10) + `];
+`
+	dir := writeProject(t, map[string]string{
+		"src/index.js": `import { words } from './words';
+import('./page').then((page) => page.show(words));
+`,
+		"src/admin.js": `import { words } from './words';
+console.log(words);
+`,
+		"src/page.js": `import { words } from './words';
+export function show(w) { return import('./nested').then((n) => n.default(w, words)); }
+`,
+		"src/nested.js": "export default function (a, b) { return a === b; }\n",
+		"src/words.js":  shared,
+	})
+	entries := []Entry{
+		{Name: "main", Path: filepath.Join(dir, "src/index.js")},
+		{Name: "admin", Path: filepath.Join(dir, "src/admin.js")},
+	}
+	urls := func(c *Chunk) []string { return []string{"/" + c.Name + ".js"} }
+
+	result, err := New(Options{BaseDir: dir, Mode: "development", Split: &SplitOptions{MinSize: 100}}).Bundle(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	chunks := make(map[string]*Chunk)
+	for _, c := range result.Chunks {
+		chunks[c.Name] = c
+	}
+	main, page, common := chunks["main"], chunks["page"], chunks["main~admin~page"]
+	if len(result.Chunks) != 5 || main == nil || page == nil || chunks["nested"] == nil || common == nil {
+		t.Fatalf("Unexpected chunks: %v", chunks)
+	}
+	if common.Kind != ChunkShared || page.Kind != ChunkAsync || !strings.Contains(common.Code, "shared words") {
+		t.Errorf("Expected words.js in a shared chunk:\n%s", common.Code)
+	}
+	if strings.Contains(main.Code, "shared words") || strings.Contains(page.Code, "var __gb =") {
+		t.Error("Expected shared modules and the runtime only once")
+	}
+	if len(main.Imports) != 1 || main.Imports[0] != common || len(main.DynamicImports) != 1 || main.DynamicImports[0] != page {
+		t.Errorf("Unexpected imports of main: %v, %v", main.Imports, main.DynamicImports)
+	}
+	start := main.Start(urls)
+	for _, want := range []string{`"src/page.js": ["/page.js", "/main~admin~page.js"]`, `"src/nested.js": ["/nested.js"]`, `__gb.load(["/main~admin~page.js"])`} {
+		if !strings.Contains(start, want) {
+			t.Errorf("Start() = %s, want it to contain %s", start, want)
+		}
+	}
+	if page.Start(urls) != "" {
+		t.Error("Expected no start code for async chunks")
+	}
+
+	// One request per chunk leaves no room for shared chunks.
+	result, err = New(Options{BaseDir: dir, Mode: "development", Split: &SplitOptions{MinSize: 100, MaxRequests: 1}}).Bundle(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	if len(result.Chunks) != 4 {
+		t.Fatalf("Expected 4 chunks, got %d", len(result.Chunks))
+	}
+	for _, c := range result.Chunks[:3] {
+		if !strings.Contains(c.Code, "shared words") || len(c.Imports) != 0 {
+			t.Errorf("Expected words.js copied into %s", c.Name)
+		}
+	}
+}
+
+func TestBundleTreeShake(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"src/index.js": `import { pick, VERSION } from 'kit';
+import { used, unusedLocal } from './local';
+import 'kit/polyfill';
+import 'kit/pure';
+console.log(pick(), VERSION, used());
+`,
+		"src/local.js": `export function used() { return helper(); }
+function helper() { return 1; }
+export function unusedLocal() { return 'unusedLocal body'; }
+export const unusedValue = compute();
+function compute() { return 2; }
+`,
+		"node_modules/kit/package.json": `{"name": "kit", "module": "index.js", "sideEffects": ["./polyfill.js"]}`,
+		"node_modules/kit/index.js": `export { default as pick } from './pick.js';
+export { default as drop } from './drop.js';
+export * from './version.js';
+export * from './extra.js';
+`,
+		"node_modules/kit/pick.js":     "export default function pick() { return 'pick body'; }\n",
+		"node_modules/kit/drop.js":     "export default function drop() { return 'drop body'; }\n",
+		"node_modules/kit/version.js":  "export const VERSION = '1.0';\nexport const unusedKit = make();\n",
+		"node_modules/kit/extra.js":    "export const extra = 'extra body';\n",
+		"node_modules/kit/polyfill.js": "globalThis.polyfilled = 'polyfill body';\n",
+		"node_modules/kit/pure.js":     "export const pure = 'pure body';\n",
+	})
+
+	result, err := New(Options{BaseDir: dir, Mode: "production", TreeShake: true}).Bundle(context.Background(), []Entry{{Name: "main", Path: filepath.Join(dir, "src/index.js")}})
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	code := result.Chunks[0].Code
+	for _, want := range []string{"pick body", "'1.0'", "polyfill body", "function helper", "compute()"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Expected %q in output:\n%s", want, code)
+		}
+	}
+	for _, unwanted := range []string{"drop body", "extra body", "pure body", "unusedLocal body", "make()", "unusedValue:"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("Expected %q to be removed:\n%s", unwanted, code)
+		}
+	}
+
+	removed := make(map[string]string)
+	for _, r := range result.Removed {
+		removed[r.Module] = strings.Join(r.Exports, ",")
+	}
+	want := map[string]string{
+		"node_modules/kit/drop.js":    "",
+		"node_modules/kit/extra.js":   "",
+		"node_modules/kit/pure.js":    "",
+		"node_modules/kit/index.js":   "drop",
+		"node_modules/kit/version.js": "unusedKit",
+		"src/local.js":                "unusedLocal,unusedValue",
+	}
+	if len(removed) != len(want) {
+		t.Errorf("Removed = %v, want %v", removed, want)
+	}
+	for module, exports := range want {
+		if got, ok := removed[module]; !ok || got != exports {
+			t.Errorf("Removed[%s] = %q, want %q", module, got, exports)
+		}
+	}
+}
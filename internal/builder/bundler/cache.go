@@ -0,0 +1,253 @@
+package bundler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+)
+
+// Cache persists per-module work between builds. *cache.DiskCache
+// implements it. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, data []byte) error
+}
+
+// cacheFormat changes whenever the layout or meaning of cached data does.
+const cacheFormat = "3"
+
+// CacheStats counts how much work a bundling run took from the cache.
+type CacheStats struct {
+	// Reused and Analyzed count JavaScript modules whose analysis was read
+	// from the cache or computed.
+	Reused   int `json:"reused"`
+	Analyzed int `json:"analyzed"`
+	// ReusedCode counts minified module bodies read from the cache.
+	ReusedCode int `json:"reused_code"`
+}
+
+// cachedModule is the analysis of a module as stored in the cache. It only
+// depends on the module's content, ID and the transform options.
+type cachedModule struct {
+	Imports []cachedImport `json:"imports,omitempty"`
+	ESM     bool           `json:"esm,omitempty"`
+	Exports []cachedExport `json:"exports,omitempty"`
+	Edits   []cachedEdit   `json:"edits,omitempty"`
+}
+
+type cachedExport struct {
+	Name     string `json:"name"`
+	Local    string `json:"local"`
+	Reexport int    `json:"reexport,omitempty"`
+	Imported string `json:"imported,omitempty"`
+	Start    int    `json:"start,omitempty"`
+	End      int    `json:"end,omitempty"`
+	Pure     bool   `json:"pure,omitempty"`
+}
+
+type cachedImport struct {
+	Specifier string     `json:"specifier"`
+	Kind      ImportKind `json:"kind"`
+	Names     []string   `json:"names,omitempty"`
+	Namespace bool       `json:"namespace,omitempty"`
+}
+
+type cachedEdit struct {
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Text      string `json:"text"`
+	Statement int    `json:"statement,omitempty"`
+}
+
+// cachedTransform is a minified module body with its source map segments.
+type cachedTransform struct {
+	Code     string       `json:"code"`
+	Segments []js.Segment `json:"segments,omitempty"`
+}
+
+var (
+	toolVersionOnce sync.Once
+	toolVersionID   string
+)
+
+// toolVersion identifies the running binary, so cached work is not reused
+// across gobuild versions.
+func toolVersion() string {
+	toolVersionOnce.Do(func() {
+		toolVersionID = "unknown"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		parts := []string{info.Main.Version}
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+				parts = append(parts, s.Value)
+			}
+		}
+		toolVersionID = strings.Join(parts, "+")
+	})
+	return toolVersionID
+}
+
+// optionsKey hashes everything in opts that changes how modules are
+// transformed.
+func optionsKey(opts Options, defines map[string]string) string {
+	keys := make([]string, 0, len(defines))
+	for k := range defines {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, part := range []string{cacheFormat, toolVersion(), opts.Mode, boolString(opts.Minify)} {
+		h.Write([]byte(part + "\x00"))
+	}
+	for _, k := range keys {
+		h.Write([]byte(k + "=" + defines[k] + "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func hashString(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// moduleKey is the cache key of m's analysis: its ID and content under the
+// current options.
+func (b *Bundler) moduleKey(m *Module) string {
+	return "module/" + hashString(b.optionsKey, m.ID, m.hash)
+}
+
+// transformKey is the cache key of m's minified body. Besides the module
+// itself it covers the registry keys of the resolved imports, which end up
+// in the code, and what tree shaking removed from it.
+func (b *Bundler) transformKey(m *Module) string {
+	parts := []string{b.optionsKey, m.ID, m.hash}
+	for _, record := range m.Imports {
+		parts = append(parts, record.Module.ref)
+	}
+	for _, e := range m.liveExports() {
+		parts = append(parts, "export:"+e.name)
+	}
+	for _, d := range m.dropped {
+		parts = append(parts, "drop:"+strconv.Itoa(d.start))
+	}
+	return "transform/" + hashString(parts...)
+}
+
+// analyze runs analyzeModule, or restores its result from the cache.
+func (b *Bundler) analyze(m *Module) error {
+	m.hash = hashString(m.Source)
+	if b.opts.Cache == nil {
+		b.count(&b.stats.Analyzed)
+		return analyzeModule(m, b.defines)
+	}
+
+	key := b.moduleKey(m)
+	if data, ok := b.opts.Cache.Get(key); ok {
+		var cached cachedModule
+		if err := json.Unmarshal(data, &cached); err == nil {
+			cached.restore(m)
+			b.count(&b.stats.Reused)
+			return nil
+		}
+	}
+
+	b.count(&b.stats.Analyzed)
+	if err := analyzeModule(m, b.defines); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(newCachedModule(m)); err == nil {
+		// A failed write only costs the next build some time.
+		b.opts.Cache.Put(key, data)
+	}
+	return nil
+}
+
+// compact runs js.Minify on the body of m, or reads the result from the
+// cache. The body runs in the module function, so its top-level bindings
+// are renamed too, except the ones the export header refers to.
+func (b *Bundler) compact(m *Module, code string) (string, []js.Segment, error) {
+	opts := js.MinifyOptions{TopLevel: true, Keep: headerNames(m)}
+	if b.opts.Cache == nil {
+		return js.Minify(code, opts)
+	}
+
+	key := b.transformKey(m)
+	if data, ok := b.opts.Cache.Get(key); ok {
+		var cached cachedTransform
+		if err := json.Unmarshal(data, &cached); err == nil {
+			b.count(&b.stats.ReusedCode)
+			return cached.Code, cached.Segments, nil
+		}
+	}
+
+	compact, segments, err := js.Minify(code, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if data, err := json.Marshal(cachedTransform{Code: compact, Segments: segments}); err == nil {
+		b.opts.Cache.Put(key, data)
+	}
+	return compact, segments, nil
+}
+
+func newCachedModule(m *Module) cachedModule {
+	c := cachedModule{ESM: m.esm}
+	for _, r := range m.Imports {
+		c.Imports = append(c.Imports, cachedImport{Specifier: r.Specifier, Kind: r.Kind, Names: r.Names, Namespace: r.Namespace})
+	}
+	for _, e := range m.exports {
+		c.Exports = append(c.Exports, cachedExport{
+			Name: e.name, Local: e.local, Reexport: e.reexport, Imported: e.imported,
+			Start: e.start, End: e.end, Pure: e.pure,
+		})
+	}
+	for _, e := range m.edits {
+		c.Edits = append(c.Edits, cachedEdit{Start: e.start, End: e.end, Text: e.text, Statement: e.statement})
+	}
+	return c
+}
+
+func (c cachedModule) restore(m *Module) {
+	m.esm = c.ESM
+	for _, r := range c.Imports {
+		m.Imports = append(m.Imports, &ImportRecord{Specifier: r.Specifier, Kind: r.Kind, Names: r.Names, Namespace: r.Namespace})
+	}
+	for _, e := range c.Exports {
+		m.exports = append(m.exports, exportEntry{
+			name: e.Name, local: e.Local, reexport: e.Reexport, imported: e.Imported,
+			start: e.Start, end: e.End, pure: e.Pure,
+		})
+	}
+	for _, e := range c.Edits {
+		m.edits = append(m.edits, edit{start: e.Start, end: e.End, text: e.Text, statement: e.Statement})
+	}
+}
+
+// count increments one of the cache counters of b.
+func (b *Bundler) count(counter *int) {
+	b.statsMu.Lock()
+	*counter++
+	b.statsMu.Unlock()
+}
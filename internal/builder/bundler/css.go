@@ -0,0 +1,169 @@
+package bundler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/css"
+)
+
+// analyzeStyle records the local @import rules and url() references of a
+// stylesheet as imports. Imported stylesheets are emitted ahead of the
+// importing one, or inlined into the blocks of the @import's condition, so
+// the rules themselves are removed. url() references are replaced with
+// placeholders for the output paths of the assets they point at. @import
+// rules for other sites are hoisted to the top of the chunk's styles, and
+// @charset rules are dropped since the output is always UTF-8.
+func analyzeStyle(m *Module) error {
+	tokens, err := css.Tokenize(m.Source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.ID, err)
+	}
+
+	for i, t := range tokens {
+		if t.Kind == css.TokenAtKeyword && t.Name() == "charset" {
+			end := i
+			for end < len(tokens)-1 && tokens[end].Kind != css.TokenSemicolon {
+				end++
+			}
+			m.edits = append(m.edits, edit{start: t.Start, end: tokens[end].End})
+		}
+	}
+
+	for _, ref := range css.References(tokens) {
+		specifier, suffix, local := styleSpecifier(ref.URL)
+		switch {
+		case ref.Kind == css.RefImport && !local:
+			m.styleImports = append(m.styleImports, m.Source[ref.Start:ref.End])
+			m.edits = append(m.edits, edit{start: ref.Start, end: ref.End})
+		case ref.Kind == css.RefImport:
+			m.Imports = append(m.Imports, &ImportRecord{Specifier: specifier, Kind: ImportStatic, condition: ref.Condition})
+			m.edits = append(m.edits, edit{start: ref.Start, end: ref.End})
+		case local:
+			m.Imports = append(m.Imports, &ImportRecord{Specifier: specifier, Kind: ImportURL, suffix: suffix})
+			m.edits = append(m.edits, edit{start: ref.Start, end: ref.End, text: recordRef(len(m.Imports) - 1)})
+		}
+	}
+	return nil
+}
+
+var urlScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// styleSpecifier turns a URL found in a stylesheet into an import specifier
+// and the query or fragment to keep. URLs in CSS are relative to the
+// stylesheet even without "./"; "~" marks a package, as in webpack. Absolute
+// paths, which refer to the public directory, fragments and URLs with a
+// scheme are not local.
+func styleSpecifier(url string) (specifier, suffix string, local bool) {
+	if url == "" || strings.HasPrefix(url, "#") || strings.HasPrefix(url, "/") || urlScheme.MatchString(url) {
+		return "", "", false
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url, suffix = url[:i], url[i:]
+	}
+	switch {
+	case strings.HasPrefix(url, "~"):
+		return url[1:], suffix, true
+	case strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../"):
+		return url, suffix, true
+	}
+	return "./" + url, suffix, true
+}
+
+// directStyles returns the stylesheets emitted on their own: those imported
+// from scripts and, transitively, those imported unconditionally by direct
+// stylesheets. The others only appear inside the blocks of a conditional
+// @import. modules must list dependencies before their importers.
+func directStyles(modules, scripts []*Module) map[*Module]bool {
+	direct := make(map[*Module]bool)
+	for _, m := range scripts {
+		if m.Kind == KindCSS {
+			// Imported by a script, possibly one in another chunk.
+			direct[m] = true
+		}
+	}
+	for i := len(modules) - 1; i >= 0; i-- {
+		m := modules[i]
+		if m.Kind == KindCSS && !direct[m] {
+			continue
+		}
+		for _, record := range m.Imports {
+			if record.Module.Kind == KindCSS && (m.Kind != KindCSS || record.condition == "") {
+				direct[record.Module] = true
+			}
+		}
+	}
+	return direct
+}
+
+// writeStyle emits the rules of stylesheet m. Conditionally imported
+// stylesheets come first, wrapped in their condition's blocks. Inside such
+// blocks (inline) unconditional imports are emitted in place as well, since
+// they share the condition. visiting guards against import cycles.
+func (b *Bundler) writeStyle(w *chunkWriter, m *Module, inline bool, visiting map[*Module]bool) error {
+	if visiting[m] {
+		return nil
+	}
+	visiting[m] = true
+	defer delete(visiting, m)
+
+	for _, record := range m.Imports {
+		if record.Kind != ImportStatic || record.Module.Kind != KindCSS {
+			continue
+		}
+		switch {
+		case record.condition != "":
+			open, close := css.ConditionBlocks(record.condition)
+			w.write(open + b.styleBreak())
+			if err := b.writeStyle(w, record.Module, true, visiting); err != nil {
+				return err
+			}
+			w.write(close + b.styleBreak())
+		case inline:
+			if err := b.writeStyle(w, record.Module, true, visiting); err != nil {
+				return err
+			}
+		}
+	}
+
+	body, err := m.applyEdits(func(record *ImportRecord) (string, error) {
+		if record.Module.Kind != KindAsset {
+			return "", fmt.Errorf("%s: url(%s) does not refer to an asset", m.ID, record.Specifier)
+		}
+		return css.QuoteURL(b.opts.PublicPath + record.Module.mediaPath + record.suffix), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !b.opts.Minify {
+		w.write("/* " + m.ID + " */\n")
+		if w.gen != nil {
+			w.mapLines(m, body)
+		}
+		w.write(strings.TrimRight(body, "\n") + "\n")
+		return nil
+	}
+
+	minified, segments, err := css.Minify(body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.ID, err)
+	}
+	if w.gen != nil {
+		source := w.gen.AddSource(m.ID, m.Source)
+		for _, seg := range segments {
+			w.addMapping(source, seg.GeneratedLine, seg.GeneratedColumn, seg.OriginalLine, seg.OriginalColumn, "")
+		}
+	}
+	w.write(minified)
+	return nil
+}
+
+// styleBreak separates blocks in unminified styles.
+func (b *Bundler) styleBreak() string {
+	if b.opts.Minify {
+		return ""
+	}
+	return "\n"
+}
@@ -0,0 +1,357 @@
+package bundler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
+)
+
+// Chunk is one output script together with the styles its modules import.
+type Chunk struct {
+	Name string
+	Kind ChunkKind
+	// Entry is the module an entry chunk runs.
+	Entry   *Module
+	Modules []*Module
+	// Code registers the chunk's modules. Entry chunks start with the
+	// runtime and must be followed by Start.
+	Code string
+	// Map is nil unless source maps are enabled. Its File field is left for
+	// the caller to fill in once the output name is known.
+	Map *sourcemap.SourceMap
+	CSS string
+	// CSSMap is the source map of CSS, if source maps are enabled and the
+	// chunk has styles.
+	CSSMap *sourcemap.SourceMap
+	// Imports are the shared chunks that must be loaded before the chunk
+	// runs, and DynamicImports the chunks its import() expressions load.
+	Imports        []*Chunk
+	DynamicImports []*Chunk
+
+	// scripts are the modules the chunk registers; targets are the
+	// import() targets starting DynamicImports.
+	scripts []*Module
+	targets []*Module
+	minify  bool
+}
+
+// chunkWriter appends generated code while tracking the output position so
+// that source map segments can be recorded.
+type chunkWriter struct {
+	b    strings.Builder
+	line int
+	col  int
+	gen  *sourcemap.Generator
+}
+
+func (w *chunkWriter) write(s string) {
+	w.b.WriteString(s)
+	if n := strings.Count(s, "\n"); n > 0 {
+		w.line += n
+		w.col = len(s) - strings.LastIndex(s, "\n") - 1
+	} else {
+		w.col += len(s)
+	}
+}
+
+// addMapping maps the position line:col of the text about to be written,
+// relative to the current output position, to a position in source.
+func (w *chunkWriter) addMapping(source, line, col, origLine, origCol int, name string) {
+	mapping := sourcemap.Mapping{
+		GeneratedLine:   w.line + line,
+		GeneratedColumn: col,
+		Source:          source,
+		OriginalLine:    origLine,
+		OriginalColumn:  origCol,
+		Name:            -1,
+	}
+	if line == 0 {
+		mapping.GeneratedColumn += w.col
+	}
+	if name != "" {
+		mapping.Name = w.gen.AddName(name)
+	}
+	w.gen.AddMapping(mapping)
+}
+
+// mapLines maps every line of code, which is about to be written at the
+// start of a line, to the same line of m.
+func (w *chunkWriter) mapLines(m *Module, code string) {
+	source := w.gen.AddSource(m.ID, m.Source)
+	for i, n := 0, strings.Count(code, "\n"); i <= n; i++ {
+		w.addMapping(source, i, 0, i, 0, "")
+	}
+}
+
+// entryChunk returns the chunk of an entry without code splitting: it
+// registers every module reachable from entry, and its styles hold every
+// stylesheet in the graph, including the ones they import.
+func (b *Bundler) entryChunk(name string, entry *Module) *Chunk {
+	return &Chunk{
+		Name:    name,
+		Kind:    ChunkEntry,
+		Entry:   entry,
+		Modules: b.order([]*Module{entry}),
+		scripts: b.scriptModules(entry),
+	}
+}
+
+// link generates the script and styles of chunk.
+func (b *Bundler) link(chunk *Chunk) error {
+	chunk.minify = b.opts.Minify
+	w := &chunkWriter{}
+	if b.opts.SourceMap {
+		w.gen = sourcemap.NewGenerator("")
+	}
+
+	if chunk.Kind == ChunkEntry {
+		runtime := runtimeSource
+		if b.opts.Minify {
+			compact, _, err := js.Minify(runtimeSource, js.MinifyOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to compact runtime: %w", err)
+			}
+			runtime = compact + "\n"
+		}
+		w.write(runtime)
+	}
+	w.write("__gb.define({")
+
+	for i, m := range chunk.scripts {
+		if i > 0 {
+			w.write(",")
+		}
+		if err := b.writeModule(w, m); err != nil {
+			return err
+		}
+	}
+
+	w.write("\n});\n")
+
+	chunk.Code = w.b.String()
+	if w.gen != nil {
+		chunk.Map = w.gen.SourceMap(true)
+	}
+	return b.linkStyles(chunk)
+}
+
+// Start returns the code that runs an entry chunk, to be appended to its
+// Code. It tells the runtime which files import() has to load for every
+// chunk reachable from the entry, loads the scripts of the shared chunks
+// the entry imports and then runs the entry module. urls returns the URL
+// of a chunk's script followed by that of its styles, if it has any. Other
+// chunks need no start code.
+func (c *Chunk) Start(urls func(*Chunk) []string) string {
+	if c.Kind != ChunkEntry {
+		return ""
+	}
+	sep, indent := ", ", " "
+	if c.minify {
+		sep, indent = ",", ""
+	}
+
+	// Map every import() target reachable from the entry to the files of
+	// its chunk and the shared chunks that chunk imports.
+	var targets []string
+	visited := make(map[*Chunk]bool)
+	queue := append([]*Chunk{c}, c.Imports...)
+	for len(queue) > 0 {
+		chunk := queue[0]
+		queue = queue[1:]
+		if visited[chunk] {
+			continue
+		}
+		visited[chunk] = true
+		for i, async := range chunk.DynamicImports {
+			var files []string
+			for _, dep := range append([]*Chunk{async}, async.Imports...) {
+				for _, url := range urls(dep) {
+					files = append(files, js.Quote(url))
+				}
+			}
+			targets = append(targets, chunk.targets[i].ref+":"+indent+"["+strings.Join(files, sep)+"]")
+			queue = append(queue, async)
+			queue = append(queue, async.Imports...)
+		}
+	}
+
+	var b strings.Builder
+	if len(targets) > 0 {
+		b.WriteString("__gb.chunks({" + strings.Join(targets, sep) + "});\n")
+	}
+	run := "__gb.require(" + c.Entry.ref + ");"
+	if len(c.Imports) == 0 {
+		b.WriteString(run + "\n")
+		return b.String()
+	}
+	scripts := make([]string, len(c.Imports))
+	for i, dep := range c.Imports {
+		scripts[i] = js.Quote(urls(dep)[0])
+	}
+	if c.minify {
+		fmt.Fprintf(&b, "__gb.load([%s]).then(function(){%s});\n", strings.Join(scripts, ","), run)
+	} else {
+		fmt.Fprintf(&b, "__gb.load([%s]).then(function () { %s });\n", strings.Join(scripts, ", "), run)
+	}
+	return b.String()
+}
+
+// linkStyles concatenates the stylesheets of chunk.
+func (b *Bundler) linkStyles(chunk *Chunk) error {
+	w := &chunkWriter{}
+	if b.opts.SourceMap {
+		w.gen = sourcemap.NewGenerator("")
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range chunk.Modules {
+		for _, rule := range m.styleImports {
+			if !seen[rule] {
+				seen[rule] = true
+				w.write(rule + b.styleBreak())
+			}
+		}
+	}
+
+	direct := directStyles(chunk.Modules, chunk.scripts)
+	for _, m := range chunk.Modules {
+		if m.Kind != KindCSS || !direct[m] {
+			continue
+		}
+		if err := b.writeStyle(w, m, false, make(map[*Module]bool)); err != nil {
+			return err
+		}
+	}
+
+	chunk.CSS = w.b.String()
+	if w.gen != nil && chunk.CSS != "" {
+		chunk.CSSMap = w.gen.SourceMap(true)
+	}
+	return nil
+}
+
+// scriptModules lists the modules reachable from entry without following
+// the imports of stylesheets, dependencies first.
+func (b *Bundler) scriptModules(entry *Module) []*Module {
+	var order []*Module
+	visited := make(map[*Module]bool)
+	var visit func(m *Module)
+	visit = func(m *Module) {
+		if visited[m] || m.removed {
+			return
+		}
+		visited[m] = true
+		if m.Kind != KindCSS {
+			for _, record := range m.Imports {
+				visit(record.Module)
+			}
+		}
+		order = append(order, m)
+	}
+	visit(entry)
+	return order
+}
+
+// writeModule emits the registry entry for m:
+//
+//	<ref>: function (module, exports, require) {<export getters>
+//	<module code>
+//	}
+//
+// The module code starts on a line of its own so that, without minification,
+// every output line maps to the same line in the original source.
+func (b *Bundler) writeModule(w *chunkWriter, m *Module) error {
+	if b.opts.Minify {
+		w.write("\n" + m.ref + ":function(module,exports,require){")
+	} else {
+		w.write("\n" + m.ref + ": function (module, exports, require) {")
+	}
+	if m.esm {
+		w.write(b.exportHeader(m))
+	}
+	w.write("\n")
+
+	code := b.moduleCode(m)
+
+	switch {
+	case m.Kind != KindJS:
+		w.write(code)
+	case b.opts.Minify:
+		if w.gen != nil {
+			source := w.gen.AddSource(m.ID, m.Source)
+			for _, seg := range m.segments {
+				w.addMapping(source, seg.GeneratedLine, seg.GeneratedColumn, seg.OriginalLine, seg.OriginalColumn, seg.Name)
+			}
+		}
+		w.write(m.minified)
+	default:
+		if w.gen != nil {
+			w.mapLines(m, code)
+		}
+		w.write(code)
+	}
+
+	w.write("\n}")
+	return nil
+}
+
+// moduleCode returns the body of the module function for m. JavaScript
+// modules must have been prepared.
+func (b *Bundler) moduleCode(m *Module) string {
+	switch m.Kind {
+	case KindJS:
+		return m.transformed
+	case KindJSON:
+		return "module.exports = " + strings.TrimSpace(m.Source) + ";"
+	case KindAsset:
+		return "module.exports = " + js.Quote(b.opts.PublicPath+m.mediaPath) + ";"
+	}
+	return ""
+}
+
+// exportHeader renders the getters that expose an ES module's exports.
+func (b *Bundler) exportHeader(m *Module) string {
+	exports := m.liveExports()
+	getters := make([]string, 0, len(exports))
+	for _, e := range exports {
+		key := e.name
+		if !isIdentifierName(key) {
+			key = strconv.Quote(key)
+		}
+		if b.opts.Minify {
+			getters = append(getters, key+":function(){return "+e.local+"}")
+		} else {
+			getters = append(getters, key+": function () { return "+e.local+"; }")
+		}
+	}
+	if b.opts.Minify {
+		return "require.esm(exports,{" + strings.Join(getters, ",") + "});"
+	}
+	return " require.esm(exports, {" + strings.Join(getters, ", ") + "});"
+}
+
+// headerNames returns the names the module function of m defines and the
+// ones its export header reads from the module body.
+func headerNames(m *Module) []string {
+	names := []string{"module", "exports", "require"}
+	for _, e := range m.liveExports() {
+		toks, err := js.Tokenize(e.local)
+		if err != nil {
+			continue
+		}
+		for i, t := range toks {
+			if t.Kind == js.TokenIdentifier && (i == 0 || !toks[i-1].IsPunct(".")) {
+				names = append(names, t.Value)
+			}
+		}
+	}
+	return names
+}
+
+// registryRef substitutes import placeholders in scripts.
+func registryRef(record *ImportRecord) (string, error) {
+	return record.Module.ref, nil
+}
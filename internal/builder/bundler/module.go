@@ -0,0 +1,252 @@
+package bundler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+)
+
+type ModuleKind int
+
+const (
+	KindJS ModuleKind = iota
+	KindJSON
+	KindCSS
+	KindAsset
+	KindEmpty
+)
+
+type ImportKind int
+
+const (
+	// ImportStatic is an import declaration.
+	ImportStatic ImportKind = iota
+	// ImportReexport is an "export ... from" declaration.
+	ImportReexport
+	// ImportDynamic is an import() expression.
+	ImportDynamic
+	// ImportRequire is a CommonJS require() call.
+	ImportRequire
+	// ImportURL is a url() reference in a stylesheet.
+	ImportURL
+)
+
+// ImportRecord is one dependency edge found in a module.
+type ImportRecord struct {
+	Specifier string
+	Kind      ImportKind
+	// Names lists the bindings the module uses from the dependency, with
+	// "default" for default imports. Namespace is set when the whole module object escapes
+	// (namespace imports, export * and require()).
+	Names     []string
+	Namespace bool
+	Module    *Module
+
+	// condition is the media query list, supports() and layer of a
+	// stylesheet @import.
+	condition string
+	// suffix is the query and fragment of a url() reference, such as
+	// "#icon".
+	suffix string
+}
+
+type Module struct {
+	// ID is the module path relative to the project root using forward
+	// slashes. It is stable across builds and used in diagnostics.
+	ID      string
+	Path    string
+	Kind    ModuleKind
+	Source  string
+	Imports []*ImportRecord
+
+	// ref is the key the module is registered under in emitted chunks.
+	ref string
+	// esm is set for modules using import/export syntax.
+	esm         bool
+	exports     []exportEntry
+	edits       []edit
+	mediaPath   string
+	transformed string
+	// minified and segments hold transformed after js.Minify when minifying.
+	minified string
+	segments []js.Segment
+	// code is what edits apply to: Source, or for a JavaScript module
+	// written in TypeScript or using JSX, the JavaScript compiled from it.
+	// It has the lines of Source, so source maps still point at Source.
+	code string
+	// hash is the SHA-256 of Source, set for JavaScript modules.
+	hash string
+	// styleImports are the @import rules of a stylesheet that refer to
+	// other sites. They are hoisted to the top of the chunk's styles.
+	styleImports []string
+
+	// Tree shaking state. removed is set for modules left out of the
+	// bundle. used holds the exports still needed, or nil when all are,
+	// and dropped the declarations of unused exports cut from code.
+	removed bool
+	used    map[string]bool
+	dropped []edit
+}
+
+// exportEntry maps an exported name to the expression its getter returns.
+type exportEntry struct {
+	name  string
+	local string
+	// reexport is one plus the index of the import record the value is
+	// taken from, or 0 for local exports. imported is its name there, "*"
+	// for the whole module.
+	reexport int
+	imported string
+	// start and end delimit the declaration of a local export in code
+	// when it declares nothing else; end is 0 otherwise. pure is set when
+	// evaluating the declaration has no side effects.
+	start int
+	end   int
+	pure  bool
+}
+
+// edit replaces code[start:end] with text. Text may reference the
+// resolved module of an import record through recordRef placeholders.
+type edit struct {
+	start int
+	end   int
+	text  string
+	// statement is one plus the index of the import record the edit loads,
+	// or 0. The edit is dropped when tree shaking removes that module.
+	statement int
+}
+
+// IsESM reports whether the module uses ES module syntax.
+func (m *Module) IsESM() bool {
+	return m.esm
+}
+
+// liveExports returns the exports that are still used.
+func (m *Module) liveExports() []exportEntry {
+	if m.used == nil {
+		return m.exports
+	}
+	var live []exportEntry
+	for _, e := range m.exports {
+		if m.used[e.name] {
+			live = append(live, e)
+		}
+	}
+	return live
+}
+
+// ExportNames lists the names the module exports directly, sorted.
+func (m *Module) ExportNames() []string {
+	names := make([]string, 0, len(m.exports))
+	for _, e := range m.exports {
+		names = append(names, e.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const placeholderMark = "\x00"
+
+// recordRef returns a placeholder for the module behind import record idx:
+// its registry key in scripts and its URL in stylesheets. Placeholders are
+// substituted once every module has been assigned a key.
+func recordRef(idx int) string {
+	return placeholderMark + strconv.Itoa(idx) + placeholderMark
+}
+
+// kindForPath classifies a module by file extension.
+func kindForPath(path string) ModuleKind {
+	if path == emptyModule {
+		return KindEmpty
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".mts", ".cts":
+		return KindJS
+	case ".json":
+		return KindJSON
+	case ".css":
+		return KindCSS
+	default:
+		return KindAsset
+	}
+}
+
+// transformOptions returns what js.Transform compiles in the module at
+// path: TypeScript by extension, and JSX in .jsx and .tsx files and in the
+// project's .js files, which Create React App allows to contain it.
+func transformOptions(path string) js.TransformOptions {
+	ext := strings.ToLower(filepath.Ext(path))
+	inPackage := strings.Contains(filepath.ToSlash(path), "/node_modules/")
+	return js.TransformOptions{
+		TypeScript: ext == ".ts" || ext == ".tsx" || ext == ".mts" || ext == ".cts",
+		JSX:        ext == ".jsx" || ext == ".tsx" || ext == ".js" && !inPackage,
+	}
+}
+
+// applyEdits rewrites the module source, substituting recordRef
+// placeholders with the text ref returns for their import record. Every
+// replacement keeps the number of line breaks of the text it replaces so
+// that original line numbers stay valid for source maps. Dropped
+// declarations take precedence over the edits inside them.
+func (m *Module) applyEdits(ref func(record *ImportRecord) (string, error)) (string, error) {
+	edits := append(append([]edit{}, m.dropped...), m.edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	pos := 0
+	for _, e := range edits {
+		if e.start < pos {
+			continue
+		}
+		b.WriteString(m.code[pos:e.start])
+
+		if e.statement > 0 && m.Imports[e.statement-1].Module.removed {
+			e.text = ""
+		}
+		text, err := m.substituteRefs(e.text, ref)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+
+		removed := strings.Count(m.code[e.start:e.end], "\n") - strings.Count(text, "\n")
+		for i := 0; i < removed; i++ {
+			b.WriteByte('\n')
+		}
+		pos = e.end
+	}
+	b.WriteString(m.code[pos:])
+	return b.String(), nil
+}
+
+func (m *Module) substituteRefs(text string, ref func(record *ImportRecord) (string, error)) (string, error) {
+	if !strings.Contains(text, placeholderMark) {
+		return text, nil
+	}
+	parts := strings.Split(text, placeholderMark)
+	var b strings.Builder
+	for i, part := range parts {
+		if i%2 == 0 {
+			b.WriteString(part)
+			continue
+		}
+		idx, err := strconv.Atoi(part)
+		if err != nil || idx >= len(m.Imports) {
+			return "", fmt.Errorf("invalid import reference in %s", m.ID)
+		}
+		record := m.Imports[idx]
+		if record.Module == nil {
+			return "", fmt.Errorf("unresolved import %q in %s", record.Specifier, m.ID)
+		}
+		text, err := ref(record)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,414 @@
+package bundler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// emptyModule is the resolution result for specifiers that a package's
+// "browser" field maps to false.
+const emptyModule = "(empty)"
+
+var defaultExtensions = []string{".tsx", ".ts", ".jsx", ".js", ".mjs", ".cjs", ".css", ".json"}
+
+type Resolver struct {
+	extensions []string
+	conditions []string
+	packages   map[string]*packageJSON
+	mu         sync.Mutex
+}
+
+type packageJSON struct {
+	dir         string
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Main        string          `json:"main"`
+	Module      string          `json:"module"`
+	Browser     json.RawMessage `json:"browser"`
+	Exports     json.RawMessage `json:"exports"`
+	SideEffects json.RawMessage `json:"sideEffects"`
+
+	sideEffectsOnce sync.Once
+	// sideEffects is nil when every file of the package has side effects,
+	// and otherwise matches the paths of those that do.
+	sideEffects *regexp.Regexp
+}
+
+// NewResolver creates a resolver that prefers browser and ES module entry
+// points. The mode ("development" or "production") is added as an export
+// condition so packages can ship mode-specific builds.
+func NewResolver(mode string) *Resolver {
+	return &Resolver{
+		extensions: defaultExtensions,
+		conditions: []string{"browser", "import", "module", mode, "default"},
+		packages:   make(map[string]*packageJSON),
+	}
+}
+
+// Resolve maps an import specifier found in importer to an absolute file
+// path.
+func (r *Resolver) Resolve(specifier, importer string) (string, error) {
+	if specifier == "" {
+		return "", fmt.Errorf("empty import specifier")
+	}
+
+	dir := filepath.Dir(importer)
+	var resolved string
+	var ok bool
+
+	switch {
+	case strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || specifier == "." || specifier == "..":
+		resolved, ok = r.resolvePath(filepath.Join(dir, filepath.FromSlash(specifier)))
+	case filepath.IsAbs(specifier):
+		resolved, ok = r.resolvePath(specifier)
+	default:
+		if pkg := r.nearestPackage(dir); pkg != nil {
+			if mapped, found := pkg.browserMapping(specifier); found {
+				resolved, ok = r.resolveMapped(pkg, mapped)
+				break
+			}
+		}
+		resolved, ok = r.resolvePackage(specifier, dir)
+	}
+
+	if !ok {
+		return "", fmt.Errorf("cannot resolve %q from %s", specifier, importer)
+	}
+	if resolved == emptyModule {
+		return resolved, nil
+	}
+	return r.applyBrowserField(resolved), nil
+}
+
+// resolvePath resolves a file or directory path by trying the exact file,
+// known extensions, a package.json entry point and index files.
+func (r *Resolver) resolvePath(p string) (string, bool) {
+	if isFile(p) {
+		return p, true
+	}
+	for _, ext := range r.extensions {
+		if isFile(p + ext) {
+			return p + ext, true
+		}
+	}
+	if !isDir(p) {
+		return "", false
+	}
+	if pkg := r.loadPackage(p); pkg != nil {
+		if entry := pkg.entryPoint(); entry != "" {
+			if resolved, ok := r.resolvePath(filepath.Join(p, filepath.FromSlash(entry))); ok {
+				return resolved, true
+			}
+		}
+	}
+	for _, ext := range r.extensions {
+		index := filepath.Join(p, "index"+ext)
+		if isFile(index) {
+			return index, true
+		}
+	}
+	return "", false
+}
+
+// resolvePackage walks up from dir looking for node_modules/<name>.
+func (r *Resolver) resolvePackage(specifier, dir string) (string, bool) {
+	name, subpath := splitPackageSpecifier(specifier)
+
+	for current := dir; ; current = filepath.Dir(current) {
+		pkgDir := filepath.Join(current, "node_modules", filepath.FromSlash(name))
+		if isDir(pkgDir) {
+			if resolved, ok := r.resolveInPackage(pkgDir, subpath); ok {
+				return resolved, true
+			}
+		}
+		if parent := filepath.Dir(current); parent == current {
+			return "", false
+		}
+	}
+}
+
+func (r *Resolver) resolveInPackage(pkgDir, subpath string) (string, bool) {
+	pkg := r.loadPackage(pkgDir)
+	if pkg != nil && len(pkg.Exports) > 0 {
+		var exports interface{}
+		if err := json.Unmarshal(pkg.Exports, &exports); err == nil {
+			if target, ok := resolveExports(exports, "."+subpath, r.conditions); ok {
+				return r.resolvePath(filepath.Join(pkgDir, filepath.FromSlash(target)))
+			}
+		}
+	}
+
+	if subpath == "" {
+		return r.resolvePath(pkgDir)
+	}
+	return r.resolvePath(filepath.Join(pkgDir, filepath.FromSlash(strings.TrimPrefix(subpath, "/"))))
+}
+
+// applyBrowserField honours file replacements declared in the nearest
+// package.json "browser" object.
+func (r *Resolver) applyBrowserField(file string) string {
+	pkg := r.nearestPackage(filepath.Dir(file))
+	if pkg == nil {
+		return file
+	}
+	rel, err := filepath.Rel(pkg.dir, file)
+	if err != nil {
+		return file
+	}
+	rel = "./" + filepath.ToSlash(rel)
+	candidates := []string{rel, strings.TrimSuffix(rel, path.Ext(rel))}
+	for _, candidate := range candidates {
+		if mapped, ok := pkg.browserMapping(candidate); ok {
+			if resolved, ok := r.resolveMapped(pkg, mapped); ok {
+				return resolved
+			}
+		}
+	}
+	return file
+}
+
+func (r *Resolver) resolveMapped(pkg *packageJSON, mapped string) (string, bool) {
+	if mapped == "" {
+		return emptyModule, true
+	}
+	return r.resolvePath(filepath.Join(pkg.dir, filepath.FromSlash(mapped)))
+}
+
+// nearestPackage returns the package.json closest to dir, if any.
+func (r *Resolver) nearestPackage(dir string) *packageJSON {
+	for current := dir; ; current = filepath.Dir(current) {
+		if pkg := r.loadPackage(current); pkg != nil {
+			return pkg
+		}
+		if parent := filepath.Dir(current); parent == current {
+			return nil
+		}
+	}
+}
+
+func (r *Resolver) loadPackage(dir string) *packageJSON {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pkg, ok := r.packages[dir]; ok {
+		return pkg
+	}
+
+	var pkg *packageJSON
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var parsed packageJSON
+		if err := json.Unmarshal(data, &parsed); err == nil {
+			parsed.dir = dir
+			pkg = &parsed
+		}
+	}
+	r.packages[dir] = pkg
+	return pkg
+}
+
+// SideEffects reports whether evaluating file may have side effects, which
+// the nearest package.json can deny with "sideEffects": false or a list of
+// the files that do have them.
+func (r *Resolver) SideEffects(file string) bool {
+	pkg := r.nearestPackage(filepath.Dir(file))
+	if pkg == nil {
+		return true
+	}
+	pkg.sideEffectsOnce.Do(pkg.parseSideEffects)
+	if pkg.sideEffects == nil {
+		return true
+	}
+	rel, err := filepath.Rel(pkg.dir, file)
+	if err != nil {
+		return true
+	}
+	return pkg.sideEffects.MatchString(filepath.ToSlash(rel))
+}
+
+// parseSideEffects compiles the "sideEffects" field. As in webpack, list
+// entries are globs relative to the package, and entries without a slash
+// match file names in any directory.
+func (p *packageJSON) parseSideEffects() {
+	var flag bool
+	if err := json.Unmarshal(p.SideEffects, &flag); err == nil {
+		if !flag {
+			p.sideEffects = regexp.MustCompile(`^$`)
+		}
+		return
+	}
+	var globs []string
+	if err := json.Unmarshal(p.SideEffects, &globs); err != nil {
+		return
+	}
+	alternatives := make([]string, 0, len(globs))
+	for _, glob := range globs {
+		glob = strings.TrimPrefix(glob, "./")
+		if !strings.Contains(glob, "/") {
+			glob = "**/" + glob
+		}
+		alternatives = append(alternatives, globRegexp(glob))
+	}
+	p.sideEffects = regexp.MustCompile(`^(?:` + strings.Join(alternatives, "|") + `)$`)
+}
+
+// globRegexp translates a glob with *, ** and ? to a regular expression.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString(`(?:.*/)?`)
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(`.*`)
+			i++
+		case glob[i] == '*':
+			b.WriteString(`[^/]*`)
+		case glob[i] == '?':
+			b.WriteString(`[^/]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// entryPoint picks the package entry, preferring the browser and ES module
+// fields over main.
+func (p *packageJSON) entryPoint() string {
+	var browser string
+	if err := json.Unmarshal(p.Browser, &browser); err == nil && browser != "" {
+		return browser
+	}
+	if p.Module != "" {
+		return p.Module
+	}
+	return p.Main
+}
+
+// browserMapping looks up key in an object-valued "browser" field. A mapping
+// to false is reported as an empty string.
+func (p *packageJSON) browserMapping(key string) (string, bool) {
+	var mappings map[string]interface{}
+	if err := json.Unmarshal(p.Browser, &mappings); err != nil {
+		return "", false
+	}
+	value, ok := mappings[key]
+	if !ok {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		if !v {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// resolveExports implements the subset of the package.json "exports"
+// algorithm needed for browser bundling: subpath maps, "*" patterns, nested
+// conditions and fallback arrays.
+func resolveExports(exports interface{}, subpath string, conditions []string) (string, bool) {
+	if obj, ok := exports.(map[string]interface{}); ok && !hasSubpathKeys(obj) {
+		if subpath != "." {
+			return "", false
+		}
+		return resolveExportTarget(obj, conditions, "")
+	}
+	if _, ok := exports.(map[string]interface{}); !ok {
+		if subpath != "." {
+			return "", false
+		}
+		return resolveExportTarget(exports, conditions, "")
+	}
+
+	obj := exports.(map[string]interface{})
+	if target, ok := obj[subpath]; ok {
+		return resolveExportTarget(target, conditions, "")
+	}
+	// Like Node, prefer the pattern with the longest prefix before the "*",
+	// then the longest pattern, so the match does not depend on map order.
+	best, bestStar := "", -1
+	for key := range obj {
+		star := strings.Index(key, "*")
+		if star < 0 || strings.Contains(key[star+1:], "*") {
+			continue
+		}
+		prefix, suffix := key[:star], key[star+1:]
+		if !strings.HasPrefix(subpath, prefix) || !strings.HasSuffix(subpath, suffix) || len(subpath) < len(prefix)+len(suffix) {
+			continue
+		}
+		if star > bestStar || (star == bestStar && len(key) > len(best)) {
+			best, bestStar = key, star
+		}
+	}
+	if bestStar < 0 {
+		return "", false
+	}
+	suffix := best[bestStar+1:]
+	return resolveExportTarget(obj[best], conditions, subpath[bestStar:len(subpath)-len(suffix)])
+}
+
+func hasSubpathKeys(obj map[string]interface{}) bool {
+	for key := range obj {
+		if strings.HasPrefix(key, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveExportTarget(target interface{}, conditions []string, pattern string) (string, bool) {
+	switch t := target.(type) {
+	case string:
+		return strings.ReplaceAll(t, "*", pattern), true
+	case []interface{}:
+		for _, candidate := range t {
+			if resolved, ok := resolveExportTarget(candidate, conditions, pattern); ok {
+				return resolved, true
+			}
+		}
+	case map[string]interface{}:
+		for _, condition := range conditions {
+			if nested, ok := t[condition]; ok {
+				if resolved, ok := resolveExportTarget(nested, conditions, pattern); ok {
+					return resolved, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// splitPackageSpecifier splits "@scope/pkg/sub/path" into the package name
+// and the "/sub/path" remainder.
+func splitPackageSpecifier(specifier string) (string, string) {
+	parts := strings.Split(specifier, "/")
+	n := 1
+	if strings.HasPrefix(specifier, "@") && len(parts) > 1 {
+		n = 2
+	}
+	if len(parts) <= n {
+		return specifier, ""
+	}
+	return strings.Join(parts[:n], "/"), "/" + strings.Join(parts[n:], "/")
+}
+
+func isFile(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
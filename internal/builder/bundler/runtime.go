@@ -0,0 +1,97 @@
+package bundler
+
+// runtimeSource is prepended to every entry chunk. It keeps a single module
+// registry per page so that several chunks can share modules, and provides
+// the helpers that the ES module transform emits calls to:
+//
+//	require.esm(exports, getters)  marks exports as an ES module namespace
+//	require.interop(m)             returns the default export of m
+//	require.star(exports, m)       re-exports every name of m
+//	require.dynamic(id)            implements import()
+//
+// With code splitting, entry chunks also register the files of the chunks
+// import() loads with chunks, and load the shared chunks they need with
+// load before running.
+const runtimeSource = `var __gb = (function (global) {
+  if (global.__gb) return global.__gb;
+  var definitions = {};
+  var cache = {};
+  var files = {};
+  var loading = {};
+  function require(id) {
+    var cached = cache[id];
+    if (cached) return cached.exports;
+    var definition = definitions[id];
+    if (!definition) throw new Error("gobuild: module " + id + " is not registered");
+    var module = cache[id] = { id: id, exports: {} };
+    definition.call(module.exports, module, module.exports, require);
+    return module.exports;
+  }
+  require.esm = function (exports, getters) {
+    Object.defineProperty(exports, "__esModule", { value: true });
+    for (var name in getters) {
+      Object.defineProperty(exports, name, { enumerable: true, get: getters[name] });
+    }
+  };
+  require.interop = function (m) {
+    return m && m.__esModule ? m["default"] : m;
+  };
+  require.star = function (exports, m) {
+    Object.keys(m).forEach(function (name) {
+      if (name === "default" || name === "__esModule" || Object.prototype.hasOwnProperty.call(exports, name)) return;
+      Object.defineProperty(exports, name, { enumerable: true, get: function () { return m[name]; } });
+    });
+  };
+  require.dynamic = function (id) {
+    var ready = id in definitions ? Promise.resolve() : load(files[id] || []);
+    return ready.then(function () { return require(id); });
+  };
+  // load fetches the script and stylesheet files in parallel, each once.
+  function load(urls) {
+    return Promise.all(urls.map(function (url) {
+      if (!loading[url]) {
+        loading[url] = fetchFile(url).catch(function (error) {
+          delete loading[url];
+          throw error;
+        });
+      }
+      return loading[url];
+    }));
+  }
+  function fetchFile(url) {
+    var css = /\.css(\?|$)/.test(url);
+    if (typeof document === "undefined") {
+      return css ? Promise.resolve() : import(url);
+    }
+    if (css && document.querySelector('link[rel="stylesheet"][href="' + url + '"]')) {
+      return Promise.resolve();
+    }
+    return new Promise(function (resolve, reject) {
+      var element = document.createElement(css ? "link" : "script");
+      if (css) {
+        element.rel = "stylesheet";
+        element.href = url;
+      } else {
+        element.async = true;
+        element.src = url;
+      }
+      element.crossOrigin = "anonymous";
+      element.onload = function () { resolve(); };
+      element.onerror = function () { reject(new Error("gobuild: failed to load " + url)); };
+      document.head.appendChild(element);
+    });
+  }
+  return global.__gb = {
+    define: function (modules) {
+      for (var id in modules) {
+        if (!(id in definitions)) definitions[id] = modules[id];
+      }
+    },
+    chunks: function (targets) {
+      for (var id in targets) files[id] = targets[id];
+    },
+    load: load,
+    require: require
+  };
+})(typeof globalThis !== "undefined" ? globalThis : self);
+`
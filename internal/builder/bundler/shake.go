@@ -0,0 +1,276 @@
+package bundler
+
+import (
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+)
+
+// Removal is a module, or some exports of a module, that tree shaking left
+// out of the bundle.
+type Removal struct {
+	Module string `json:"module"`
+	// Exports lists the unused exports of a module that was kept. It is
+	// empty when the whole module was removed.
+	Exports []string `json:"exports,omitempty"`
+}
+
+// shaker finds the modules and exports a bundle needs, starting from the
+// entries. A module is needed when a needed module imports names from it,
+// or imports it at all and it may have side effects; re-exports only pass
+// on the names actually used.
+type shaker struct {
+	b    *Bundler
+	live map[*Module]bool
+	// all marks modules whose whole namespace is used; used holds the
+	// exports used from the others.
+	all  map[*Module]bool
+	used map[*Module]map[string]bool
+}
+
+// shake marks the modules among modules that roots do not need as removed,
+// drops the unused exports of the others and reports what was removed.
+// Every export of the roots and of import() targets is used.
+func (b *Bundler) shake(roots, modules []*Module) []Removal {
+	s := &shaker{
+		b:    b,
+		live: make(map[*Module]bool),
+		all:  make(map[*Module]bool),
+		used: make(map[*Module]map[string]bool),
+	}
+	for _, root := range roots {
+		s.useAll(root)
+	}
+
+	var removals []Removal
+	for _, m := range modules {
+		if !s.live[m] {
+			m.removed = true
+			removals = append(removals, Removal{Module: m.ID})
+			continue
+		}
+		if !m.esm || s.all[m] {
+			continue
+		}
+		m.used = s.used[m]
+		if m.used == nil {
+			m.used = make(map[string]bool)
+		}
+		var unused []string
+		for _, e := range m.exports {
+			if !m.used[e.name] {
+				unused = append(unused, e.name)
+			}
+		}
+		if len(unused) > 0 {
+			m.dropped = s.dropDeclarations(m)
+			removals = append(removals, Removal{Module: m.ID, Exports: unused})
+		}
+	}
+	return removals
+}
+
+// sideEffects reports whether evaluating m may have side effects.
+func (s *shaker) sideEffects(m *Module) bool {
+	switch m.Kind {
+	case KindCSS:
+		return true
+	case KindEmpty:
+		return false
+	}
+	return s.b.resolver.SideEffects(m.Path)
+}
+
+func (s *shaker) markLive(m *Module) {
+	if s.live[m] {
+		return
+	}
+	s.live[m] = true
+	for _, record := range m.Imports {
+		target := record.Module
+		switch {
+		case m.Kind == KindCSS:
+			s.markLive(target)
+		case record.Kind == ImportReexport:
+			// Re-exported names are used on demand, in use.
+			if s.sideEffects(target) {
+				s.markLive(target)
+			}
+		default:
+			if record.Namespace {
+				s.useAll(target)
+			}
+			for _, name := range record.Names {
+				s.use(target, name)
+			}
+			if s.sideEffects(target) {
+				s.markLive(target)
+			}
+		}
+	}
+}
+
+// use marks the export name of m as used, along with what it re-exports.
+func (s *shaker) use(m *Module, name string) {
+	if s.all[m] || s.used[m][name] {
+		return
+	}
+	if s.used[m] == nil {
+		s.used[m] = make(map[string]bool)
+	}
+	s.used[m][name] = true
+	s.markLive(m)
+
+	found := false
+	for _, e := range m.exports {
+		if e.name == name {
+			found = true
+			s.useReexport(m, e)
+		}
+	}
+	// Names a module does not export itself may come from export *, which
+	// never passes on default exports.
+	if !found && name != "default" {
+		for _, target := range starTargets(m) {
+			if provides(target, name, make(map[*Module]bool)) {
+				s.use(target, name)
+			}
+		}
+	}
+}
+
+// provides reports whether m exports name itself or through export *.
+// The exports of CommonJS modules are not known, so they may provide any
+// name.
+func provides(m *Module, name string, visited map[*Module]bool) bool {
+	if !m.esm {
+		return true
+	}
+	if visited[m] {
+		return false
+	}
+	visited[m] = true
+	for _, e := range m.exports {
+		if e.name == name {
+			return true
+		}
+	}
+	for _, target := range starTargets(m) {
+		if provides(target, name, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// useAll marks every export of m as used.
+func (s *shaker) useAll(m *Module) {
+	if s.all[m] {
+		return
+	}
+	s.all[m] = true
+	s.markLive(m)
+	for _, e := range m.exports {
+		s.useReexport(m, e)
+	}
+	for _, target := range starTargets(m) {
+		s.useAll(target)
+	}
+}
+
+func (s *shaker) useReexport(m *Module, e exportEntry) {
+	if e.reexport == 0 {
+		return
+	}
+	target := m.Imports[e.reexport-1].Module
+	if e.imported == "*" {
+		s.useAll(target)
+	} else {
+		s.use(target, e.imported)
+	}
+}
+
+// starTargets returns the modules m re-exports with "export * from".
+func starTargets(m *Module) []*Module {
+	aliased := make(map[int]bool)
+	for _, e := range m.exports {
+		if e.reexport > 0 {
+			aliased[e.reexport-1] = true
+		}
+	}
+	var targets []*Module
+	for i, record := range m.Imports {
+		if record.Kind == ImportReexport && record.Namespace && !aliased[i] {
+			targets = append(targets, record.Module)
+		}
+	}
+	return targets
+}
+
+// dropDeclarations returns edits cutting the declarations of unused exports
+// that nothing else in m refers to. Declarations with side effects are only
+// cut from modules whose package declares them free of side effects. Cutting
+// one declaration may leave others unreferenced, so this repeats until
+// nothing changes.
+func (s *shaker) dropDeclarations(m *Module) []edit {
+	pure := !s.sideEffects(m)
+	var candidates []exportEntry
+	for _, e := range m.exports {
+		if !m.used[e.name] && e.end > 0 && (e.pure || pure) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	toks, err := js.Tokenize(m.code)
+	if err != nil {
+		return nil
+	}
+	// Names exported more than once may still be used under another name.
+	exported := make(map[string]int)
+	for _, e := range m.exports {
+		exported[e.local]++
+	}
+
+	var dropped []edit
+	inDropped := func(pos int) bool {
+		for _, d := range dropped {
+			if pos >= d.start && pos < d.end {
+				return true
+			}
+		}
+		return false
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(candidates); i++ {
+			e := candidates[i]
+			if exported[e.local] > 1 || referenced(toks, e, inDropped) {
+				continue
+			}
+			dropped = append(dropped, edit{start: e.start, end: e.end})
+			candidates = append(candidates[:i], candidates[i+1:]...)
+			i--
+			changed = true
+		}
+	}
+	return dropped
+}
+
+// referenced reports whether a token outside the declaration of e and the
+// dropped declarations refers to its local name. Property names after a
+// dot are not references; other uses of the name are counted to be safe.
+func referenced(toks []js.Token, e exportEntry, inDropped func(int) bool) bool {
+	for i, t := range toks {
+		if t.Kind != js.TokenIdentifier || t.Value != e.local {
+			continue
+		}
+		if i > 0 && (toks[i-1].IsPunct(".") || toks[i-1].IsPunct("?.")) {
+			continue
+		}
+		if (t.Start >= e.start && t.Start < e.end) || inDropped(t.Start) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,306 @@
+package bundler
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SplitOptions configures code splitting: every import() target starts a
+// chunk of its own, and modules needed by several chunks move to shared
+// chunks.
+type SplitOptions struct {
+	// MinSize is the smallest shared chunk, in bytes of module code.
+	// Smaller groups of shared modules are copied into every chunk that
+	// needs them instead.
+	MinSize int
+	// MaxRequests bounds how many files loading a chunk takes, counting the
+	// chunk itself. The smallest shared chunks are copied into the chunk
+	// until it fits. Zero means no limit.
+	MaxRequests int
+}
+
+// ChunkKind tells how a chunk is loaded.
+type ChunkKind int
+
+const (
+	// ChunkEntry is loaded by a page and carries the runtime.
+	ChunkEntry ChunkKind = iota
+	// ChunkAsync is loaded by import().
+	ChunkAsync
+	// ChunkShared holds modules of several other chunks, which load it
+	// first.
+	ChunkShared
+)
+
+// splitRoot is a module that starts a chunk: an entry or an import()
+// target.
+type splitRoot struct {
+	module *Module
+	name   string
+	kind   ChunkKind
+	// modules are reachable from module without crossing an import(),
+	// dependencies first.
+	modules []*Module
+	// groups are the shared groups the root needs.
+	groups []*splitGroup
+	chunk  *Chunk
+}
+
+// splitGroup is a set of modules needed by the same roots.
+type splitGroup struct {
+	roots   []int
+	modules []*Module
+	size    int
+	// users are the roots that load the group as a chunk of its own rather
+	// than a copy.
+	users map[int]bool
+	chunk *Chunk
+}
+
+// split assigns the script modules reachable from entries to entry, async
+// and shared chunks, and returns the chunks without code.
+func (b *Bundler) split(entries []Entry, entryModules []*Module) []*Chunk {
+	var roots []*splitRoot
+	asyncRoots := make(map[*Module]int)
+	for i, m := range entryModules {
+		roots = append(roots, &splitRoot{module: m, name: entries[i].Name, kind: ChunkEntry})
+	}
+	// Async roots are appended while walking, so this visits them too.
+	for i := 0; i < len(roots); i++ {
+		var targets []*Module
+		roots[i].modules, targets = staticModules(roots[i].module)
+		for _, t := range targets {
+			if _, ok := asyncRoots[t]; !ok {
+				asyncRoots[t] = len(roots)
+				roots = append(roots, &splitRoot{module: t, kind: ChunkAsync})
+			}
+		}
+	}
+
+	// Group modules by the set of roots that reach them, in the order they
+	// are first reached.
+	reached := make(map[*Module][]int)
+	var order []*Module
+	for i, r := range roots {
+		for _, m := range r.modules {
+			if _, ok := reached[m]; !ok {
+				order = append(order, m)
+			}
+			reached[m] = append(reached[m], i)
+		}
+	}
+	groups := make(map[string]*splitGroup)
+	var groupOrder []*splitGroup
+	for _, m := range order {
+		key := rootKey(reached[m])
+		g, ok := groups[key]
+		if !ok {
+			g = &splitGroup{roots: reached[m], users: make(map[int]bool)}
+			groups[key] = g
+			groupOrder = append(groupOrder, g)
+		}
+		g.modules = append(g.modules, m)
+		g.size += len(b.chunkCode(m))
+	}
+
+	// Shared groups below the minimum size are copied. Roots that would
+	// need too many requests copy their smallest shared groups.
+	opts := b.opts.Split
+	for _, g := range groupOrder {
+		if len(g.roots) < 2 || g.size < opts.MinSize {
+			continue
+		}
+		for _, r := range g.roots {
+			g.users[r] = true
+			roots[r].groups = append(roots[r].groups, g)
+		}
+	}
+	for i, r := range roots {
+		if opts.MaxRequests <= 0 || len(r.groups)+1 <= opts.MaxRequests {
+			continue
+		}
+		sort.SliceStable(r.groups, func(x, y int) bool { return r.groups[x].size < r.groups[y].size })
+		for len(r.groups)+1 > opts.MaxRequests {
+			delete(r.groups[0].users, i)
+			r.groups = r.groups[1:]
+		}
+	}
+	// A shared group left with one user is better off inside it.
+	for _, g := range groupOrder {
+		if len(g.users) == 1 {
+			for r := range g.users {
+				delete(g.users, r)
+				kept := roots[r].groups[:0]
+				for _, other := range roots[r].groups {
+					if other != g {
+						kept = append(kept, other)
+					}
+				}
+				roots[r].groups = kept
+			}
+		}
+	}
+
+	// Each root's chunk holds its own modules and copies of the groups it
+	// does not load separately, in dependency order.
+	used := make(map[string]bool)
+	for _, r := range roots {
+		if r.kind == ChunkEntry {
+			used[r.name] = true
+		}
+	}
+	var chunks []*Chunk
+	for i, r := range roots {
+		if r.kind == ChunkAsync {
+			r.name = uniqueName(used, strings.TrimSuffix(filepath.Base(r.module.Path), filepath.Ext(r.module.Path)))
+		}
+		var modules []*Module
+		for _, m := range order {
+			g := groups[rootKey(reached[m])]
+			if contains(g.roots, i) && !g.users[i] {
+				modules = append(modules, m)
+			}
+		}
+		r.chunk = &Chunk{Name: r.name, Kind: r.kind, scripts: modules}
+		if r.kind == ChunkEntry {
+			r.chunk.Entry = r.module
+		}
+		chunks = append(chunks, r.chunk)
+	}
+	for _, g := range groupOrder {
+		if len(g.users) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(g.roots))
+		for _, r := range g.roots {
+			names = append(names, roots[r].name)
+		}
+		name := "shared"
+		if len(names) <= 3 {
+			name = strings.Join(names, "~")
+		}
+		g.chunk = &Chunk{Name: uniqueName(used, name), Kind: ChunkShared, scripts: g.modules}
+		chunks = append(chunks, g.chunk)
+	}
+
+	// Link chunks to the shared chunks they import and the async chunks
+	// their import() expressions load.
+	for _, r := range roots {
+		sort.SliceStable(r.groups, func(x, y int) bool { return r.groups[x].roots[0] < r.groups[y].roots[0] })
+		for _, g := range r.groups {
+			r.chunk.Imports = append(r.chunk.Imports, g.chunk)
+		}
+	}
+	for _, c := range chunks {
+		seen := make(map[*Chunk]bool)
+		for _, m := range c.scripts {
+			for _, record := range m.Imports {
+				if record.Kind != ImportDynamic {
+					continue
+				}
+				target := roots[asyncRoots[record.Module]]
+				if !seen[target.chunk] {
+					seen[target.chunk] = true
+					c.DynamicImports = append(c.DynamicImports, target.chunk)
+					c.targets = append(c.targets, target.module)
+				}
+			}
+		}
+	}
+	for _, c := range chunks {
+		c.Modules = chunkModules(c.scripts)
+	}
+	return chunks
+}
+
+// staticModules returns the script modules reachable from root without
+// crossing an import() or following the imports of stylesheets,
+// dependencies first, and the targets of the import() expressions found.
+func staticModules(root *Module) ([]*Module, []*Module) {
+	var order, targets []*Module
+	visited := make(map[*Module]bool)
+	var visit func(m *Module)
+	visit = func(m *Module) {
+		if visited[m] || m.removed {
+			return
+		}
+		visited[m] = true
+		if m.Kind != KindCSS {
+			for _, record := range m.Imports {
+				if record.Kind == ImportDynamic {
+					targets = append(targets, record.Module)
+				} else {
+					visit(record.Module)
+				}
+			}
+		}
+		order = append(order, m)
+	}
+	visit(root)
+	return order, targets
+}
+
+// chunkModules adds the modules stylesheets among scripts import to them,
+// keeping dependencies first.
+func chunkModules(scripts []*Module) []*Module {
+	seen := make(map[*Module]bool, len(scripts))
+	for _, m := range scripts {
+		seen[m] = true
+	}
+	var modules []*Module
+	var visit func(m *Module)
+	visit = func(m *Module) {
+		for _, record := range m.Imports {
+			if !seen[record.Module] {
+				seen[record.Module] = true
+				visit(record.Module)
+				modules = append(modules, record.Module)
+			}
+		}
+	}
+	for _, m := range scripts {
+		if m.Kind == KindCSS {
+			visit(m)
+		}
+		modules = append(modules, m)
+	}
+	return modules
+}
+
+// chunkCode returns the code m contributes to a chunk, for sizing.
+func (b *Bundler) chunkCode(m *Module) string {
+	if m.Kind == KindJS && b.opts.Minify {
+		return m.minified
+	}
+	return b.moduleCode(m)
+}
+
+func rootKey(roots []int) string {
+	parts := make([]string, len(roots))
+	for i, r := range roots {
+		parts[i] = strconv.Itoa(r)
+	}
+	return strings.Join(parts, ",")
+}
+
+func contains(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is taken,
+// and marks the result as taken.
+func uniqueName(used map[string]bool, name string) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + "-" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,222 @@
+// Package compress writes precompressed gzip and Brotli siblings of build
+// outputs, such as main.js.gz and main.js.br, for servers that send them
+// to clients accepting those encodings.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/skbhati199/go-web-build/internal/builder/parallel"
+)
+
+// Encoding is a precompression format.
+type Encoding struct {
+	// Name is the Content-Encoding token.
+	Name string
+	// Ext is appended to the compressed file's name.
+	Ext string
+	// MaxLevel is the highest level the encoder supports.
+	MaxLevel int
+	encode   func(data []byte, level int) ([]byte, error)
+}
+
+// The supported encodings.
+var (
+	Gzip   = Encoding{Name: "gzip", Ext: ".gz", MaxLevel: gzip.BestCompression, encode: encodeGzip}
+	Brotli = Encoding{Name: "br", Ext: ".br", MaxLevel: brotli.BestCompression, encode: encodeBrotli}
+)
+
+// Defaults for the zero Options.
+const (
+	DefaultMinSize  = 1024
+	DefaultMinRatio = 0.9
+)
+
+// Options controls Files.
+type Options struct {
+	// Algorithm is "gzip", "brotli" or "all". Empty means all.
+	Algorithm string
+	// Level is the compression level. Zero uses each encoder's best level;
+	// higher levels are capped at MaxLevel.
+	Level int
+	// MinSize skips files smaller than this many bytes. Zero uses
+	// DefaultMinSize.
+	MinSize int64
+	// MinRatio drops compressed files larger than this fraction of the
+	// original. Zero uses DefaultMinRatio.
+	MinRatio float64
+	// Jobs bounds how many files are compressed at once. Zero uses one job
+	// per CPU.
+	Jobs int
+}
+
+// Result describes a compressed sibling. Paths are slash separated and
+// relative to the directory passed to Files.
+type Result struct {
+	Path     string `json:"path"`
+	Source   string `json:"source"`
+	Encoding string `json:"encoding"`
+	Size     int64  `json:"size"`
+	Original int64  `json:"original"`
+}
+
+// Encodings returns the encodings selected by an Algorithm setting.
+func Encodings(algorithm string) ([]Encoding, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "all":
+		return []Encoding{Gzip, Brotli}, nil
+	case "gzip", "gz":
+		return []Encoding{Gzip}, nil
+	case "brotli", "br":
+		return []Encoding{Brotli}, nil
+	}
+	return nil, fmt.Errorf("unknown compression algorithm %q (expected gzip, brotli or all)", algorithm)
+}
+
+// Files writes compressed siblings of files, which are relative to dir.
+// Files below MinSize or of an incompressible type are skipped, as are
+// siblings that do not shrink below MinRatio; stale siblings of those are
+// removed. Results are in the order of files and encodings.
+func Files(ctx context.Context, dir string, files []string, opts Options) ([]Result, error) {
+	encodings, err := Encodings(opts.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MinSize == 0 {
+		opts.MinSize = DefaultMinSize
+	}
+	if opts.MinRatio == 0 {
+		opts.MinRatio = DefaultMinRatio
+	}
+
+	results := make([][]Result, len(files))
+	err = parallel.Run(ctx, opts.Jobs, len(files), func(ctx context.Context, i int) error {
+		var err error
+		results[i], err = compressFile(dir, files[i], encodings, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var all []Result
+	for _, r := range results {
+		all = append(all, r...)
+	}
+	return all, nil
+}
+
+func compressFile(dir, rel string, encodings []Encoding, opts Options) ([]Result, error) {
+	if !Compressible(rel) {
+		return nil, nil
+	}
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+
+	var results []Result
+	for _, enc := range encodings {
+		target := path + enc.Ext
+		if int64(len(data)) < opts.MinSize {
+			if err := removeStale(target); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		level := opts.Level
+		if level <= 0 || level > enc.MaxLevel {
+			level = enc.MaxLevel
+		}
+		compressed, err := enc.encode(data, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s %s: %w", enc.Name, rel, err)
+		}
+		if float64(len(compressed)) > float64(len(data))*opts.MinRatio {
+			if err := removeStale(target); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := os.WriteFile(target, compressed, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", rel+enc.Ext, err)
+		}
+		results = append(results, Result{
+			Path:     rel + enc.Ext,
+			Source:   rel,
+			Encoding: enc.Name,
+			Size:     int64(len(compressed)),
+			Original: int64(len(data)),
+		})
+	}
+	return results, nil
+}
+
+// removeStale deletes a sibling left by an earlier build, so servers do not
+// send outdated content.
+func removeStale(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// incompressible lists types whose content is compressed already.
+var incompressible = map[string]bool{
+	".gz": true, ".br": true, ".zst": true, ".zip": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".ogg": true,
+	".pdf": true,
+}
+
+// Compressible reports whether a file's type is worth compressing.
+func Compressible(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if incompressible[ext] {
+		return false
+	}
+	switch ext {
+	case ".js", ".mjs", ".cjs", ".css", ".html", ".htm", ".json", ".map", ".webmanifest",
+		".svg", ".xml", ".txt", ".wasm", ".ttf", ".otf", ".ico":
+		return true
+	}
+	ct, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return strings.HasPrefix(ct, "text/") || strings.HasSuffix(ct, "+json") || strings.HasSuffix(ct, "+xml") ||
+		ct == "application/json" || ct == "application/javascript" || ct == "application/xml"
+}
+
+func encodeGzip(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeBrotli(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, level)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	script := strings.Repeat("console.log('hello, world');\n", 200)
+	noise := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(noise)
+	files := map[string][]byte{
+		"assets/main.js":    []byte(script),
+		"assets/small.css":  []byte("body{margin:0}"),
+		"assets/random.txt": noise,
+		"static/logo.png":   []byte(script),
+		// Left by an earlier build, when random.txt was compressible.
+		"assets/random.txt.gz": []byte("stale"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Files(context.Background(), dir, []string{"assets/main.js", "assets/small.css", "assets/random.txt", "static/logo.png"}, Options{})
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.Path)
+		if r.Original != int64(len(script)) || r.Size >= r.Original {
+			t.Errorf("Unexpected sizes for %s: %d of %d", r.Path, r.Size, r.Original)
+		}
+	}
+	if want := []string{"assets/main.js.gz", "assets/main.js.br"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Files() wrote %q, want %q", paths, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "assets", "random.txt.gz")); !os.IsNotExist(err) {
+		t.Errorf("Stale sibling of an incompressible file was kept")
+	}
+
+	for _, r := range results {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(r.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var reader io.Reader = brotli.NewReader(f)
+		if r.Encoding == "gzip" {
+			if reader, err = gzip.NewReader(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil || !bytes.Equal(data, []byte(script)) {
+			t.Errorf("%s does not decode to the original (error %v)", r.Path, err)
+		}
+	}
+
+	if _, err := Files(context.Background(), dir, nil, Options{Algorithm: "zstd"}); err == nil {
+		t.Error("Expected error for an unknown algorithm")
+	}
+}
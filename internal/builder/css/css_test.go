@@ -0,0 +1,88 @@
+package css
+
+import (
+	"testing"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "declarations", in: "a {\n  color : red ;\n  margin: 0 auto;\n}\n", want: "a{color:red;margin:0 auto}"},
+		{name: "comments", in: "/* header */\na { color: red; /* note */ }\n/*! license */\n", want: "a{color:red}/*! license */"},
+		{name: "descendant pseudo class", in: "a :hover, b > c  d { x: y }", want: "a :hover,b>c d{x:y}"},
+		{name: "calc keeps spaces", in: "a { width: calc( 100% - 2 * 0.5em ); }", want: "a{width:calc(100% - 2 * .5em)}"},
+		{name: "important", in: "a { color: red ! important }", want: "a{color:red!important}"},
+		{name: "media", in: "@media screen and (max-width: 600px) {\n  a { color: red }\n}", want: "@media screen and (max-width:600px){a{color:red}}"},
+		{name: "supports", in: "@supports not (display: grid) { .x { float: left } }", want: "@supports not (display:grid){.x{float:left}}"},
+		{name: "custom properties", in: ":root {\n  --gap:  4px  ;\n  --empty: ;\n  --json: { \"a\": 1 };\n}", want: ":root{--gap:4px;--empty: ;--json:{ \"a\": 1 }}"},
+		{name: "strings and urls", in: "a { content: \"a  b\"; background: url( x.png ) no-repeat; }", want: "a{content:\"a  b\";background:url( x.png ) no-repeat}"},
+		{name: "empty rules", in: "a {}\nb { }\nc { d: e }", want: "c{d:e}"},
+		{name: "statements", in: "@charset \"UTF-8\";\n@import url(x.css) screen;\na{b:c}", want: "@charset \"UTF-8\";@import url(x.css) screen;a{b:c}"},
+		{name: "nesting", in: ".a {\n  color: red;\n  & .b { color: blue }\n  @media (min-width: 1px) { color: green }\n}", want: ".a{color:red;& .b{color:blue}@media (min-width:1px){color:green}}"},
+		{name: "keyframes", in: "@keyframes spin { from { transform: rotate(0deg) } to { transform: rotate(360deg) } }", want: "@keyframes spin{from{transform:rotate(0deg)}to{transform:rotate(360deg)}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, segments, err := Minify(tt.in)
+			if err != nil {
+				t.Fatalf("Minify failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Minify(%q)\n got: %s\nwant: %s", tt.in, got, tt.want)
+			}
+			if got != "" && len(segments) == 0 {
+				t.Errorf("Expected segments")
+			}
+		})
+	}
+}
+
+func TestMinifyErrors(t *testing.T) {
+	for _, in := range []string{"a { content: \"open }", "a { b: c } /* open", "a { background: url(x.png }"} {
+		if _, _, err := Minify(in); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	src := `@import "./base.css";
+@import url('theme.css') layer(theme) supports(display: grid) screen;
+a { background: url(img/a.png) , url( "b.svg#x" ); }
+`
+	tokens, err := Tokenize(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := References(tokens)
+
+	want := []struct {
+		kind      ReferenceKind
+		url       string
+		text      string
+		condition string
+	}{
+		{RefImport, "./base.css", `@import "./base.css";`, ""},
+		{RefImport, "theme.css", `@import url('theme.css') layer(theme) supports(display: grid) screen;`, "layer(theme) supports(display: grid) screen"},
+		{RefURL, "img/a.png", "url(img/a.png)", ""},
+		{RefURL, "b.svg#x", `url( "b.svg#x" )`, ""},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("Expected %d references, got %+v", len(want), refs)
+	}
+	for i, w := range want {
+		r := refs[i]
+		if r.Kind != w.kind || r.URL != w.url || src[r.Start:r.End] != w.text || r.Condition != w.condition {
+			t.Errorf("Reference %d: got %+v (%q)", i, r, src[r.Start:r.End])
+		}
+	}
+
+	open, close := ConditionBlocks(refs[1].Condition)
+	if open != "@layer theme{@supports (display: grid){@media screen{" || close != "}}}" {
+		t.Errorf("Unexpected condition blocks: %q %q", open, close)
+	}
+}
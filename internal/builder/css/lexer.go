@@ -0,0 +1,352 @@
+// Package css tokenizes, rewrites and minifies stylesheets.
+package css
+
+import (
+	"fmt"
+	"strings"
+)
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenWhitespace
+	TokenComment
+	TokenIdent
+	// TokenFunction is a name followed by "(", such as "calc(". The value
+	// includes the parenthesis.
+	TokenFunction
+	TokenAtKeyword
+	TokenHash
+	TokenString
+	// TokenURL is an unquoted url(...) including "url(" and ")". Quoted URLs
+	// are a TokenFunction followed by a TokenString.
+	TokenURL
+	TokenNumber
+	TokenPercentage
+	TokenDimension
+	TokenDelim
+	TokenColon
+	TokenSemicolon
+	TokenComma
+	TokenOpenBrace
+	TokenCloseBrace
+	TokenOpenParen
+	TokenCloseParen
+	TokenOpenBracket
+	TokenCloseBracket
+	// TokenCDO and TokenCDC are the HTML comment markers "<!--" and "-->".
+	TokenCDO
+	TokenCDC
+)
+
+// Token is a single lexical token. Value is the exact source text. Start and
+// End are byte offsets into the source; Line and Col are zero-based and
+// point at Start.
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Start int
+	End   int
+	Line  int
+	Col   int
+}
+
+// IsDelim reports whether the token is the given delimiter character.
+func (t Token) IsDelim(c byte) bool {
+	return t.Kind == TokenDelim && t.Value[0] == c
+}
+
+// IsIdent reports whether the token is an identifier, compared ASCII case
+// insensitively as CSS keywords are.
+func (t Token) IsIdent(name string) bool {
+	return t.Kind == TokenIdent && strings.EqualFold(t.Value, name)
+}
+
+// Name returns the name of an at-keyword or function token, lower-cased and
+// without "@" or "(".
+func (t Token) Name() string {
+	switch t.Kind {
+	case TokenAtKeyword:
+		return strings.ToLower(t.Value[1:])
+	case TokenFunction:
+		return strings.ToLower(t.Value[:len(t.Value)-1])
+	}
+	return ""
+}
+
+type SyntaxError struct {
+	Line    int
+	Col     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line+1, e.Col+1, e.Message)
+}
+
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+	tokens    []Token
+}
+
+// Tokenize splits a stylesheet into tokens following CSS Syntax Level 3.
+// Whitespace and comments are kept as tokens, so concatenating the values
+// reproduces the input. Unterminated strings, comments and URLs are errors
+// rather than being recovered from as browsers do.
+func Tokenize(src string) ([]Token, error) {
+	l := &lexer{src: src}
+	for l.pos < len(l.src) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) errorf(start int, format string, args ...any) error {
+	line, col := 0, 0
+	for i := 0; i < start; i++ {
+		if l.src[i] == '\n' {
+			line++
+			col = 0
+		} else {
+			col++
+		}
+	}
+	return &SyntaxError{Line: line, Col: col, Message: fmt.Sprintf(format, args...)}
+}
+
+// emit records the token spanning src[start:l.pos] and advances the line
+// counter over any line breaks inside it.
+func (l *lexer) emit(kind TokenKind, start, line, col int) {
+	value := l.src[start:l.pos]
+	l.tokens = append(l.tokens, Token{Kind: kind, Value: value, Start: start, End: l.pos, Line: line, Col: col})
+	for i := start; i < l.pos; i++ {
+		if l.src[i] == '\n' {
+			l.line++
+			l.lineStart = i + 1
+		}
+	}
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+func (l *lexer) next() error {
+	start, line, col := l.pos, l.line, l.pos-l.lineStart
+	c := l.src[l.pos]
+
+	switch {
+	case isWhitespace(c):
+		for l.pos < len(l.src) && isWhitespace(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(TokenWhitespace, start, line, col)
+
+	case c == '/' && l.peek(1) == '*':
+		end := strings.Index(l.src[l.pos+2:], "*/")
+		if end < 0 {
+			return l.errorf(start, "unterminated comment")
+		}
+		l.pos += end + 4
+		l.emit(TokenComment, start, line, col)
+
+	case c == '"' || c == '\'':
+		if err := l.scanString(c); err != nil {
+			return err
+		}
+		l.emit(TokenString, start, line, col)
+
+	case isDigit(c) || (c == '.' || c == '+' || c == '-') && l.startsNumber():
+		l.scanNumber()
+		kind := TokenNumber
+		if l.peek(0) == '%' {
+			l.pos++
+			kind = TokenPercentage
+		} else if l.startsIdent(0) {
+			l.scanName()
+			kind = TokenDimension
+		}
+		l.emit(kind, start, line, col)
+
+	case c == '<' && strings.HasPrefix(l.src[l.pos:], "<!--"):
+		l.pos += 4
+		l.emit(TokenCDO, start, line, col)
+
+	case c == '-' && strings.HasPrefix(l.src[l.pos:], "-->"):
+		l.pos += 3
+		l.emit(TokenCDC, start, line, col)
+
+	case l.startsIdent(0):
+		return l.scanIdentLike(start, line, col)
+
+	case c == '@' && l.startsIdent(1):
+		l.pos++
+		l.scanName()
+		l.emit(TokenAtKeyword, start, line, col)
+
+	case c == '#' && (isNameChar(l.peek(1)) || l.peek(1) == '\\' && l.peek(2) != '\n'):
+		l.pos++
+		l.scanName()
+		l.emit(TokenHash, start, line, col)
+
+	default:
+		kinds := map[byte]TokenKind{
+			':': TokenColon, ';': TokenSemicolon, ',': TokenComma,
+			'{': TokenOpenBrace, '}': TokenCloseBrace,
+			'(': TokenOpenParen, ')': TokenCloseParen,
+			'[': TokenOpenBracket, ']': TokenCloseBracket,
+		}
+		kind, ok := kinds[c]
+		if !ok {
+			kind = TokenDelim
+		}
+		l.pos++
+		l.emit(kind, start, line, col)
+	}
+	return nil
+}
+
+func (l *lexer) scanString(quote byte) error {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case quote:
+			l.pos++
+			return nil
+		case '\n':
+			return l.errorf(start, "unterminated string")
+		case '\\':
+			l.pos++
+		}
+		l.pos++
+	}
+	return l.errorf(start, "unterminated string")
+}
+
+// scanIdentLike scans an identifier, a function token or an unquoted URL.
+func (l *lexer) scanIdentLike(start, line, col int) error {
+	l.scanName()
+	if l.peek(0) != '(' {
+		l.emit(TokenIdent, start, line, col)
+		return nil
+	}
+	l.pos++
+	if !strings.EqualFold(l.src[start:l.pos], "url(") {
+		l.emit(TokenFunction, start, line, col)
+		return nil
+	}
+
+	// url( followed by a quoted string is an ordinary function.
+	i := l.pos
+	for i < len(l.src) && isWhitespace(l.src[i]) {
+		i++
+	}
+	if i < len(l.src) && (l.src[i] == '"' || l.src[i] == '\'') {
+		l.emit(TokenFunction, start, line, col)
+		return nil
+	}
+	for l.pos < len(l.src) && l.src[l.pos] != ')' {
+		if l.src[l.pos] == '\\' {
+			l.pos++
+		}
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return l.errorf(start, "unterminated url()")
+	}
+	l.pos++
+	l.emit(TokenURL, start, line, col)
+	return nil
+}
+
+func (l *lexer) scanName() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isNameChar(c):
+			l.pos++
+		case c == '\\' && l.peek(1) != '\n' && l.peek(1) != 0:
+			l.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) scanNumber() {
+	if c := l.peek(0); c == '+' || c == '-' {
+		l.pos++
+	}
+	for isDigit(l.peek(0)) {
+		l.pos++
+	}
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		l.pos++
+		for isDigit(l.peek(0)) {
+			l.pos++
+		}
+	}
+	if c := l.peek(0); c == 'e' || c == 'E' {
+		n := 1
+		if s := l.peek(1); s == '+' || s == '-' {
+			n = 2
+		}
+		if isDigit(l.peek(n)) {
+			l.pos += n
+			for isDigit(l.peek(0)) {
+				l.pos++
+			}
+		}
+	}
+}
+
+// startsNumber reports whether a number starts at the current position.
+func (l *lexer) startsNumber() bool {
+	c, n := l.peek(0), l.peek(1)
+	switch {
+	case c == '+' || c == '-':
+		return isDigit(n) || n == '.' && isDigit(l.peek(2))
+	case c == '.':
+		return isDigit(n)
+	}
+	return isDigit(c)
+}
+
+// startsIdent reports whether an identifier starts n bytes ahead.
+func (l *lexer) startsIdent(n int) bool {
+	c := l.peek(n)
+	switch {
+	case c == '-':
+		next := l.peek(n + 1)
+		return isNameStart(next) || next == '-' || next == '\\' && l.peek(n+2) != '\n'
+	case c == '\\':
+		return l.peek(n+1) != '\n' && l.peek(n+1) != 0
+	}
+	return isNameStart(c)
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c) || c == '-'
+}
@@ -0,0 +1,372 @@
+package css
+
+import (
+	"strings"
+)
+
+// Segment ties the start of a token in minified output to its position in
+// the input. Lines and columns are zero-based.
+type Segment struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	OriginalLine    int
+	OriginalColumn  int
+}
+
+// context decides which whitespace inside a run of component values is
+// significant.
+type context int
+
+const (
+	// contextSelector is a style rule prelude, where whitespace is the
+	// descendant combinator.
+	contextSelector context = iota
+	// contextPrelude is an at-rule prelude such as a media query list.
+	contextPrelude
+	// contextValue is a declaration, where whitespace separates values and
+	// is required around + and - in calc().
+	contextValue
+)
+
+// ruleListAtRules hold rules rather than declarations in their blocks.
+var ruleListAtRules = map[string]bool{
+	"media": true, "supports": true, "layer": true, "container": true,
+	"document": true, "scope": true, "starting-style": true, "keyframes": true,
+}
+
+type piece struct {
+	text string
+	tok  *Token
+}
+
+type minifier struct {
+	src    string
+	tokens []Token
+	pieces []piece
+	// prev is the last token written, or nil after a brace or semicolon.
+	prev *Token
+}
+
+// Minify removes comments and redundant whitespace from a stylesheet. At-rule
+// blocks such as @media and @supports are kept as written, and custom
+// property values are copied verbatim since they are only interpreted where
+// they are used. Comments starting with "/*!" are kept between rules, and
+// empty style rules are dropped. It returns one segment per written token.
+func Minify(src string) (string, []Segment, error) {
+	tokens, err := Tokenize(src)
+	if err != nil {
+		return "", nil, err
+	}
+	m := &minifier{src: src, tokens: tokens}
+	m.rules(0, false)
+	return m.output()
+}
+
+func (m *minifier) output() (string, []Segment, error) {
+	var b strings.Builder
+	segments := make([]Segment, 0, len(m.pieces))
+	line, col := 0, 0
+	for _, p := range m.pieces {
+		if p.tok != nil {
+			segments = append(segments, Segment{GeneratedLine: line, GeneratedColumn: col, OriginalLine: p.tok.Line, OriginalColumn: p.tok.Col})
+		}
+		b.WriteString(p.text)
+		if n := strings.Count(p.text, "\n"); n > 0 {
+			line += n
+			col = len(p.text) - strings.LastIndex(p.text, "\n") - 1
+		} else {
+			col += len(p.text)
+		}
+	}
+	return b.String(), segments, nil
+}
+
+func (m *minifier) emit(text string, tok *Token) {
+	m.pieces = append(m.pieces, piece{text: text, tok: tok})
+}
+
+// punct writes a brace or semicolon, after which no whitespace is needed.
+func (m *minifier) punct(text string, i int) {
+	var tok *Token
+	if i < len(m.tokens) {
+		tok = &m.tokens[i]
+	}
+	m.emit(text, tok)
+	m.prev = nil
+}
+
+// trimSemicolon drops a semicolon that ends the output or a block.
+func (m *minifier) trimSemicolon() {
+	if n := len(m.pieces); n > 0 && m.pieces[n-1].text == ";" {
+		m.pieces = m.pieces[:n-1]
+	}
+}
+
+// rules minifies a list of rules starting at i. Nested lists end at the
+// closing brace of their block, whose index is returned.
+func (m *minifier) rules(i int, nested bool) int {
+	for i < len(m.tokens) {
+		t := &m.tokens[i]
+		switch t.Kind {
+		case TokenWhitespace, TokenCDO, TokenCDC, TokenSemicolon:
+			i++
+		case TokenComment:
+			if strings.HasPrefix(t.Value, "/*!") {
+				m.emit(t.Value, t)
+				m.prev = nil
+			}
+			i++
+		case TokenCloseBrace:
+			if nested {
+				return i
+			}
+			i++
+		case TokenAtKeyword:
+			i = m.atRule(i, false)
+		default:
+			i = m.qualifiedRule(i)
+		}
+	}
+	return i
+}
+
+// declarations minifies the contents of a style rule block starting at i,
+// which may include nested rules, and returns the index of its closing
+// brace.
+func (m *minifier) declarations(i int) int {
+	for i < len(m.tokens) {
+		t := m.tokens[i]
+		switch {
+		case t.Kind == TokenWhitespace || t.Kind == TokenComment || t.Kind == TokenSemicolon:
+			i++
+		case t.Kind == TokenCloseBrace:
+			return i
+		case t.Kind == TokenAtKeyword:
+			i = m.atRule(i, true)
+		case t.Kind == TokenIdent && strings.HasPrefix(t.Value, "--"):
+			i = m.customProperty(i)
+		default:
+			end := m.preludeEnd(i)
+			if end < len(m.tokens) && m.tokens[end].Kind == TokenOpenBrace {
+				i = m.qualifiedRule(i)
+				continue
+			}
+			m.sequence(i, end, contextValue)
+			m.punct(";", end)
+			i = end
+		}
+	}
+	return i
+}
+
+// atRule minifies the at-rule at i and returns the index after it. Inside
+// style rules, conditional rules such as @media hold declarations.
+func (m *minifier) atRule(i int, inStyle bool) int {
+	at := &m.tokens[i]
+	m.emit(at.Value, at)
+	m.prev = at
+
+	end := m.preludeEnd(i + 1)
+	m.sequence(i+1, end, contextPrelude)
+	if end >= len(m.tokens) || m.tokens[end].Kind != TokenOpenBrace {
+		m.punct(";", end)
+		if end < len(m.tokens) && m.tokens[end].Kind == TokenSemicolon {
+			end++
+		}
+		return end
+	}
+
+	m.punct("{", end)
+	var close int
+	if ruleListAtRules[unprefixed(at.Name())] && !inStyle {
+		close = m.rules(end+1, true)
+	} else {
+		close = m.declarations(end + 1)
+	}
+	m.trimSemicolon()
+	m.punct("}", close)
+	return close + 1
+}
+
+// qualifiedRule minifies the style rule at i and returns the index after it.
+func (m *minifier) qualifiedRule(i int) int {
+	start := len(m.pieces)
+	end := m.preludeEnd(i)
+	m.sequence(i, end, contextSelector)
+	if end >= len(m.tokens) || m.tokens[end].Kind != TokenOpenBrace {
+		// Browsers drop rules without a block; so do we.
+		m.pieces = m.pieces[:start]
+		m.prev = nil
+		if end < len(m.tokens) && m.tokens[end].Kind == TokenSemicolon {
+			end++
+		}
+		return end
+	}
+
+	m.punct("{", end)
+	close := m.declarations(end + 1)
+	m.trimSemicolon()
+	if m.pieces[len(m.pieces)-1].text == "{" {
+		m.pieces = m.pieces[:start]
+		m.prev = nil
+	} else {
+		m.punct("}", close)
+	}
+	return close + 1
+}
+
+// customProperty copies a custom property declaration, trimming only the
+// whitespace around its value.
+func (m *minifier) customProperty(i int) int {
+	name := &m.tokens[i]
+	colon := skipTrivia(m.tokens, i+1)
+	if colon >= len(m.tokens) || m.tokens[colon].Kind != TokenColon {
+		end := m.preludeEnd(i)
+		m.sequence(i, end, contextValue)
+		m.punct(";", end)
+		return end
+	}
+
+	end, depth := colon+1, 0
+	for ; end < len(m.tokens); end++ {
+		k := m.tokens[end].Kind
+		if depth == 0 && (k == TokenSemicolon || k == TokenCloseBrace) {
+			break
+		}
+		switch k {
+		case TokenOpenBrace, TokenOpenParen, TokenOpenBracket, TokenFunction:
+			depth++
+		case TokenCloseBrace, TokenCloseParen, TokenCloseBracket:
+			depth--
+		}
+	}
+
+	m.emit(name.Value, name)
+	m.emit(":", &m.tokens[colon])
+	valueEnd := len(m.src)
+	if end < len(m.tokens) {
+		valueEnd = m.tokens[end].Start
+	}
+	value := strings.TrimSpace(m.src[m.tokens[colon].End:valueEnd])
+	if value == "" {
+		value = " "
+	}
+	first := skipTrivia(m.tokens, colon+1)
+	if first < end {
+		m.emit(value, &m.tokens[first])
+	} else {
+		m.emit(value, nil)
+	}
+	m.punct(";", end)
+	return end
+}
+
+// preludeEnd returns the index of the first semicolon or brace at i or after
+// it that is not nested in parentheses or brackets.
+func (m *minifier) preludeEnd(i int) int {
+	depth := 0
+	for ; i < len(m.tokens); i++ {
+		switch m.tokens[i].Kind {
+		case TokenFunction, TokenOpenParen, TokenOpenBracket:
+			depth++
+		case TokenCloseParen, TokenCloseBracket:
+			if depth > 0 {
+				depth--
+			}
+		case TokenSemicolon, TokenOpenBrace, TokenCloseBrace:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// sequence writes the component values in tokens[from:to] with the
+// whitespace the context requires.
+func (m *minifier) sequence(from, to int, ctx context) {
+	space := false
+	for i := from; i < to; i++ {
+		t := &m.tokens[i]
+		if t.Kind == TokenWhitespace || t.Kind == TokenComment {
+			space = true
+			continue
+		}
+		if space && m.prev != nil && (wouldMerge(*m.prev, *t) || !noSpaceAfter(*m.prev, ctx) && !noSpaceBefore(*t, ctx)) {
+			m.emit(" ", nil)
+		}
+		text := t.Value
+		if ctx == contextValue {
+			text = shortenNumber(*t)
+		}
+		m.emit(text, t)
+		m.prev = t
+		space = false
+	}
+}
+
+func noSpaceAfter(t Token, ctx context) bool {
+	switch t.Kind {
+	case TokenComma, TokenOpenParen, TokenOpenBracket, TokenFunction:
+		return true
+	case TokenColon:
+		return ctx != contextSelector
+	case TokenDelim:
+		return ctx == contextSelector && strings.Contains(">+~", t.Value) ||
+			ctx == contextValue && strings.Contains("/!", t.Value)
+	}
+	return false
+}
+
+func noSpaceBefore(t Token, ctx context) bool {
+	switch t.Kind {
+	case TokenComma, TokenCloseParen, TokenCloseBracket:
+		return true
+	case TokenColon:
+		return ctx != contextSelector
+	case TokenDelim:
+		return ctx == contextSelector && strings.Contains(">+~", t.Value) ||
+			ctx == contextValue && strings.Contains("/!", t.Value)
+	}
+	return false
+}
+
+// wouldMerge reports whether writing next directly after prev, which were
+// separated in the source, would tokenize differently, such as two
+// identifiers running together.
+func wouldMerge(prev, next Token) bool {
+	first := next.Value[0]
+	startsName := isNameChar(first) || first == '\\' || first == '.'
+	switch prev.Kind {
+	case TokenIdent, TokenAtKeyword, TokenHash, TokenNumber, TokenDimension:
+		return startsName || first == '(' && prev.Kind == TokenIdent || first == '%' && prev.Kind == TokenNumber
+	case TokenDelim:
+		return strings.Contains("#@-+.\\", prev.Value) && startsName || prev.Value == "/" && first == '*'
+	}
+	return false
+}
+
+// shortenNumber drops the leading zero of fractions, as in "0.5em".
+func shortenNumber(t Token) string {
+	switch t.Kind {
+	case TokenNumber, TokenPercentage, TokenDimension:
+		v := t.Value
+		if strings.HasPrefix(v, "0.") && len(v) > 2 && isDigit(v[2]) {
+			return v[1:]
+		}
+		if strings.HasPrefix(v, "-0.") && len(v) > 3 && isDigit(v[3]) {
+			return "-" + v[2:]
+		}
+	}
+	return t.Value
+}
+
+// unprefixed strips a vendor prefix such as "-webkit-" from an at-rule name.
+func unprefixed(name string) string {
+	if strings.HasPrefix(name, "-") {
+		if i := strings.Index(name[1:], "-"); i >= 0 {
+			return name[i+2:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,236 @@
+package css
+
+import (
+	"strings"
+)
+
+type ReferenceKind int
+
+const (
+	// RefImport is an @import rule.
+	RefImport ReferenceKind = iota
+	// RefURL is a url() value.
+	RefURL
+)
+
+// Reference is an @import rule or url() value found in a stylesheet.
+// Start and End delimit the source text to replace to rewrite it: the whole
+// rule including its semicolon for imports, and the url() for URLs.
+type Reference struct {
+	Kind  ReferenceKind
+	URL   string
+	Start int
+	End   int
+	Line  int
+	Col   int
+	// Condition is the layer, supports() and media query list of an
+	// @import, such as "screen and (min-width: 40em)".
+	Condition string
+}
+
+// References lists the @import rules and url() values of a stylesheet in
+// source order. url() values inside @import rules are reported as part of
+// the import only.
+func References(tokens []Token) []Reference {
+	var refs []Reference
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Kind == TokenAtKeyword && t.Name() == "import":
+			ref, end, ok := importRule(tokens, i)
+			if ok {
+				refs = append(refs, ref)
+			}
+			i = end
+		case t.Kind == TokenURL || t.Kind == TokenFunction && t.Name() == "url":
+			url, end, ok := urlValue(tokens, i)
+			if ok {
+				refs = append(refs, Reference{Kind: RefURL, URL: url, Start: t.Start, End: tokens[end].End, Line: t.Line, Col: t.Col})
+			}
+			i = end
+		}
+	}
+	return refs
+}
+
+// importRule parses the @import at tokens[i]. It returns the index of the
+// last token of the rule.
+func importRule(tokens []Token, i int) (Reference, int, bool) {
+	at := tokens[i]
+	j := skipTrivia(tokens, i+1)
+	if j >= len(tokens) {
+		return Reference{}, len(tokens) - 1, false
+	}
+
+	var url string
+	var ok bool
+	switch t := tokens[j]; {
+	case t.Kind == TokenString:
+		url, ok = Unquote(t.Value), true
+	case t.Kind == TokenURL || t.Kind == TokenFunction && t.Name() == "url":
+		url, j, ok = urlValue(tokens, j)
+	}
+
+	end := j
+	for end < len(tokens) && tokens[end].Kind != TokenSemicolon {
+		if tokens[end].Kind == TokenOpenBrace || tokens[end].Kind == TokenCloseBrace {
+			// Not a valid statement; browsers drop it.
+			return Reference{}, end, false
+		}
+		end++
+	}
+	if !ok {
+		return Reference{}, min(end, len(tokens)-1), false
+	}
+
+	var cond strings.Builder
+	for _, t := range tokens[j+1 : end] {
+		if t.Kind != TokenComment {
+			cond.WriteString(t.Value)
+		}
+	}
+	ref := Reference{Kind: RefImport, URL: url, Start: at.Start, Line: at.Line, Col: at.Col, Condition: collapseSpace(cond.String())}
+	if end < len(tokens) {
+		ref.End = tokens[end].End
+	} else {
+		end = len(tokens) - 1
+		ref.End = tokens[end].End
+	}
+	return ref, end, true
+}
+
+// urlValue reads the url() starting at tokens[i], either an unquoted URL
+// token or url( followed by a string. It returns the index of the closing
+// token.
+func urlValue(tokens []Token, i int) (string, int, bool) {
+	t := tokens[i]
+	if t.Kind == TokenURL {
+		inner := strings.TrimSpace(t.Value[4 : len(t.Value)-1])
+		return unescape(inner), i, true
+	}
+	j := skipTrivia(tokens, i+1)
+	if j >= len(tokens) || tokens[j].Kind != TokenString {
+		return "", i, false
+	}
+	url := Unquote(tokens[j].Value)
+	k := skipTrivia(tokens, j+1)
+	if k >= len(tokens) || tokens[k].Kind != TokenCloseParen {
+		return "", j, false
+	}
+	return url, k, true
+}
+
+func skipTrivia(tokens []Token, i int) int {
+	for i < len(tokens) && (tokens[i].Kind == TokenWhitespace || tokens[i].Kind == TokenComment) {
+		i++
+	}
+	return i
+}
+
+// Unquote returns the contents of a CSS string token.
+func Unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return unescape(s)
+}
+
+// unescape resolves backslash escapes of characters; hex escapes are rare
+// in URLs and kept as written.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && !isHex(s[i+1]) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// QuoteURL renders url as a url() value, quoting it when needed.
+func QuoteURL(url string) string {
+	if !strings.ContainsAny(url, " \t\n\"'()\\") {
+		return "url(" + url + ")"
+	}
+	return `url("` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(url) + `")`
+}
+
+// ConditionBlocks returns the at-rules an @import condition stands for
+// (layer, supports() and a media query list) as the text opening and closing
+// the blocks that the imported rules go between.
+func ConditionBlocks(condition string) (open, close string) {
+	tokens, err := Tokenize(condition)
+	if err != nil {
+		return "@media " + condition + "{", "}"
+	}
+
+	var rules []string
+	i := skipTrivia(tokens, 0)
+	if i < len(tokens) && tokens[i].IsIdent("layer") {
+		rules = append(rules, "@layer")
+		i = skipTrivia(tokens, i+1)
+	} else if i < len(tokens) && tokens[i].Kind == TokenFunction && tokens[i].Name() == "layer" {
+		end := closingParen(tokens, i)
+		rules = append(rules, "@layer "+strings.TrimSpace(joinTokens(tokens[i+1:end])))
+		i = skipTrivia(tokens, end+1)
+	}
+	if i < len(tokens) && tokens[i].Kind == TokenFunction && tokens[i].Name() == "supports" {
+		end := closingParen(tokens, i)
+		test := strings.TrimSpace(joinTokens(tokens[i+1 : end]))
+		if !strings.HasPrefix(test, "(") && !strings.HasPrefix(strings.ToLower(test), "not ") {
+			test = "(" + test + ")"
+		}
+		rules = append(rules, "@supports "+test)
+		i = skipTrivia(tokens, end+1)
+	}
+	if media := strings.TrimSpace(joinTokens(tokens[min(i, len(tokens)):])); media != "" {
+		rules = append(rules, "@media "+media)
+	}
+
+	for _, rule := range rules {
+		open += rule + "{"
+		close += "}"
+	}
+	return open, close
+}
+
+// closingParen returns the index of the token closing the function or
+// parenthesis at tokens[i].
+func closingParen(tokens []Token, i int) int {
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		switch tokens[j].Kind {
+		case TokenFunction, TokenOpenParen:
+			depth++
+		case TokenCloseParen:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+func joinTokens(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Value)
+	}
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
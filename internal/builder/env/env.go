@@ -0,0 +1,249 @@
+// Package env loads .env files and turns the variables that are safe to
+// expose to client code into compile-time defines.
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+)
+
+// DefaultPrefixes mark variables as public when no prefixes are configured.
+var DefaultPrefixes = []string{"PUBLIC_", "REACT_APP_"}
+
+// Files returns the .env files read for environment, lowest precedence
+// first.
+func Files(environment string) []string {
+	files := []string{".env"}
+	if environment != "" {
+		files = append(files, ".env."+environment)
+	}
+	return append(files, ".env.local")
+}
+
+// Load reads the .env files of environment in dir, each overriding the ones
+// before it, with variables of the process environment taking precedence
+// over all of them. Only variables whose name starts with one of prefixes
+// are returned; nothing else is ever exposed. Missing files are skipped.
+func Load(dir, environment string, prefixes []string) (map[string]string, error) {
+	if len(prefixes) == 0 {
+		prefixes = DefaultPrefixes
+	}
+
+	all := make(map[string]string)
+	for _, name := range Files(environment) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := Parse(string(data), all); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			all[key] = value
+		}
+	}
+
+	public := make(map[string]string)
+	for key, value := range all {
+		if isPublic(key, prefixes) {
+			public[key] = value
+		}
+	}
+	return public, nil
+}
+
+func isPublic(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	reference  = regexp.MustCompile(`\\?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// Parse reads KEY=VALUE lines into vars. Lines may start with "export", and
+// "#" starts a comment outside quotes. Single-quoted values are literal;
+// double-quoted values may span lines and interpret \n, \t, \" and \\.
+// ${NAME} in unquoted and double-quoted values expands to a variable set
+// earlier or in the process environment; \${NAME} is kept as written.
+func Parse(src string, vars map[string]string) error {
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !keyPattern.MatchString(key) {
+			return fmt.Errorf("%d: expected KEY=VALUE", line)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return fmt.Errorf("%d: unterminated single-quoted value", line)
+			}
+			vars[key] = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			start := line
+			raw := value[1:]
+			for !closedQuote(raw) {
+				if !scanner.Scan() {
+					return fmt.Errorf("%d: unterminated double-quoted value", start)
+				}
+				line++
+				raw += "\n" + scanner.Text()
+			}
+			vars[key] = expand(unescape(raw[:closingQuote(raw)]), vars)
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = value[:i]
+			}
+			vars[key] = expand(strings.TrimSpace(value), vars)
+		}
+	}
+	return scanner.Err()
+}
+
+// closingQuote returns the index of the first unescaped double quote in s,
+// or -1.
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func closedQuote(s string) bool {
+	return closingQuote(s) >= 0
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(s)
+}
+
+func expand(value string, vars map[string]string) string {
+	return reference.ReplaceAllStringFunc(value, func(s string) string {
+		if strings.HasPrefix(s, `\`) {
+			return s[1:]
+		}
+		name := s[2 : len(s)-1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return os.Getenv(name)
+	})
+}
+
+// Defines returns the compile-time defines for the public variables vars:
+// process.env.NAME and import.meta.env.NAME for each of them, NODE_ENV and
+// PUBLIC_URL on process.env, and MODE, DEV, PROD and BASE_URL on
+// import.meta.env; these built-in names take precedence over vars.
+// process.env and import.meta.env themselves become object literals holding
+// the same values, so lookups of other names, including secrets, evaluate
+// to undefined rather than reaching the real environment.
+func Defines(vars map[string]string, mode, publicPath string) map[string]string {
+	processEnv := make(map[string]string)
+	metaEnv := make(map[string]string)
+	for key, value := range vars {
+		processEnv[key] = js.Quote(value)
+		metaEnv[key] = js.Quote(value)
+	}
+	processEnv["NODE_ENV"] = js.Quote(mode)
+	processEnv["PUBLIC_URL"] = js.Quote(strings.TrimSuffix(publicPath, "/"))
+	metaEnv["MODE"] = js.Quote(mode)
+	metaEnv["DEV"] = fmt.Sprint(mode != "production")
+	metaEnv["PROD"] = fmt.Sprint(mode == "production")
+	metaEnv["BASE_URL"] = js.Quote(publicPath)
+
+	defines := make(map[string]string)
+	for _, env := range []struct {
+		object string
+		values map[string]string
+	}{{"process.env", processEnv}, {"import.meta.env", metaEnv}} {
+		for key, value := range env.values {
+			defines[env.object+"."+key] = value
+		}
+		defines[env.object] = objectLiteral(env.values)
+	}
+	return defines
+}
+
+func objectLiteral(values map[string]string) string {
+	keys := sortedKeys(values)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(js.Quote(key) + ":" + values[key])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var builtinMeta = map[string]bool{"MODE": true, "DEV": true, "PROD": true, "BASE_URL": true}
+
+// Declaration returns a TypeScript declaration of import.meta.env and
+// process.env with the public variables vars.
+func Declaration(vars map[string]string) string {
+	keys := sortedKeys(vars)
+	var b strings.Builder
+	b.WriteString("// Generated by gobuild from the project's .env files. Do not edit.\n\n")
+	b.WriteString("interface ImportMetaEnv {\n")
+	b.WriteString("  readonly MODE: string;\n  readonly DEV: boolean;\n  readonly PROD: boolean;\n  readonly BASE_URL: string;\n")
+	for _, key := range keys {
+		if !builtinMeta[key] {
+			fmt.Fprintf(&b, "  readonly %s: string;\n", key)
+		}
+	}
+	b.WriteString("}\n\ninterface ImportMeta {\n  readonly env: ImportMetaEnv;\n}\n\n")
+	b.WriteString("declare namespace NodeJS {\n  interface ProcessEnv {\n")
+	b.WriteString("    readonly NODE_ENV: \"development\" | \"production\";\n    readonly PUBLIC_URL: string;\n")
+	for _, key := range keys {
+		if key != "NODE_ENV" && key != "PUBLIC_URL" {
+			fmt.Fprintf(&b, "    readonly %s: string;\n", key)
+		}
+	}
+	b.WriteString("  }\n}\n")
+	return b.String()
+}
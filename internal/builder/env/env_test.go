@@ -0,0 +1,80 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `# comment
+export A=1
+B = two words # trailing comment
+C='literal ${A} \n'
+D="line\nbreak \"quoted\""
+E="multi
+line"
+F=${A}-${MISSING_VARIABLE_FOR_TEST}-\${A}
+`
+	vars := make(map[string]string)
+	if err := Parse(src, vars); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"A": "1",
+		"B": "two words",
+		"C": `literal ${A} \n`,
+		"D": "line\nbreak \"quoted\"",
+		"E": "multi\nline",
+		"F": "1--${A}",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("Parse() = %q, want %q", vars, want)
+	}
+
+	for _, bad := range []string{"NOVALUE", "A B=1", "A=\"open", "A='open"} {
+		if err := Parse(bad, map[string]string{}); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":             "PUBLIC_A=base\nPUBLIC_B=base\nPUBLIC_C=base\nSECRET=hunter2\n",
+		".env.production":  "PUBLIC_B=production\nPUBLIC_C=production\n",
+		".env.local":       "PUBLIC_C=local\n",
+		".env.development": "PUBLIC_A=development\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PUBLIC_FROM_PROCESS", "process")
+
+	vars, err := Load(dir, "production", []string{"PUBLIC_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"PUBLIC_A": "base", "PUBLIC_B": "production", "PUBLIC_C": "local", "PUBLIC_FROM_PROCESS": "process"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("Load() = %q, want %q", vars, want)
+	}
+
+	defines := Defines(vars, "production", "/app/")
+	if defines["import.meta.env.PUBLIC_B"] != `"production"` || defines["process.env.PUBLIC_URL"] != `"/app"` || defines["import.meta.env.PROD"] != "true" {
+		t.Errorf("Unexpected defines: %q", defines)
+	}
+	for key, value := range defines {
+		if strings.Contains(key, "SECRET") || strings.Contains(value, "hunter2") {
+			t.Errorf("Secret leaked into define %s = %s", key, value)
+		}
+	}
+	if decl := Declaration(vars); !strings.Contains(decl, "readonly PUBLIC_FROM_PROCESS: string;") || strings.Contains(decl, "SECRET") {
+		t.Errorf("Unexpected declaration:\n%s", decl)
+	}
+}
@@ -0,0 +1,267 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/bundler"
+	"github.com/skbhati199/go-web-build/internal/builder/html"
+	"github.com/skbhati199/go-web-build/pkg/utils"
+)
+
+// defaultPage is the HTML entry used when none are configured, if it exists.
+const defaultPage = publicDir + "/index.html"
+
+// htmlPage is an HTML entry. Scripts in it that refer to project sources,
+// such as <script type="module" src="/src/main.ts">, are the entries of its
+// chunk set; they are removed from source and replaced by tags for the
+// built chunks. Pages without such scripts load the project's entries.
+type htmlPage struct {
+	// out is the output path: relative to the public directory for pages
+	// in it, otherwise relative to the project.
+	out     string
+	source  string
+	entries []bundler.Entry
+	// chunks names the chunks the page loads, in order.
+	chunks []string
+}
+
+// chunkFiles are the outputs of an entry chunk that pages link to.
+type chunkFiles struct {
+	script, scriptIntegrity string
+	style, styleIntegrity   string
+	// imports are the shared chunks loaded before the chunk runs.
+	imports []*bundler.Chunk
+}
+
+// loadPages reads the configured HTML entries, or public/index.html.
+func loadPages(baseDir string, configured []string) ([]*htmlPage, error) {
+	if len(configured) == 0 {
+		if !utils.FileExists(filepath.Join(baseDir, filepath.FromSlash(defaultPage))) {
+			return nil, nil
+		}
+		configured = []string{defaultPage}
+	}
+
+	pages := make([]*htmlPage, 0, len(configured))
+	for _, rel := range configured {
+		file := filepath.Join(baseDir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HTML entry %s: %w", rel, err)
+		}
+		page := &htmlPage{out: pageOutput(baseDir, file)}
+		page.source, page.entries = sourceScripts(baseDir, file, string(data))
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// pageOutput returns the output path of the page at file.
+func pageOutput(baseDir, file string) string {
+	if rel, err := filepath.Rel(filepath.Join(baseDir, publicDir), file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return sourceID(baseDir, file)
+}
+
+// sourceScripts removes the script elements of a page whose src is a
+// project source outside the public directory and returns them as entries.
+// Absolute URLs are resolved against the project, relative ones against the
+// page.
+func sourceScripts(baseDir, file, source string) (string, []bundler.Entry) {
+	var entries []bundler.Entry
+	var b strings.Builder
+	last := 0
+	tags := html.Tags(source)
+	for i, tag := range tags {
+		src, ok := tag.Attr("src")
+		if tag.Name != "script" || tag.Closing || !ok || strings.Contains(src.Value, "://") {
+			continue
+		}
+		target := filepath.Join(filepath.Dir(file), filepath.FromSlash(src.Value))
+		if strings.HasPrefix(src.Value, "/") {
+			target = filepath.Join(baseDir, filepath.FromSlash(src.Value))
+		}
+		if !isEntryFile(target) || pageOutput(baseDir, target) != sourceID(baseDir, target) {
+			continue
+		}
+
+		end := tag.End
+		if i+1 < len(tags) && tags[i+1].Name == "script" && tags[i+1].Closing {
+			end = tags[i+1].End
+		}
+		b.WriteString(source[last:tag.Start])
+		last = end
+		name := strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+		entries = append(entries, bundler.Entry{Name: name, Path: target})
+	}
+	b.WriteString(source[last:])
+	return b.String(), entries
+}
+
+func isEntryFile(file string) bool {
+	ext := filepath.Ext(file)
+	for _, e := range entryExtensions {
+		if ext == e {
+			return utils.FileExists(file)
+		}
+	}
+	return false
+}
+
+// pageEntries combines the project entries with those of the pages. Every
+// page is assigned its chunk set. project is only called when a page
+// without scripts of its own, or the lack of pages, needs it.
+func pageEntries(pages []*htmlPage, project func() ([]bundler.Entry, error)) ([]bundler.Entry, error) {
+	var entries []bundler.Entry
+	byPath := make(map[string]string)
+	byName := make(map[string]string)
+	add := func(e bundler.Entry) (string, error) {
+		if name, ok := byPath[e.Path]; ok {
+			return name, nil
+		}
+		if other, ok := byName[e.Name]; ok {
+			return "", fmt.Errorf("entry points %s and %s are both named %q", other, e.Path, e.Name)
+		}
+		byPath[e.Path], byName[e.Name] = e.Name, e.Path
+		entries = append(entries, e)
+		return e.Name, nil
+	}
+
+	var shared []string
+	needShared := len(pages) == 0
+	for _, page := range pages {
+		needShared = needShared || len(page.entries) == 0
+	}
+	if needShared {
+		projectEntries, err := project()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range projectEntries {
+			name, err := add(e)
+			if err != nil {
+				return nil, err
+			}
+			shared = append(shared, name)
+		}
+	}
+
+	for _, page := range pages {
+		if len(page.entries) == 0 {
+			page.chunks = shared
+			continue
+		}
+		for _, e := range page.entries {
+			name, err := add(e)
+			if err != nil {
+				return nil, err
+			}
+			page.chunks = append(page.chunks, name)
+		}
+	}
+	return entries, nil
+}
+
+var placeholder = regexp.MustCompile(`%([A-Z][A-Z0-9_]*)%`)
+
+// pagePlaceholders are the %NAME% values substituted in pages: the public
+// environment variables vars and the built-in names, which take precedence.
+func pagePlaceholders(opts Options, vars map[string]string) map[string]string {
+	values := make(map[string]string, len(vars)+3)
+	for key, value := range vars {
+		values[key] = value
+	}
+	values["PUBLIC_URL"] = strings.TrimSuffix(opts.PublicPath, "/")
+	values["MODE"] = opts.Mode
+	values["NODE_ENV"] = opts.Mode
+	return values
+}
+
+// writePage substitutes placeholders, points references at hashed outputs
+// and adds tags for the page's chunks. Chunks the page already refers to
+// are not added again. Injected tags carry integrity attributes, and go at
+// the end of the head, where module scripts do not block rendering.
+func (w *outputWriter) writePage(page *htmlPage, values map[string]string, minify bool) error {
+	doc := placeholder.ReplaceAllStringFunc(page.source, func(s string) string {
+		if v, ok := values[s[1:len(s)-1]]; ok {
+			return v
+		}
+		return s
+	})
+	if w.names != nil {
+		doc = w.refs.rewriteHTML(page.out, doc, w.publicPath)
+	}
+
+	linked := make(map[string]bool)
+	for _, tag := range html.Tags(doc) {
+		for _, name := range []string{"src", "href"} {
+			if a, ok := tag.Attr(name); ok {
+				linked[w.outputPath(page.out, a.Value)] = true
+			}
+		}
+	}
+
+	// The shared chunks an entry imports are preloaded, so they download
+	// alongside it rather than after it has run, and their styles linked.
+	var styles, scripts strings.Builder
+	for _, name := range page.chunks {
+		files, ok := w.chunks[name]
+		if !ok {
+			continue
+		}
+		for _, dep := range files.imports {
+			shared := w.chunks[dep.Name]
+			if shared.style != "" && !linked[shared.style] {
+				linked[shared.style] = true
+				fmt.Fprintf(&styles, `<link rel="stylesheet" href="%s" integrity="%s" crossorigin="anonymous">`, w.publicPath+shared.style, shared.styleIntegrity)
+			}
+			if !linked[shared.script] {
+				linked[shared.script] = true
+				fmt.Fprintf(&scripts, `<link rel="preload" as="script" href="%s" crossorigin="anonymous">`, w.publicPath+shared.script)
+			}
+		}
+		if files.style != "" && !linked[files.style] {
+			fmt.Fprintf(&styles, `<link rel="stylesheet" href="%s" integrity="%s" crossorigin="anonymous">`, w.publicPath+files.style, files.styleIntegrity)
+		}
+		if !linked[files.script] {
+			fmt.Fprintf(&scripts, `<script type="module" src="%s" integrity="%s" crossorigin="anonymous"></script>`, w.publicPath+files.script, files.scriptIntegrity)
+		}
+	}
+	doc = injectTags(doc, styles.String()+scripts.String())
+	if minify {
+		doc = html.Minify(doc)
+	}
+	return w.writeFile(page.out, []byte(doc))
+}
+
+// outputPath maps a URL found in the page at rel to a path relative to the
+// output directory.
+func (w *outputWriter) outputPath(rel, url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	if strings.HasPrefix(url, w.publicPath) {
+		return strings.TrimPrefix(url, w.publicPath)
+	}
+	return path.Join(path.Dir(rel), url)
+}
+
+// injectTags inserts tags before </head>, or before <body> or at the end
+// of documents without a head.
+func injectTags(doc, tags string) string {
+	if tags == "" {
+		return doc
+	}
+	for _, tag := range html.Tags(doc) {
+		if tag.Name == "head" && tag.Closing || tag.Name == "body" && !tag.Closing {
+			return doc[:tag.Start] + tags + doc[tag.Start:]
+		}
+	}
+	return doc + tags
+}
@@ -0,0 +1,64 @@
+package html
+
+import (
+	"testing"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "block whitespace", in: "<!DOCTYPE html>\n<html>\n  <head>\n    <title> App </title>\n  </head>\n</html>\n", want: "<!DOCTYPE html><html><head><title>App</title></head></html>"},
+		{name: "inline whitespace", in: "<p>\n  Hello,\n  <b>world</b> <i>!</i>\n</p>", want: "<p>Hello, <b>world</b> <i>!</i></p>"},
+		{name: "comments", in: "<div><!-- note --> a <!--[if IE]>x<![endif]--><!--! keep --></div>", want: "<div>a <!--[if IE]>x<![endif]--><!--! keep --></div>"},
+		{name: "attributes", in: "<a  href = \"/x\"\n   title='y'  hidden>z</a><br />", want: "<a href=\"/x\" title='y' hidden>z</a><br/>"},
+		{name: "unquoted self closing", in: "<img src=a.png />", want: "<img src=a.png />"},
+		{name: "case", in: "<svg viewBox=\"0 0 1 1\"><foreignObject></foreignObject></svg>", want: "<svg viewBox=\"0 0 1 1\"><foreignObject></foreignObject></svg>"},
+		{name: "raw text", in: "<script>\n  if (a < b) { x('  </p>  ') }\n</script>\n<style> a  { } </style>", want: "<script>\n  if (a < b) { x('  </p>  ') }\n</script><style> a  { } </style>"},
+		{name: "pre", in: "<pre>\n  a\n    b</pre>  <p> c </p>", want: "<pre>\n  a\n    b</pre><p>c</p>"},
+		{name: "stray less than", in: "<p>1 < 2</p>", want: "<p>1 < 2</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Minify(tt.in); got != tt.want {
+				t.Errorf("Minify(%q)\n got: %s\nwant: %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	src := `<!-- <a href="no"> --><SCRIPT type=module src="/src/main.ts"></script><textarea><b></textarea><link href='x.css' rel=stylesheet>`
+	tags := Tags(src)
+
+	want := []struct {
+		name    string
+		closing bool
+		text    string
+	}{
+		{"script", false, `<SCRIPT type=module src="/src/main.ts">`},
+		{"script", true, "</script>"},
+		{"textarea", false, "<textarea>"},
+		{"textarea", true, "</textarea>"},
+		{"link", false, "<link href='x.css' rel=stylesheet>"},
+	}
+	if len(tags) != len(want) {
+		t.Fatalf("Expected %d tags, got %+v", len(want), tags)
+	}
+	for i, w := range want {
+		tag := tags[i]
+		if tag.Name != w.name || tag.Closing != w.closing || src[tag.Start:tag.End] != w.text {
+			t.Errorf("Tag %d: got %+v (%q)", i, tag, src[tag.Start:tag.End])
+		}
+	}
+
+	if a, ok := tags[0].Attr("SRC"); !ok || a.Value != "/src/main.ts" || src[a.ValueStart:a.ValueEnd] != a.Value {
+		t.Errorf("Unexpected src attribute: %+v", a)
+	}
+	if a, ok := tags[4].Attr("rel"); !ok || a.Value != "stylesheet" || a.Quoted {
+		t.Errorf("Unexpected rel attribute: %+v", a)
+	}
+}
@@ -0,0 +1,154 @@
+package html
+
+import (
+	"strings"
+)
+
+// blockElements are elements around which whitespace does not render, so
+// it can be removed next to their tags rather than collapsed.
+var blockElements = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true,
+	"link": true, "base": true, "script": true, "style": true, "noscript": true,
+	"template": true, "div": true, "p": true, "section": true, "article": true,
+	"aside": true, "header": true, "footer": true, "nav": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"td": true, "th": true, "caption": true, "colgroup": true, "col": true,
+	"form": true, "fieldset": true, "legend": true, "hr": true, "br": true,
+	"figure": true, "figcaption": true, "blockquote": true, "address": true,
+	"details": true, "summary": true, "dialog": true,
+}
+
+// Minify removes comments and redundant whitespace from an HTML document.
+// Runs of whitespace in text collapse to a single space, which is dropped
+// next to tags of block elements, and whitespace between attributes is
+// normalised. The contents of pre, textarea, script and style elements are
+// kept as written, as are conditional comments and comments starting with
+// "<!--!".
+func Minify(src string) string {
+	nodes := scan(src)
+	var b strings.Builder
+	b.Grow(len(src))
+	pre := 0
+
+	for i, n := range nodes {
+		switch n.kind {
+		case nodeComment:
+			if keepComment(src, n) {
+				b.WriteString(src[n.start:n.end])
+			}
+		case nodeDeclaration, nodeRawText:
+			b.WriteString(src[n.start:n.end])
+		case nodeTag:
+			writeTag(&b, src, n.tag)
+			if n.tag.Name == "pre" {
+				if n.tag.Closing {
+					pre = max(pre-1, 0)
+				} else if !n.tag.SelfClosing {
+					pre++
+				}
+			}
+		case nodeText:
+			text := src[n.start:n.end]
+			if pre > 0 {
+				b.WriteString(text)
+				continue
+			}
+			text = collapseSpace(text)
+			if trimsBefore(src, nodes, i) {
+				text = strings.TrimPrefix(text, " ")
+			}
+			if trimsAfter(src, nodes, i) {
+				text = strings.TrimSuffix(text, " ")
+			}
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// trimsBefore reports whether whitespace at the start of text node i can be
+// dropped: it starts the document or follows a block element's tag. Dropped
+// comments are skipped over.
+func trimsBefore(src string, nodes []node, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		if nodes[j].kind != nodeComment || keepComment(src, nodes[j]) {
+			return trims(nodes[j])
+		}
+	}
+	return true
+}
+
+// trimsAfter is trimsBefore for the end of text node i.
+func trimsAfter(src string, nodes []node, i int) bool {
+	for j := i + 1; j < len(nodes); j++ {
+		if nodes[j].kind != nodeComment || keepComment(src, nodes[j]) {
+			return trims(nodes[j])
+		}
+	}
+	return true
+}
+
+// keepComment reports whether comment n is a conditional comment or starts
+// with "<!--!".
+func keepComment(src string, n node) bool {
+	comment := src[n.start:n.end]
+	return strings.HasPrefix(comment, "<!--[if") || strings.HasPrefix(comment, "<!--<![") || strings.HasPrefix(comment, "<!--!")
+}
+
+func trims(n node) bool {
+	return n.kind == nodeDeclaration || n.kind == nodeTag && blockElements[n.tag.Name]
+}
+
+// writeTag writes a tag with single spaces between its attributes. Names
+// keep their case.
+func writeTag(b *strings.Builder, src string, tag Tag) {
+	b.WriteString(src[tag.Start:tag.nameEnd])
+	unquoted := false
+	for _, a := range tag.Attrs {
+		b.WriteByte(' ')
+		b.WriteString(src[a.Start:a.nameEnd])
+		if a.ValueEnd == a.End && a.ValueStart == a.End {
+			unquoted = false
+			continue
+		}
+		b.WriteByte('=')
+		b.WriteString(src[a.ValueStart-boolInt(a.Quoted) : a.ValueEnd+boolInt(a.Quoted)])
+		unquoted = !a.Quoted
+	}
+	if tag.SelfClosing {
+		if unquoted {
+			b.WriteByte(' ')
+		}
+		b.WriteByte('/')
+	}
+	b.WriteByte('>')
+}
+
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		if isSpace(s[i]) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,235 @@
+// Package html scans and minifies HTML documents.
+package html
+
+import (
+	"strings"
+)
+
+// Attr is an attribute of a tag. Start and End delimit the whole attribute;
+// ValueStart and ValueEnd delimit its value without quotes, and are equal to
+// End for attributes without a value. Entities in values are not decoded.
+type Attr struct {
+	Name       string
+	Value      string
+	Quoted     bool
+	Start      int
+	End        int
+	ValueStart int
+	ValueEnd   int
+	nameEnd    int
+}
+
+// Tag is a start or end tag. Name is lower-cased. Start and End are the
+// byte offsets of its "<" and just after its ">".
+type Tag struct {
+	Name        string
+	Closing     bool
+	SelfClosing bool
+	Attrs       []Attr
+	Start       int
+	End         int
+	nameEnd     int
+}
+
+// Attr returns the attribute called name, compared case insensitively.
+func (t Tag) Attr(name string) (Attr, bool) {
+	for _, a := range t.Attrs {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return Attr{}, false
+}
+
+type nodeKind int
+
+const (
+	nodeText nodeKind = iota
+	// nodeRawText is the content of a script, style or textarea element,
+	// which is not markup.
+	nodeRawText
+	nodeTag
+	nodeComment
+	// nodeDeclaration is a doctype or other "<!" declaration.
+	nodeDeclaration
+)
+
+type node struct {
+	kind       nodeKind
+	start, end int
+	tag        Tag
+}
+
+// rawTextElements hold text that is not parsed as markup.
+var rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true}
+
+// Tags returns the start and end tags of a document in source order. Tags
+// inside comments and inside script, style and textarea elements are not
+// reported.
+func Tags(src string) []Tag {
+	var tags []Tag
+	for _, n := range scan(src) {
+		if n.kind == nodeTag {
+			tags = append(tags, n.tag)
+		}
+	}
+	return tags
+}
+
+// scan splits src into text, tags, comments and declarations. Markup it
+// cannot make sense of, such as a "<" not starting a tag, is text, as it is
+// for browsers.
+func scan(src string) []node {
+	var nodes []node
+	text := 0
+	flushText := func(end int) {
+		if end > text {
+			nodes = append(nodes, node{kind: nodeText, start: text, end: end})
+		}
+	}
+
+	for i := 0; i < len(src); {
+		if src[i] != '<' {
+			i++
+			continue
+		}
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 7
+			}
+			flushText(i)
+			nodes = append(nodes, node{kind: nodeComment, start: i, end: i + end})
+			i += end
+			text = i
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			flushText(i)
+			nodes = append(nodes, node{kind: nodeDeclaration, start: i, end: i + end + 1})
+			i += end + 1
+			text = i
+		default:
+			tag, ok := scanTag(src, i)
+			if !ok {
+				i++
+				continue
+			}
+			flushText(i)
+			nodes = append(nodes, node{kind: nodeTag, start: tag.Start, end: tag.End, tag: tag})
+			i = tag.End
+			text = i
+			if tag.Closing || tag.SelfClosing || !rawTextElements[tag.Name] {
+				continue
+			}
+			end := indexFold(src[i:], "</"+tag.Name)
+			if end < 0 {
+				end = len(src) - i
+			}
+			if end > 0 {
+				nodes = append(nodes, node{kind: nodeRawText, start: i, end: i + end})
+			}
+			i += end
+			text = i
+		}
+	}
+	flushText(len(src))
+	return nodes
+}
+
+// scanTag reads the tag starting at src[i], which is "<".
+func scanTag(src string, i int) (Tag, bool) {
+	tag := Tag{Start: i}
+	j := i + 1
+	if j < len(src) && src[j] == '/' {
+		tag.Closing = true
+		j++
+	}
+	if j >= len(src) || !isLetter(src[j]) {
+		return Tag{}, false
+	}
+	nameStart := j
+	for j < len(src) && !isSpace(src[j]) && src[j] != '/' && src[j] != '>' {
+		j++
+	}
+	tag.Name, tag.nameEnd = strings.ToLower(src[nameStart:j]), j
+
+	for {
+		for j < len(src) && (isSpace(src[j]) || src[j] == '/') {
+			if src[j] == '/' && j+1 < len(src) && src[j+1] == '>' {
+				tag.SelfClosing = true
+			}
+			j++
+		}
+		if j >= len(src) {
+			return Tag{}, false
+		}
+		if src[j] == '>' {
+			tag.End = j + 1
+			return tag, true
+		}
+
+		attr := Attr{Start: j}
+		for j < len(src) && !isSpace(src[j]) && src[j] != '/' && src[j] != '>' && (src[j] != '=' || j == attr.Start) {
+			j++
+		}
+		attr.Name, attr.nameEnd = strings.ToLower(src[attr.Start:j]), j
+		k := j
+		for k < len(src) && isSpace(src[k]) {
+			k++
+		}
+		if k < len(src) && src[k] == '=' {
+			k++
+			for k < len(src) && isSpace(src[k]) {
+				k++
+			}
+			switch {
+			case k < len(src) && (src[k] == '"' || src[k] == '\''):
+				end := strings.IndexByte(src[k+1:], src[k])
+				if end < 0 {
+					return Tag{}, false
+				}
+				attr.Quoted = true
+				attr.ValueStart, attr.ValueEnd = k+1, k+1+end
+				j = k + end + 2
+			default:
+				attr.ValueStart = k
+				for k < len(src) && !isSpace(src[k]) && src[k] != '>' {
+					k++
+				}
+				attr.ValueEnd = k
+				j = k
+			}
+			attr.Value = src[attr.ValueStart:attr.ValueEnd]
+		} else {
+			attr.ValueStart, attr.ValueEnd = j, j
+		}
+		attr.End = j
+		tag.Attrs = append(tag.Attrs, attr)
+	}
+}
+
+// indexFold is strings.Index with ASCII case folding. substr must be lower
+// case.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i] == substr[0] && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
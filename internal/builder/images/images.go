@@ -0,0 +1,174 @@
+// Package images recompresses PNG and JPEG assets and renders narrower
+// variants of them for responsive srcset attributes.
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+)
+
+// Version changes whenever Optimize produces different output for the same
+// input, so cached results are not reused.
+const Version = "1"
+
+// DefaultQuality is the JPEG quality of the zero Options.
+const DefaultQuality = 80
+
+// Options controls Optimize.
+type Options struct {
+	// Quality is the JPEG quality, 1 to 100. Zero uses DefaultQuality.
+	Quality int
+	// StripMetadata removes text, EXIF, XMP and IPTC metadata. Colour
+	// profiles are always kept, since they change how an image looks.
+	StripMetadata bool
+	// Widths are the widths of resized variants. Variants that would not
+	// be narrower or smaller than the image are skipped.
+	Widths []int
+}
+
+// Result is an optimized image.
+type Result struct {
+	Data []byte `json:"data"`
+	// Width and Height are the display size of the image.
+	Width    int       `json:"width"`
+	Height   int       `json:"height"`
+	Variants []Variant `json:"variants,omitempty"`
+}
+
+// Variant is a resized copy of an image.
+type Variant struct {
+	Width int    `json:"width"`
+	Data  []byte `json:"data"`
+}
+
+// Supported reports whether Optimize handles a file, by its extension.
+func Supported(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// Optimize re-encodes a PNG or JPEG image, keeping whichever of the result
+// and the original is smaller. JPEG orientation is applied to the pixels
+// of re-encoded images. Animated PNGs and CMYK JPEGs are never re-encoded,
+// only stripped.
+func Optimize(data []byte, opts Options) (*Result, error) {
+	if opts.Quality == 0 {
+		opts.Quality = DefaultQuality
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return nil, fmt.Errorf("JPEG quality %d is out of range 1-100", opts.Quality)
+	}
+	var f format
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		f = pngFormat{}
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		f = jpegFormat{}
+	default:
+		return nil, fmt.Errorf("not a PNG or JPEG image")
+	}
+
+	meta, err := f.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// The smallest candidate wins. When stripping, the original competes
+	// without its metadata, unless that would lose its orientation.
+	best := data
+	if opts.StripMetadata {
+		best = nil
+		if meta.orientation <= 1 {
+			best = meta.strip()
+		}
+	}
+	if !meta.reencode {
+		if best == nil {
+			// Stripping would lose the orientation.
+			best = data
+		}
+		b := img.Bounds()
+		result := &Result{Data: best, Width: b.Dx(), Height: b.Dy()}
+		if meta.orientation >= 5 {
+			result.Width, result.Height = result.Height, result.Width
+		}
+		return result, nil
+	}
+
+	var oriented *image.RGBA
+	if meta.orientation > 1 {
+		oriented = orient(toRGBA(img), meta.orientation)
+		img = oriented
+	}
+	encoded, err := f.encode(img, opts)
+	if err != nil {
+		return nil, err
+	}
+	encoded = meta.insert(encoded, opts.StripMetadata)
+	if best == nil || len(encoded) < len(best) {
+		best = encoded
+	}
+
+	b := img.Bounds()
+	result := &Result{Data: best, Width: b.Dx(), Height: b.Dy()}
+	for _, w := range opts.Widths {
+		if w <= 0 || w >= result.Width {
+			continue
+		}
+		if oriented == nil {
+			oriented = toRGBA(img)
+		}
+		h := max(1, (result.Height*w+result.Width/2)/result.Width)
+		encoded, err := f.encode(resize(oriented, w, h), opts)
+		if err != nil {
+			return nil, err
+		}
+		encoded = meta.insert(encoded, opts.StripMetadata)
+		if len(encoded) >= len(best) {
+			// Resizing can turn a palette image into a larger true colour
+			// one; the full image is then the better choice.
+			continue
+		}
+		result.Variants = append(result.Variants, Variant{Width: w, Data: encoded})
+	}
+	return result, nil
+}
+
+// format encodes one image type and reads the metadata of its files.
+type format interface {
+	parse(data []byte) (*metadata, error)
+	encode(img image.Image, opts Options) ([]byte, error)
+}
+
+type pngFormat struct{}
+
+func (pngFormat) encode(img image.Image, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type jpegFormat struct{}
+
+func (jpegFormat) encode(img image.Image, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.Quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
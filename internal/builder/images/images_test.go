@@ -0,0 +1,99 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// pngChunk encodes a PNG chunk.
+func pngChunk(typ, data string) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, typ+data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE([]byte(typ+data)))
+}
+
+func TestOptimize(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 4), uint8(y * 5), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	// Add a text chunk and a colour chunk after the header.
+	encoded := buf.Bytes()
+	var withMeta []byte
+	withMeta = append(withMeta, encoded[:33]...)
+	withMeta = append(withMeta, pngChunk("tEXt", "Comment\x00made by a camera")...)
+	withMeta = append(withMeta, pngChunk("sRGB", "\x00")...)
+	withMeta = append(withMeta, encoded[33:]...)
+
+	result, err := Optimize(withMeta, Options{StripMetadata: true, Widths: []int{32, 64, 100}})
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if bytes.Contains(result.Data, []byte("tEXt")) || !bytes.Contains(result.Data, []byte("sRGB")) {
+		t.Error("Expected text metadata to be stripped and the colour chunk kept")
+	}
+	if len(result.Data) > len(withMeta) || result.Width != 64 || result.Height != 48 {
+		t.Errorf("Unexpected result: %d bytes, %dx%d", len(result.Data), result.Width, result.Height)
+	}
+	if len(result.Variants) != 1 || result.Variants[0].Width != 32 {
+		t.Fatalf("Expected one 32px variant, got %+v", result.Variants)
+	}
+	variant, err := png.Decode(bytes.NewReader(result.Variants[0].Data))
+	if err != nil || variant.Bounds().Dx() != 32 || variant.Bounds().Dy() != 24 {
+		t.Errorf("Unexpected variant (error %v)", err)
+	}
+
+	// A JPEG stored sideways, left half red, with EXIF orientation 6.
+	photo := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 20 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			photo.Set(x, y, c)
+		}
+	}
+	buf.Reset()
+	if err := jpeg.Encode(&buf, photo, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00\x00\x00")
+	exif := append([]byte("\xff\xe1\x00\x00Exif\x00\x00"), tiff...)
+	binary.BigEndian.PutUint16(exif[2:], uint16(len(exif)-2))
+	sideways := append(append(append([]byte{}, buf.Bytes()[:2]...), exif...), buf.Bytes()[2:]...)
+
+	result, err = Optimize(sideways, Options{StripMetadata: true})
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) || result.Width != 20 || result.Height != 40 {
+		t.Fatalf("Expected an upright image without EXIF, got %dx%d", result.Width, result.Height)
+	}
+	upright, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, b, _ := upright.At(10, 5).RGBA(); r < b {
+		t.Error("Expected the red half on top")
+	}
+	if r, _, b, _ := upright.At(10, 35).RGBA(); r > b {
+		t.Error("Expected the blue half at the bottom")
+	}
+
+	if _, err := Optimize([]byte("GIF89a"), Options{}); err == nil {
+		t.Error("Expected error for an unsupported format")
+	}
+}
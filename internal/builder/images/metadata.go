@@ -0,0 +1,195 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// segment is a PNG chunk or JPEG marker segment, as a range of the file.
+type segment struct {
+	start, end int
+	// colour segments describe how pixel values are displayed; the others
+	// are metadata.
+	colour bool
+}
+
+// metadata is what Optimize needs to know about an image file besides its
+// pixels.
+type metadata struct {
+	jpeg bool
+	// segments are the colour and metadata segments in file order.
+	segments []segment
+	// orientation is the EXIF orientation, 1 to 8, or 0 if there is none.
+	// orientationAt is its offset in the file and order its byte order.
+	orientation   int
+	orientationAt int
+	order         binary.ByteOrder
+	// gray is set for grayscale PNGs, whose ICC profiles do not fit colour
+	// images.
+	gray bool
+	// reencode is false for images that cannot be re-encoded faithfully.
+	reencode bool
+	src      []byte
+}
+
+// pngColour and pngMetadata are the ancillary PNG chunks copied into
+// re-encoded images. Chunks that depend on the colour type, such as bKGD
+// and sBIT, are dropped since the encoder may choose a different one.
+var (
+	pngColour   = map[string]bool{"iCCP": true, "sRGB": true, "gAMA": true, "cHRM": true, "cICP": true}
+	pngMetadata = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true, "pHYs": true}
+)
+
+func (pngFormat) parse(data []byte) (*metadata, error) {
+	m := &metadata{reencode: true, src: data}
+	for i := len(pngSignature); ; {
+		if i+12 > len(data) {
+			return nil, fmt.Errorf("truncated PNG chunk at offset %d", i)
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated PNG chunk at offset %d", i)
+		}
+		switch typ := string(data[i+4 : i+8]); {
+		case typ == "IHDR" && length >= 10:
+			colourType := data[i+8+9]
+			m.gray = colourType == 0 || colourType == 4
+		case typ == "acTL":
+			// Animated PNGs decode to their first frame only.
+			m.reencode = false
+		case typ == "IEND":
+			return m, nil
+		case pngColour[typ] || pngMetadata[typ]:
+			m.segments = append(m.segments, segment{start: i, end: end, colour: pngColour[typ]})
+		}
+		i = end
+	}
+}
+
+func (jpegFormat) parse(data []byte) (*metadata, error) {
+	m := &metadata{jpeg: true, reencode: true, src: data}
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xff {
+			return nil, fmt.Errorf("invalid JPEG marker at offset %d", i)
+		}
+		marker := data[i+1]
+		if marker == 0xff {
+			// Fill byte.
+			i++
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			// Start of scan: the rest is image data.
+			return m, nil
+		}
+		if marker == 0x01 || marker >= 0xd0 && marker <= 0xd7 {
+			i += 2
+			continue
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment at offset %d", i)
+		}
+		payload := data[i+4 : end]
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")):
+			m.exif(i+10, data[i+10:end])
+			m.segments = append(m.segments, segment{start: i, end: end})
+		case marker == 0xe2 && bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")):
+			m.segments = append(m.segments, segment{start: i, end: end, colour: true})
+		case marker == 0xe0 || marker == 0xee:
+			// JFIF and Adobe segments tell decoders how to read the file.
+		case marker >= 0xe1 && marker <= 0xef || marker == 0xfe:
+			m.segments = append(m.segments, segment{start: i, end: end})
+		case marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc:
+			if len(payload) >= 6 && payload[5] == 4 {
+				// CMYK colours would not survive re-encoding.
+				m.reencode = false
+			}
+		}
+		i = end
+	}
+}
+
+// exif records the orientation tag of the TIFF structure at tiff, which
+// starts at offset base in the file.
+func (m *metadata) exif(base int, tiff []byte) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + 12*k
+		if e+12 > len(tiff) {
+			return
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 && order.Uint16(tiff[e+2:]) == 3 {
+			if v := int(order.Uint16(tiff[e+8:])); v >= 1 && v <= 8 {
+				m.orientation, m.orientationAt, m.order = v, base+e+8, order
+			}
+			return
+		}
+	}
+}
+
+// strip returns the original file without its metadata segments.
+func (m *metadata) strip() []byte {
+	data := m.src
+	out := make([]byte, 0, len(data))
+	i := 0
+	for _, s := range m.segments {
+		if !s.colour {
+			out = append(out, data[i:s.start]...)
+			i = s.end
+		}
+	}
+	return append(out, data[i:]...)
+}
+
+// insert copies the colour segments of the original, and unless strip is
+// set its metadata, into a re-encoded file. Since re-encoded images are
+// upright, their EXIF orientation is reset.
+func (m *metadata) insert(encoded []byte, strip bool) []byte {
+	// Segments go after the PNG header chunk, or the JPEG start of image.
+	at := 2
+	gray := false
+	if !m.jpeg {
+		at = len(pngSignature) + 25
+		colourType := encoded[len(pngSignature)+8+9]
+		gray = colourType == 0 || colourType == 4
+	}
+
+	out := make([]byte, 0, len(encoded)+len(m.src)/8)
+	out = append(out, encoded[:at]...)
+	for _, s := range m.segments {
+		if !s.colour && strip {
+			continue
+		}
+		if !m.jpeg && m.gray && !gray && string(m.src[s.start+4:s.start+8]) == "iCCP" {
+			continue
+		}
+		start := len(out)
+		out = append(out, m.src[s.start:s.end]...)
+		if m.orientation > 1 && m.orientationAt >= s.start && m.orientationAt < s.end {
+			m.order.PutUint16(out[start+m.orientationAt-s.start:], 1)
+		}
+	}
+	return append(out, encoded[at:]...)
+}
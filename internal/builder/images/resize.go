@@ -0,0 +1,122 @@
+package images
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// toRGBA returns img as premultiplied RGBA with its origin at (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && b.Min == (image.Point{}) {
+		return rgba
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// orient turns an image stored with an EXIF orientation upright.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// (sx, sy) is the stored pixel displayed at (x, y).
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+// tap is the weight of a source pixel in a destination pixel.
+type tap struct {
+	index  int
+	weight float32
+}
+
+// taps returns, for each of n destination pixels, the source pixels it
+// covers out of size, weighted by their overlap.
+func taps(size, n int) [][]tap {
+	scale := float64(size) / float64(n)
+	all := make([][]tap, n)
+	for d := range all {
+		lo, hi := float64(d)*scale, float64(d+1)*scale
+		for i := int(lo); i < int(math.Ceil(hi)) && i < size; i++ {
+			overlap := math.Min(hi, float64(i+1)) - math.Max(lo, float64(i))
+			if overlap > 0 {
+				all[d] = append(all[d], tap{i, float32(overlap / scale)})
+			}
+		}
+	}
+	return all
+}
+
+// resize scales src down to w by h pixels, averaging the source pixels
+// each destination pixel covers. Premultiplied colours keep transparent
+// pixels from bleeding into their neighbours.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	xtaps, ytaps := taps(sw, w), taps(sh, h)
+
+	// Scale rows first, then columns.
+	rows := make([]float32, sh*w*4)
+	for y := 0; y < sh; y++ {
+		line := src.Pix[y*src.Stride:]
+		for x, ts := range xtaps {
+			out := rows[(y*w+x)*4:][:4]
+			for _, t := range ts {
+				p := line[t.index*4:][:4]
+				for c := range out {
+					out[c] += float32(p[c]) * t.weight
+				}
+			}
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, ts := range ytaps {
+		for x := 0; x < w; x++ {
+			var sum [4]float32
+			for _, t := range ts {
+				p := rows[(t.index*w+x)*4:][:4]
+				for c := range sum {
+					sum[c] += p[c] * t.weight
+				}
+			}
+			out := dst.Pix[dst.PixOffset(x, y):][:4]
+			for c := range out {
+				out[c] = uint8(min(255, sum[c]+0.5))
+			}
+			// Rounding may leave a channel above its alpha.
+			for c := 0; c < 3; c++ {
+				out[c] = min(out[c], out[3])
+			}
+		}
+	}
+	return dst
+}
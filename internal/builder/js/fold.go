@@ -0,0 +1,231 @@
+package js
+
+import (
+	"math"
+	"strings"
+)
+
+// binaryPrecedence ranks the binary operators; higher binds tighter.
+var binaryPrecedence = map[string]int{
+	"??": 4, "||": 4, "&&": 5, "|": 6, "^": 7, "&": 8,
+	"==": 9, "!=": 9, "===": 9, "!==": 9,
+	"<": 10, ">": 10, "<=": 10, ">=": 10,
+	"<<": 11, ">>": 11, ">>>": 11,
+	"+": 12, "-": 12, "*": 13, "/": 13, "%": 13, "**": 14,
+}
+
+// fold replaces binary expressions of two numeric or two string literals
+// with their value, as long as neighbouring operators bind less tightly.
+// Results that would be longer than the expression are left alone.
+func (a *analysis) fold(pieces []piece) []piece {
+	for changed := true; changed; {
+		changed = false
+		for k := 0; k+2 < len(pieces); k++ {
+			text, kind, ok := a.foldAt(pieces, k)
+			if !ok {
+				continue
+			}
+			pieces[k].text, pieces[k].kind, pieces[k].name = text, kind, ""
+			pieces[k].last = pieces[k+2].last
+			pieces = append(pieces[:k+1], pieces[k+3:]...)
+			changed = true
+		}
+	}
+	return pieces
+}
+
+func (a *analysis) foldAt(pieces []piece, k int) (string, TokenKind, bool) {
+	left, op, right := pieces[k], pieces[k+1], pieces[k+2]
+	prec, ok := binaryPrecedence[op.text]
+	if !ok || op.kind != TokenPunctuator || left.kind != right.kind {
+		return "", 0, false
+	}
+	if !a.boundaryBefore(pieces, k, prec) || !a.boundaryAfter(pieces, k+3, op.text, prec) {
+		return "", 0, false
+	}
+
+	var text string
+	kind := TokenNumber
+	switch left.kind {
+	case TokenNumber:
+		x, okx := parseNumber(left.text)
+		y, oky := parseNumber(right.text)
+		if !okx || !oky {
+			return "", 0, false
+		}
+		if result, ok := compare(op.text, x, y, x == y); ok {
+			text, kind = result, TokenKeyword
+			break
+		}
+		v, ok := arithmetic(op.text, x, y)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", 0, false
+		}
+		text = formatNumber(v)
+	case TokenString:
+		x, okx := simpleString(left.text)
+		y, oky := simpleString(right.text)
+		if !okx || !oky {
+			return "", 0, false
+		}
+		switch op.text {
+		case "+":
+			text, kind = Quote(x+y), TokenString
+		case "==", "===", "!=", "!==":
+			text, _ = compare(op.text, 0, 0, x == y)
+			kind = TokenKeyword
+		default:
+			return "", 0, false
+		}
+	default:
+		return "", 0, false
+	}
+	if len(text) > len(left.text)+len(op.text)+len(right.text) {
+		return "", 0, false
+	}
+	return text, kind, true
+}
+
+// boundaryBefore reports whether nothing before pieces[k] binds to it more
+// tightly than an operator of precedence prec.
+func (a *analysis) boundaryBefore(pieces []piece, k, prec int) bool {
+	if k == 0 {
+		return true
+	}
+	p := pieces[k-1]
+	switch p.kind {
+	case TokenPunctuator:
+		if q, ok := binaryPrecedence[p.text]; ok {
+			if (p.text == "+" || p.text == "-") && (k < 2 || !canEnd(a.toks[pieces[k-2].last])) {
+				// A unary operator.
+				return false
+			}
+			return q < prec
+		}
+		switch p.text {
+		case "(", "[", "{", ",", ";", "?", ":", "=>", "...":
+			return true
+		}
+		return isAssignment(p.text)
+	case TokenKeyword:
+		switch p.text {
+		case "return", "case", "throw", "else", "do":
+			return true
+		}
+	case TokenTemplate:
+		part := templatePart(a.toks[p.last])
+		return part == templateHead || part == templateMiddle
+	}
+	return false
+}
+
+// boundaryAfter reports whether nothing from pieces[k] on binds to the
+// operand before it more tightly than op.
+func (a *analysis) boundaryAfter(pieces []piece, k int, op string, prec int) bool {
+	if k == len(pieces) {
+		return true
+	}
+	n := pieces[k]
+	if a.toks[n.first].NewlineBefore && asiBreak(a.toks[pieces[k-1].last], a.toks[n.first]) {
+		return true
+	}
+	switch n.kind {
+	case TokenPunctuator:
+		if q, ok := binaryPrecedence[n.text]; ok {
+			return q < prec || q == prec && op != "**"
+		}
+		switch n.text {
+		case ")", "]", "}", ",", ";", ":", "?":
+			return true
+		}
+	case TokenTemplate:
+		return n.text[0] == '}'
+	}
+	return false
+}
+
+func compare(op string, x, y float64, equal bool) (string, bool) {
+	var v bool
+	switch op {
+	case "==", "===":
+		v = equal
+	case "!=", "!==":
+		v = !equal
+	case "<":
+		v = x < y
+	case ">":
+		v = x > y
+	case "<=":
+		v = x <= y
+	case ">=":
+		v = x >= y
+	default:
+		return "", false
+	}
+	if v {
+		return "!0", true
+	}
+	return "!1", true
+}
+
+func arithmetic(op string, x, y float64) (float64, bool) {
+	switch op {
+	case "+":
+		return x + y, true
+	case "-":
+		return x - y, true
+	case "*":
+		return x * y, true
+	case "/":
+		return x / y, true
+	case "%":
+		return math.Mod(x, y), true
+	case "**":
+		return math.Pow(x, y), true
+	}
+	// Bitwise operators work on 32-bit integers; only fold exact ones.
+	if x != math.Trunc(x) || y != math.Trunc(y) || math.Abs(x) > 1<<53 || math.Abs(y) > 1<<53 {
+		return 0, false
+	}
+	i, j := int32(uint32(int64(x))), uint32(int64(y))
+	switch op {
+	case "<<":
+		return float64(i << (j & 31)), true
+	case ">>":
+		return float64(i >> (j & 31)), true
+	case ">>>":
+		return float64(uint32(i) >> (j & 31)), true
+	case "&":
+		return float64(i & int32(j)), true
+	case "|":
+		return float64(i | int32(j)), true
+	case "^":
+		return float64(i ^ int32(j)), true
+	}
+	return 0, false
+}
+
+// shortNumber rewrites a plain decimal literal in its shortest form.
+func shortNumber(text string) string {
+	if strings.ContainsAny(text, "_xXoObBnN") || len(text) > 1 && text[0] == '0' && isDigit(text[1]) {
+		return text
+	}
+	v, ok := parseNumber(text)
+	if !ok {
+		return text
+	}
+	if s := formatNumber(v); len(s) < len(text) {
+		if w, ok := parseNumber(s); ok && w == v {
+			return s
+		}
+	}
+	return text
+}
+
+// simpleString returns the value of a string literal without escapes.
+func simpleString(lit string) (string, bool) {
+	if len(lit) < 2 || strings.ContainsAny(lit, "\\\n") {
+		return "", false
+	}
+	return lit[1 : len(lit)-1], true
+}
@@ -0,0 +1,501 @@
+package js
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsxElement is a JSX element or fragment. Offsets index the source it was
+// lexed from.
+type jsxElement struct {
+	start, end int
+	// name is the tag name, or "" for a fragment.
+	name     string
+	attrs    []jsxAttr
+	children []jsxNode
+}
+
+// jsxAttr is an attribute, or a spread attribute when name is "".
+type jsxAttr struct {
+	start int
+	name  string
+	value jsxNode
+}
+
+type jsxNodeKind uint8
+
+const (
+	// jsxNone is the value of an attribute without one.
+	jsxNone jsxNodeKind = iota
+	// jsxText is text between tags; start and end delimit it.
+	jsxText
+	// jsxString is a quoted attribute value; start and end include the
+	// quotes.
+	jsxString
+	// jsxExpression is an expression container; start and end delimit the
+	// code between the braces, or after "..." in spread attributes.
+	jsxExpression
+	jsxChild
+)
+
+type jsxNode struct {
+	kind       jsxNodeKind
+	start, end int
+	element    *jsxElement
+}
+
+// jsxStart reports whether the "<" at the current position in expression
+// position opens an element. In TypeScript "<T,>" and "<T extends U>" are
+// type parameters of an arrow function instead.
+func (l *lexer) jsxStart() bool {
+	c := l.peek(1)
+	if c == '>' {
+		return true
+	}
+	if !isIdentStart(c) {
+		return false
+	}
+	i := l.pos + 1
+	for i < len(l.src) && isIdentPart(l.src[i]) {
+		i++
+	}
+	for i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t' || l.src[i] == '\n' || l.src[i] == '\r') {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == ',' {
+		return false
+	}
+	if rest := l.src[i:]; strings.HasPrefix(rest, "extends") && len(rest) > 7 && (rest[7] == ' ' || rest[7] == '\n') {
+		return !strings.HasPrefix(strings.TrimLeft(rest[7:], " \t\r\n"), "=")
+	}
+	return true
+}
+
+// scanJSXElement scans the element starting at the "<" at the current
+// position, including its children and closing tag.
+func (l *lexer) scanJSXElement() (*jsxElement, error) {
+	el := &jsxElement{start: l.pos}
+	l.pos++
+	if err := l.skipTrivia(); err != nil {
+		return nil, err
+	}
+	if l.peek(0) == '>' {
+		l.pos++
+		return el, l.scanJSXChildren(el)
+	}
+	name, err := l.scanJSXName()
+	if err != nil {
+		return nil, err
+	}
+	el.name = name
+
+	for {
+		if err := l.skipTrivia(); err != nil {
+			return nil, err
+		}
+		start := l.pos
+		switch c := l.peek(0); {
+		case c == '/':
+			l.pos++
+			if err := l.skipTrivia(); err != nil {
+				return nil, err
+			}
+			if l.peek(0) != '>' {
+				return nil, l.errorf("expected \">\" to close <%s>", name)
+			}
+			l.pos++
+			el.end = l.pos
+			return el, nil
+		case c == '>':
+			l.pos++
+			return el, l.scanJSXChildren(el)
+		case c == '{':
+			l.pos++
+			if err := l.skipTrivia(); err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(l.src[l.pos:], "...") {
+				return nil, l.errorf("expected \"...\" in JSX spread attribute")
+			}
+			l.pos += 3
+			value, err := l.scanJSXExpression(l.pos)
+			if err != nil {
+				return nil, err
+			}
+			el.attrs = append(el.attrs, jsxAttr{start: start, value: value})
+		case isIdentStart(c):
+			attr := jsxAttr{start: start}
+			if attr.name, err = l.scanJSXName(); err != nil {
+				return nil, err
+			}
+			if err := l.skipTrivia(); err != nil {
+				return nil, err
+			}
+			if l.peek(0) == '=' {
+				l.pos++
+				if err := l.skipTrivia(); err != nil {
+					return nil, err
+				}
+				if attr.value, err = l.scanJSXAttrValue(); err != nil {
+					return nil, err
+				}
+			}
+			el.attrs = append(el.attrs, attr)
+		case c == 0:
+			return nil, l.errorf("unterminated JSX element <%s>", name)
+		default:
+			return nil, l.errorf("unexpected %q in JSX element <%s>", c, name)
+		}
+	}
+}
+
+func (l *lexer) scanJSXAttrValue() (jsxNode, error) {
+	start := l.pos
+	switch c := l.peek(0); c {
+	case '"', '\'':
+		end := strings.IndexByte(l.src[l.pos+1:], c)
+		if end < 0 {
+			return jsxNode{}, l.errorf("unterminated string literal")
+		}
+		l.skipLines(l.pos + 1 + end + 1)
+		return jsxNode{kind: jsxString, start: start, end: l.pos}, nil
+	case '{':
+		l.pos++
+		return l.scanJSXExpression(l.pos)
+	case '<':
+		el, err := l.scanJSXElement()
+		if err != nil {
+			return jsxNode{}, err
+		}
+		return jsxNode{kind: jsxChild, start: start, end: l.pos, element: el}, nil
+	}
+	return jsxNode{}, l.errorf("expected a JSX attribute value")
+}
+
+// scanJSXChildren scans the children of el up to and including its closing
+// tag.
+func (l *lexer) scanJSXChildren(el *jsxElement) error {
+	for {
+		start := l.pos
+		switch l.peek(0) {
+		case 0:
+			if el.name == "" {
+				return l.errorf("unterminated JSX fragment")
+			}
+			return l.errorf("unterminated JSX element <%s>", el.name)
+		case '<':
+			line, lineStart := l.line, l.lineStart
+			l.pos++
+			if err := l.skipTrivia(); err != nil {
+				return err
+			}
+			if l.peek(0) == '/' {
+				l.pos++
+				if err := l.skipTrivia(); err != nil {
+					return err
+				}
+				name := ""
+				if l.peek(0) != '>' {
+					var err error
+					if name, err = l.scanJSXName(); err != nil {
+						return err
+					}
+					if err := l.skipTrivia(); err != nil {
+						return err
+					}
+				}
+				if name != el.name {
+					return l.errorf("expected closing tag </%s> but found </%s>", el.name, name)
+				}
+				if l.peek(0) != '>' {
+					return l.errorf("expected \">\" to close </%s>", name)
+				}
+				l.pos++
+				el.end = l.pos
+				return nil
+			}
+			l.pos, l.line, l.lineStart = start, line, lineStart
+			child, err := l.scanJSXElement()
+			if err != nil {
+				return err
+			}
+			el.children = append(el.children, jsxNode{kind: jsxChild, start: start, end: l.pos, element: child})
+		case '{':
+			l.pos++
+			child, err := l.scanJSXExpression(l.pos)
+			if err != nil {
+				return err
+			}
+			el.children = append(el.children, child)
+		default:
+			end := strings.IndexAny(l.src[l.pos:], "<{")
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.skipLines(l.pos + end)
+			el.children = append(el.children, jsxNode{kind: jsxText, start: start, end: l.pos})
+		}
+	}
+}
+
+// scanJSXExpression scans JavaScript from start up to the "}" closing an
+// expression container and moves past it.
+func (l *lexer) scanJSXExpression(start int) (jsxNode, error) {
+	sub := &lexer{src: l.src, pos: start, line: l.line, lineStart: l.lineStart, jsx: true, typescript: l.typescript, elements: l.elements}
+	for {
+		if err := sub.skipTrivia(); err != nil {
+			return jsxNode{}, err
+		}
+		if sub.pos >= len(sub.src) {
+			return jsxNode{}, sub.errorf("unterminated JSX expression")
+		}
+		if sub.src[sub.pos] == '}' && len(sub.braces) == 0 {
+			break
+		}
+		if err := sub.next(); err != nil {
+			return jsxNode{}, err
+		}
+	}
+	l.pos, l.line, l.lineStart = sub.pos+1, sub.line, sub.lineStart
+	return jsxNode{kind: jsxExpression, start: start, end: sub.pos}, nil
+}
+
+// scanJSXName scans a tag or attribute name, which may contain dashes,
+// dots and a namespace colon.
+func (l *lexer) scanJSXName() (string, error) {
+	start := l.pos
+	for l.pos < len(l.src) && (isIdentPart(l.src[l.pos]) || l.src[l.pos] == '-' || l.src[l.pos] == '.' || l.src[l.pos] == ':') {
+		l.pos++
+	}
+	if l.pos == start {
+		return "", l.errorf("expected a JSX name")
+	}
+	return l.src[start:l.pos], nil
+}
+
+// skipLines moves to end, counting the line breaks on the way.
+func (l *lexer) skipLines(end int) {
+	for ; l.pos < end; l.pos++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.lineStart = l.pos + 1
+		}
+	}
+}
+
+// jsx renders el as React.createElement calls. Line breaks inside el are
+// kept between the arguments so that the code after it stays on its line.
+func (t *transformer) jsx(el *jsxElement) (string, error) {
+	var b strings.Builder
+	lines := 0
+	write := func(s string) {
+		b.WriteString(s)
+		lines += strings.Count(s, "\n")
+	}
+	// pad adds the line breaks of the source up to pos.
+	pad := func(pos int) {
+		for n := strings.Count(t.src[el.start:pos], "\n"); lines < n; lines++ {
+			b.WriteByte('\n')
+		}
+	}
+
+	write("React.createElement(" + jsxTag(el.name) + ", ")
+	if len(el.attrs) == 0 {
+		write("null")
+	} else {
+		write("{")
+		for i, attr := range el.attrs {
+			if i > 0 {
+				write(", ")
+			}
+			pad(attr.start)
+			value, err := t.jsxValue(attr.value)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case attr.name == "":
+				write("..." + value)
+			case value == "":
+				return "", t.errorAt(attr.value.start, "JSX attribute %s has an empty expression", attr.name)
+			case isJSXIdentifier(attr.name):
+				write(attr.name + ": " + value)
+			default:
+				write(Quote(attr.name) + ": " + value)
+			}
+		}
+		write("}")
+	}
+
+	for _, child := range el.children {
+		var value string
+		if child.kind == jsxText {
+			if text := cleanJSXText(decodeEntities(t.src[child.start:child.end])); text != "" {
+				value = Quote(text)
+			}
+		} else {
+			var err error
+			if value, err = t.jsxValue(child); err != nil {
+				return "", err
+			}
+		}
+		if value == "" {
+			continue
+		}
+		write(", ")
+		pad(child.start)
+		write(value)
+	}
+	pad(el.end)
+	write(")")
+	return b.String(), nil
+}
+
+// jsxValue renders an attribute value or child. Empty expression
+// containers, which only hold comments, render as "".
+func (t *transformer) jsxValue(n jsxNode) (string, error) {
+	switch n.kind {
+	case jsxNone:
+		return "true", nil
+	case jsxString:
+		return Quote(decodeEntities(t.src[n.start+1 : n.end-1])), nil
+	case jsxChild:
+		return t.jsx(n.element)
+	}
+	code := t.src[n.start:n.end]
+	if l := (&lexer{src: code}); l.skipTrivia() == nil && l.pos == len(code) {
+		return "", nil
+	}
+	// The parentheses make the code an expression even if it starts with
+	// a brace.
+	out, err := transform("("+code+")", t.opts, true)
+	if err != nil {
+		if se, ok := err.(*SyntaxError); ok {
+			line, col := position(t.src, n.start)
+			if se.Line == 0 {
+				se.Col += col - 1
+			}
+			se.Line += line
+		}
+		return "", err
+	}
+	return out[1 : len(out)-1], nil
+}
+
+// jsxTag renders a tag name: intrinsic elements, whose names start with a
+// lowercase letter or contain a dash or colon, as strings and components
+// as references.
+func jsxTag(name string) string {
+	switch {
+	case name == "":
+		return "React.Fragment"
+	case name[0] >= 'a' && name[0] <= 'z' && !strings.Contains(name, "."), strings.ContainsAny(name, "-:"):
+		return Quote(name)
+	}
+	return name
+}
+
+func isJSXIdentifier(name string) bool {
+	return !strings.ContainsAny(name, "-:.")
+}
+
+// cleanJSXText collapses the whitespace of JSX text the way React expects:
+// lines are trimmed, blank lines dropped and the rest joined by spaces.
+func cleanJSXText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	last := -1
+	for i, line := range lines {
+		if strings.Trim(line, " \t") != "" {
+			last = i
+		}
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", " ")
+		if i > 0 {
+			line = strings.TrimLeft(line, " ")
+		}
+		if i < len(lines)-1 {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" {
+			continue
+		}
+		b.WriteString(line)
+		if i != last {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// entities are the named character references decoded in JSX text and
+// attribute strings.
+var entities = map[string]rune{
+	"amp": '&', "lt": '<', "gt": '>', "quot": '"', "apos": '\'', "nbsp": '\u00a0',
+	"copy": '©', "reg": '®', "trade": '™', "hellip": '…', "mdash": '—', "ndash": '–',
+	"lsquo": '‘', "rsquo": '’', "ldquo": '“', "rdquo": '”', "laquo": '«', "raquo": '»',
+	"bull": '•', "middot": '·', "times": '×', "divide": '÷', "deg": '°', "plusmn": '±',
+	"para": '¶', "sect": '§', "cent": '¢', "pound": '£', "euro": '€', "yen": '¥',
+	"larr": '←', "rarr": '→', "uarr": '↑', "darr": '↓', "harr": '↔', "hearts": '♥',
+	"check": '✓', "ensp": '\u2002', "emsp": '\u2003', "thinsp": '\u2009', "zwj": '\u200d',
+	"zwnj": '\u200c', "shy": '\u00ad', "iexcl": '¡', "iquest": '¿', "micro": 'µ',
+	"frac12": '½', "frac14": '¼', "frac34": '¾', "sup2": '²', "sup3": '³', "prime": '′',
+}
+
+// decodeEntities replaces character references such as "&amp;" and
+// "&#x27;". Unknown references are left as they are.
+func decodeEntities(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		amp := strings.IndexByte(s, '&')
+		if amp < 0 {
+			break
+		}
+		b.WriteString(s[:amp])
+		s = s[amp:]
+		semi := strings.IndexByte(s, ';')
+		if semi < 0 || semi > 10 {
+			b.WriteByte('&')
+			s = s[1:]
+			continue
+		}
+		if r, ok := decodeEntity(s[1:semi]); ok {
+			b.WriteRune(r)
+			s = s[semi+1:]
+		} else {
+			b.WriteByte('&')
+			s = s[1:]
+		}
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+func decodeEntity(name string) (rune, bool) {
+	if !strings.HasPrefix(name, "#") {
+		r, ok := entities[name]
+		return r, ok
+	}
+	var n uint64
+	var err error
+	if strings.HasPrefix(name, "#x") || strings.HasPrefix(name, "#X") {
+		n, err = strconv.ParseUint(name[2:], 16, 32)
+	} else {
+		n, err = strconv.ParseUint(name[1:], 10, 32)
+	}
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// position returns the zero-based line and column of offset in src.
+func position(src string, offset int) (int, int) {
+	line := strings.Count(src[:offset], "\n")
+	return line, offset - strings.LastIndexByte(src[:offset], '\n') - 1
+}
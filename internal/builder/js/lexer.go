@@ -0,0 +1,604 @@
+package js
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenIdentifier
+	TokenKeyword
+	TokenPunctuator
+	TokenString
+	TokenTemplate
+	TokenNumber
+	TokenRegExp
+	// TokenJSX is a whole JSX element, only produced when lexing JSX.
+	TokenJSX
+)
+
+// Token is a single lexical token. Start and End are byte offsets into the
+// source; Line and Col are zero-based and point at Start.
+type Token struct {
+	Kind          TokenKind
+	Value         string
+	Start         int
+	End           int
+	Line          int
+	Col           int
+	NewlineBefore bool
+}
+
+func (t Token) Is(kind TokenKind, value string) bool {
+	return t.Kind == kind && t.Value == value
+}
+
+// IsPunct reports whether the token is the given punctuator.
+func (t Token) IsPunct(value string) bool {
+	return t.Kind == TokenPunctuator && t.Value == value
+}
+
+// IsName reports whether the token is an identifier or keyword with the given
+// spelling. Contextual keywords such as "from" and "as" are identifiers.
+func (t Token) IsName(value string) bool {
+	return (t.Kind == TokenIdentifier || t.Kind == TokenKeyword) && t.Value == value
+}
+
+var keywords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true, "let": true, "static": true,
+}
+
+// IsKeyword reports whether name is a reserved word that can never be used
+// as a binding name.
+func IsKeyword(name string) bool {
+	return keywords[name]
+}
+
+// keywords after which a slash starts a regular expression rather than a
+// division.
+var regexAfterKeyword = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// punctuators sorted longest first so the scanner can match greedily.
+var punctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--", "+=", "-=",
+	"*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+	"{", "}", "(", ")", "[", "]", ";", ",", "<", ">", "+", "-", "*", "/", "%",
+	"&", "|", "^", "!", "~", "?", ":", "=", ".", "@", "#",
+}
+
+type SyntaxError struct {
+	Line    int
+	Col     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line+1, e.Col+1, e.Message)
+}
+
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+	tokens    []Token
+	newline   bool
+	// braces tracks open "{" so that a "}" closing a template substitution
+	// resumes scanning the template rather than emitting a punctuator.
+	braces []bool
+	// jsx enables JSX elements, which are recorded in elements by start
+	// offset. typescript makes a "!" after an operand a non-null assertion.
+	jsx        bool
+	typescript bool
+	elements   map[int]*jsxElement
+}
+
+// Tokenize splits JavaScript source into tokens. Comments and whitespace are
+// dropped; a line break (including one inside a block comment) is recorded on
+// the following token as NewlineBefore so callers can honour ASI.
+func Tokenize(src string) ([]Token, error) {
+	return (&lexer{src: src}).run()
+}
+
+func (l *lexer) run() ([]Token, error) {
+	for {
+		if err := l.skipTrivia(); err != nil {
+			return nil, err
+		}
+		if l.pos >= len(l.src) {
+			break
+		}
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: l.line, Col: l.pos - l.lineStart, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) skipTrivia() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.newLine(l.pos + 1)
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case c == 0xE2 && (strings.HasPrefix(l.src[l.pos:], "\u2028") || strings.HasPrefix(l.src[l.pos:], "\u2029")):
+			l.pos += 3
+			l.newLine(l.pos)
+		case c == 0xC2 && strings.HasPrefix(l.src[l.pos:], "\u00a0"):
+			l.pos += 2
+		case c == 0xEF && strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += 3
+		case c == '/' && l.peek(1) == '/':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '/' && l.peek(1) == '*':
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return l.errorf("unterminated comment")
+			}
+			stop := l.pos + 2 + end + 2
+			for i := l.pos; i < stop; i++ {
+				if l.src[i] == '\n' {
+					l.newLine(i + 1)
+				}
+			}
+			l.pos = stop
+		case c == '#' && l.pos == 0 && l.peek(1) == '!':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *lexer) newLine(start int) {
+	l.line++
+	l.lineStart = start
+	l.newline = true
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) emit(kind TokenKind, start, line, col int) {
+	l.tokens = append(l.tokens, Token{
+		Kind:          kind,
+		Value:         l.src[start:l.pos],
+		Start:         start,
+		End:           l.pos,
+		Line:          line,
+		Col:           col,
+		NewlineBefore: l.newline,
+	})
+	l.newline = false
+}
+
+func (l *lexer) next() error {
+	start, line, col := l.pos, l.line, l.pos-l.lineStart
+	c := l.src[l.pos]
+
+	switch {
+	case isIdentStart(c):
+		l.scanIdentifier()
+		kind := TokenIdentifier
+		if keywords[l.src[start:l.pos]] {
+			kind = TokenKeyword
+		}
+		l.emit(kind, start, line, col)
+		return nil
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.scanNumber()
+		l.emit(TokenNumber, start, line, col)
+		return nil
+	case c == '"' || c == '\'':
+		if err := l.scanString(c); err != nil {
+			return err
+		}
+		l.emit(TokenString, start, line, col)
+		return nil
+	case c == '`':
+		l.pos++
+		if err := l.scanTemplate(); err != nil {
+			return err
+		}
+		l.emit(TokenTemplate, start, line, col)
+		return nil
+	case c == '}' && len(l.braces) > 0 && l.braces[len(l.braces)-1]:
+		l.braces = l.braces[:len(l.braces)-1]
+		l.pos++
+		if err := l.scanTemplate(); err != nil {
+			return err
+		}
+		l.emit(TokenTemplate, start, line, col)
+		return nil
+	case c == '<' && l.jsx && l.regexAllowed() && l.jsxStart():
+		newline := l.newline
+		el, err := l.scanJSXElement()
+		if err != nil {
+			return err
+		}
+		l.elements[start] = el
+		l.newline = newline
+		l.emit(TokenJSX, start, line, col)
+		return nil
+	case c == '/' && l.regexAllowed():
+		if err := l.scanRegExp(); err != nil {
+			return err
+		}
+		l.emit(TokenRegExp, start, line, col)
+		return nil
+	}
+
+	for _, p := range punctuators {
+		if strings.HasPrefix(l.src[l.pos:], p) {
+			// "?." followed by a digit is a conditional, not optional chaining.
+			if p == "?." && isDigit(l.peek(2)) {
+				continue
+			}
+			l.pos += len(p)
+			switch p {
+			case "{":
+				l.braces = append(l.braces, false)
+			case "}":
+				if len(l.braces) > 0 {
+					l.braces = l.braces[:len(l.braces)-1]
+				}
+			}
+			l.emit(TokenPunctuator, start, line, col)
+			return nil
+		}
+	}
+
+	return l.errorf("unexpected character %q", c)
+}
+
+func (l *lexer) scanIdentifier() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isIdentPart(c) {
+			l.pos++
+			continue
+		}
+		if c == '\\' && l.peek(1) == 'u' {
+			l.pos += 2
+			continue
+		}
+		break
+	}
+}
+
+func (l *lexer) scanNumber() {
+	if l.src[l.pos] == '0' && strings.ContainsRune("xXoObB", rune(l.peek(1))) {
+		l.pos += 2
+		for l.pos < len(l.src) && (isHexDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	} else {
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+		if l.pos < len(l.src) && l.src[l.pos] == '.' {
+			l.pos++
+			for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+				l.pos++
+			}
+		}
+		if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+			l.pos++
+			if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+				l.pos++
+			}
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+		}
+	}
+	// BigInt suffix
+	if l.pos < len(l.src) && l.src[l.pos] == 'n' {
+		l.pos++
+	}
+}
+
+func (l *lexer) scanString(quote byte) error {
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '\\':
+			if l.peek(1) == '\n' {
+				l.pos += 2
+				l.line++
+				l.lineStart = l.pos
+				continue
+			}
+			l.pos += 2
+			continue
+		case '\n':
+			return l.errorf("unterminated string literal")
+		case quote:
+			l.pos++
+			return nil
+		}
+		l.pos++
+	}
+	return l.errorf("unterminated string literal")
+}
+
+// scanTemplate scans template characters up to and including the closing
+// backtick or the "${" that opens a substitution.
+func (l *lexer) scanTemplate() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == '`':
+			l.pos++
+			return nil
+		case c == '$' && l.peek(1) == '{':
+			l.pos += 2
+			l.braces = append(l.braces, true)
+			return nil
+		case c == '\n':
+			l.pos++
+			l.line++
+			l.lineStart = l.pos
+			continue
+		}
+		l.pos++
+	}
+	return l.errorf("unterminated template literal")
+}
+
+func (l *lexer) scanRegExp() error {
+	l.pos++
+	inClass := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == '\n':
+			return l.errorf("unterminated regular expression")
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			l.pos++
+			for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+				l.pos++
+			}
+			return nil
+		}
+		l.pos++
+	}
+	return l.errorf("unterminated regular expression")
+}
+
+// regexAllowed decides whether a "/" starts a regular expression based on the
+// previous significant token.
+func (l *lexer) regexAllowed() bool {
+	if len(l.tokens) == 0 {
+		return true
+	}
+	prev := l.tokens[len(l.tokens)-1]
+	switch prev.Kind {
+	case TokenNumber, TokenString, TokenRegExp, TokenTemplate, TokenJSX:
+		return false
+	case TokenIdentifier:
+		return regexAfterKeyword[prev.Value]
+	case TokenKeyword:
+		return regexAfterKeyword[prev.Value] || !(prev.Value == "this" || prev.Value == "super" ||
+			prev.Value == "true" || prev.Value == "false" || prev.Value == "null")
+	case TokenPunctuator:
+		if prev.Value == "!" && l.typescript && l.nonNull(len(l.tokens)-1) {
+			return false
+		}
+		return prev.Value != ")" && prev.Value != "]" && prev.Value != "}" &&
+			prev.Value != "++" && prev.Value != "--"
+	}
+	return true
+}
+
+// nonNull reports whether the "!" token at i directly follows an operand,
+// which makes it a TypeScript non-null assertion rather than a negation.
+func (l *lexer) nonNull(i int) bool {
+	if i == 0 || l.tokens[i].NewlineBefore {
+		return false
+	}
+	prev := l.tokens[i-1]
+	switch prev.Kind {
+	case TokenIdentifier, TokenNumber, TokenString, TokenTemplate, TokenRegExp, TokenJSX:
+		return true
+	case TokenKeyword:
+		return prev.Value == "this" || prev.Value == "super"
+	case TokenPunctuator:
+		if prev.Value == "!" {
+			return l.nonNull(i - 1)
+		}
+		return prev.Value == ")" || prev.Value == "]"
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80 || c == '\\'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// Unquote returns the value of a string literal token. Only the escapes that
+// appear in module specifiers and environment values are interpreted.
+func Unquote(lit string) string {
+	if len(lit) < 2 {
+		return lit
+	}
+	body := lit[1 : len(lit)-1]
+	if !strings.Contains(body, "\\") {
+		return body
+	}
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i+1 == len(body) {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\n':
+		default:
+			b.WriteByte(body[i])
+		}
+	}
+	return b.String()
+}
+
+// Quote renders s as a double-quoted JavaScript string literal.
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\u2028':
+			b.WriteString(`\u2028`)
+		case '\u2029':
+			b.WriteString(`\u2029`)
+		case '<':
+			// Avoid "</script>" terminating an inline script.
+			b.WriteString(`\x3c`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\x%02x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// parseNumber parses a numeric literal. BigInt and legacy octal literals
+// are not supported.
+func parseNumber(text string) (float64, bool) {
+	text = strings.ReplaceAll(text, "_", "")
+	if text == "" || strings.HasSuffix(text, "n") {
+		return 0, false
+	}
+	if len(text) > 1 && text[0] == '0' {
+		base := 0
+		switch text[1] | 0x20 {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			v, err := strconv.ParseUint(text[2:], base, 64)
+			return float64(v), err == nil && v <= 1<<53
+		}
+		if isDigit(text[1]) {
+			return 0, false
+		}
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	return v, err == nil
+}
+
+// formatNumber returns the shortest literal for v.
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		zeros := len(s) - len(strings.TrimRight(s, "0"))
+		if zeros > 2 {
+			return s[:len(s)-zeros] + "e" + strconv.Itoa(zeros)
+		}
+		return s
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if mantissa, exp, ok := strings.Cut(s, "e"); ok {
+		sign := ""
+		if strings.HasPrefix(exp, "-") {
+			sign = "-"
+		}
+		s = mantissa + "e" + sign + strings.TrimLeft(exp, "+-0")
+	}
+	if strings.HasPrefix(s, "0.") {
+		return s[1:]
+	}
+	if strings.HasPrefix(s, "-0.") {
+		return "-" + s[2:]
+	}
+	return s
+}
+
+func isAssignment(op string) bool {
+	switch op {
+	case "=", "+=", "-=", "*=", "/=", "%=", "**=", "<<=", ">>=", ">>>=", "&=", "|=", "^=", "&&=", "||=", "??=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package js
+
+import "strings"
+
+// MinifyOptions controls Minify.
+type MinifyOptions struct {
+	// TopLevel renames top-level bindings too. Only set it for code that
+	// runs in a scope of its own, such as a bundled module body.
+	TopLevel bool
+	// Keep lists names that are never renamed nor given to other bindings.
+	Keep []string
+}
+
+// piece is a token of minified output.
+type piece struct {
+	text string
+	kind TokenKind
+	// first and last are the input tokens the piece replaces.
+	first, last int
+	// name is the original identifier, recorded in source maps.
+	name string
+}
+
+// Minify removes comments and whitespace from src, renames local bindings
+// to short names, folds constant expressions and drops semicolons before
+// closing braces. Line breaks are only kept where automatic semicolon
+// insertion or a restricted production depends on them. Bindings that may
+// be reached by a direct eval or with statement keep their names. It
+// returns one segment per output token.
+func Minify(src string, opts MinifyOptions) (string, []Segment, error) {
+	toks, err := Tokenize(src)
+	if err != nil {
+		return "", nil, err
+	}
+	a, err := analyze(toks)
+	if err != nil {
+		return "", nil, err
+	}
+	keep := make(map[string]bool, len(opts.Keep))
+	for _, name := range opts.Keep {
+		keep[name] = true
+	}
+	a.mangle(opts.TopLevel, keep)
+	return a.print(a.fold(a.pieces()))
+}
+
+// pieces returns the output tokens with bindings renamed and literals in
+// their shortest form.
+func (a *analysis) pieces() []piece {
+	pieces := make([]piece, 0, len(a.toks))
+	for i, t := range a.toks {
+		p := piece{text: t.Value, kind: t.Kind, first: i, last: i}
+		if t.Kind == TokenIdentifier {
+			p.name = t.Value
+		}
+		b := a.refs[i]
+		switch {
+		case b != nil && b.renamed != "" && a.roles[i] == roleShorthand:
+			p.text = t.Value + ":" + b.renamed
+		case b != nil && b.renamed != "":
+			p.text = b.renamed
+		case t.Kind == TokenNumber:
+			p.text = shortNumber(t.Value)
+		case (t.IsName("true") || t.IsName("false")) && a.roles[i] != roleKey && !a.operand(i):
+			p.text, p.kind = "!1", TokenKeyword
+			if t.Value == "true" {
+				p.text = "!0"
+			}
+		case t.IsName("undefined") && a.roles[i] == roleRef && b == nil && !a.operand(i) && !a.assigned(i):
+			p.text, p.kind = "void 0", TokenKeyword
+		}
+		pieces = append(pieces, p)
+	}
+	return pieces
+}
+
+// operand reports whether the token at i is the operand of a member access,
+// call, tagged template or exponentiation, where a unary replacement would
+// need parentheses.
+func (a *analysis) operand(i int) bool {
+	next := a.tok(i + 1)
+	return next.IsPunct(".") || next.IsPunct("?.") || next.IsPunct("[") || next.IsPunct("(") ||
+		next.IsPunct("**") || next.Kind == TokenTemplate
+}
+
+// assigned reports whether the token at i is the target of an assignment or
+// update.
+func (a *analysis) assigned(i int) bool {
+	prev, next := a.tok(i-1), a.tok(i+1)
+	return prev.IsPunct("++") || prev.IsPunct("--") || next.IsPunct("++") || next.IsPunct("--") ||
+		next.Kind == TokenPunctuator && isAssignment(next.Value) || next.IsPunct("=>")
+}
+
+// print writes pieces, separating them only where needed.
+func (a *analysis) print(pieces []piece) (string, []Segment, error) {
+	var b strings.Builder
+	segments := make([]Segment, 0, len(pieces))
+	line, col := 0, 0
+	var prev *piece
+
+	for i := range pieces {
+		p := &pieces[i]
+		if p.text == ";" && p.kind == TokenPunctuator && i+1 < len(pieces) && pieces[i+1].text == "}" && a.optionalSemicolon(prev) {
+			continue
+		}
+		switch {
+		case prev == nil:
+		case a.lineBreak(prev, p):
+			b.WriteByte('\n')
+			line++
+			col = 0
+		case separate(*prev, *p):
+			b.WriteByte(' ')
+			col++
+		}
+
+		t := a.toks[p.first]
+		segments = append(segments, Segment{GeneratedLine: line, GeneratedColumn: col, OriginalLine: t.Line, OriginalColumn: t.Col, Name: p.name})
+
+		b.WriteString(p.text)
+		if n := strings.Count(p.text, "\n"); n > 0 {
+			line += n
+			col = len(p.text) - strings.LastIndex(p.text, "\n") - 1
+		} else {
+			col += len(p.text)
+		}
+		prev = p
+	}
+	return b.String(), segments, nil
+}
+
+// lineBreak reports whether the line break between prev and next in the
+// input has to be kept, because a statement may end there.
+func (a *analysis) lineBreak(prev, next *piece) bool {
+	broken := false
+	for i := prev.last + 1; i <= next.first; i++ {
+		broken = broken || a.toks[i].NewlineBefore
+	}
+	if broken && a.declarationEnd[prev.last] {
+		return true
+	}
+	if !broken || a.statementEnd[prev.last] || a.toks[prev.last].IsPunct(")") && a.controlHeader(prev.last) {
+		return false
+	}
+	return asiBreak(a.toks[prev.last], a.toks[next.first])
+}
+
+// optionalSemicolon reports whether a semicolon after prev and before a
+// closing brace can be dropped: it may not be the empty body of a control
+// statement or label.
+func (a *analysis) optionalSemicolon(prev *piece) bool {
+	if prev == nil {
+		return true
+	}
+	t := a.toks[prev.last]
+	switch {
+	case t.IsPunct(")"):
+		return !a.controlHeader(prev.last)
+	case t.IsName("else") || t.IsName("do") || t.IsPunct(":"):
+		return false
+	}
+	return true
+}
+
+// separate reports whether two adjacent pieces would merge into different
+// tokens if printed without a space between them.
+func separate(prev, next piece) bool {
+	p, n := prev.text, next.text
+	last, first := p[len(p)-1], n[0]
+	switch {
+	case isWordByte(last) && isWordByte(first):
+		return true
+	case prev.kind == TokenNumber && first == '.':
+		return true
+	case prev.kind == TokenRegExp && isWordByte(first):
+		return true
+	case last == '+' && first == '+', last == '-' && first == '-':
+		return true
+	case last == '/' && (first == '/' || first == '*'):
+		return true
+	case last == '<' && first == '!':
+		return true
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return isIdentPart(c) || c == '\\' || c >= 0x80
+}
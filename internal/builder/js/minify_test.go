@@ -0,0 +1,60 @@
+package js
+
+import "testing"
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts MinifyOptions
+		want string
+	}{
+		{name: "parameters", in: "function f(alpha, beta) { return alpha + beta }", want: "function f(a,b){return a+b}"},
+		{name: "shadowing", in: "function f(first) { var second = first; { let first = 2; second += first } return second }", want: "function f(b){var a=b;{let c=2;a+=c}return a}"},
+		{name: "line breaks", in: "let x\n[a, b] = [b, a]\nvar c = 1\nc\n++d", want: "let x\n[a,b]=[b,a]\nvar c=1\nc\n++d"},
+		{name: "restricted production", in: "return\nvalue", want: "return\nvalue"},
+		{name: "constants", in: "x = 1 + 2 * 3; y = \"a\" + 'b'; z = 2 ** 3 ** 2; w = 1 - 2 - 3; v = a + 1 + 2; u = 0.50 + 1000", want: "x=7;y=\"ab\";z=2**3**2;w=-4;v=a+1+2;u=1000.5"},
+		{name: "shorthand properties", in: "function g(value) { const { name, size = 1 } = value; return { name, size } }", want: "function g(a){const{name:b,size:c=1}=a;return{name:b,size:c}}"},
+		{name: "booleans", in: "f(true, false, undefined, x.true, { true: 1 }, !true.valueOf())", want: "f(!0,!1,void 0,x.true,{true:1},!true.valueOf())"},
+		{name: "eval", in: "function h(code) { var local = 1; return eval(code) }", want: "function h(code){var local=1;return eval(code)}"},
+		{name: "keyword property", in: "x = obj.default\nnext()", want: "x=obj.default\nnext()"},
+		{name: "semicolons", in: "if (a) { b(); } else { c(); }\nwhile (x);\nfunction q() { return 1; }", want: "if(a){b()}else{c()}while(x);function q(){return 1}"},
+		{name: "labels", in: "label: for (;;) { if (a) continue label; break label }", want: "label:for(;;){if(a)continue label;break label}"},
+		{name: "class", in: "class A { #count = 0\n static make(seed) { return new A(seed) }\n get count() { return this.#count } }", want: "class A{#count=0\nstatic make(a){return new A(a)}get count(){return this.#count}}"},
+		{name: "async arrow", in: "const fn = async (items) => { for await (const item of items) use(item?.value ?? 0.5) }", opts: MinifyOptions{TopLevel: true}, want: "const a=async(b)=>{for await(const c of b)use(c?.value??.5)}"},
+		{name: "keep", in: "var kept = 1, renamed = 2; function exported() { return kept + renamed }", opts: MinifyOptions{TopLevel: true, Keep: []string{"kept", "exported"}}, want: "var kept=1,a=2;function exported(){return kept+a}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Minify(tt.in, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Minify(%q)\n got: %s\nwant: %s", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := Minify("f(a]", MinifyOptions{}); err == nil {
+		t.Error("Expected error for unbalanced brackets")
+	}
+}
+
+func TestMinifySegments(t *testing.T) {
+	_, segments, err := Minify("function add(left, right) {\n  return left + right\n}", MinifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "right" in the return statement is printed as "b" at 0:27.
+	for _, seg := range segments {
+		if seg.GeneratedColumn == 27 {
+			if seg.OriginalLine != 1 || seg.OriginalColumn != 16 || seg.Name != "right" {
+				t.Errorf("Unexpected segment %+v", seg)
+			}
+			return
+		}
+	}
+	t.Errorf("No segment at column 27: %+v", segments)
+}
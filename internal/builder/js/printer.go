@@ -0,0 +1,98 @@
+package js
+
+import "strings"
+
+// Segment ties the start of a token in compacted output to its position in
+// the input. Lines and columns are zero-based.
+type Segment struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	OriginalLine    int
+	OriginalColumn  int
+	// Name is set for identifiers so source maps can record the original
+	// symbol.
+	Name string
+}
+
+// Compact removes comments and redundant whitespace from src. Line breaks
+// between tokens are kept, so automatic semicolon insertion behaves exactly
+// as in the input; everything else is collapsed to the single space needed
+// to keep adjacent tokens apart. It returns one segment per token.
+func Compact(src string) (string, []Segment, error) {
+	toks, err := Tokenize(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	segments := make([]Segment, 0, len(toks))
+	line, col := 0, 0
+	var prev *Token
+
+	for i := range toks {
+		t := &toks[i]
+		switch {
+		case prev == nil:
+		case t.NewlineBefore:
+			b.WriteByte('\n')
+			line++
+			col = 0
+		case needsSpace(*prev, *t):
+			b.WriteByte(' ')
+			col++
+		}
+
+		seg := Segment{GeneratedLine: line, GeneratedColumn: col, OriginalLine: t.Line, OriginalColumn: t.Col}
+		if t.Kind == TokenIdentifier {
+			seg.Name = t.Value
+		}
+		segments = append(segments, seg)
+
+		b.WriteString(t.Value)
+		if n := strings.Count(t.Value, "\n"); n > 0 {
+			line += n
+			col = len(t.Value) - strings.LastIndex(t.Value, "\n") - 1
+		} else {
+			col += len(t.Value)
+		}
+		prev = t
+	}
+	return b.String(), segments, nil
+}
+
+// needsSpace reports whether two adjacent tokens would merge into different
+// tokens if printed without whitespace between them.
+func needsSpace(prev, next Token) bool {
+	if isWordToken(prev) && isWordToken(next) {
+		return true
+	}
+	if prev.Kind == TokenNumber && next.IsPunct(".") {
+		return true
+	}
+	if prev.Kind != TokenPunctuator || next.Kind != TokenPunctuator && next.Kind != TokenRegExp {
+		return prev.Kind == TokenRegExp && isWordToken(next)
+	}
+
+	p, n := prev.Value, next.Value
+	switch {
+	case (p == "+" || p == "++") && strings.HasPrefix(n, "+"):
+		return true
+	case (p == "-" || p == "--") && strings.HasPrefix(n, "-"):
+		return true
+	case p == "/" && (strings.HasPrefix(n, "/") || strings.HasPrefix(n, "*")):
+		return true
+	case p == "<" && strings.HasPrefix(n, "!"):
+		return true
+	}
+	return false
+}
+
+// isWordToken reports whether a token starts or ends with an identifier
+// character.
+func isWordToken(t Token) bool {
+	switch t.Kind {
+	case TokenIdentifier, TokenKeyword, TokenNumber:
+		return true
+	}
+	return false
+}
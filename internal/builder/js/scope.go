@@ -0,0 +1,1099 @@
+package js
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// role is what an identifier token stands for.
+type role uint8
+
+const (
+	roleOther role = iota
+	// roleRef is a binding or a reference to one.
+	roleRef
+	// roleShorthand is a shorthand property such as {a}, which is a
+	// property name and a reference at once.
+	roleShorthand
+	// roleKey is a property name, label or modifier, which is never renamed.
+	roleKey
+)
+
+// scope is a function, block, catch clause, for statement or named class
+// expression. Function scopes receive var declarations.
+type scope struct {
+	parent   *scope
+	function bool
+	decls    map[string]*binding
+	children []*scope
+	// unsafe scopes contain a direct eval or with statement, so their
+	// names may be looked up dynamically and must be kept.
+	unsafe bool
+	// end is the token index at which an arrow function with an expression
+	// body ends, or -1.
+	end int
+	// statement is set for function declarations, class methods and catch
+	// clauses, whose bodies end a statement or member.
+	statement bool
+}
+
+type binding struct {
+	name  string
+	scope *scope
+	uses  int
+	first int
+	// renamed is the mangled name, or "" if the binding keeps its name.
+	renamed string
+}
+
+func newScope(parent *scope, function bool) *scope {
+	s := &scope{parent: parent, function: function, decls: make(map[string]*binding), end: -1}
+	if parent != nil {
+		parent.children = append(parent.children, s)
+	}
+	return s
+}
+
+func (s *scope) declare(name string) {
+	if _, ok := s.decls[name]; !ok {
+		s.decls[name] = &binding{name: name, scope: s, first: -1}
+	}
+}
+
+// functionScope returns the scope var declarations in s belong to.
+func (s *scope) functionScope() *scope {
+	for s.parent != nil && !s.function {
+		s = s.parent
+	}
+	return s
+}
+
+func (s *scope) lookup(name string) *binding {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.decls[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+type frameKind uint8
+
+const (
+	frameBlock frameKind = iota
+	frameObject
+	frameClass
+	frameParen
+	frameBracket
+	frameTemplate
+)
+
+// frame is an open bracket of the token stream.
+type frame struct {
+	kind frameKind
+	// expectKey is set in object literals and class bodies when the next
+	// token starts a member.
+	expectKey bool
+	// ternary counts the unmatched "?" in the frame.
+	ternary int
+	// caseColon is set between case and its colon.
+	caseColon bool
+	// pop is the number of scopes that end with the frame.
+	pop int
+	// params holds the scope whose parameter list the frame is.
+	params *scope
+	// forScope is a for statement's scope opened with its header.
+	forScope *scope
+	// computedKey marks a bracket frame holding a computed member name.
+	computedKey bool
+	// forHead marks the parenthesized head of a for statement.
+	forHead bool
+	// statement is set for blocks and bodies after which no semicolon is
+	// needed.
+	statement bool
+}
+
+// analysis classifies the identifiers of a token stream and resolves them
+// to bindings.
+type analysis struct {
+	toks   []Token
+	match  []int
+	roles  []role
+	scopes []*scope
+	refs   map[int]*binding
+	free   map[string]bool
+
+	frames []*frame
+	cur    *scope
+	// pendingBody is the scope whose body the next "{" opens.
+	pendingBody *scope
+	// pendingFunc is the scope of a function whose parameter list the next
+	// "(" opens; pendingMethod asks for a new one.
+	pendingFunc   *scope
+	pendingMethod bool
+	// pendingStatement marks the function of pendingMethod as a statement.
+	pendingStatement bool
+	// pendingFor is set between "for" and its "(", pendingCatch between
+	// "catch" and its "(".
+	pendingFor   bool
+	pendingCatch bool
+	// forBody is the scope of a for statement whose body comes next.
+	forBody *scope
+	// statementScopes are for statement scopes whose body is a single
+	// statement; they end at the statement's semicolon.
+	statementScopes []statementScope
+	// classBody holds the frame depth and scope count of the class bodies
+	// expected next.
+	classBody   []classPending
+	staticBlock bool
+	// colons marks ":" tokens of object properties.
+	colons map[int]bool
+	// current is the index of the token being handled.
+	current int
+	// statementEnd marks the "}" tokens that end a statement or class
+	// member, after which a line break is never significant.
+	statementEnd map[int]bool
+	// declarationEnd marks the last token of declarations ended by a line
+	// break, which must be kept even before a token that could otherwise
+	// continue an expression.
+	declarationEnd map[int]bool
+}
+
+type statementScope struct {
+	scope *scope
+	depth int
+}
+
+type classPending struct {
+	depth     int
+	pop       int
+	statement bool
+}
+
+// analyze builds the scope tree of toks and resolves every reference.
+func analyze(toks []Token) (*analysis, error) {
+	match, err := matchBrackets(toks)
+	if err != nil {
+		return nil, err
+	}
+	a := &analysis{
+		toks:   toks,
+		match:  match,
+		roles:  make([]role, len(toks)),
+		refs:   make(map[int]*binding),
+		free:   make(map[string]bool),
+		colons: make(map[int]bool),
+
+		statementEnd:   make(map[int]bool),
+		declarationEnd: make(map[int]bool),
+	}
+	top := newScope(nil, true)
+	a.scopes = append(a.scopes, top)
+	a.cur = top
+	a.frames = []*frame{{kind: frameBlock}}
+
+	scopeOf := make([]*scope, len(toks))
+	for i := range toks {
+		a.step(i)
+		if a.roles[i] == roleRef || a.roles[i] == roleShorthand {
+			scopeOf[i] = a.cur
+		}
+	}
+
+	for i, s := range scopeOf {
+		if s == nil {
+			continue
+		}
+		name := toks[i].Value
+		b := s.lookup(name)
+		if b == nil {
+			a.free[name] = true
+			continue
+		}
+		b.uses++
+		if b.first < 0 {
+			b.first = i
+		}
+		a.refs[i] = b
+	}
+	return a, nil
+}
+
+// Reference is an identifier that refers to a name its code does not
+// declare.
+type Reference struct {
+	// Index is the position of the identifier in the token stream.
+	Index int
+	// Shorthand is set for shorthand properties such as {a}, which name a
+	// property as well.
+	Shorthand bool
+}
+
+// FreeReferences returns the identifiers of toks that do not resolve to a
+// declaration in toks, such as globals and the bindings of import
+// declarations, grouped by name in source order.
+func FreeReferences(toks []Token) (map[string][]Reference, error) {
+	a, err := analyze(append([]Token(nil), toks...))
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string][]Reference)
+	for i, r := range a.roles {
+		if (r == roleRef || r == roleShorthand) && a.refs[i] == nil {
+			refs[toks[i].Value] = append(refs[toks[i].Value], Reference{Index: i, Shorthand: r == roleShorthand})
+		}
+	}
+	return refs, nil
+}
+
+// matchBrackets pairs every bracket, and every template head with its tail.
+func matchBrackets(toks []Token) ([]int, error) {
+	match := make([]int, len(toks))
+	var stack []int
+	for i, t := range toks {
+		match[i] = -1
+		switch {
+		case t.IsPunct("(") || t.IsPunct("[") || t.IsPunct("{") || templatePart(t) == templateHead:
+			stack = append(stack, i)
+		case t.IsPunct(")") || t.IsPunct("]") || t.IsPunct("}") || templatePart(t) == templateTail:
+			if len(stack) == 0 {
+				return nil, &SyntaxError{Line: t.Line, Col: t.Col, Message: fmt.Sprintf("unexpected %q", t.Value)}
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !closes(toks[open], t) {
+				return nil, &SyntaxError{Line: t.Line, Col: t.Col, Message: fmt.Sprintf("unexpected %q", t.Value)}
+			}
+			match[open], match[i] = i, open
+		}
+	}
+	if len(stack) > 0 {
+		t := toks[stack[len(stack)-1]]
+		return nil, &SyntaxError{Line: t.Line, Col: t.Col, Message: fmt.Sprintf("unclosed %q", t.Value)}
+	}
+	return match, nil
+}
+
+func closes(open, close Token) bool {
+	switch open.Value {
+	case "(":
+		return close.IsPunct(")")
+	case "[":
+		return close.IsPunct("]")
+	case "{":
+		return close.IsPunct("}")
+	}
+	return close.Kind == TokenTemplate
+}
+
+type templateKind uint8
+
+const (
+	templateNone templateKind = iota
+	templateFull
+	templateHead
+	templateMiddle
+	templateTail
+)
+
+// templatePart tells the pieces of a template literal apart: the lexer
+// emits one token up to each "${" and one from each closing "}".
+func templatePart(t Token) templateKind {
+	if t.Kind != TokenTemplate {
+		return templateNone
+	}
+	opens := false
+	if v := t.Value; strings.HasSuffix(v, "${") {
+		escapes := len(v) - 2 - len(strings.TrimRight(v[:len(v)-2], "\\"))
+		opens = escapes%2 == 0
+	}
+	switch {
+	case t.Value[0] == '`' && opens:
+		return templateHead
+	case t.Value[0] == '`':
+		return templateFull
+	case opens:
+		return templateMiddle
+	}
+	return templateTail
+}
+
+func (a *analysis) tok(i int) Token {
+	if i < 0 || i >= len(a.toks) {
+		return Token{Kind: TokenEOF}
+	}
+	return a.toks[i]
+}
+
+func (a *analysis) top() *frame {
+	return a.frames[len(a.frames)-1]
+}
+
+func (a *analysis) push(f *frame) {
+	a.frames = append(a.frames, f)
+}
+
+func (a *analysis) enter(function bool) *scope {
+	a.cur = newScope(a.cur, function)
+	return a.cur
+}
+
+func (a *analysis) leave(n int) {
+	for ; n > 0 && a.cur.parent != nil; n-- {
+		a.cur = a.cur.parent
+	}
+}
+
+// step classifies token i and updates the bracket and scope state.
+func (a *analysis) step(i int) {
+	t := a.toks[i]
+	for a.cur.end == i {
+		a.cur.end = -1
+		a.leave(1)
+	}
+	a.endStatementScopes(i)
+
+	prev := a.tok(i - 1)
+	if prev.IsPunct(".") || prev.IsPunct("?.") || prev.IsPunct("#") {
+		if t.Kind == TokenIdentifier || t.Kind == TokenKeyword {
+			a.key(i)
+			if prev.IsPunct("#") {
+				a.memberName(i)
+			}
+			return
+		}
+	}
+
+	f := a.top()
+	if f.kind == frameClass && !f.expectKey && t.NewlineBefore && asiBreak(prev, t) {
+		f.expectKey = true
+	}
+	if (f.kind == frameObject || f.kind == frameClass) && f.expectKey && a.member(i, f) {
+		return
+	}
+
+	switch t.Kind {
+	case TokenPunctuator:
+		a.punct(i, f)
+	case TokenTemplate:
+		switch templatePart(t) {
+		case templateHead:
+			a.push(&frame{kind: frameTemplate})
+		case templateTail:
+			a.close()
+		}
+	case TokenIdentifier, TokenKeyword:
+		a.name(i, f)
+	}
+}
+
+// member handles token i at the start of an object or class member. It
+// reports whether the token was consumed.
+func (a *analysis) member(i int, f *frame) bool {
+	t := a.toks[i]
+	next := a.tok(i + 1)
+	switch {
+	case t.IsPunct("*") || t.IsPunct("#"):
+		return true
+	case t.IsPunct("..."):
+		f.expectKey = false
+		return true
+	case t.IsPunct("["):
+		f.expectKey = false
+		a.push(&frame{kind: frameBracket, computedKey: true})
+		return true
+	case t.IsPunct("{") && a.staticBlock:
+		return false
+	case t.Kind == TokenIdentifier || t.Kind == TokenKeyword:
+	case t.Kind == TokenString || t.Kind == TokenNumber:
+		a.memberName(i)
+		return true
+	default:
+		return false
+	}
+
+	modifier := t.Value == "get" || t.Value == "set" || t.Value == "async" ||
+		f.kind == frameClass && (t.Value == "static" || t.Value == "accessor")
+	if modifier && startsMemberName(next) && !(t.Value == "async" && next.NewlineBefore) {
+		a.key(i)
+		if t.Value == "static" && next.IsPunct("{") {
+			a.staticBlock = true
+		}
+		return true
+	}
+
+	if f.kind == frameObject && t.Kind == TokenIdentifier && (next.IsPunct(",") || next.IsPunct("}") || next.IsPunct("=")) {
+		a.roles[i] = roleShorthand
+		f.expectKey = false
+		return true
+	}
+	a.key(i)
+	a.memberName(i)
+	return true
+}
+
+// key marks the name at i as a property name. Keywords used as names are
+// turned into identifiers, so they are treated as such from then on.
+func (a *analysis) key(i int) {
+	a.roles[i] = roleKey
+	a.toks[i].Kind = TokenIdentifier
+}
+
+// memberName is called after the name of a member at i.
+func (a *analysis) memberName(i int) {
+	f := a.top()
+	if f.kind != frameObject && f.kind != frameClass {
+		return
+	}
+	f.expectKey = false
+	switch next := a.tok(i + 1); {
+	case next.IsPunct("("):
+		a.pendingMethod = true
+		a.pendingStatement = f.kind == frameClass
+	case next.IsPunct(":"):
+		a.colons[i+1] = true
+	}
+}
+
+func startsMemberName(t Token) bool {
+	switch t.Kind {
+	case TokenIdentifier, TokenKeyword, TokenString, TokenNumber:
+		return true
+	}
+	return t.IsPunct("[") || t.IsPunct("*") || t.IsPunct("#") || t.IsPunct("{")
+}
+
+func (a *analysis) punct(i int, f *frame) {
+	t := a.toks[i]
+	switch t.Value {
+	case "{":
+		a.openBrace(i)
+	case "}", ")", "]":
+		a.close()
+	case "(":
+		a.openParen(i)
+	case "[":
+		a.push(&frame{kind: frameBracket})
+	case ",":
+		if f.kind == frameObject {
+			f.expectKey = true
+		}
+	case ";":
+		if f.kind == frameClass {
+			f.expectKey = true
+		}
+		a.endStatementScopesAt(len(a.frames))
+	case "?":
+		f.ternary++
+	case ":":
+		switch {
+		case a.colons[i]:
+		case f.ternary > 0:
+			f.ternary--
+		case f.caseColon:
+			f.caseColon = false
+		}
+	case "=>":
+		if a.tok(i + 1).IsPunct("{") {
+			a.pendingBody = a.cur
+		} else {
+			a.cur.end = a.expressionEnd(i + 1)
+		}
+	}
+}
+
+func (a *analysis) openBrace(i int) {
+	switch {
+	case a.pendingBody != nil:
+		a.push(&frame{kind: frameBlock, pop: 1, statement: a.pendingBody.statement})
+		a.pendingBody = nil
+	case a.forBody != nil:
+		a.forBody = nil
+		a.enter(false)
+		a.push(&frame{kind: frameBlock, pop: 2, statement: true})
+	case len(a.classBody) > 0 && a.classBody[len(a.classBody)-1].depth == len(a.frames):
+		pending := a.classBody[len(a.classBody)-1]
+		a.classBody = a.classBody[:len(a.classBody)-1]
+		a.push(&frame{kind: frameClass, expectKey: true, pop: pending.pop, statement: pending.statement})
+	case a.staticBlock:
+		a.staticBlock = false
+		a.enter(false)
+		a.push(&frame{kind: frameBlock, pop: 1, statement: true})
+	case a.expressionPosition(i):
+		a.push(&frame{kind: frameObject, expectKey: true})
+	default:
+		a.enter(false)
+		a.push(&frame{kind: frameBlock, pop: 1, statement: true})
+	}
+}
+
+func (a *analysis) openParen(i int) {
+	switch {
+	case a.pendingFunc != nil || a.pendingMethod:
+		s := a.pendingFunc
+		if s == nil {
+			s = a.enter(true)
+			s.statement = a.pendingStatement
+		}
+		a.pendingFunc, a.pendingMethod, a.pendingStatement = nil, false, false
+		a.declareParams(i, s)
+		a.push(&frame{kind: frameParen, params: s})
+	case a.tok(a.match[i] + 1).IsPunct("=>"):
+		s := a.enter(true)
+		a.declareParams(i, s)
+		a.push(&frame{kind: frameParen})
+	case a.pendingCatch:
+		a.pendingCatch = false
+		s := a.enter(false)
+		s.statement = true
+		a.declareParams(i, s)
+		a.push(&frame{kind: frameParen, params: s})
+	case a.pendingFor:
+		a.pendingFor = false
+		f := &frame{kind: frameParen, forHead: true}
+		if next := a.tok(i + 1); next.IsName("let") || next.IsName("const") {
+			f.forScope = a.enter(false)
+		}
+		a.push(f)
+	default:
+		a.push(&frame{kind: frameParen})
+	}
+}
+
+// close pops the innermost frame and the scopes ending with it.
+func (a *analysis) close() {
+	if len(a.frames) == 1 {
+		return
+	}
+	f := a.top()
+	a.frames = a.frames[:len(a.frames)-1]
+	a.endStatementScopesAt(len(a.frames) + 1)
+	a.leave(f.pop)
+	if f.statement {
+		a.statementEnd[a.current] = true
+	}
+
+	switch {
+	case f.params != nil:
+		a.pendingBody = f.params
+	case f.forScope != nil:
+		if a.tok(a.current + 1).IsPunct("{") {
+			a.forBody = f.forScope
+		} else {
+			a.statementScopes = append(a.statementScopes, statementScope{scope: f.forScope, depth: len(a.frames)})
+		}
+	case f.computedKey:
+		a.memberName(a.current)
+	}
+	if parent := a.top(); parent.kind == frameClass && f.kind == frameBlock {
+		parent.expectKey = true
+	}
+}
+
+// endStatementScopes ends single-statement for scopes when automatic
+// semicolon insertion ends their statement before token i.
+func (a *analysis) endStatementScopes(i int) {
+	a.current = i
+	if len(a.statementScopes) == 0 || !a.toks[i].NewlineBefore || !asiBreak(a.tok(i-1), a.toks[i]) {
+		return
+	}
+	if a.tok(i-1).IsPunct(")") && a.controlHeader(i-1) {
+		return
+	}
+	a.endStatementScopesAt(len(a.frames))
+}
+
+// controlHeader reports whether the ")" at i closes the head of an if,
+// while, for or with statement.
+func (a *analysis) controlHeader(i int) bool {
+	open := a.match[i]
+	j := open - 1
+	if a.tok(j).IsName("await") {
+		j--
+	}
+	p := a.tok(j)
+	return p.Kind == TokenKeyword && a.roles[j] != roleKey &&
+		(p.Value == "if" || p.Value == "while" || p.Value == "for" || p.Value == "with")
+}
+
+// endStatementScopesAt ends the single-statement for scopes opened at frame
+// depth or deeper.
+func (a *analysis) endStatementScopesAt(depth int) {
+	for n := len(a.statementScopes); n > 0 && a.statementScopes[n-1].depth >= depth; n-- {
+		s := a.statementScopes[n-1].scope
+		a.statementScopes = a.statementScopes[:n-1]
+		for a.cur != s && a.cur.parent != nil {
+			a.leave(1)
+		}
+		a.leave(1)
+	}
+}
+
+func (a *analysis) name(i int, f *frame) {
+	t := a.toks[i]
+	next := a.tok(i + 1)
+
+	switch {
+	case t.IsName("function"):
+		a.function(i)
+	case t.IsName("class"):
+		a.class(i)
+	case t.IsName("var") || t.IsName("const") || t.IsName("let") && (next.Kind == TokenIdentifier || next.IsPunct("[") || next.IsPunct("{")):
+		target := a.cur
+		if t.Value == "var" {
+			target = a.cur.functionScope()
+		}
+		a.declareList(i+1, target)
+	case t.IsName("catch") && next.IsPunct("("):
+		a.pendingCatch = true
+	case t.IsName("for"):
+		a.pendingFor = true
+	case t.IsName("with"):
+		a.markUnsafe()
+	case t.IsName("case"):
+		f.caseColon = true
+	case t.IsName("default") && next.IsPunct(":"):
+		f.caseColon = true
+	case (t.IsName("break") || t.IsName("continue")) && next.Kind == TokenIdentifier && !next.NewlineBefore:
+		a.roles[i+1] = roleKey
+	case t.Kind != TokenIdentifier || a.roles[i] == roleKey:
+	case t.Value == "async" && !next.NewlineBefore && (next.IsName("function") ||
+		next.Kind == TokenIdentifier && a.tok(i+2).IsPunct("=>") ||
+		next.IsPunct("(") && a.tok(a.match[i+1]+1).IsPunct("=>")):
+		a.roles[i] = roleKey
+	case t.Value == "of" && f.forHead && i > 0 && a.bindingEnd(i-1):
+		a.roles[i] = roleKey
+	case next.IsPunct(":") && !a.colons[i+1] && f.kind != frameObject && f.ternary == 0 && !f.caseColon:
+		// A label.
+		a.roles[i] = roleKey
+	case next.IsPunct("=>"):
+		s := a.enter(true)
+		s.declare(t.Value)
+		a.roles[i] = roleRef
+	default:
+		if t.Value == "eval" && next.IsPunct("(") {
+			a.markUnsafe()
+		}
+		a.roles[i] = roleRef
+	}
+}
+
+// bindingEnd reports whether a binding or assignment target may end at i.
+func (a *analysis) bindingEnd(i int) bool {
+	t := a.toks[i]
+	return t.Kind == TokenIdentifier || t.IsPunct("]") || t.IsPunct("}") || t.IsName("let") || t.IsName("static") || t.IsName("yield") || t.IsName("await")
+}
+
+// function handles the function keyword at i. Declarations bind their name
+// in the enclosing function scope; expressions bind it in their own scope.
+func (a *analysis) function(i int) {
+	j := i + 1
+	if a.tok(j).IsPunct("*") {
+		j++
+	}
+	start := i
+	if a.tok(i - 1).IsName("async") {
+		start = i - 1
+	}
+	expression := a.expressionPosition(start) || a.tok(start-1).IsPunct("=>")
+	name := a.tok(j)
+	hasName := name.Kind == TokenIdentifier || name.IsName("yield") || name.IsName("await") || name.IsName("let") || name.IsName("static")
+
+	if !expression {
+		if hasName {
+			a.cur.functionScope().declare(name.Value)
+			a.roles[j] = roleRef
+		}
+		a.pendingMethod, a.pendingStatement = true, true
+		return
+	}
+	s := a.enter(true)
+	if hasName {
+		s.declare(name.Value)
+	}
+	a.pendingFunc = s
+}
+
+// class handles the class keyword at i.
+func (a *analysis) class(i int) {
+	name := a.tok(i + 1)
+	hasName := name.Kind == TokenIdentifier
+	pending := classPending{depth: len(a.frames)}
+	switch {
+	case !a.expressionPosition(i) && !a.tok(i-1).IsPunct("=>"):
+		pending.statement = true
+		if hasName {
+			a.cur.declare(name.Value)
+		}
+	case hasName:
+		a.enter(false).declare(name.Value)
+		pending.pop = 1
+	}
+	a.classBody = append(a.classBody, pending)
+}
+
+func (a *analysis) markUnsafe() {
+	for s := a.cur; s != nil; s = s.parent {
+		s.unsafe = true
+	}
+}
+
+// expressionPosition reports whether an expression, rather than a
+// statement, starts at token i: whether "{" there opens an object literal
+// and whether function and class are expressions.
+func (a *analysis) expressionPosition(i int) bool {
+	p := a.tok(i - 1)
+	switch p.Kind {
+	case TokenEOF, TokenNumber, TokenString, TokenRegExp:
+		return false
+	case TokenTemplate:
+		part := templatePart(p)
+		return part == templateHead || part == templateMiddle
+	case TokenIdentifier:
+		return p.Value == "of"
+	case TokenKeyword:
+		switch p.Value {
+		case "return", "typeof", "void", "delete", "in", "instanceof", "new", "yield",
+			"await", "case", "throw", "extends", "var", "let", "const", "default":
+			return true
+		}
+		return false
+	}
+	switch p.Value {
+	case ")", "]", "}", ";", "++", "--", "=>":
+		return false
+	case "{":
+		return false
+	case ":":
+		return a.colons[i-1] || a.colonIsTernary(i-1)
+	}
+	return true
+}
+
+// colonIsTernary reports whether the ":" at i closed a conditional
+// expression, by looking for an unmatched "?" before it.
+func (a *analysis) colonIsTernary(i int) bool {
+	depth, pending := 0, 0
+	for j := i - 1; j >= 0; j-- {
+		t := a.toks[j]
+		switch {
+		case t.IsPunct(")") || t.IsPunct("]") || t.IsPunct("}") || templatePart(t) == templateTail:
+			j = a.match[j]
+		case t.IsPunct("(") || t.IsPunct("[") || t.IsPunct("{") || templatePart(t) == templateHead || templatePart(t) == templateMiddle || t.IsPunct(";"):
+			return false
+		case t.IsPunct(":") && !a.colons[j]:
+			pending++
+		case t.IsPunct("?"):
+			if pending == 0 {
+				return true
+			}
+			pending--
+		case t.IsName("case") || t.IsName("default"):
+			if depth == 0 && pending == 0 {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// expressionEnd returns the index of the token ending an arrow function's
+// expression body that starts at i.
+func (a *analysis) expressionEnd(i int) int {
+	ternary := 0
+	for j := i; j < len(a.toks); j++ {
+		t := a.toks[j]
+		if j > i && t.NewlineBefore && asiBreak(a.toks[j-1], t) {
+			return j
+		}
+		switch {
+		case t.IsPunct("(") || t.IsPunct("[") || t.IsPunct("{") || templatePart(t) == templateHead:
+			j = a.match[j]
+		case t.IsPunct(")") || t.IsPunct("]") || t.IsPunct("}") || t.IsPunct(",") || t.IsPunct(";") ||
+			templatePart(t) == templateMiddle || templatePart(t) == templateTail:
+			return j
+		case t.IsPunct("?"):
+			ternary++
+		case t.IsPunct(":"):
+			if ternary == 0 {
+				return j
+			}
+			ternary--
+		}
+	}
+	return len(a.toks)
+}
+
+// declareList declares the bindings of a var, let or const declaration
+// whose first declarator starts at i.
+func (a *analysis) declareList(i int, s *scope) {
+	for i < len(a.toks) {
+		i = a.declarePattern(i, s)
+		if next := a.tok(i); next.NewlineBefore && !next.IsPunct("=") && !next.IsPunct(",") {
+			a.declarationEnd[i-1] = true
+		}
+		if a.tok(i).IsPunct("=") {
+			i = a.skipExpression(i+1, true)
+		}
+		if !a.tok(i).IsPunct(",") {
+			return
+		}
+		i++
+	}
+}
+
+// declareParams declares the parameters in the list opened at i.
+func (a *analysis) declareParams(open int, s *scope) {
+	end := a.match[open]
+	for i := open + 1; i < end; {
+		switch t := a.toks[i]; {
+		case t.IsPunct(","):
+			i++
+			continue
+		case t.IsPunct("..."):
+			i = a.declarePattern(i+1, s)
+		default:
+			i = a.declarePattern(i, s)
+		}
+		if a.tok(i).IsPunct("=") {
+			i = a.skipExpression(i+1, false)
+		}
+		if i < end && !a.tok(i).IsPunct(",") {
+			i++
+		}
+	}
+}
+
+// declarePattern declares the names bound by the binding pattern at i and
+// returns the index after it.
+func (a *analysis) declarePattern(i int, s *scope) int {
+	t := a.tok(i)
+	switch {
+	case t.Kind == TokenIdentifier || t.IsName("yield") || t.IsName("await") || t.IsName("let") || t.IsName("static"):
+		s.declare(t.Value)
+		return i + 1
+	case t.IsPunct("["):
+		end := a.match[i]
+		for j := i + 1; j < end; {
+			switch e := a.toks[j]; {
+			case e.IsPunct(","):
+				j++
+				continue
+			case e.IsPunct("..."):
+				j = a.declarePattern(j+1, s)
+			default:
+				j = a.declarePattern(j, s)
+			}
+			if a.tok(j).IsPunct("=") {
+				j = a.skipExpression(j+1, false)
+			}
+			if j < end && !a.tok(j).IsPunct(",") {
+				j++
+			}
+		}
+		return end + 1
+	case t.IsPunct("{"):
+		end := a.match[i]
+		for j := i + 1; j < end; {
+			e := a.toks[j]
+			switch {
+			case e.IsPunct(","):
+				j++
+				continue
+			case e.IsPunct("..."):
+				j = a.declarePattern(j+1, s)
+			case e.IsPunct("[") && a.tok(a.match[j]+1).IsPunct(":"):
+				j = a.declarePattern(a.match[j]+2, s)
+			case a.tok(j + 1).IsPunct(":"):
+				j = a.declarePattern(j+2, s)
+			default:
+				j = a.declarePattern(j, s)
+			}
+			if a.tok(j).IsPunct("=") {
+				j = a.skipExpression(j+1, false)
+			}
+			if j < end && !a.tok(j).IsPunct(",") {
+				j++
+			}
+		}
+		return end + 1
+	}
+	return i + 1
+}
+
+// skipExpression returns the index of the "," or closing bracket ending the
+// expression at i. Statement-level expressions also end at semicolons and
+// where a line break ends the statement.
+func (a *analysis) skipExpression(i int, statement bool) int {
+	for j := i; j < len(a.toks); j++ {
+		t := a.toks[j]
+		if statement && j > i && t.NewlineBefore && asiBreak(a.toks[j-1], t) {
+			return j
+		}
+		switch {
+		case t.IsPunct("(") || t.IsPunct("[") || t.IsPunct("{") || templatePart(t) == templateHead:
+			j = a.match[j]
+		case t.IsPunct(",") || t.IsPunct(";") || t.IsPunct(")") || t.IsPunct("]") || t.IsPunct("}"):
+			return j
+		}
+	}
+	return len(a.toks)
+}
+
+// canEnd reports whether a statement may end with t, so a line break after
+// it could be a statement boundary.
+func canEnd(t Token) bool {
+	switch t.Kind {
+	case TokenIdentifier, TokenNumber, TokenString, TokenRegExp:
+		return true
+	case TokenTemplate:
+		part := templatePart(t)
+		return part == templateFull || part == templateTail
+	case TokenKeyword:
+		switch t.Value {
+		case "this", "super", "null", "true", "false", "return", "break", "continue",
+			"debugger", "yield", "await", "let", "static", "throw":
+			return true
+		}
+		return false
+	}
+	switch t.Value {
+	case ")", "]", "}", "++", "--":
+		return true
+	}
+	return false
+}
+
+// continues reports whether t continues the expression before it whenever
+// that is possible, so no semicolon is inserted before it.
+func continues(t Token) bool {
+	switch t.Kind {
+	case TokenPunctuator:
+		switch t.Value {
+		case "{", "++", "--", "!", "~", "#", "@", "...":
+			return false
+		}
+		return true
+	case TokenTemplate:
+		return true
+	case TokenKeyword:
+		return t.Value == "in" || t.Value == "instanceof"
+	}
+	return false
+}
+
+// restricted keywords end their statement at a line break.
+func restricted(t Token) bool {
+	switch t.Value {
+	case "return", "break", "continue", "throw", "yield", "await", "async", "get", "set", "static":
+		return t.Kind == TokenKeyword || t.Kind == TokenIdentifier
+	}
+	return false
+}
+
+// asiBreak reports whether a line break between prev and next may end a
+// statement.
+func asiBreak(prev, next Token) bool {
+	return canEnd(prev) && (restricted(prev) || !continues(next))
+}
+
+// mangle assigns short names to the bindings that can be renamed. Bindings
+// of unsafe scopes, of the top-level scope unless topLevel is set, and
+// those named in keep are left alone. Names are handed out by nesting
+// depth, so sibling scopes reuse them and no binding is shadowed by a new
+// name.
+func (a *analysis) mangle(topLevel bool, keep map[string]bool) {
+	avoid := make(map[string]bool, len(a.free)+len(keep))
+	for name := range a.free {
+		avoid[name] = true
+	}
+	for name := range keep {
+		avoid[name] = true
+	}
+	renamable := func(s *scope, b *binding) bool {
+		return !s.unsafe && (s.parent != nil || topLevel && !keep[b.name])
+	}
+	var walk func(s *scope)
+	walk = func(s *scope) {
+		for _, b := range s.decls {
+			if !renamable(s, b) {
+				avoid[b.name] = true
+			}
+		}
+		for _, child := range s.children {
+			walk(child)
+		}
+	}
+	walk(a.scopes[0])
+
+	gen := &nameGenerator{avoid: avoid}
+	var assign func(s *scope, slot int)
+	assign = func(s *scope, slot int) {
+		bindings := make([]*binding, 0, len(s.decls))
+		for _, b := range s.decls {
+			if renamable(s, b) && b.uses > 0 {
+				bindings = append(bindings, b)
+			}
+		}
+		sort.Slice(bindings, func(i, j int) bool {
+			if bindings[i].uses != bindings[j].uses {
+				return bindings[i].uses > bindings[j].uses
+			}
+			return bindings[i].first < bindings[j].first
+		})
+		for _, b := range bindings {
+			b.renamed = gen.name(slot)
+			slot++
+		}
+		for _, child := range s.children {
+			assign(child, slot)
+		}
+	}
+	assign(a.scopes[0], 0)
+}
+
+// reservedNames may not be used as mangled names.
+var reservedNames = map[string]bool{
+	"enum": true, "implements": true, "interface": true, "package": true,
+	"private": true, "protected": true, "public": true, "arguments": true,
+	"eval": true, "undefined": true, "NaN": true, "Infinity": true,
+	"async": true, "of": true, "get": true, "set": true,
+}
+
+const (
+	nameStart = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$"
+	namePart  = nameStart + "0123456789"
+)
+
+// nameGenerator maps slot numbers to the shortest available names.
+type nameGenerator struct {
+	avoid map[string]bool
+	names []string
+	next  int
+}
+
+func (g *nameGenerator) name(slot int) string {
+	for len(g.names) <= slot {
+		for {
+			name := encodeName(g.next)
+			g.next++
+			if !g.avoid[name] && !keywords[name] && !reservedNames[name] {
+				g.names = append(g.names, name)
+				break
+			}
+		}
+	}
+	return g.names[slot]
+}
+
+func encodeName(n int) string {
+	b := []byte{nameStart[n%len(nameStart)]}
+	n /= len(nameStart)
+	for n > 0 {
+		n--
+		b = append(b, namePart[n%len(namePart)])
+		n /= len(namePart)
+	}
+	return string(b)
+}
@@ -0,0 +1,334 @@
+package js
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TransformOptions selects the syntax Transform compiles to JavaScript.
+type TransformOptions struct {
+	// TypeScript strips types and type-only declarations and compiles enums
+	// and parameter properties.
+	TypeScript bool
+	// JSX compiles elements to React.createElement calls. With TypeScript
+	// it also rules out "<T>value" type assertions.
+	JSX bool
+}
+
+// Transform compiles the TypeScript and JSX in src to JavaScript. Every line
+// of src stays on the same line of the output, so line numbers, and source
+// maps built from them, still point at the original. Imports only used as
+// types are dropped like the TypeScript compiler does, and a module using
+// JSX without a React binding of its own gets one from require("react").
+// Syntax with no JavaScript equivalent, such as namespaces and decorators,
+// is reported as an error.
+func Transform(src string, opts TransformOptions) (string, error) {
+	return transform(src, opts, false)
+}
+
+// transformer records the edits that compile one source.
+type transformer struct {
+	src     string
+	opts    TransformOptions
+	toks    []Token
+	match   []int
+	pos     int
+	edits   []textEdit
+	imports []importDecl
+	err     error
+}
+
+// textEdit replaces src[start:end] with text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// importDecl is an import declaration whose bindings may only be used as
+// types. first and last are its token indices and from that of its module
+// specifier; types is set if it imports type-only specifiers.
+type importDecl struct {
+	first, last, from int
+	names             []importName
+	types             bool
+}
+
+// importName is a default, namespace or named import. text is how it is
+// written in the import clause.
+type importName struct {
+	local string
+	text  string
+	kind  importKind
+}
+
+type importKind uint8
+
+const (
+	importDefault importKind = iota
+	importNamespace
+	importNamed
+)
+
+// transform is Transform; nested is set for the code of JSX expression
+// containers, which is compiled on its own.
+func transform(src string, opts TransformOptions, nested bool) (string, error) {
+	l := &lexer{src: src, jsx: opts.JSX, typescript: opts.TypeScript, elements: make(map[int]*jsxElement)}
+	toks, err := l.run()
+	if err != nil {
+		return "", err
+	}
+	if !opts.TypeScript && len(l.elements) == 0 {
+		return src, nil
+	}
+
+	t := &transformer{src: src, opts: opts, toks: toks}
+	jsx := false
+	for _, tok := range toks {
+		if tok.Kind == TokenJSX {
+			code, err := t.jsx(l.elements[tok.Start])
+			if err != nil {
+				return "", err
+			}
+			t.replace(tok.Start, tok.End, code)
+			jsx = true
+		}
+	}
+	if opts.TypeScript {
+		if t.match, err = matchBrackets(toks); err != nil {
+			return "", err
+		}
+		for t.peek().Kind != TokenEOF {
+			t.statement()
+		}
+		if t.err != nil {
+			return "", t.err
+		}
+		if !nested {
+			t.elideImports()
+		}
+	}
+
+	code := t.render(0, len(src), t.edits)
+	if jsx && !nested {
+		if toks, err := Tokenize(code); err == nil {
+			if refs, err := FreeReferences(toks); err == nil && len(refs["React"]) > 0 && !importsName(toks, "React") {
+				code = `var React = require("react");` + code
+			}
+		}
+	}
+	return code, nil
+}
+
+// elideImports drops the imports that are only used as types, which
+// stripping types has left unreferenced.
+func (t *transformer) elideImports() {
+	if len(t.imports) == 0 {
+		return
+	}
+	blanked := append([]textEdit(nil), t.edits...)
+	for _, d := range t.imports {
+		blanked = append(blanked, textEdit{start: t.toks[d.first].Start, end: t.toks[d.last].End})
+	}
+	toks, err := Tokenize(t.render(0, len(t.src), blanked))
+	if err != nil {
+		return
+	}
+	refs, err := FreeReferences(toks)
+	if err != nil {
+		return
+	}
+
+	for _, d := range t.imports {
+		var kept []importName
+		for _, name := range d.names {
+			if len(refs[name.local]) > 0 {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) == len(d.names) && !d.types {
+			continue
+		}
+		if len(kept) == 0 {
+			t.removeStatement(d.first, d.last+1)
+			continue
+		}
+		var clause, named []string
+		for _, name := range kept {
+			if name.kind == importNamed {
+				named = append(named, name.text)
+			} else {
+				clause = append(clause, name.text)
+			}
+		}
+		if len(named) > 0 {
+			clause = append(clause, "{ "+strings.Join(named, ", ")+" }")
+		}
+		start, end := t.toks[d.first].Start, t.toks[d.last].End
+		t.replace(start, end, "import "+strings.Join(clause, ", ")+" from "+t.src[t.toks[d.from].Start:end])
+	}
+}
+
+// importsName reports whether an import declaration in toks binds name.
+func importsName(toks []Token, name string) bool {
+	for i, tok := range toks {
+		if !tok.IsName("import") || i > 0 && toks[i-1].IsPunct(".") {
+			continue
+		}
+		for j := i + 1; j < len(toks) && !toks[j].IsName("from") && !toks[j].IsPunct(";") && toks[j].Kind != TokenString; j++ {
+			if toks[j].Is(TokenIdentifier, name) && !toks[j+1].IsName("as") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *transformer) replace(start, end int, text string) {
+	t.edits = append(t.edits, textEdit{start: start, end: end, text: text})
+}
+
+// remove deletes the tokens from..to-1.
+func (t *transformer) remove(from, to int) {
+	if to > from {
+		t.replace(t.toks[from].Start, t.toks[to-1].End, "")
+	}
+}
+
+// removeStatement deletes the declaration in tokens from..to-1. A semicolon
+// takes its place where the statements around it would otherwise run
+// together.
+func (t *transformer) removeStatement(from, to int) {
+	if to <= from {
+		return
+	}
+	text := ""
+	if prev := t.tok(from - 1); from > 0 && !prev.IsPunct(";") && !prev.IsPunct("{") && continuesStatement(t.tok(to)) {
+		text = ";"
+	}
+	t.replace(t.toks[from].Start, t.toks[to-1].End, text)
+}
+
+// continuesStatement reports whether tok, at the start of a line, would
+// continue the statement on the line before.
+func continuesStatement(tok Token) bool {
+	switch tok.Kind {
+	case TokenTemplate, TokenRegExp:
+		return true
+	case TokenPunctuator:
+		switch tok.Value {
+		case "(", "[", "+", "-", "/":
+			return true
+		}
+	}
+	return false
+}
+
+// render applies the edits that lie within src[start:end] and returns the
+// result. Edits inside a larger one are dropped, insertions go before other
+// edits at the same offset, and every edit keeps the line breaks of the text
+// it replaces.
+func (t *transformer) render(start, end int, edits []textEdit) string {
+	var within []textEdit
+	for _, e := range edits {
+		if e.start >= start && e.end <= end {
+			within = append(within, e)
+		}
+	}
+	sort.SliceStable(within, func(i, j int) bool {
+		a, b := within[i], within[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		if (a.start == a.end) != (b.start == b.end) {
+			return a.start == a.end
+		}
+		return a.end > b.end
+	})
+
+	var b strings.Builder
+	pos := start
+	for _, e := range within {
+		if e.start < pos {
+			continue
+		}
+		b.WriteString(t.src[pos:e.start])
+		text := e.text
+		if text == "" && e.start > 0 && e.end < len(t.src) && isIdentPart(t.src[e.start-1]) && isIdentPart(t.src[e.end]) {
+			text = " "
+		}
+		b.WriteString(text)
+		for n := strings.Count(t.src[e.start:e.end], "\n") - strings.Count(text, "\n"); n > 0; n-- {
+			b.WriteByte('\n')
+		}
+		pos = e.end
+	}
+	b.WriteString(t.src[pos:end])
+	return b.String()
+}
+
+func (t *transformer) tok(i int) Token {
+	if i < 0 || i >= len(t.toks) {
+		return Token{Kind: TokenEOF}
+	}
+	return t.toks[i]
+}
+
+func (t *transformer) peek() Token {
+	return t.tok(t.pos)
+}
+
+func (t *transformer) next() Token {
+	tok := t.peek()
+	if t.pos < len(t.toks) {
+		t.pos++
+	}
+	return tok
+}
+
+// eat consumes the punctuator p if it comes next.
+func (t *transformer) eat(p string) bool {
+	if t.peek().IsPunct(p) {
+		t.pos++
+		return true
+	}
+	return false
+}
+
+func (t *transformer) expect(p string) {
+	if !t.eat(p) {
+		t.unexpected()
+	}
+}
+
+// more reports whether a list continues before the punctuator closing it.
+func (t *transformer) more(closing string) bool {
+	tok := t.peek()
+	return tok.Kind != TokenEOF && !tok.IsPunct(closing)
+}
+
+func (t *transformer) unexpected() {
+	tok := t.peek()
+	if tok.Kind == TokenEOF {
+		if len(t.toks) > 0 {
+			tok = t.toks[len(t.toks)-1]
+		}
+		t.fail(tok, "unexpected end of input")
+		return
+	}
+	t.fail(tok, "unexpected %q", tok.Value)
+}
+
+// fail records the first error and skips to the end of the input, which
+// ends every loop of the parser.
+func (t *transformer) fail(tok Token, format string, args ...interface{}) {
+	if t.err == nil {
+		t.err = &SyntaxError{Line: tok.Line, Col: tok.Col, Message: fmt.Sprintf(format, args...)}
+	}
+	t.pos = len(t.toks)
+}
+
+func (t *transformer) errorAt(offset int, format string, args ...interface{}) error {
+	line, col := position(t.src, offset)
+	return &SyntaxError{Line: line, Col: col, Message: fmt.Sprintf(format, args...)}
+}
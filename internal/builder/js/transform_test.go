@@ -0,0 +1,77 @@
+package js
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	ts := TransformOptions{TypeScript: true}
+	tsx := TransformOptions{TypeScript: true, JSX: true}
+	tests := []struct {
+		name string
+		in   string
+		opts TransformOptions
+		want string
+	}{
+		{name: "annotations", in: "function f(a: number, b?: string): void { let c: T<U> = a as any; return c! }", opts: ts, want: "function f(a, b) { let c = a ; return c }"},
+		{name: "type declarations", in: "type A = B | C\ninterface D extends E { f(): void }\nlet x = 1", opts: ts, want: "\n\nlet x = 1"},
+		{name: "statement boundary", in: "let a = b\ntype T = number\n[1].map(f)", opts: ts, want: "let a = b\n;\n[1].map(f)"},
+		{name: "type arguments", in: "f<T>(x); new Map<string, number>(); a < b && c > d", opts: ts, want: "f(x); new Map(); a < b && c > d"},
+		{name: "generic arrow", in: "const id = <T,>(x: T): T => x", opts: tsx, want: "const id = (x) => x"},
+		{name: "enum", in: "enum E { A, B = 'b', C = 4, D }", opts: ts, want: `var E; (function (E) { E[E["A"] = 0] = "A"; E["B"] = 'b'; E[E["C"] = 4] = "C"; E[E["D"] = 5] = "D"; })(E || (E = {}));`},
+		{name: "enum references", in: "const enum F { A = 1, B = A << 1 }", opts: ts, want: `var F; (function (F) { F[F["A"] = 1] = "A"; F[F["B"] = F.A << 1] = "B"; })(F || (F = {}));`},
+		{name: "class", in: "abstract class A<T> extends B<T> implements C { private x: number = 1; declare y: T; abstract m(): void; get z(): T { return this.y } }", opts: ts, want: " class A extends B  {  x = 1;   get z() { return this.y } }"},
+		{name: "parameter properties", in: "class P extends Q { constructor(private a: string, b: number) { super(b); run() } }", opts: ts, want: "class P extends Q { constructor( a, b) { super(b); this.a = a; run() } }"},
+		{name: "overloads", in: "function o(a: string): void;\nfunction o(a: any) {}", opts: ts, want: "\nfunction o(a) {}"},
+		{name: "type imports", in: "import type { A } from './a';\nimport { b, type C, D } from './b';\nimport E from './e';\nexport { type F, b };\nlet x: D = b(E)", opts: ts, want: "\nimport { b } from './b';\nimport E from './e';\nexport {  b };\nlet x = b(E)"},
+		{name: "side effect import", in: "import './style.css'", opts: ts, want: "import './style.css'"},
+		{name: "export assignment", in: "import x = require('x');\nexport = x;", opts: ts, want: "var x = require('x');\nmodule.exports = x;"},
+		{name: "elements", in: `import React from 'react'; let a = <div className="x" {...p}>Hi {name} &amp; <b>you</b></div>`, opts: tsx, want: `import React from 'react'; let a = React.createElement("div", {className: "x", ...p}, "Hi ", name, " & ", React.createElement("b", null, "you"))`},
+		{name: "components", in: "x = <><Foo.Bar on /><my-el /></>", opts: TransformOptions{JSX: true}, want: `var React = require("react");x = React.createElement(React.Fragment, null, React.createElement(Foo.Bar, {on: true}), React.createElement("my-el", null))`},
+		{name: "nested expressions", in: "y = <ul>{items.map(i => <li key={i}>{i}</li>)}</ul>", opts: TransformOptions{JSX: true}, want: `var React = require("react");y = React.createElement("ul", null, items.map(i => React.createElement("li", {key: i}, i)))`},
+		{name: "comparison", in: "x = a < b; y = c <d> e", opts: TransformOptions{}, want: "x = a < b; y = c <d> e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Transform(tt.in, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Transform(%q)\n got: %s\nwant: %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformLines(t *testing.T) {
+	src := "import React from 'react';\ninterface Props {\n  name: string\n}\nexport const App = ({ name }: Props) => (\n  <div>\n    <p>{name}</p>\n  </div>\n);\nlater();\n"
+	got, err := Transform(src, TransformOptions{TypeScript: true, JSX: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != strings.Count(src, "\n")+1 || lines[9] != "later();" {
+		t.Errorf("Lines moved:\n%s", got)
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "namespace N { export const a = 1 }", want: "1:1: TypeScript namespaces are not supported"},
+		{in: "class A {\n  @dec m() {}\n}", want: "2:3: decorators are not supported"},
+		{in: "let a = <div>\n  <p>{x}</b>\n</div>", want: "2:"},
+		{in: "let a = <p>{\n  1 +\n}</p>", want: "3:"},
+	}
+	for _, tt := range tests {
+		_, err := Transform(tt.in, TransformOptions{TypeScript: true, JSX: true})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Transform(%q) = %v, want error starting with %q", tt.in, err, tt.want)
+		}
+	}
+}
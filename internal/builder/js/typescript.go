@@ -0,0 +1,1537 @@
+package js
+
+import "strings"
+
+// The TypeScript transform walks the token stream with a recursive descent
+// parser that knows just enough JavaScript to tell where types are written.
+// It never builds a syntax tree: types are skipped and recorded as edits
+// that delete them, and the few constructs with runtime semantics, enums
+// and parameter properties, are rewritten in place.
+
+// classModifiers can precede a class member; tsModifiers are the ones only
+// TypeScript knows, which are deleted.
+var (
+	classModifiers = map[string]bool{
+		"public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+		"override": true, "declare": true, "static": true, "accessor": true, "async": true,
+		"get": true, "set": true,
+	}
+	tsModifiers = map[string]bool{
+		"public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+		"override": true, "declare": true,
+	}
+)
+
+var binaryOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "**": true,
+	"==": true, "!=": true, "===": true, "!==": true, "<": true, ">": true, "<=": true, ">=": true,
+	"<<": true, ">>": true, ">>>": true, "&": true, "|": true, "^": true, "&&": true, "||": true, "??": true,
+}
+
+// typePunctuators are the punctuators that may appear in type arguments
+// besides brackets.
+var typePunctuators = map[string]bool{
+	".": true, ",": true, "|": true, "&": true, "?": true, ":": true, "=>": true, "-": true, "...": true,
+}
+
+func (t *transformer) statement() {
+	start := t.pos
+	tok, next := t.peek(), t.tok(t.pos+1)
+	switch {
+	case tok.IsPunct("{"):
+		t.block()
+	case tok.IsPunct(";"):
+		t.next()
+	case tok.IsPunct("@"):
+		t.fail(tok, "decorators are not supported")
+	case tok.IsName("import") && !next.IsPunct("(") && !next.IsPunct("."):
+		t.importDeclaration()
+	case tok.IsName("export"):
+		t.exportDeclaration()
+	case tok.IsName("const") && next.IsName("enum"):
+		t.enum()
+	case t.declarationKeywords() > 0:
+		t.variables(false)
+		t.eat(";")
+	case t.startsFunction():
+		t.function(start, false)
+	case tok.IsName("class"):
+		t.class()
+	case tok.IsName("if"):
+		t.next()
+		t.parenthesized()
+		t.statement()
+		if t.peek().IsName("else") {
+			t.next()
+			t.statement()
+		}
+	case tok.IsName("for"):
+		t.next()
+		if t.peek().IsName("await") {
+			t.next()
+		}
+		t.expect("(")
+		t.forHead()
+		t.expect(")")
+		t.statement()
+	case tok.IsName("while") || tok.IsName("with"):
+		t.next()
+		t.parenthesized()
+		t.statement()
+	case tok.IsName("do"):
+		t.next()
+		t.statement()
+		if !t.peek().IsName("while") {
+			t.unexpected()
+			return
+		}
+		t.next()
+		t.parenthesized()
+		t.eat(";")
+	case tok.IsName("switch"):
+		t.next()
+		t.parenthesized()
+		t.expect("{")
+		for t.more("}") {
+			switch {
+			case t.peek().IsName("case"):
+				t.next()
+				t.expression(false)
+				t.expect(":")
+			case t.peek().IsName("default"):
+				t.next()
+				t.expect(":")
+			default:
+				t.statement()
+			}
+		}
+		t.expect("}")
+	case tok.IsName("try"):
+		t.next()
+		t.block()
+		if t.peek().IsName("catch") {
+			t.next()
+			if t.eat("(") {
+				t.binding()
+				t.annotation()
+				t.expect(")")
+			}
+			t.block()
+		}
+		if t.peek().IsName("finally") {
+			t.next()
+			t.block()
+		}
+	case tok.IsName("return") || tok.IsName("throw"):
+		t.next()
+		if n := t.peek(); !n.NewlineBefore && n.Kind != TokenEOF && !n.IsPunct(";") && !n.IsPunct("}") {
+			t.expression(false)
+		}
+		t.eat(";")
+	case tok.IsName("break") || tok.IsName("continue"):
+		t.next()
+		if n := t.peek(); n.Kind == TokenIdentifier && !n.NewlineBefore {
+			t.next()
+		}
+		t.eat(";")
+	case tok.Kind == TokenIdentifier && next.IsPunct(":"):
+		// A label.
+		t.next()
+		t.next()
+		t.statement()
+	default:
+		if !t.typeDeclaration(start) {
+			t.expression(false)
+			t.eat(";")
+		}
+	}
+}
+
+func (t *transformer) block() {
+	t.expect("{")
+	for t.more("}") {
+		t.statement()
+	}
+	t.expect("}")
+}
+
+func (t *transformer) parenthesized() {
+	t.expect("(")
+	t.expression(false)
+	t.expect(")")
+}
+
+// typeDeclaration handles the statements that only TypeScript has, whose
+// first token is an identifier, and reports whether it found one. The
+// statement starts at token start, which is an export keyword before the
+// current token if there is one.
+func (t *transformer) typeDeclaration(start int) bool {
+	tok, next := t.peek(), t.tok(t.pos+1)
+	if tok.Kind != TokenIdentifier || next.NewlineBefore {
+		return false
+	}
+	switch {
+	case tok.Value == "type" && next.Kind == TokenIdentifier:
+		t.next()
+		t.next()
+		t.typeParameters()
+		t.expect("=")
+		t.requireType()
+		t.eat(";")
+		t.removeStatement(start, t.pos)
+	case tok.Value == "interface" && next.Kind == TokenIdentifier:
+		t.interfaceDeclaration()
+		t.removeStatement(start, t.pos)
+	case tok.Value == "declare" && (next.Kind == TokenIdentifier || next.Kind == TokenKeyword):
+		t.next()
+		if n := t.peek(); n.IsName("module") || n.IsName("namespace") || n.IsName("global") {
+			t.next()
+			for n := t.peek(); n.Kind == TokenIdentifier || n.Kind == TokenString || n.IsPunct("."); n = t.peek() {
+				t.next()
+			}
+			if t.peek().IsPunct("{") {
+				t.skipBrackets()
+			} else {
+				t.eat(";")
+			}
+		} else {
+			t.statement()
+		}
+		t.removeStatement(start, t.pos)
+	case tok.Value == "abstract" && next.IsName("class"):
+		t.remove(t.pos, t.pos+1)
+		t.next()
+		t.class()
+	case tok.Value == "enum" && next.Kind == TokenIdentifier:
+		t.enum()
+	case (tok.Value == "namespace" || tok.Value == "module") && (next.Kind == TokenIdentifier || next.Kind == TokenString):
+		t.fail(tok, "TypeScript namespaces are not supported; use ES modules instead")
+	default:
+		return false
+	}
+	return true
+}
+
+func (t *transformer) interfaceDeclaration() {
+	t.next()
+	t.next()
+	t.typeParameters()
+	if t.peek().IsName("extends") {
+		t.next()
+		t.typeList()
+	}
+	if !t.peek().IsPunct("{") {
+		t.unexpected()
+		return
+	}
+	t.skipBrackets()
+}
+
+// enum compiles an enum declaration to the object TypeScript builds for it:
+//
+//	var E; (function (E) { E[E["A"] = 0] = "A"; })(E || (E = {}));
+//
+// Members initialized with strings have no reverse mapping. Const enums are
+// compiled the same way, since other modules cannot inline their values.
+func (t *transformer) enum() {
+	from := t.pos
+	if t.peek().IsName("const") {
+		t.next()
+	}
+	t.next()
+	nameTok := t.next()
+	if nameTok.Kind != TokenIdentifier {
+		t.fail(nameTok, "expected an enum name")
+		return
+	}
+	name := nameTok.Value
+
+	type member struct {
+		key        string
+		start      int
+		init, stop int
+	}
+	var members []member
+	t.expect("{")
+	for t.more("}") {
+		key := t.next()
+		m := member{key: key.Value, start: key.Start}
+		switch key.Kind {
+		case TokenIdentifier, TokenKeyword:
+		case TokenString:
+			m.key = Unquote(key.Value)
+		default:
+			t.fail(key, "unexpected %q in enum %s", key.Value, name)
+			return
+		}
+		if t.eat("=") {
+			m.init = t.pos
+			t.assignment(false)
+			m.stop = t.pos
+		}
+		members = append(members, m)
+		if !t.eat(",") {
+			break
+		}
+	}
+	t.expect("}")
+	if t.err != nil {
+		return
+	}
+
+	begin, end := t.toks[from].Start, t.toks[t.pos-1].End
+	var b strings.Builder
+	lines := 0
+	write := func(s string) {
+		b.WriteString(s)
+		lines += strings.Count(s, "\n")
+	}
+	write("var " + name + "; (function (" + name + ") {")
+	declared := make(map[string]bool)
+	next, numeric, prev := 0.0, true, ""
+	for _, m := range members {
+		for n := strings.Count(t.src[begin:m.start], "\n"); lines < n; lines++ {
+			b.WriteByte('\n')
+		}
+		key := Quote(m.key)
+		value, reverse := "", true
+		switch {
+		case m.stop == 0 && numeric:
+			value = formatNumber(next)
+			next++
+		case m.stop == 0:
+			value = name + "[" + Quote(prev) + "] + 1"
+		default:
+			value = t.enumValue(name, m.init, m.stop, declared)
+			first := t.toks[m.init]
+			if v, ok := enumNumber(t.toks[m.init:m.stop]); ok {
+				next, numeric = v+1, true
+			} else {
+				numeric = false
+				reverse = !(m.stop == m.init+1 && (first.Kind == TokenString || templatePart(first) == templateFull))
+			}
+		}
+		if reverse {
+			write(" " + name + "[" + name + "[" + key + "] = " + value + "] = " + key + ";")
+		} else {
+			write(" " + name + "[" + key + "] = " + value + ";")
+		}
+		declared[m.key] = true
+		prev = m.key
+	}
+	for n := strings.Count(t.src[begin:end], "\n"); lines < n; lines++ {
+		b.WriteByte('\n')
+	}
+	write(" })(" + name + " || (" + name + " = {}));")
+	t.replace(begin, end, b.String())
+}
+
+// enumValue renders the initializer in tokens from..to-1 of a member of
+// enum name, qualifying references to the members declared before it.
+func (t *transformer) enumValue(name string, from, to int, declared map[string]bool) string {
+	edits := t.edits
+	for i := from; i < to; i++ {
+		tok := t.toks[i]
+		if tok.Kind == TokenIdentifier && declared[tok.Value] && !t.tok(i-1).IsPunct(".") && !t.tok(i-1).IsPunct("?.") {
+			edits = append(edits, textEdit{start: tok.Start, end: tok.End, text: name + "." + tok.Value})
+		}
+	}
+	return t.render(t.toks[from].Start, t.toks[to-1].End, edits)
+}
+
+// enumNumber returns the value of an initializer that is a numeric literal,
+// possibly negated.
+func enumNumber(toks []Token) (float64, bool) {
+	sign := 1.0
+	if len(toks) == 2 && toks[0].IsPunct("-") {
+		sign, toks = -1, toks[1:]
+	}
+	if len(toks) != 1 || toks[0].Kind != TokenNumber {
+		return 0, false
+	}
+	v, ok := parseNumber(toks[0].Value)
+	return sign * v, ok
+}
+
+func (t *transformer) importDeclaration() {
+	first := t.pos
+	t.next()
+	tok, next := t.peek(), t.tok(t.pos+1)
+
+	if tok.IsName("type") && (next.IsPunct("{") || next.IsPunct("*") ||
+		next.Kind == TokenIdentifier && !(next.IsName("from") && t.tok(t.pos+2).Kind == TokenString)) {
+		// import type ...
+		t.next()
+		if t.tok(t.pos + 1).IsPunct("=") {
+			t.next()
+			t.next()
+			t.expression(false)
+			t.eat(";")
+		} else {
+			for n := t.peek(); n.Kind != TokenEOF && !(n.IsName("from") && t.tok(t.pos+1).Kind == TokenString); n = t.peek() {
+				if n.IsPunct("{") {
+					t.skipBrackets()
+				} else {
+					t.next()
+				}
+			}
+			t.next()
+			t.next()
+			t.importAttributes()
+		}
+		t.removeStatement(first, t.pos)
+		return
+	}
+	if tok.Kind == TokenIdentifier && next.IsPunct("=") {
+		// import name = require("module")
+		t.replace(t.toks[first].Start, t.toks[first].End, "var")
+		t.next()
+		t.next()
+		t.expression(false)
+		t.eat(";")
+		return
+	}
+	if tok.Kind == TokenString {
+		t.next()
+		t.importAttributes()
+		return
+	}
+
+	d := importDecl{first: first}
+	if tok.Kind == TokenIdentifier && !(tok.IsName("from") && next.Kind == TokenString) {
+		d.names = append(d.names, importName{local: tok.Value, text: tok.Value, kind: importDefault})
+		t.next()
+		t.eat(",")
+	}
+	if t.eat("*") {
+		t.next()
+		local := t.next()
+		d.names = append(d.names, importName{local: local.Value, text: "* as " + local.Value, kind: importNamespace})
+	} else if t.eat("{") {
+		for t.more("}") {
+			typeOnly := t.typeModifier()
+			imported := t.next()
+			local := imported.Value
+			if t.peek().IsName("as") {
+				t.next()
+				local = t.next().Value
+			}
+			if typeOnly {
+				d.types = true
+			} else {
+				d.names = append(d.names, importName{local: local, text: t.src[imported.Start:t.toks[t.pos-1].End], kind: importNamed})
+			}
+			if !t.eat(",") {
+				break
+			}
+		}
+		t.expect("}")
+	}
+	if !t.peek().IsName("from") {
+		t.unexpected()
+		return
+	}
+	t.next()
+	d.from = t.pos
+	if t.peek().Kind != TokenString {
+		t.unexpected()
+		return
+	}
+	t.next()
+	t.importAttributes()
+	d.last = t.pos - 1
+	t.imports = append(t.imports, d)
+}
+
+// typeModifier consumes the type keyword of a type-only import or export
+// specifier such as "type A" or "type A as B".
+func (t *transformer) typeModifier() bool {
+	next := t.tok(t.pos + 1)
+	if !t.peek().IsName("type") || next.IsPunct(",") || next.IsPunct("}") || next.IsName("as") && !t.tok(t.pos+2).IsName("as") {
+		return false
+	}
+	t.next()
+	return true
+}
+
+// importAttributes skips an "assert { ... }" or "with { ... }" clause and
+// the semicolon ending an import or export declaration.
+func (t *transformer) importAttributes() {
+	if n := t.peek(); (n.IsName("assert") || n.IsName("with")) && !n.NewlineBefore && t.tok(t.pos+1).IsPunct("{") {
+		t.next()
+		t.skipBrackets()
+	}
+	t.eat(";")
+}
+
+func (t *transformer) exportDeclaration() {
+	start := t.pos
+	t.next()
+	tok, next := t.peek(), t.tok(t.pos+1)
+	switch {
+	case tok.IsName("type") && (next.IsPunct("{") || next.IsPunct("*")):
+		// export type { A } or export type * from "module"
+		t.next()
+		if t.peek().IsPunct("{") {
+			t.skipBrackets()
+		} else {
+			t.next()
+			if t.peek().IsName("as") {
+				t.next()
+				t.next()
+			}
+		}
+		t.exportFrom()
+		t.removeStatement(start, t.pos)
+	case tok.IsPunct("="):
+		t.replace(t.toks[start].Start, tok.End, "module.exports =")
+		t.next()
+		t.expression(false)
+		t.eat(";")
+	case tok.IsName("as") && next.IsName("namespace"):
+		t.next()
+		t.next()
+		t.next()
+		t.eat(";")
+		t.removeStatement(start, t.pos)
+	case tok.IsName("import") && next.Kind == TokenIdentifier:
+		t.fail(tok, "\"export import\" aliases are not supported")
+	case tok.IsPunct("{"):
+		t.exportClause(start)
+	case tok.IsPunct("*"):
+		t.next()
+		if t.peek().IsName("as") {
+			t.next()
+			t.next()
+		}
+		t.exportFrom()
+	case tok.IsName("default"):
+		t.next()
+		switch n := t.peek(); {
+		case n.IsName("interface") && t.tok(t.pos+1).Kind == TokenIdentifier:
+			t.interfaceDeclaration()
+			t.removeStatement(start, t.pos)
+		case n.IsName("abstract") && t.tok(t.pos+1).IsName("class"):
+			t.remove(t.pos, t.pos+1)
+			t.next()
+			t.class()
+		case t.startsFunction():
+			t.function(start, false)
+		case n.IsName("class"):
+			t.class()
+		default:
+			t.assignment(false)
+			t.eat(";")
+		}
+	case t.startsFunction():
+		t.function(start, false)
+	default:
+		if !t.typeDeclaration(start) {
+			t.statement()
+		}
+	}
+}
+
+// exportClause handles "export { ... }", deleting type-only specifiers and
+// the whole declaration if it has nothing else.
+func (t *transformer) exportClause(start int) {
+	t.next()
+	values, types := 0, 0
+	for t.more("}") {
+		from := t.pos
+		typeOnly := t.typeModifier()
+		t.next()
+		if t.peek().IsName("as") {
+			t.next()
+			t.next()
+		}
+		comma := t.eat(",")
+		if typeOnly {
+			t.remove(from, t.pos)
+			types++
+		} else {
+			values++
+		}
+		if !comma {
+			break
+		}
+	}
+	t.expect("}")
+	t.exportFrom()
+	if values == 0 && types > 0 {
+		t.removeStatement(start, t.pos)
+	}
+}
+
+// exportFrom skips the optional from clause of an export declaration.
+func (t *transformer) exportFrom() {
+	if t.peek().IsName("from") {
+		t.next()
+		t.next()
+	}
+	t.importAttributes()
+}
+
+func (t *transformer) startsFunction() bool {
+	tok, next := t.peek(), t.tok(t.pos+1)
+	return tok.IsName("function") || tok.IsName("async") && next.IsName("function") && !next.NewlineBefore
+}
+
+// function handles a function declaration or expression. Declarations
+// without a body are overload signatures, which are deleted from token
+// start on.
+func (t *transformer) function(start int, expression bool) {
+	if t.peek().IsName("async") {
+		t.next()
+	}
+	t.next()
+	t.eat("*")
+	if n := t.peek(); !n.IsPunct("(") && !n.IsPunct("<") {
+		t.next()
+	}
+	t.typeParameters()
+	t.parameters(false)
+	t.annotation()
+	if t.peek().IsPunct("{") {
+		t.functionBody(nil)
+		return
+	}
+	if expression {
+		t.unexpected()
+		return
+	}
+	t.eat(";")
+	t.removeStatement(start, t.pos)
+}
+
+// functionBody handles a function body. properties are the parameter
+// properties of a constructor, which are assigned at its start or right
+// after the super call.
+func (t *transformer) functionBody(properties []string) {
+	open := t.peek()
+	t.expect("{")
+	insert, lead := open.End, ""
+	called := false
+	for t.more("}") {
+		first, second := t.peek(), t.tok(t.pos+1)
+		t.statement()
+		if len(properties) > 0 && !called && first.IsName("super") && second.IsPunct("(") {
+			called = true
+			last := t.tok(t.pos - 1)
+			insert = last.End
+			if !last.IsPunct(";") {
+				lead = ";"
+			}
+		}
+	}
+	t.expect("}")
+	if len(properties) > 0 {
+		assignments := make([]string, len(properties))
+		for i, name := range properties {
+			assignments[i] = "this." + name + " = " + name + ";"
+		}
+		t.replace(insert, insert, lead+" "+strings.Join(assignments, " "))
+	}
+}
+
+// parameters handles a parameter list and returns the names of the
+// parameter properties of a constructor.
+func (t *transformer) parameters(constructor bool) []string {
+	var properties []string
+	t.expect("(")
+	for t.more(")") {
+		start := t.pos
+		if tok := t.peek(); tok.IsPunct("@") {
+			t.fail(tok, "decorators are not supported")
+			return nil
+		}
+		property := false
+		for tok := t.peek(); constructor && tok.Kind == TokenIdentifier && tsModifiers[tok.Value] && startsBinding(t.tok(t.pos+1)); tok = t.peek() {
+			t.remove(t.pos, t.pos+1)
+			t.next()
+			property = true
+		}
+		if t.peek().IsName("this") && t.tok(t.pos+1).IsPunct(":") {
+			// The type of this.
+			t.next()
+			t.annotation()
+			t.eat(",")
+			t.remove(start, t.pos)
+			continue
+		}
+		t.eat("...")
+		name := t.peek()
+		t.binding()
+		if t.peek().IsPunct("?") {
+			t.remove(t.pos, t.pos+1)
+			t.next()
+		}
+		t.annotation()
+		if t.eat("=") {
+			t.assignment(false)
+		}
+		if property {
+			properties = append(properties, name.Value)
+		}
+		if !t.eat(",") {
+			break
+		}
+	}
+	t.expect(")")
+	return properties
+}
+
+// class handles a class declaration or expression.
+func (t *transformer) class() {
+	t.next()
+	if n := t.peek(); (n.Kind == TokenIdentifier || n.Kind == TokenKeyword && !IsKeyword(n.Value)) && !n.IsName("implements") {
+		t.next()
+	}
+	t.typeParameters()
+	if t.peek().IsName("extends") {
+		t.next()
+		t.leftHandSide()
+		if t.peek().IsPunct("<") {
+			t.typeParameters()
+		}
+	}
+	if t.peek().IsName("implements") {
+		from := t.pos
+		t.next()
+		t.typeList()
+		t.remove(from, t.pos)
+	}
+	t.expect("{")
+	for t.more("}") {
+		t.member()
+	}
+	t.expect("}")
+}
+
+// member handles a class member. Index signatures, overload signatures,
+// abstract members and declared fields are deleted.
+func (t *transformer) member() {
+	start := t.pos
+	if t.eat(";") {
+		return
+	}
+	if tok := t.peek(); tok.IsPunct("@") {
+		t.fail(tok, "decorators are not supported")
+		return
+	}
+	if t.peek().IsName("static") && t.tok(t.pos+1).IsPunct("{") {
+		t.next()
+		t.block()
+		return
+	}
+
+	erase := false
+	for tok := t.peek(); classModifiers[tok.Value] && (tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword) && t.modifierAt(t.pos); tok = t.peek() {
+		if tsModifiers[tok.Value] {
+			t.remove(t.pos, t.pos+1)
+			erase = erase || tok.Value == "declare" || tok.Value == "abstract"
+		}
+		t.next()
+	}
+	t.eat("*")
+	if t.peek().IsPunct("[") && t.tok(t.pos+2).IsPunct(":") {
+		// An index signature.
+		t.skipBrackets()
+		t.annotation()
+		t.eat(";")
+		t.removeStatement(start, t.pos)
+		return
+	}
+
+	name := t.peek()
+	t.propertyName()
+	if t.peek().IsPunct("?") || t.peek().IsPunct("!") {
+		t.remove(t.pos, t.pos+1)
+		t.next()
+	}
+	if t.peek().IsPunct("(") || t.peek().IsPunct("<") {
+		t.typeParameters()
+		properties := t.parameters(name.IsName("constructor"))
+		t.annotation()
+		if t.peek().IsPunct("{") {
+			t.functionBody(properties)
+			return
+		}
+		t.eat(";")
+		t.removeStatement(start, t.pos)
+		return
+	}
+	t.annotation()
+	if t.eat("=") {
+		t.assignment(false)
+	}
+	t.eat(";")
+	if erase {
+		t.removeStatement(start, t.pos)
+	}
+}
+
+// modifierAt reports whether the word at token i modifies the member name
+// following it on the same line, rather than being the name itself.
+func (t *transformer) modifierAt(i int) bool {
+	next := t.tok(i + 1)
+	if next.NewlineBefore {
+		return false
+	}
+	switch next.Kind {
+	case TokenIdentifier, TokenKeyword, TokenString, TokenNumber:
+		return true
+	}
+	return next.IsPunct("[") || next.IsPunct("#") || next.IsPunct("*")
+}
+
+func (t *transformer) propertyName() {
+	switch tok := t.peek(); {
+	case tok.IsPunct("["):
+		t.next()
+		t.assignment(false)
+		t.expect("]")
+	case tok.IsPunct("#"):
+		t.next()
+		t.next()
+	case tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword || tok.Kind == TokenString || tok.Kind == TokenNumber:
+		t.next()
+	default:
+		t.unexpected()
+	}
+}
+
+// declarationKeywords returns the number of tokens of the keywords
+// starting a variable declaration at the current token, or 0.
+func (t *transformer) declarationKeywords() int {
+	tok, next := t.peek(), t.tok(t.pos+1)
+	switch {
+	case tok.IsName("var") || tok.IsName("const") || tok.IsName("let") && startsBinding(next):
+		return 1
+	case tok.IsName("using") && next.Kind == TokenIdentifier && !next.NewlineBefore:
+		return 1
+	case tok.IsName("await") && next.IsName("using") && !next.NewlineBefore:
+		if n := t.tok(t.pos + 2); n.Kind == TokenIdentifier && !n.NewlineBefore {
+			return 2
+		}
+	}
+	return 0
+}
+
+// variables handles the declarations of a var, let, const or using
+// statement.
+func (t *transformer) variables(noIn bool) {
+	t.pos += t.declarationKeywords()
+	for {
+		t.binding()
+		if t.peek().IsPunct("!") {
+			t.remove(t.pos, t.pos+1)
+			t.next()
+		}
+		t.annotation()
+		if t.eat("=") {
+			t.assignment(noIn)
+		}
+		if !t.eat(",") {
+			return
+		}
+	}
+}
+
+func (t *transformer) forHead() {
+	if t.declarationKeywords() > 0 {
+		t.variables(true)
+	} else if !t.peek().IsPunct(";") {
+		t.expression(true)
+	}
+	if t.peek().IsName("of") || t.peek().IsName("in") {
+		t.next()
+		t.expression(false)
+		return
+	}
+	t.expect(";")
+	if !t.peek().IsPunct(";") {
+		t.expression(false)
+	}
+	t.expect(";")
+	if !t.peek().IsPunct(")") {
+		t.expression(false)
+	}
+}
+
+// binding handles a binding identifier or destructuring pattern.
+func (t *transformer) binding() {
+	switch tok := t.peek(); {
+	case tok.IsPunct("{"):
+		t.next()
+		for t.more("}") {
+			if t.eat("...") {
+				t.binding()
+			} else {
+				t.propertyName()
+				if t.eat(":") {
+					t.binding()
+				}
+				if t.eat("=") {
+					t.assignment(false)
+				}
+			}
+			if !t.eat(",") {
+				break
+			}
+		}
+		t.expect("}")
+	case tok.IsPunct("["):
+		t.next()
+		for t.more("]") {
+			if t.eat(",") {
+				continue
+			}
+			t.eat("...")
+			t.binding()
+			if t.eat("=") {
+				t.assignment(false)
+			}
+			if !t.eat(",") {
+				break
+			}
+		}
+		t.expect("]")
+	case tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword:
+		t.next()
+	default:
+		t.unexpected()
+	}
+}
+
+func startsBinding(tok Token) bool {
+	return tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword && !IsKeyword(tok.Value) || tok.IsPunct("{") || tok.IsPunct("[")
+}
+
+func (t *transformer) expression(noIn bool) {
+	t.assignment(noIn)
+	for t.eat(",") {
+		t.assignment(noIn)
+	}
+}
+
+// assignment handles an assignment expression. noIn leaves "in" to the
+// head of a for statement.
+func (t *transformer) assignment(noIn bool) {
+	if t.arrow() {
+		return
+	}
+	if t.peek().IsName("yield") {
+		t.next()
+		t.eat("*")
+		if n := t.peek(); !n.NewlineBefore && startsExpression(n) {
+			t.assignment(noIn)
+		}
+		return
+	}
+	t.binary(noIn)
+	if t.eat("?") {
+		t.assignment(false)
+		t.expect(":")
+		t.assignment(noIn)
+		return
+	}
+	if tok := t.peek(); tok.Kind == TokenPunctuator && isAssignment(tok.Value) {
+		t.next()
+		t.assignment(noIn)
+	}
+}
+
+// arrow handles an arrow function if one starts at the current token.
+func (t *transformer) arrow() bool {
+	i := t.pos
+	if tok, next := t.tok(i), t.tok(i+1); tok.IsName("async") && !next.NewlineBefore &&
+		(next.Kind == TokenIdentifier && t.tok(i+2).IsPunct("=>") || next.IsPunct("(") || next.IsPunct("<")) {
+		i++
+	}
+	switch head := t.tok(i); {
+	case head.Kind == TokenIdentifier || head.IsName("yield") || head.IsName("await"):
+		if !t.tok(i + 1).IsPunct("=>") {
+			return false
+		}
+	case head.IsPunct("(") || head.IsPunct("<"):
+		if !t.arrowAt(i) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	t.pos = i
+	t.typeParameters()
+	if t.peek().IsPunct("(") {
+		t.parameters(false)
+	} else {
+		t.next()
+	}
+	t.annotation()
+	t.expect("=>")
+	if t.peek().IsPunct("{") {
+		t.functionBody(nil)
+	} else {
+		t.assignment(false)
+	}
+	return true
+}
+
+// arrowAt reports whether the parenthesis or type parameters at token i
+// start an arrow function.
+func (t *transformer) arrowAt(i int) bool {
+	if t.tok(i).IsPunct("<") {
+		if i = t.skipAngles(i, false); i < 0 {
+			return false
+		}
+	}
+	if !t.tok(i).IsPunct("(") {
+		return false
+	}
+	closing := t.match[i]
+	after := t.tok(closing + 1)
+	if after.IsPunct("=>") {
+		return !after.NewlineBefore
+	}
+	if !after.IsPunct(":") {
+		return false
+	}
+	// A return type: (a): T => a
+	saved := t.pos
+	t.pos = closing + 2
+	ok := t.typ() && t.peek().IsPunct("=>")
+	t.pos = saved
+	return ok
+}
+
+func (t *transformer) binary(noIn bool) {
+	t.unary()
+	for {
+		tok := t.peek()
+		switch {
+		case (tok.Is(TokenIdentifier, "as") || tok.Is(TokenIdentifier, "satisfies")) && !tok.NewlineBefore:
+			from := t.pos
+			t.next()
+			t.requireType()
+			t.remove(from, t.pos)
+		case tok.Kind == TokenPunctuator && binaryOperators[tok.Value], tok.IsName("instanceof"), tok.IsName("in") && !noIn:
+			t.next()
+			t.unary()
+		default:
+			return
+		}
+	}
+}
+
+func (t *transformer) unary() {
+	tok := t.peek()
+	switch {
+	case tok.Kind == TokenPunctuator && (tok.Value == "!" || tok.Value == "~" || tok.Value == "+" || tok.Value == "-" || tok.Value == "++" || tok.Value == "--"):
+		t.next()
+		t.unary()
+	case tok.IsName("typeof") || tok.IsName("void") || tok.IsName("delete") || tok.IsName("await") && startsExpression(t.tok(t.pos+1)):
+		t.next()
+		t.unary()
+	case tok.IsPunct("<") && !t.opts.JSX:
+		// A type assertion: <T>value
+		end := t.skipAngles(t.pos, false)
+		if end < 0 {
+			t.unexpected()
+			return
+		}
+		t.remove(t.pos, end)
+		t.pos = end
+		t.unary()
+	default:
+		t.leftHandSide()
+		if tok := t.peek(); (tok.IsPunct("++") || tok.IsPunct("--")) && !tok.NewlineBefore {
+			t.next()
+		}
+	}
+}
+
+func (t *transformer) leftHandSide() {
+	if t.peek().IsName("new") {
+		t.newExpression()
+	} else {
+		t.primary()
+	}
+	t.suffixes(true)
+}
+
+func (t *transformer) newExpression() {
+	t.next()
+	if t.eat(".") {
+		// new.target
+		t.next()
+		return
+	}
+	if t.peek().IsName("new") {
+		t.newExpression()
+	} else {
+		t.primary()
+	}
+	t.suffixes(false)
+	if t.peek().IsPunct("(") {
+		t.arguments()
+	}
+}
+
+// suffixes handles member accesses, calls unless calls is false, tagged
+// templates, non-null assertions and type arguments.
+func (t *transformer) suffixes(calls bool) {
+	for {
+		tok := t.peek()
+		switch {
+		case tok.IsPunct("."):
+			t.next()
+			t.eat("#")
+			t.next()
+		case tok.IsPunct("?."):
+			t.next()
+			switch n := t.peek(); {
+			case n.IsPunct("("):
+				t.arguments()
+			case n.IsPunct("["):
+				t.next()
+				t.expression(false)
+				t.expect("]")
+			case n.IsPunct("<"):
+				if t.typeArguments() {
+					t.arguments()
+				} else {
+					t.unexpected()
+				}
+			default:
+				t.eat("#")
+				t.next()
+			}
+		case tok.IsPunct("["):
+			t.next()
+			t.expression(false)
+			t.expect("]")
+		case tok.IsPunct("(") && calls:
+			t.arguments()
+		case templatePart(tok) == templateFull || templatePart(tok) == templateHead:
+			t.template()
+		case tok.IsPunct("!") && !tok.NewlineBefore:
+			t.remove(t.pos, t.pos+1)
+			t.next()
+		case tok.IsPunct("<") && t.typeArguments():
+		default:
+			return
+		}
+	}
+}
+
+// typeArguments deletes the type arguments of a call or an instantiation
+// expression at the current "<" and reports whether it found any. Like
+// TypeScript it takes "<" for a comparison unless the matching ">" is
+// followed by something that cannot start an expression.
+func (t *transformer) typeArguments() bool {
+	end := t.skipAngles(t.pos, true)
+	if end < 0 {
+		return false
+	}
+	switch next := t.tok(end); {
+	case next.IsPunct("(") || next.Kind == TokenTemplate:
+	case next.IsPunct("<") || next.IsPunct(">") || next.IsPunct("+") || next.IsPunct("-"):
+		return false
+	case !next.NewlineBefore && startsExpression(next):
+		return false
+	}
+	t.remove(t.pos, end)
+	t.pos = end
+	return true
+}
+
+func (t *transformer) arguments() {
+	t.expect("(")
+	for t.more(")") {
+		t.eat("...")
+		t.assignment(false)
+		if !t.eat(",") {
+			break
+		}
+	}
+	t.expect(")")
+}
+
+func (t *transformer) primary() {
+	tok := t.peek()
+	switch {
+	case tok.Kind == TokenJSX || tok.Kind == TokenString || tok.Kind == TokenNumber || tok.Kind == TokenRegExp:
+		t.next()
+	case tok.Kind == TokenTemplate:
+		t.template()
+	case t.startsFunction():
+		t.function(t.pos, true)
+	case tok.IsName("class"):
+		t.class()
+	case tok.IsPunct("("):
+		t.parenthesized()
+	case tok.IsPunct("["):
+		t.next()
+		for t.more("]") {
+			if t.eat(",") {
+				continue
+			}
+			t.eat("...")
+			t.assignment(false)
+			if !t.eat(",") {
+				break
+			}
+		}
+		t.expect("]")
+	case tok.IsPunct("{"):
+		t.object()
+	case tok.IsPunct("#"):
+		t.next()
+		t.next()
+	case tok.IsPunct("@"):
+		t.fail(tok, "decorators are not supported")
+	case tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword:
+		t.next()
+	default:
+		t.unexpected()
+	}
+}
+
+func (t *transformer) object() {
+	t.next()
+	for t.more("}") {
+		if t.eat("...") {
+			t.assignment(false)
+		} else {
+			for tok := t.peek(); (tok.IsName("get") || tok.IsName("set") || tok.IsName("async")) && t.modifierAt(t.pos); tok = t.peek() {
+				t.next()
+			}
+			t.eat("*")
+			t.propertyName()
+			switch {
+			case t.peek().IsPunct("(") || t.peek().IsPunct("<"):
+				t.typeParameters()
+				t.parameters(false)
+				t.annotation()
+				t.functionBody(nil)
+			case t.eat(":") || t.eat("="):
+				t.assignment(false)
+			}
+		}
+		if !t.eat(",") {
+			break
+		}
+	}
+	t.expect("}")
+}
+
+func (t *transformer) template() {
+	tok := t.next()
+	if templatePart(tok) != templateHead {
+		return
+	}
+	for {
+		t.expression(false)
+		part := t.next()
+		if part.Kind != TokenTemplate {
+			t.fail(part, "unterminated template literal")
+			return
+		}
+		if templatePart(part) == templateTail {
+			return
+		}
+	}
+}
+
+// startsExpression reports whether tok can start an expression.
+func startsExpression(tok Token) bool {
+	switch tok.Kind {
+	case TokenEOF:
+		return false
+	case TokenKeyword:
+		return tok.Value != "in" && tok.Value != "instanceof"
+	case TokenPunctuator:
+		switch tok.Value {
+		case "(", "[", "{", "!", "~", "+", "-", "++", "--", "<", "#", "@":
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// annotation deletes a type annotation or return type if one follows.
+func (t *transformer) annotation() {
+	if t.peek().IsPunct(":") {
+		from := t.pos
+		t.next()
+		t.requireType()
+		t.remove(from, t.pos)
+	}
+}
+
+// typeParameters deletes type parameters or arguments if they follow.
+func (t *transformer) typeParameters() {
+	if !t.peek().IsPunct("<") {
+		return
+	}
+	end := t.skipAngles(t.pos, false)
+	if end < 0 {
+		t.unexpected()
+		return
+	}
+	t.remove(t.pos, end)
+	t.pos = end
+}
+
+func (t *transformer) typeList() {
+	for {
+		t.requireType()
+		if !t.eat(",") {
+			return
+		}
+	}
+}
+
+func (t *transformer) requireType() {
+	if !t.typ() {
+		t.unexpected()
+	}
+}
+
+// typ skips a type and reports whether there was one. It records no edits
+// and never fails, so it can be used to look ahead.
+func (t *transformer) typ() bool {
+	if ok, function := t.functionType(); function {
+		return ok
+	}
+	if !t.unionType() {
+		return false
+	}
+	if t.peek().IsName("extends") {
+		// A conditional type: T extends U ? X : Y
+		t.next()
+		if !t.unionType() || !t.eat("?") || !t.typ() || !t.eat(":") {
+			return false
+		}
+		return t.typ()
+	}
+	return true
+}
+
+// functionType skips a function or constructor type, and reports whether
+// one was found and whether the current token started one.
+func (t *transformer) functionType() (ok, function bool) {
+	i := t.pos
+	if t.tok(i).IsName("abstract") && t.tok(i+1).IsName("new") {
+		i++
+	}
+	if t.tok(i).IsName("new") {
+		i++
+	}
+	if t.tok(i).IsPunct("<") {
+		if i = t.skipAngles(i, false); i < 0 {
+			return false, true
+		}
+	}
+	if !t.tok(i).IsPunct("(") || !t.tok(t.match[i]+1).IsPunct("=>") || !t.parametersAt(i) {
+		return false, i != t.pos
+	}
+	t.pos = t.match[i] + 2
+	return t.typ(), true
+}
+
+// parametersAt reports whether the parenthesis at token i, which is
+// followed by "=>", starts the parameters of a function type rather than a
+// parenthesized type such as the "((a: A) => B)" of "(): ((a: A) => B) =>".
+func (t *transformer) parametersAt(i int) bool {
+	first, second := t.tok(i+1), t.tok(i+2)
+	switch {
+	case first.IsPunct(")") || first.IsPunct("..."):
+		return true
+	case first.IsPunct("{") || first.IsPunct("["):
+		after := t.tok(t.match[i+1] + 1)
+		return after.IsPunct(":") || after.IsPunct(",") || after.IsPunct("=") || after.IsPunct("?") ||
+			after.IsPunct(")") && t.tok(t.match[i]+1).IsPunct("=>")
+	case first.Kind == TokenIdentifier || first.Kind == TokenKeyword:
+		return second.IsPunct(":") || second.IsPunct(",") || second.IsPunct("?") || second.IsPunct("=") || second.IsPunct(")")
+	}
+	return false
+}
+
+func (t *transformer) unionType() bool {
+	t.eat("|")
+	for {
+		if !t.intersectionType() {
+			return false
+		}
+		if !t.eat("|") {
+			return true
+		}
+	}
+}
+
+func (t *transformer) intersectionType() bool {
+	t.eat("&")
+	for {
+		if !t.operatorType() {
+			return false
+		}
+		if !t.eat("&") {
+			return true
+		}
+	}
+}
+
+func (t *transformer) operatorType() bool {
+	tok, next := t.peek(), t.tok(t.pos+1)
+	switch {
+	case (tok.IsName("keyof") || tok.IsName("unique") || tok.IsName("readonly")) && startsType(next):
+		t.next()
+		return t.operatorType()
+	case tok.IsName("infer") && next.Kind == TokenIdentifier:
+		t.next()
+		t.next()
+		return true
+	}
+	if !t.primaryType() {
+		return false
+	}
+	// Array types and indexed access types.
+	for t.peek().IsPunct("[") && !t.peek().NewlineBefore {
+		t.skipBrackets()
+	}
+	return true
+}
+
+func (t *transformer) primaryType() bool {
+	tok, next := t.peek(), t.tok(t.pos+1)
+	switch {
+	case tok.IsPunct("(") || tok.IsPunct("{") || tok.IsPunct("["):
+		t.skipBrackets()
+	case tok.Kind == TokenString || tok.Kind == TokenNumber:
+		t.next()
+	case tok.Kind == TokenTemplate:
+		t.next()
+		if templatePart(tok) == templateHead {
+			for {
+				if !t.typ() {
+					return false
+				}
+				part := t.next()
+				if part.Kind != TokenTemplate {
+					return false
+				}
+				if templatePart(part) == templateTail {
+					break
+				}
+			}
+		}
+	case tok.IsPunct("-") && next.Kind == TokenNumber:
+		t.next()
+		t.next()
+	case tok.IsName("typeof"):
+		t.next()
+		if t.peek().IsName("import") {
+			return t.primaryType()
+		}
+		t.entityName()
+		t.typeArgumentList()
+	case tok.IsName("import") && next.IsPunct("("):
+		t.next()
+		t.skipBrackets()
+		t.entityMembers()
+		t.typeArgumentList()
+	case tok.IsName("asserts") && (next.Kind == TokenIdentifier || next.IsName("this")) && !next.NewlineBefore:
+		// asserts value [is T]
+		t.next()
+		t.next()
+		if t.peek().IsName("is") {
+			t.next()
+			return t.typ()
+		}
+	case tok.Kind == TokenIdentifier || tok.Kind == TokenKeyword:
+		t.entityName()
+		if n := t.peek(); n.IsName("is") && !n.NewlineBefore {
+			// A type predicate: value is T
+			t.next()
+			return t.typ()
+		}
+		t.typeArgumentList()
+	default:
+		return false
+	}
+	return true
+}
+
+func (t *transformer) entityName() {
+	t.next()
+	t.entityMembers()
+}
+
+func (t *transformer) entityMembers() {
+	for t.peek().IsPunct(".") && t.tok(t.pos+1).Kind != TokenEOF {
+		t.next()
+		t.next()
+	}
+}
+
+func (t *transformer) typeArgumentList() {
+	if n := t.peek(); n.IsPunct("<") && !n.NewlineBefore {
+		if end := t.skipAngles(t.pos, false); end > 0 {
+			t.pos = end
+		}
+	}
+}
+
+func startsType(tok Token) bool {
+	switch tok.Kind {
+	case TokenIdentifier, TokenKeyword, TokenString, TokenNumber, TokenTemplate:
+		return true
+	}
+	return tok.IsPunct("(") || tok.IsPunct("[") || tok.IsPunct("{") || tok.IsPunct("-") || tok.IsPunct("<")
+}
+
+func (t *transformer) skipBrackets() {
+	if closing := t.match[t.pos]; closing > t.pos {
+		t.pos = closing + 1
+		return
+	}
+	t.unexpected()
+}
+
+// skipAngles returns the index of the token after the ">" matching the "<"
+// at token i, or -1. ">>" and ">>>" close several levels at once. strict
+// only allows tokens that can appear in type arguments, to tell them from
+// comparisons.
+func (t *transformer) skipAngles(i int, strict bool) int {
+	depth := 0
+	for ; i < len(t.toks); i++ {
+		tok := t.toks[i]
+		switch {
+		case tok.IsPunct("<"):
+			depth++
+		case tok.IsPunct(">"):
+			depth--
+		case tok.IsPunct(">>"):
+			depth -= 2
+		case tok.IsPunct(">>>"):
+			depth -= 3
+		case tok.IsPunct("(") || tok.IsPunct("[") || tok.IsPunct("{") || templatePart(tok) == templateHead:
+			i = t.match[i]
+		case tok.IsPunct(")") || tok.IsPunct("]") || tok.IsPunct("}") || tok.IsPunct(";") || tok.Kind == TokenJSX:
+			return -1
+		case strict && (tok.Kind == TokenPunctuator && !typePunctuators[tok.Value] || tok.Kind == TokenRegExp):
+			return -1
+		}
+		if depth == 0 {
+			return i + 1
+		}
+		if depth < 0 {
+			return -1
+		}
+	}
+	return -1
+}
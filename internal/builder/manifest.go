@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ManifestFile is written to the output directory of production builds. It
+// is not called manifest.json so it cannot clash with a web app manifest in
+// the public directory.
+const ManifestFile = "asset-manifest.json"
+
+// Manifest maps the logical names of hashed outputs to the files written
+// for them. Scripts and styles are named by the path they would have without
+// the hash, such as "assets/main.js"; imported assets by their source path,
+// such as "src/logo.png".
+type Manifest map[string]*ManifestEntry
+
+// ManifestEntry describes one hashed output file. Imports, DynamicImports,
+// CSS and Assets hold logical names of other manifest entries.
+type ManifestEntry struct {
+	File      string `json:"file"`
+	Src       string `json:"src,omitempty"`
+	IsEntry   bool   `json:"is_entry,omitempty"`
+	Integrity string `json:"integrity"`
+	Size      int64  `json:"size"`
+	Map       string `json:"map,omitempty"`
+	// Srcset lists the resized variants of an image and the image itself
+	// for a srcset attribute.
+	Srcset string `json:"srcset,omitempty"`
+	// Imports are the chunks that must be loaded before this one.
+	Imports []string `json:"imports,omitempty"`
+	// DynamicImports are the chunks loaded by import() expressions.
+	DynamicImports []string `json:"dynamic_imports,omitempty"`
+	CSS            []string `json:"css,omitempty"`
+	Assets         []string `json:"assets,omitempty"`
+}
+
+// Integrity returns the subresource integrity value of content.
+func Integrity(content []byte) string {
+	sum := sha512.Sum384(content)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// urlRewriter maps the unhashed output paths of files to their hashed
+// paths, and rewrites references to them. Paths claimed by more than one
+// file map to "" and are left alone.
+type urlRewriter map[string]string
+
+func (r urlRewriter) add(unhashed, hashed string) {
+	if existing, ok := r[unhashed]; ok && existing != hashed {
+		r[unhashed] = ""
+		return
+	}
+	r[unhashed] = hashed
+}
+
+func (r urlRewriter) lookup(unhashed string) (string, bool) {
+	hashed := r[unhashed]
+	return hashed, hashed != ""
+}
+
+var (
+	htmlRef = regexp.MustCompile(`(?i)(\s(?:src|href)\s*=\s*)("[^"]*"|'[^']*')`)
+	cssRef  = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^'")\s]*)\s*\)`)
+)
+
+// rewriteHTML replaces src and href attributes of the HTML file at rel that
+// point to unhashed outputs.
+func (r urlRewriter) rewriteHTML(rel, html, publicPath string) string {
+	return htmlRef.ReplaceAllStringFunc(html, func(s string) string {
+		parts := htmlRef.FindStringSubmatch(s)
+		quote := parts[2][:1]
+		url := parts[2][1 : len(parts[2])-1]
+		return parts[1] + quote + r.rewriteURL(rel, url, publicPath) + quote
+	})
+}
+
+// rewriteCSS replaces url() references in the stylesheet at rel.
+func (r urlRewriter) rewriteCSS(rel, css, publicPath string) string {
+	return cssRef.ReplaceAllStringFunc(css, func(s string) string {
+		arg := cssRef.FindStringSubmatch(s)[1]
+		quote := ""
+		if arg != "" && (arg[0] == '"' || arg[0] == '\'') {
+			quote, arg = arg[:1], arg[1:len(arg)-1]
+		}
+		return "url(" + quote + r.rewriteURL(rel, arg, publicPath) + quote + ")"
+	})
+}
+
+// rewriteURL maps a URL found in the file at rel to its hashed equivalent.
+// URLs under publicPath stay absolute and relative URLs stay relative; other
+// URLs are returned unchanged.
+func (r urlRewriter) rewriteURL(rel, url, publicPath string) string {
+	if url == "" || strings.HasPrefix(url, "#") || strings.HasPrefix(url, "data:") {
+		return url
+	}
+	target, suffix := url, ""
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target, suffix = target[:i], target[i:]
+	}
+
+	switch {
+	case strings.HasPrefix(target, publicPath):
+		if hashed, ok := r.lookup(strings.TrimPrefix(target, publicPath)); ok {
+			return publicPath + hashed + suffix
+		}
+	case !strings.HasPrefix(target, "/") && !strings.Contains(target, "://"):
+		if hashed, ok := r.lookup(path.Join(path.Dir(rel), target)); ok {
+			return relativeURL(rel, hashed) + suffix
+		}
+	}
+	return url
+}
@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/bundler"
+	"github.com/skbhati199/go-web-build/internal/builder/images"
+	"github.com/skbhati199/go-web-build/internal/builder/naming"
+)
+
+// ImageStats counts the images optimized by a build.
+type ImageStats struct {
+	// Optimized and Reused count images processed by this build or read
+	// from the cache.
+	Optimized int `json:"optimized"`
+	Reused    int `json:"reused"`
+	// Variants counts the resized copies written.
+	Variants int `json:"variants"`
+	// Saved is how many bytes optimization took off the original images.
+	Saved int64 `json:"saved"`
+}
+
+// imageVariant is a written copy of an image, for srcset.
+type imageVariant struct {
+	path  string
+	width int
+}
+
+// optimizeImage runs images.Optimize on an imported image, or reads the
+// result from the cache, and writes its variants. It returns the data to
+// write for the image itself and the variants including the image, widest
+// last.
+func (w *outputWriter) optimizeImage(media *bundler.Media, data []byte) ([]byte, []imageVariant, error) {
+	key := imageKey(*w.images, data)
+	var result *images.Result
+	reused := false
+	if w.cache != nil {
+		if cached, ok := w.cache.Get(key); ok {
+			reused = json.Unmarshal(cached, &result) == nil && result != nil
+		}
+	}
+	if !reused {
+		var err error
+		if result, err = images.Optimize(data, *w.images); err != nil {
+			return nil, nil, fmt.Errorf("failed to optimize %s: %w", media.Source, err)
+		}
+		if w.cache != nil {
+			if encoded, err := json.Marshal(result); err == nil {
+				// A failed write only costs the next build some time.
+				w.cache.Put(key, encoded)
+			}
+		}
+	}
+
+	var variants []imageVariant
+	ext := filepath.Ext(media.Path)
+	for _, v := range result.Variants {
+		suffix := "-" + strconv.Itoa(v.Width) + "w"
+		path := strings.TrimSuffix(media.Path, ext) + suffix + ext
+		if w.names != nil {
+			name := strings.TrimSuffix(filepath.Base(media.Source), filepath.Ext(media.Source))
+			path = naming.Expand(w.names.Asset, name+suffix, ext, v.Data)
+		}
+		if err := w.writeFile(path, v.Data); err != nil {
+			return nil, nil, err
+		}
+		variants = append(variants, imageVariant{path: path, width: v.Width})
+	}
+	if len(variants) > 0 {
+		variants = append(variants, imageVariant{path: media.Path, width: result.Width})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := &w.result.Images
+	if reused {
+		stats.Reused++
+	} else {
+		stats.Optimized++
+	}
+	stats.Variants += len(result.Variants)
+	stats.Saved += int64(len(data) - len(result.Data))
+	return result.Data, variants, nil
+}
+
+// imageKey is the cache key of an optimized image: its content under the
+// options and encoder version.
+func imageKey(opts images.Options, data []byte) string {
+	parts := []string{images.Version, runtime.Version(), strconv.Itoa(opts.Quality), strconv.FormatBool(opts.StripMetadata)}
+	for _, width := range opts.Widths {
+		parts = append(parts, strconv.Itoa(width))
+	}
+	return "image/" + naming.Hash([]byte(strings.Join(append(parts, naming.Hash(data)), "\x00")))
+}
+
+// srcset formats variants as the value of a srcset attribute.
+func srcset(publicPath string, variants []imageVariant) string {
+	parts := make([]string, len(variants))
+	for i, v := range variants {
+		parts[i] = publicPath + v.path + " " + strconv.Itoa(v.width) + "w"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,98 @@
+// Package naming expands output file name patterns such as
+// "assets/[name].[hash:8].js".
+//
+// Patterns are slash separated paths relative to the output directory and
+// may contain these placeholders:
+//
+//	[name]    the entry, chunk or source file name without extension
+//	[ext]     the extension of the output, including the dot
+//	[hash]    the content hash, 8 hex digits
+//	[hash:N]  the first N hex digits of the content hash (4 to 64)
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const defaultHashLength = 8
+
+var placeholder = regexp.MustCompile(`\[([a-z]+)(?::(\d+))?\]`)
+
+// hashPlaceholder matches a hash placeholder together with the separator in
+// front of it, which is dropped along with it by Unhashed.
+var hashPlaceholder = regexp.MustCompile(`[.\-_]?\[hash(?::\d+)?\]`)
+
+// Validate checks that pattern only uses known placeholders, names the file
+// and stays inside the output directory.
+func Validate(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty name pattern")
+	}
+	if path.IsAbs(pattern) || strings.HasPrefix(path.Clean(pattern), "..") {
+		return fmt.Errorf("name pattern %q must be relative to the output directory", pattern)
+	}
+	if !strings.Contains(pattern, "[name]") {
+		return fmt.Errorf("name pattern %q must contain [name]", pattern)
+	}
+	for _, m := range placeholder.FindAllStringSubmatch(pattern, -1) {
+		switch m[1] {
+		case "name", "ext":
+			if m[2] != "" {
+				return fmt.Errorf("name pattern %q: [%s] takes no length", pattern, m[1])
+			}
+		case "hash":
+			if m[2] == "" {
+				continue
+			}
+			if n, _ := strconv.Atoi(m[2]); n < 4 || n > 2*sha256.Size {
+				return fmt.Errorf("name pattern %q: hash length must be between 4 and %d", pattern, 2*sha256.Size)
+			}
+		default:
+			return fmt.Errorf("name pattern %q: unknown placeholder [%s]", pattern, m[1])
+		}
+	}
+	return nil
+}
+
+// Expand substitutes the placeholders of pattern for a file with the given
+// name, extension and content.
+func Expand(pattern, name, ext string, content []byte) string {
+	var sum string
+	return placeholder.ReplaceAllStringFunc(pattern, func(s string) string {
+		m := placeholder.FindStringSubmatch(s)
+		switch m[1] {
+		case "name":
+			return name
+		case "ext":
+			return ext
+		case "hash":
+			if sum == "" {
+				sum = Hash(content)
+			}
+			n := defaultHashLength
+			if m[2] != "" {
+				n, _ = strconv.Atoi(m[2])
+			}
+			return sum[:min(n, len(sum))]
+		}
+		return s
+	})
+}
+
+// Unhashed expands pattern without its hash, giving the stable logical name
+// of a file, such as "assets/main.js" for "assets/[name].[hash:8].js".
+func Unhashed(pattern, name, ext string) string {
+	return Expand(hashPlaceholder.ReplaceAllString(pattern, ""), name, ext, nil)
+}
+
+// Hash returns the hex encoded SHA-256 of content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
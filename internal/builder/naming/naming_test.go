@@ -0,0 +1,43 @@
+package naming
+
+import "testing"
+
+func TestExpand(t *testing.T) {
+	content := []byte("console.log(1)")
+	hash := Hash(content)
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"assets/[name].[hash:8][ext]", "assets/main." + hash[:8] + ".js"},
+		{"[name]-[hash][ext]", "main-" + hash[:8] + ".js"},
+		{"js/[hash:12]/[name].js", "js/" + hash[:12] + "/main.js"},
+		{"[name][ext]", "main.js"},
+	}
+	for _, tt := range tests {
+		if got := Expand(tt.pattern, "main", ".js", content); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	if got := Unhashed("assets/[name].[hash:8][ext]", "main", ".js"); got != "assets/main.js" {
+		t.Errorf("Unhashed() = %q, want assets/main.js", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{"assets/[name].[hash:8][ext]", "[name].js"}
+	invalid := []string{"", "assets/[hash].js", "[name].[contenthash].js", "[name].[hash:2].js", "../[name].js", "/abs/[name].js"}
+
+	for _, p := range valid {
+		if err := Validate(p); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", p, err)
+		}
+	}
+	for _, p := range invalid {
+		if err := Validate(p); err == nil {
+			t.Errorf("Validate(%q) succeeded, want an error", p)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package parallel runs independent build steps on a bounded pool of
+// goroutines.
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Jobs returns the pool size for a configured job count: n itself when
+// positive, otherwise the number of CPUs.
+func Jobs(n int) int {
+	if n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
+
+// Errors collects the failures of a Run in task order.
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  " + strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return b.String()
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Run calls fn for every task index in [0, n) on at most jobs goroutines
+// (see Jobs). The first failure cancels the context passed to fn, and tasks
+// that have not started yet are skipped. Run returns an Errors holding every
+// failure in task order; failures that only report the cancellation are
+// left out when there are others. If ctx itself is cancelled, its error is
+// returned.
+func Run(ctx context.Context, jobs, n int, fn func(ctx context.Context, i int) error) error {
+	if n == 0 {
+		return ctx.Err()
+	}
+	jobs = min(Jobs(jobs), n)
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	tasks := make(chan int)
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				if taskCtx.Err() != nil {
+					continue
+				}
+				if err := fn(taskCtx, i); err != nil {
+					errs[i] = err
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		tasks <- i
+	}
+	close(tasks)
+	wg.Wait()
+
+	var failures, cancellations Errors
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case isCancellation(err):
+			cancellations = append(cancellations, err)
+		default:
+			failures = append(failures, err)
+		}
+	}
+	switch {
+	case len(failures) > 0:
+		return failures
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case len(cancellations) > 0:
+		return cancellations
+	}
+	return nil
+}
+
+func isCancellation(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
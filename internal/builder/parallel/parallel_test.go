@@ -0,0 +1,80 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	results := make([]int, 100)
+	err := Run(context.Background(), 4, len(results), func(ctx context.Context, i int) error {
+		results[i] = i * i
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for i, r := range results {
+		if r != i*i {
+			t.Fatalf("Task %d: got %d", i, r)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	// All tasks start before any fails. Tasks 3 and 7 fail and the others
+	// wait for the cancellation and report it.
+	var ready sync.WaitGroup
+	ready.Add(10)
+	err := Run(context.Background(), 10, 10, func(ctx context.Context, i int) error {
+		ready.Done()
+		ready.Wait()
+		if i == 3 || i == 7 {
+			return fmt.Errorf("task %d failed", i)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected Errors, got %v", err)
+	}
+	if len(errs) != 2 || errs[0].Error() != "task 3 failed" || errs[1].Error() != "task 7 failed" {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+	if !strings.HasPrefix(err.Error(), "2 errors:") {
+		t.Errorf("Unexpected report: %s", err)
+	}
+}
+
+func TestRunSkipsAfterFailure(t *testing.T) {
+	var ran atomic.Int32
+	err := Run(context.Background(), 1, 10, func(ctx context.Context, i int) error {
+		ran.Add(1)
+		return fmt.Errorf("task %d failed", i)
+	})
+	if err == nil || err.Error() != "task 0 failed" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ran.Load() != 1 {
+		t.Errorf("Expected remaining tasks to be skipped, %d ran", ran.Load())
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Run(ctx, 2, 5, func(ctx context.Context, i int) error {
+		t.Errorf("Task %d ran after cancellation", i)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package sourcemap
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DecodeMappings parses the VLQ "mappings" field. It is the inverse of
+// EncodeMappings; segments without a source get Source and Name -1.
+func DecodeMappings(mappings string) ([]Mapping, error) {
+	var result []Mapping
+	line, column := 0, 0
+	source, origLine, origColumn, name := 0, 0, 0, 0
+
+	pos := 0
+	for pos < len(mappings) {
+		switch mappings[pos] {
+		case ';':
+			line++
+			column = 0
+			pos++
+			continue
+		case ',':
+			pos++
+			continue
+		}
+
+		var fields [5]int
+		n := 0
+		for pos < len(mappings) && mappings[pos] != ',' && mappings[pos] != ';' {
+			if n == len(fields) {
+				return nil, fmt.Errorf("line %d: segment has more than 5 fields", line+1)
+			}
+			value, next, err := decodeVLQ(mappings, pos)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line+1, err)
+			}
+			fields[n] = value
+			n++
+			pos = next
+		}
+
+		column += fields[0]
+		m := Mapping{GeneratedLine: line, GeneratedColumn: column, Source: -1, Name: -1}
+		switch n {
+		case 1:
+		case 4, 5:
+			source += fields[1]
+			origLine += fields[2]
+			origColumn += fields[3]
+			m.Source, m.OriginalLine, m.OriginalColumn = source, origLine, origColumn
+			if n == 5 {
+				name += fields[4]
+				m.Name = name
+			}
+		default:
+			return nil, fmt.Errorf("line %d: segment has %d fields", line+1, n)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Position is a location in an original source. Line and Column are
+// one-based, as in stack traces.
+type Position struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Consumer answers original-position queries against a parsed source map.
+type Consumer struct {
+	sm    *SourceMap
+	lines [][]Mapping
+}
+
+func NewConsumer(sm *SourceMap) (*Consumer, error) {
+	if sm.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", sm.Version)
+	}
+	mappings, err := DecodeMappings(sm.Mappings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mappings: %w", err)
+	}
+
+	c := &Consumer{sm: sm}
+	for _, m := range mappings {
+		for len(c.lines) <= m.GeneratedLine {
+			c.lines = append(c.lines, nil)
+		}
+		c.lines[m.GeneratedLine] = append(c.lines[m.GeneratedLine], m)
+	}
+	for _, segs := range c.lines {
+		sort.SliceStable(segs, func(i, j int) bool { return segs[i].GeneratedColumn < segs[j].GeneratedColumn })
+	}
+	return c, nil
+}
+
+// OriginalPosition maps a one-based generated line and column to the
+// original source. It uses the closest segment at or before the column, the
+// way browsers and error trackers do.
+func (c *Consumer) OriginalPosition(line, column int) (Position, bool) {
+	m, ok := c.segment(line-1, column-1)
+	if !ok {
+		return Position{}, false
+	}
+	pos := Position{
+		Source: c.SourcePath(m.Source),
+		Line:   m.OriginalLine + 1,
+		Column: m.OriginalColumn + 1,
+	}
+	if m.Name >= 0 && m.Name < len(c.sm.Names) {
+		pos.Name = c.sm.Names[m.Name]
+	}
+	return pos, true
+}
+
+// segment returns the mapping closest at or before a zero-based generated
+// line and column.
+func (c *Consumer) segment(line, column int) (Mapping, bool) {
+	if line < 0 || line >= len(c.lines) {
+		return Mapping{}, false
+	}
+	segs := c.lines[line]
+	i := sort.Search(len(segs), func(i int) bool { return segs[i].GeneratedColumn > column }) - 1
+	if i < 0 || segs[i].Source < 0 || segs[i].Source >= len(c.sm.Sources) {
+		return Mapping{}, false
+	}
+	return segs[i], true
+}
+
+// Chain maps sm, the source map of a file generated from the one c
+// describes, through c, so the result points at the original sources.
+// Segments on unmapped code are dropped. Names of the original sources win
+// over the ones sm records.
+func (c *Consumer) Chain(sm *SourceMap) (*SourceMap, error) {
+	mappings, err := DecodeMappings(sm.Mappings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mappings: %w", err)
+	}
+	g := NewGenerator(sm.File)
+	for _, m := range mappings {
+		if m.Source < 0 {
+			continue
+		}
+		orig, ok := c.segment(m.OriginalLine, m.OriginalColumn)
+		if !ok {
+			continue
+		}
+		content := ""
+		if orig.Source < len(c.sm.SourcesContent) {
+			content = c.sm.SourcesContent[orig.Source]
+		}
+		chained := Mapping{
+			GeneratedLine:   m.GeneratedLine,
+			GeneratedColumn: m.GeneratedColumn,
+			Source:          g.AddSource(c.sm.Sources[orig.Source], content),
+			OriginalLine:    orig.OriginalLine,
+			OriginalColumn:  orig.OriginalColumn,
+			Name:            -1,
+		}
+		switch {
+		case orig.Name >= 0 && orig.Name < len(c.sm.Names):
+			chained.Name = g.AddName(c.sm.Names[orig.Name])
+		case m.Name >= 0 && m.Name < len(sm.Names):
+			chained.Name = g.AddName(sm.Names[m.Name])
+		}
+		g.AddMapping(chained)
+	}
+	chainedMap := g.SourceMap(len(c.sm.SourcesContent) > 0)
+	chainedMap.SourceRoot = c.sm.SourceRoot
+	return chainedMap, nil
+}
+
+// Segments returns the mappings of a zero-based generated line, ordered by
+// column.
+func (c *Consumer) Segments(line int) []Mapping {
+	if line < 0 || line >= len(c.lines) {
+		return nil
+	}
+	return c.lines[line]
+}
+
+// SourcePath returns the path of the source at idx with sourceRoot applied.
+func (c *Consumer) SourcePath(idx int) string {
+	source := c.sm.Sources[idx]
+	if c.sm.SourceRoot != "" && !strings.Contains(source, "://") && !strings.HasPrefix(source, "/") {
+		return strings.TrimSuffix(c.sm.SourceRoot, "/") + "/" + source
+	}
+	return source
+}
+
+// SourceContent returns the embedded content of source, if the map carries
+// sourcesContent.
+func (c *Consumer) SourceContent(source string) (string, bool) {
+	for i := range c.sm.Sources {
+		if c.SourcePath(i) == source && i < len(c.sm.SourcesContent) {
+			return c.sm.SourcesContent[i], true
+		}
+	}
+	return "", false
+}
+
+var sourceMappingURL = regexp.MustCompile(`[#@] sourceMappingURL=(\S+)\s*(?:\*/)?\s*$`)
+
+// MappingURL returns the URL named by the sourceMappingURL comment on the
+// last line of a script or stylesheet, or "" if there is none.
+func MappingURL(code string) string {
+	tail := strings.TrimRight(code, "\n\r\t ")
+	if i := strings.LastIndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	m := sourceMappingURL.FindStringSubmatch(tail)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
@@ -52,7 +52,24 @@ func (g *DebugSymbolGenerator) parseSourceMap(symbol *DebugSymbol) error {
 		return fmt.Errorf("failed to parse source map: %w", err)
 	}
 
-	// Implement VLQ decoding and mapping generation
-	// This is a simplified version, you'll need to implement the full VLQ decoder
+	mappings, err := DecodeMappings(sourceMap.Mappings)
+	if err != nil {
+		return fmt.Errorf("failed to decode mappings: %w", err)
+	}
+
+	// LineMapping keeps the first original line of each generated line;
+	// Variables maps generated positions to the original names found there.
+	// Both use one-based lines and columns.
+	for _, m := range mappings {
+		if m.Source < 0 {
+			continue
+		}
+		if _, ok := symbol.LineMapping[m.GeneratedLine+1]; !ok {
+			symbol.LineMapping[m.GeneratedLine+1] = m.OriginalLine + 1
+		}
+		if m.Name >= 0 && m.Name < len(sourceMap.Names) {
+			symbol.Variables[fmt.Sprintf("%d:%d", m.GeneratedLine+1, m.GeneratedColumn+1)] = sourceMap.Names[m.Name]
+		}
+	}
 	return nil
 }
@@ -0,0 +1,128 @@
+package sourcemap
+
+import (
+	"sort"
+	"strings"
+)
+
+// Mapping ties a position in generated code to a position in an original
+// source. Lines and columns are zero-based. Source and Name index into the
+// map's Sources and Names; a negative Source marks generated-only code and a
+// negative Name means the segment carries no name.
+type Mapping struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	Source          int
+	OriginalLine    int
+	OriginalColumn  int
+	Name            int
+}
+
+// Generator accumulates mappings for one generated file and renders them as
+// a version 3 source map.
+type Generator struct {
+	file        string
+	sources     []string
+	contents    []string
+	sourceIndex map[string]int
+	names       []string
+	nameIndex   map[string]int
+	mappings    []Mapping
+}
+
+func NewGenerator(file string) *Generator {
+	return &Generator{
+		file:        file,
+		sourceIndex: make(map[string]int),
+		nameIndex:   make(map[string]int),
+	}
+}
+
+// AddSource registers an original source and returns its index. Adding the
+// same path twice returns the existing index.
+func (g *Generator) AddSource(path, content string) int {
+	if idx, ok := g.sourceIndex[path]; ok {
+		return idx
+	}
+	g.sourceIndex[path] = len(g.sources)
+	g.sources = append(g.sources, path)
+	g.contents = append(g.contents, content)
+	return len(g.sources) - 1
+}
+
+// AddName registers a symbol name and returns its index.
+func (g *Generator) AddName(name string) int {
+	if idx, ok := g.nameIndex[name]; ok {
+		return idx
+	}
+	g.nameIndex[name] = len(g.names)
+	g.names = append(g.names, name)
+	return len(g.names) - 1
+}
+
+func (g *Generator) AddMapping(m Mapping) {
+	g.mappings = append(g.mappings, m)
+}
+
+// SourceMap renders the collected mappings. When includeContent is false the
+// sourcesContent field is omitted.
+func (g *Generator) SourceMap(includeContent bool) *SourceMap {
+	sm := &SourceMap{
+		Version:  3,
+		File:     g.file,
+		Sources:  append([]string{}, g.sources...),
+		Names:    append([]string{}, g.names...),
+		Mappings: EncodeMappings(g.mappings),
+	}
+	if includeContent {
+		sm.SourcesContent = append([]string{}, g.contents...)
+	}
+	return sm
+}
+
+// EncodeMappings serialises mappings into the VLQ "mappings" field.
+func EncodeMappings(mappings []Mapping) string {
+	sorted := append([]Mapping{}, mappings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].GeneratedLine != sorted[j].GeneratedLine {
+			return sorted[i].GeneratedLine < sorted[j].GeneratedLine
+		}
+		return sorted[i].GeneratedColumn < sorted[j].GeneratedColumn
+	})
+
+	var b strings.Builder
+	line, prevColumn := 0, 0
+	prevSource, prevOrigLine, prevOrigColumn, prevName := 0, 0, 0, 0
+	first := true
+
+	for _, m := range sorted {
+		for line < m.GeneratedLine {
+			b.WriteByte(';')
+			line++
+			prevColumn = 0
+			first = true
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+
+		encodeVLQ(&b, m.GeneratedColumn-prevColumn)
+		prevColumn = m.GeneratedColumn
+		if m.Source < 0 {
+			continue
+		}
+
+		encodeVLQ(&b, m.Source-prevSource)
+		encodeVLQ(&b, m.OriginalLine-prevOrigLine)
+		encodeVLQ(&b, m.OriginalColumn-prevOrigColumn)
+		prevSource, prevOrigLine, prevOrigColumn = m.Source, m.OriginalLine, m.OriginalColumn
+
+		if m.Name >= 0 {
+			encodeVLQ(&b, m.Name-prevName)
+			prevName = m.Name
+		}
+	}
+
+	return b.String()
+}
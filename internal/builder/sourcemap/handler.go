@@ -5,9 +5,14 @@ import (
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type SourceMapHandler struct {
@@ -52,5 +57,140 @@ func (h *SourceMapHandler) StoreSourceMap(filename string, data []byte) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	return os.WriteFile(versionedPath, compressed, 0644)
+	if err := os.WriteFile(versionedPath, compressed, 0644); err != nil {
+		return err
+	}
+
+	// Stored maps are named by content hash, so record which generated file
+	// each one belongs to.
+	index, err := h.readIndex()
+	if err != nil {
+		return err
+	}
+	index[filepath.ToSlash(filename)] = filepath.Base(versionedPath)
+	return h.writeIndex(index)
+}
+
+// LoadSourceMap returns the stored map for a generated file. The name may be
+// the stored filename, a path or URL ending in it, or a base name that
+// matches exactly one stored file.
+func (h *SourceMapHandler) LoadSourceMap(name string) (*SourceMap, string, error) {
+	index, err := h.readIndex()
+	if err != nil {
+		return nil, "", err
+	}
+	filename, ok := matchStoredFile(index, name)
+	if !ok {
+		return nil, "", fmt.Errorf("no source map stored for %s in release %s", name, h.version)
+	}
+
+	f, err := os.Open(filepath.Join(h.storageDir, h.version, index[filename]))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open source map for %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decompress source map for %s: %w", filename, err)
+	}
+	defer gz.Close()
+
+	var sm SourceMap
+	if err := json.NewDecoder(gz).Decode(&sm); err != nil {
+		return nil, "", fmt.Errorf("invalid source map for %s: %w", filename, err)
+	}
+	return &sm, filename, nil
+}
+
+// StoredFiles lists the generated files with a stored map, sorted.
+func (h *SourceMapHandler) StoredFiles() ([]string, error) {
+	index, err := h.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(index))
+	for name := range index {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Versions lists the release versions stored under storageDir.
+func Versions(storageDir string) ([]string, error) {
+	entries, err := os.ReadDir(storageDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source map storage: %w", err)
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	return versions, nil
+}
+
+const indexName = "index.json"
+
+func (h *SourceMapHandler) readIndex() (map[string]string, error) {
+	index := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(h.storageDir, h.version, indexName))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source map index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid source map index: %w", err)
+	}
+	return index, nil
+}
+
+func (h *SourceMapHandler) writeIndex(index map[string]string) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(h.storageDir, h.version, indexName), data, 0644)
+}
+
+// matchStoredFile finds the index key for name, which may be a URL with a
+// query string.
+func matchStoredFile(index map[string]string, name string) (string, bool) {
+	if u, err := url.Parse(name); err == nil && u.Scheme != "" {
+		name = u.Path
+	}
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+
+	if _, ok := index[name]; ok {
+		return name, true
+	}
+
+	var suffix, base []string
+	for key := range index {
+		switch {
+		case strings.HasSuffix(name, "/"+strings.TrimPrefix(key, "/")):
+			suffix = append(suffix, key)
+		case path.Base(key) == path.Base(name):
+			base = append(base, key)
+		}
+	}
+	// Prefer the longest stored path that the name ends with.
+	if len(suffix) > 0 {
+		sort.Slice(suffix, func(i, j int) bool { return len(suffix[i]) > len(suffix[j]) })
+		return suffix[0], true
+	}
+	if len(base) == 1 {
+		return base[0], true
+	}
+	return "", false
 }
@@ -0,0 +1,171 @@
+package sourcemap
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is one line of a stack trace. Lines without a file:line:column
+// location have an empty File.
+type Frame struct {
+	Raw      string `json:"raw"`
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// frameLocation matches "file:line:column" where file may be a URL. It
+// covers V8 ("at fn (url:1:2)"), Firefox and Safari ("fn@url:1:2") and bare
+// locations.
+var frameLocation = regexp.MustCompile(`((?:[A-Za-z][\w+.-]*://)?[^\s()@]+):(\d+):(\d+)`)
+
+// ParseFrame extracts the location and function name from a stack trace
+// line.
+func ParseFrame(line string) Frame {
+	frame := Frame{Raw: line}
+	matches := frameLocation.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return frame
+	}
+	m := matches[len(matches)-1]
+	frame.File = line[m[2]:m[3]]
+	frame.Line, _ = strconv.Atoi(line[m[4]:m[5]])
+	frame.Column, _ = strconv.Atoi(line[m[6]:m[7]])
+
+	prefix := strings.TrimSpace(line[:m[0]])
+	switch {
+	case strings.HasPrefix(prefix, "at "):
+		frame.Function = strings.TrimSpace(strings.TrimSuffix(prefix[3:], "("))
+	case strings.HasSuffix(prefix, "@"):
+		frame.Function = strings.TrimSuffix(prefix, "@")
+	}
+	return frame
+}
+
+// ContextLine is a line of original source shown around a resolved frame.
+type ContextLine struct {
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+	Current bool   `json:"current,omitempty"`
+}
+
+// ResolvedFrame is a frame together with its original location. Error
+// explains why a frame with a location could not be resolved.
+type ResolvedFrame struct {
+	Frame
+	Original *Position     `json:"original,omitempty"`
+	Context  []ContextLine `json:"context,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Resolver symbolicates stack traces with the maps stored by a
+// SourceMapHandler.
+type Resolver struct {
+	handler *SourceMapHandler
+	// SourceDir is searched for original sources when a map does not embed
+	// sourcesContent.
+	SourceDir string
+	// ContextLines is the number of lines shown before and after the
+	// original line.
+	ContextLines int
+
+	consumers map[string]*Consumer
+	errors    map[string]error
+}
+
+func NewResolver(handler *SourceMapHandler) *Resolver {
+	return &Resolver{
+		handler:      handler,
+		SourceDir:    ".",
+		ContextLines: 2,
+		consumers:    make(map[string]*Consumer),
+		errors:       make(map[string]error),
+	}
+}
+
+// ResolveTrace resolves every line of a stack trace read from r.
+func (r *Resolver) ResolveTrace(in io.Reader) ([]ResolvedFrame, error) {
+	var frames []ResolvedFrame
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		frames = append(frames, r.Resolve(ParseFrame(scanner.Text())))
+	}
+	return frames, scanner.Err()
+}
+
+// Resolve maps a single frame to its original location.
+func (r *Resolver) Resolve(frame Frame) ResolvedFrame {
+	resolved := ResolvedFrame{Frame: frame}
+	if frame.File == "" {
+		return resolved
+	}
+
+	consumer, err := r.consumer(frame.File)
+	if err != nil {
+		resolved.Error = err.Error()
+		return resolved
+	}
+	pos, ok := consumer.OriginalPosition(frame.Line, frame.Column)
+	if !ok {
+		resolved.Error = "no mapping for this position"
+		return resolved
+	}
+	resolved.Original = &pos
+	resolved.Context = r.context(consumer, pos)
+	return resolved
+}
+
+func (r *Resolver) consumer(file string) (*Consumer, error) {
+	if c, ok := r.consumers[file]; ok {
+		return c, nil
+	}
+	if err, ok := r.errors[file]; ok {
+		return nil, err
+	}
+
+	sm, _, err := r.handler.LoadSourceMap(file)
+	var c *Consumer
+	if err == nil {
+		c, err = NewConsumer(sm)
+	}
+	if err != nil {
+		r.errors[file] = err
+		return nil, err
+	}
+	r.consumers[file] = c
+	return c, nil
+}
+
+func (r *Resolver) context(c *Consumer, pos Position) []ContextLine {
+	if r.ContextLines < 0 {
+		return nil
+	}
+	content, ok := c.SourceContent(pos.Source)
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(r.SourceDir, filepath.FromSlash(pos.Source)))
+		if err != nil {
+			return nil
+		}
+		content = string(data)
+	}
+
+	lines := strings.Split(content, "\n")
+	first := max(pos.Line-r.ContextLines, 1)
+	last := min(pos.Line+r.ContextLines, len(lines))
+	var result []ContextLine
+	for n := first; n <= last; n++ {
+		result = append(result, ContextLine{
+			Line:    n,
+			Text:    strings.TrimRight(lines[n-1], "\r"),
+			Current: n == pos.Line,
+		})
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMappingsRoundTrip(t *testing.T) {
+	mappings := []Mapping{
+		{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 0, OriginalColumn: 0, Name: -1},
+		{GeneratedLine: 0, GeneratedColumn: 9, Source: 0, OriginalLine: 2, OriginalColumn: 4, Name: 0},
+		{GeneratedLine: 0, GeneratedColumn: 20, Source: -1, Name: -1},
+		{GeneratedLine: 2, GeneratedColumn: 3, Source: 1, OriginalLine: 0, OriginalColumn: 17, Name: 1},
+	}
+	decoded, err := DecodeMappings(EncodeMappings(mappings))
+	if err != nil {
+		t.Fatalf("DecodeMappings failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, mappings) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", decoded, mappings)
+	}
+
+	if _, err := DecodeMappings("AA!"); err == nil {
+		t.Error("Expected error for invalid VLQ data")
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		line string
+		want Frame
+	}{
+		{"    at render (https://cdn.example.com/assets/main.min.js:1:2345)", Frame{Function: "render", File: "https://cdn.example.com/assets/main.min.js", Line: 1, Column: 2345}},
+		{"    at https://cdn.example.com/main.js:3:4", Frame{File: "https://cdn.example.com/main.js", Line: 3, Column: 4}},
+		{"onClick@http://localhost:3000/assets/main.js:10:20", Frame{Function: "onClick", File: "http://localhost:3000/assets/main.js", Line: 10, Column: 20}},
+		{"TypeError: x is undefined", Frame{}},
+	}
+	for _, tt := range tests {
+		got := ParseFrame(tt.line)
+		tt.want.Raw = tt.line
+		if got != tt.want {
+			t.Errorf("ParseFrame(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestResolveStoredMap(t *testing.T) {
+	gen := NewGenerator("main.min.js")
+	src := gen.AddSource("src/app.js", "function greet() {\n  throw new Error('hi');\n}\n")
+	gen.AddMapping(Mapping{GeneratedLine: 0, GeneratedColumn: 0, Source: src, OriginalLine: 0, OriginalColumn: 0, Name: -1})
+	gen.AddMapping(Mapping{GeneratedLine: 0, GeneratedColumn: 14, Source: src, OriginalLine: 1, OriginalColumn: 2, Name: gen.AddName("greet")})
+	data, err := json.Marshal(gen.SourceMap(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := NewSourceMapHandler(dir, "1.2.0", true).StoreSourceMap("assets/main.min.js", data); err != nil {
+		t.Fatalf("StoreSourceMap failed: %v", err)
+	}
+
+	resolver := NewResolver(NewSourceMapHandler(dir, "1.2.0", true))
+	resolver.ContextLines = 1
+	frames, err := resolver.ResolveTrace(strings.NewReader("Error: hi\n    at a (https://example.com/assets/main.min.js?v=3:1:20)\n"))
+	if err != nil {
+		t.Fatalf("ResolveTrace failed: %v", err)
+	}
+	if len(frames) != 2 || frames[0].Original != nil {
+		t.Fatalf("Unexpected frames: %+v", frames)
+	}
+
+	got := frames[1]
+	want := Position{Source: "src/app.js", Line: 2, Column: 3, Name: "greet"}
+	if got.Original == nil || *got.Original != want {
+		t.Fatalf("Original = %+v, want %+v (error %q)", got.Original, want, got.Error)
+	}
+	if len(got.Context) != 3 || !got.Context[1].Current || got.Context[1].Text != "  throw new Error('hi');" {
+		t.Errorf("Unexpected context: %+v", got.Context)
+	}
+
+	missing := resolver.Resolve(ParseFrame("at vendor.js:1:1"))
+	if missing.Original != nil || missing.Error == "" {
+		t.Errorf("Expected unresolved frame for unknown file, got %+v", missing)
+	}
+}
+
+func TestChain(t *testing.T) {
+	input := &SourceMap{
+		Version:        3,
+		File:           "bundle.js",
+		Sources:        []string{"src/a.js"},
+		SourcesContent: []string{"a"},
+		Names:          []string{"original"},
+		Mappings: EncodeMappings([]Mapping{
+			{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 2, OriginalColumn: 4, Name: 0},
+			{GeneratedLine: 0, GeneratedColumn: 10, Source: 0, OriginalLine: 3, OriginalColumn: 0, Name: -1},
+		}),
+	}
+	minified := &SourceMap{
+		Version: 3,
+		File:    "bundle.js",
+		Sources: []string{"bundle.js"},
+		Names:   []string{"renamed"},
+		Mappings: EncodeMappings([]Mapping{
+			{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 0, OriginalColumn: 0, Name: 0},
+			{GeneratedLine: 0, GeneratedColumn: 5, Source: 0, OriginalLine: 0, OriginalColumn: 12, Name: 0},
+			{GeneratedLine: 0, GeneratedColumn: 8, Source: 0, OriginalLine: 5, OriginalColumn: 0, Name: -1},
+		}),
+	}
+
+	c, err := NewConsumer(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chained, err := c.Chain(minified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := DecodeMappings(chained.Mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mapping{
+		{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 2, OriginalColumn: 4, Name: 0},
+		{GeneratedLine: 0, GeneratedColumn: 5, Source: 0, OriginalLine: 3, OriginalColumn: 0, Name: 1},
+	}
+	if !reflect.DeepEqual(mappings, want) {
+		t.Errorf("Chained mappings = %+v, want %+v", mappings, want)
+	}
+	if !reflect.DeepEqual(chained.Sources, []string{"src/a.js"}) || !reflect.DeepEqual(chained.Names, []string{"original", "renamed"}) || !reflect.DeepEqual(chained.SourcesContent, []string{"a"}) {
+		t.Errorf("Unexpected chained map %+v", chained)
+	}
+}
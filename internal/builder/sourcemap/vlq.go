@@ -0,0 +1,63 @@
+package sourcemap
+
+import (
+	"fmt"
+	"strings"
+)
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+var base64Index = func() [256]int {
+	var index [256]int
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base64Chars); i++ {
+		index[base64Chars[i]] = i
+	}
+	return index
+}()
+
+// encodeVLQ appends the base64 VLQ encoding of value to b.
+func encodeVLQ(b *strings.Builder, value int) {
+	vlq := value << 1
+	if value < 0 {
+		vlq = (-value << 1) | 1
+	}
+	for {
+		digit := vlq & 31
+		vlq >>= 5
+		if vlq > 0 {
+			digit |= 32
+		}
+		b.WriteByte(base64Chars[digit])
+		if vlq == 0 {
+			return
+		}
+	}
+}
+
+// decodeVLQ decodes one value starting at pos and returns it together with
+// the position of the next unread character.
+func decodeVLQ(s string, pos int) (int, int, error) {
+	result, shift := 0, 0
+	for {
+		if pos >= len(s) {
+			return 0, pos, fmt.Errorf("unexpected end of VLQ data")
+		}
+		digit := base64Index[s[pos]]
+		if digit < 0 {
+			return 0, pos, fmt.Errorf("invalid VLQ character %q", s[pos])
+		}
+		pos++
+		result += (digit & 31) << shift
+		shift += 5
+		if digit&32 == 0 {
+			break
+		}
+	}
+	if result&1 == 1 {
+		return -(result >> 1), pos, nil
+	}
+	return result >> 1, pos, nil
+}
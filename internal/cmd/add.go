@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	templateengine "github.com/skbhati199/go-web-build/internal/template-engine"
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <feature> [project-dir]",
+	Short: "Add a feature to an existing project",
+	Long: `Add a feature to an existing React project by applying its template overlay.
+
+Features: ` + strings.Join(templateengine.FeatureNames(), ", ") + `
+
+Dependencies and scripts are merged into package.json. Files are only
+replaced while they are unchanged from how gobuild generated them; if a file
+has local changes nothing is written unless --force is given. The feature is
+recorded in ` + templateengine.RecordFile + `.`,
+	Example: `  # Add React Router to the project in the current directory
+  gobuild add router
+
+  # Preview adding Redux to another project
+  gobuild add redux ./myapp --dry-run`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeFeatures,
+	RunE:              runAdd,
+}
+
+func init() {
+	addCmd.Flags().Bool("force", false, "replace files with local changes and conflicting package.json entries")
+	addCmd.Flags().Bool("dry-run", false, "show the changes without writing them")
+
+	rootCmd.AddCommand(addCmd)
+}
+
+func completeFeatures(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return templateengine.FeatureNames(), cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.NewValidationError(fmt.Sprintf("project directory %s does not exist", dir), nil)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	opts := templateengine.FeatureOptions{}
+	opts.Force, _ = cmd.Flags().GetBool("force")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	manager := templateengine.NewManager(cfg.Templates.Directory)
+	result, err := manager.AddFeature(dir, args[0], opts)
+	if err != nil {
+		return errors.NewTemplateError(fmt.Sprintf("failed to add %s", args[0]), err)
+	}
+
+	conflicts := result.Conflicts()
+	err = printResult(result, func() error {
+		verb := "Added"
+		switch {
+		case conflicts > 0:
+			verb = "Cannot add"
+		case result.DryRun:
+			verb = "Would add"
+		}
+		fmt.Printf("%s %s (%s %s) to %s\n", verb, result.Feature, result.Overlay, result.Version, dir)
+		if len(result.Files) == 0 {
+			fmt.Println("Project already has every file of the feature")
+			return nil
+		}
+		fmt.Println()
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "ACTION\tPATH\tNOTE")
+		for _, f := range result.Files {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", f.Action, f.Path, f.Note)
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if conflicts > 0 {
+		return errors.NewValidationError(fmt.Sprintf("%d file(s) have local changes; nothing was written (use --force to replace them)", conflicts), nil)
+	}
+	if !result.DryRun && !result.Recorded {
+		progressf("Note: %s has no %s, so the feature was not recorded\n", dir, templateengine.RecordFile)
+	}
+	return nil
+}
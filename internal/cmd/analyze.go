@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/skbhati199/go-web-build/internal/builder/analyze"
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzeReportDir string
+	analyzeTop       int
+	analyzeSort      string
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [build-dir]",
+	Short: "Break down bundle size by source file and package",
+	Long: `Read the scripts and stylesheets in build-dir (build.out_dir by default)
+with their source maps and attribute every byte to the original source file
+and node_modules package it came from.
+
+Writes report.json and a self-contained treemap, report.html, to
+--report-dir and prints the largest packages. Bytes no source map covers,
+such as the module runtime, are reported as (unmapped).`,
+	Example: `  # Analyze the last build
+  gobuild build && gobuild analyze
+
+  # Rank the 20 largest packages by gzip size
+  gobuild analyze dist --top 20 --sort gzip`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().StringVar(&analyzeReportDir, "report-dir", filepath.Join("build", "analyze"), "directory to write report.json and report.html to")
+	analyzeCmd.Flags().IntVar(&analyzeTop, "top", 10, "number of packages to list")
+	analyzeCmd.Flags().StringVar(&analyzeSort, "sort", "size", "rank packages by minified size or gzip size (size, gzip)")
+	analyzeCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{"size", "gzip"}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	if analyzeSort != "size" && analyzeSort != "gzip" {
+		return errors.NewUsageError(fmt.Sprintf("unsupported --sort %q (expected size or gzip)", analyzeSort), nil)
+	}
+
+	dir := ""
+	if len(args) == 1 {
+		dir = args[0]
+	} else {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		dir = cfg.Build.OutDir
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.NewValidationError(fmt.Sprintf("build directory %s does not exist; run gobuild build first", dir), nil)
+	}
+
+	report, err := analyze.Analyze(dir)
+	if err != nil {
+		return errors.NewSystemError("failed to analyze build", err)
+	}
+	jsonPath, htmlPath, err := writeAnalyzeReports(report)
+	if err != nil {
+		return err
+	}
+
+	packages := append([]analyze.Package(nil), report.Packages...)
+	if analyzeSort == "gzip" {
+		sort.SliceStable(packages, func(i, j int) bool { return packages[i].Gzip > packages[j].Gzip })
+	}
+	if analyzeTop >= 0 && len(packages) > analyzeTop {
+		packages = packages[:analyzeTop]
+	}
+
+	result := map[string]interface{}{
+		"json":     jsonPath,
+		"html":     htmlPath,
+		"bytes":    report.Bytes,
+		"gzip":     report.Gzip,
+		"packages": packages,
+	}
+	return printResult(result, func() error {
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "PACKAGE\tMINIFIED\tGZIP\tFILES")
+		for _, p := range packages {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", p.Name, formatSize(p.Bytes), formatSize(p.Gzip), p.Sources)
+		}
+		fmt.Fprintf(w, "Total\t%s\t%s\t\n", formatSize(report.Bytes), formatSize(report.Gzip))
+		w.Flush()
+		fmt.Printf("\nReport: %s\nTreemap: %s\n", jsonPath, htmlPath)
+		return nil
+	})
+}
+
+// writeAnalyzeReports writes the JSON report and HTML treemap to
+// --report-dir.
+func writeAnalyzeReports(report *analyze.Report) (string, string, error) {
+	if err := os.MkdirAll(analyzeReportDir, 0755); err != nil {
+		return "", "", errors.NewSystemError("failed to create report directory", err)
+	}
+
+	jsonPath := filepath.Join(analyzeReportDir, "report.json")
+	htmlPath := filepath.Join(analyzeReportDir, "report.html")
+	for _, out := range []struct {
+		path  string
+		write func(*os.File) error
+	}{
+		{jsonPath, func(f *os.File) error { return analyze.WriteJSON(f, report) }},
+		{htmlPath, func(f *os.File) error { return analyze.WriteHTML(f, report) }},
+	} {
+		f, err := os.Create(out.path)
+		if err != nil {
+			return "", "", errors.NewSystemError("failed to create report", err)
+		}
+		err = out.write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", "", errors.NewSystemError(fmt.Sprintf("failed to write %s", out.path), err)
+		}
+	}
+	return jsonPath, htmlPath, nil
+}
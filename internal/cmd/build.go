@@ -2,7 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/builder/bundler"
+	"github.com/skbhati199/go-web-build/internal/builder/compress"
+	"github.com/skbhati199/go-web-build/internal/builder/images"
+	"github.com/skbhati199/go-web-build/internal/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -19,13 +26,11 @@ Supports various optimization options and build modes.`,
   gobuild build --mode development --sourcemap
 
   # Build with custom output directory
-  gobuild build --out-dir ./dist`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		mode, _ := cmd.Flags().GetString("mode")
+  gobuild build --out ./dist
 
-		fmt.Printf("Building project in %s mode\n", mode)
-		return nil
-	},
+  # Rebuild everything, bypassing the build cache
+  gobuild build --no-cache`,
+	RunE: runBuild,
 }
 
 func init() {
@@ -33,6 +38,166 @@ func init() {
 	buildCmd.Flags().StringP("out", "o", "dist", "output directory")
 	buildCmd.Flags().BoolP("minify", "M", true, "enable minification")
 	buildCmd.Flags().BoolP("sourcemap", "s", false, "generate source maps")
+	buildCmd.Flags().IntP("jobs", "j", 0, "maximum number of files processed in parallel (0 = one per CPU)")
+	buildCmd.Flags().Bool("no-cache", false, "ignore and do not update the build cache")
+	buildCmd.Flags().Bool("verbose", false, "report the modules and exports removed by tree shaking")
 
 	rootCmd.AddCommand(buildCmd)
 }
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	opts, err := buildOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	progressf("Building project in %s mode\n", opts.Mode)
+
+	result, err := builder.New().Run(cmd.Context(), opts)
+	if err != nil {
+		return errors.NewBuildError("build failed", err)
+	}
+
+	return printResult(buildResult{
+		Mode:       result.Mode,
+		OutDir:     result.OutDir,
+		Modules:    result.Modules,
+		Cache:      result.Cache,
+		Outputs:    result.Outputs,
+		Images:     result.Images,
+		Compressed: result.Compressed,
+		Removed:    result.Removed,
+		DurationMS: result.Duration.Milliseconds(),
+	}, func() error {
+		for _, out := range result.Outputs {
+			fmt.Printf("  %-48s %s\n", out.Path, formatSize(out.Size))
+		}
+		for _, c := range result.Compressed {
+			fmt.Printf("  %-48s %s (%.0f%% of %s)\n", c.Path, formatSize(c.Size), 100*float64(c.Size)/float64(c.Original), formatSize(c.Original))
+		}
+		cached := ""
+		if result.Cache.Reused > 0 {
+			cached = fmt.Sprintf(" (%d from cache)", result.Cache.Reused)
+		}
+		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose && len(result.Removed) > 0 {
+			printRemoved(result.Removed)
+		}
+		if img := result.Images; img.Optimized+img.Reused > 0 {
+			fmt.Printf("Optimized %d images (%d from cache, %d variants), saving %s\n", img.Optimized+img.Reused, img.Reused, img.Variants, formatSize(img.Saved))
+		}
+		fmt.Printf("Built %d modules%s into %s in %s\n", result.Modules, cached, result.OutDir, result.Duration.Round(time.Millisecond))
+		return nil
+	})
+}
+
+// buildResult is the structured output of gobuild build.
+type buildResult struct {
+	Mode       string               `json:"mode"`
+	OutDir     string               `json:"out_dir"`
+	Modules    int                  `json:"modules"`
+	Cache      bundler.CacheStats   `json:"cache"`
+	Outputs    []builder.OutputFile `json:"outputs"`
+	Images     builder.ImageStats   `json:"images"`
+	Compressed []compress.Result    `json:"compressed,omitempty"`
+	Removed    []bundler.Removal    `json:"removed,omitempty"`
+	DurationMS int64                `json:"duration_ms"`
+}
+
+// printRemoved reports what tree shaking left out of the bundle.
+func printRemoved(removed []bundler.Removal) {
+	modules, exports := 0, 0
+	for _, r := range removed {
+		if len(r.Exports) == 0 {
+			modules++
+			fmt.Printf("  removed %s\n", r.Module)
+		} else {
+			exports += len(r.Exports)
+			fmt.Printf("  removed from %s: %s\n", r.Module, strings.Join(r.Exports, ", "))
+		}
+	}
+	fmt.Printf("Tree shaking removed %d modules and %d unused exports\n", modules, exports)
+}
+
+// buildOptions merges BuildConfig with the command line. Flags only take
+// precedence when they are set explicitly.
+func buildOptions(cmd *cobra.Command) (builder.Options, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return builder.Options{}, err
+	}
+
+	flags := cmd.Flags()
+	mode, _ := flags.GetString("mode")
+	if mode != "development" && mode != "production" {
+		return builder.Options{}, errors.NewValidationError(fmt.Sprintf("invalid build mode %q", mode), nil)
+	}
+
+	opts := builder.Options{
+		Mode:      mode,
+		OutDir:    cfg.Build.OutDir,
+		Config:    cfgFile,
+		BaseDir:   ".",
+		Minify:    cfg.Build.Minify && mode == "production",
+		SourceMap: cfg.Build.SourceMap,
+		Jobs:      cfg.Build.ParallelJobs,
+		HTML:      cfg.Build.HTML,
+
+		Environment:    cfg.Environment,
+		EnvPrefixes:    cfg.Build.Env.Prefixes,
+		EnvDeclaration: cfg.Build.Env.Declaration,
+		Naming: builder.Naming{
+			Entry: cfg.Build.Naming.Entry,
+			Chunk: cfg.Build.Naming.Chunk,
+			CSS:   cfg.Build.Naming.CSS,
+			Asset: cfg.Build.Naming.Asset,
+		},
+	}
+	if flags.Changed("out") {
+		opts.OutDir, _ = flags.GetString("out")
+	}
+	if flags.Changed("minify") {
+		opts.Minify, _ = flags.GetBool("minify")
+	}
+	if flags.Changed("sourcemap") {
+		opts.SourceMap, _ = flags.GetBool("sourcemap")
+	}
+	opts.MinifyHTML = opts.Minify
+	if flags.Changed("jobs") {
+		opts.Jobs, _ = flags.GetInt("jobs")
+		if opts.Jobs < 0 {
+			return builder.Options{}, errors.NewValidationError("--jobs must not be negative", nil)
+		}
+	}
+	if noCache, _ := flags.GetBool("no-cache"); cfg.Build.Cache && !noCache {
+		opts.CacheDir = cfg.Build.CacheDir
+	}
+	if img := cfg.Build.Assets.Images; img.Optimize && mode == "production" {
+		opts.Images = &images.Options{Quality: img.Quality, StripMetadata: img.StripMetadata}
+		if img.Responsive {
+			opts.Images.Widths = img.Widths
+		}
+	}
+	if sp := cfg.Build.Splitting; sp.Enabled {
+		opts.Split = &bundler.SplitOptions{MinSize: sp.MinSize, MaxRequests: sp.MaxRequests}
+	}
+	opts.TreeShake = cfg.Build.TreeShaking && mode == "production"
+	if c := cfg.Build.Compression; c.Enabled && mode == "production" {
+		opts.Compression = &compress.Options{
+			Algorithm: c.Algorithm,
+			Level:     c.Level,
+			MinSize:   c.MinSize,
+			MinRatio:  c.MinRatio,
+		}
+	}
+	return opts, nil
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.2f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
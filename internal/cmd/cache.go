@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/pkg/cache"
+	"github.com/skbhati199/go-web-build/internal/pkg/maintenance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheDir string
+
+	cacheOldest int
+
+	pruneMaxAge  string
+	pruneMaxSize string
+	pruneDryRun  bool
+
+	verifyFix bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the build cache",
+	Long: `Inspect and manage the build cache stored in build.cache_dir.
+
+Each cache entry records the SHA-256 of its content; "verify" re-hashes the
+stored content to detect corruption.`,
+	Example: `  # Show cache size and hit ratio
+  gobuild cache stats
+
+  # Drop entries unused for a week and keep the cache under 500MB
+  gobuild cache prune --max-age 7d --max-size 500MB
+
+  # Remove corrupt entries
+  gobuild cache verify --fix`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show entry counts, size, hit ratio and the oldest entries",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old entries or shrink the cache to a size budget",
+	Long: `Remove entries not used within --max-age, then the least recently used
+entries until the cache fits in --max-size. At least one limit is required.`,
+	Args: cobra.NoArgs,
+	RunE: runCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cache entry",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check every entry against its stored content hash",
+	Long:  `Check every entry against its stored content hash. Exits non-zero if corrupt entries are found and --fix is not given.`,
+	Args:  cobra.NoArgs,
+	RunE:  runCacheVerify,
+}
+
+func init() {
+	cacheCmd.PersistentFlags().StringVarP(&cacheDir, "dir", "d", "", "cache directory (defaults to build.cache_dir from config)")
+
+	cacheStatsCmd.Flags().IntVar(&cacheOldest, "oldest", 5, "number of least recently used entries to list")
+
+	cachePruneCmd.Flags().StringVar(&pruneMaxAge, "max-age", "", "remove entries not used for this long (e.g. 36h, 7d)")
+	cachePruneCmd.Flags().StringVar(&pruneMaxSize, "max-size", "", "shrink the cache to at most this size (e.g. 500MB, 2GB)")
+	cachePruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "list the entries that would be removed without removing them")
+
+	cacheVerifyCmd.Flags().BoolVar(&verifyFix, "fix", false, "remove corrupt entries")
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// openCache resolves the cache directory and opens it.
+func openCache() (*cache.DiskCache, error) {
+	dir := cacheDir
+	if dir == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Build.CacheDir == "" {
+			return nil, errors.NewConfigError("build.cache_dir is not set; pass --dir", nil)
+		}
+		dir = cfg.Build.CacheDir
+	}
+
+	c, err := cache.OpenDiskCache(dir)
+	if err != nil {
+		return nil, errors.NewSystemError(fmt.Sprintf("failed to open cache in %s", dir), err)
+	}
+	return c, nil
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		return errors.NewSystemError("failed to read cache statistics", err)
+	}
+	oldest := c.Entries()
+	if cacheOldest >= 0 && len(oldest) > cacheOldest {
+		oldest = oldest[:cacheOldest]
+	}
+
+	result := map[string]interface{}{
+		"stats":     stats,
+		"hit_ratio": stats.HitRatio(),
+		"oldest":    oldest,
+	}
+	return printResult(result, func() error {
+		return printCacheStats(stats, oldest)
+	})
+}
+
+func printCacheStats(stats cache.Stats, oldest []cache.Entry) error {
+	w := newTable(os.Stdout)
+	fmt.Fprintf(w, "Directory:\t%s\n", stats.Dir)
+	fmt.Fprintf(w, "Entries:\t%d\n", stats.Entries)
+	fmt.Fprintf(w, "Total size:\t%s\n", formatSize(stats.TotalBytes))
+	if len(stats.Runs) > 0 {
+		fmt.Fprintf(w, "Hit ratio:\t%.1f%% (%d hits, %d misses over %d builds)\n",
+			stats.HitRatio()*100, stats.Hits, stats.Misses, len(stats.Runs))
+	} else {
+		fmt.Fprintf(w, "Hit ratio:\tno builds recorded\n")
+	}
+	w.Flush()
+
+	if len(oldest) == 0 {
+		return nil
+	}
+	fmt.Println("\nOldest entries:")
+	w = newTable(os.Stdout)
+	fmt.Fprintln(w, "  LAST USED\tSIZE\tKEY")
+	for _, e := range oldest {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", e.AccessedAt.Format(time.RFC3339), formatSize(e.Size), e.Key)
+	}
+	return w.Flush()
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	var opts cache.PruneOptions
+	var err error
+	if pruneMaxAge != "" {
+		if opts.MaxAge, err = maintenance.ParseDuration(pruneMaxAge); err != nil {
+			return errors.NewValidationError("invalid --max-age", err)
+		}
+		if opts.MaxAge < 0 {
+			return errors.NewValidationError(fmt.Sprintf("invalid --max-age %q: must not be negative", pruneMaxAge), nil)
+		}
+	}
+	if pruneMaxSize != "" {
+		if opts.MaxBytes, err = parseByteSize(pruneMaxSize); err != nil {
+			return errors.NewValidationError("invalid --max-size", err)
+		}
+	}
+	if opts.MaxAge == 0 && opts.MaxBytes == 0 {
+		return errors.NewValidationError("prune needs --max-age or --max-size", nil)
+	}
+	opts.DryRun = pruneDryRun
+
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+	removed, err := c.Prune(opts)
+	if err != nil {
+		return errors.NewSystemError("failed to prune cache", err)
+	}
+
+	var freed int64
+	for _, e := range removed {
+		freed += e.Size
+	}
+	result := map[string]interface{}{
+		"dry_run":     opts.DryRun,
+		"removed":     removed,
+		"freed_bytes": freed,
+	}
+	return printResult(result, func() error {
+		verb := "Removed"
+		if opts.DryRun {
+			verb = "Would remove"
+			for _, e := range removed {
+				fmt.Printf("  %s\t%s\n", formatSize(e.Size), e.Key)
+			}
+		}
+		fmt.Printf("%s %d entries (%s)\n", verb, len(removed), formatSize(freed))
+		return nil
+	})
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		return errors.NewSystemError("failed to read cache statistics", err)
+	}
+	if err := c.Clear(); err != nil {
+		return errors.NewSystemError("failed to clear cache", err)
+	}
+
+	result := map[string]interface{}{
+		"removed":     stats.Entries,
+		"freed_bytes": stats.TotalBytes,
+	}
+	return printResult(result, func() error {
+		fmt.Printf("Removed %d entries (%s) from %s\n", stats.Entries, formatSize(stats.TotalBytes), c.Dir())
+		return nil
+	})
+}
+
+func runCacheVerify(cmd *cobra.Command, args []string) error {
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+	total := len(c.Entries())
+	problems, err := c.Verify(verifyFix)
+	if err != nil {
+		return errors.NewSystemError("failed to verify cache", err)
+	}
+
+	result := map[string]interface{}{
+		"checked":  total,
+		"problems": problems,
+		"fixed":    verifyFix && len(problems) > 0,
+	}
+	err = printResult(result, func() error {
+		for _, p := range problems {
+			fmt.Printf("  corrupt  %s: %s\n", p.Key, p.Reason)
+		}
+		fmt.Printf("Checked %d entries, %d corrupt\n", total, len(problems))
+		if verifyFix && len(problems) > 0 {
+			fmt.Printf("Removed %d corrupt entries\n", len(problems))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 && !verifyFix {
+		return errors.NewValidationError(fmt.Sprintf("%d corrupt cache entries; run with --fix to remove them", len(problems)), nil)
+	}
+	return nil
+}
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses sizes such as "512", "200KB" or "1.5GB" using binary
+// units, matching formatSize.
+func parseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if trimmed, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(trimmed), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
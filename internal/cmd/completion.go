@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/template-engine/version"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for bash, zsh or fish. Besides commands and
+flags, the scripts complete framework, template and template version names
+from the configured templates directory.`,
+	Example: `  # Load completions in the current bash session
+  source <(gobuild completion bash)
+
+  # Install zsh completions
+  gobuild completion zsh > "${fpath[1]}/_gobuild"
+
+  # Install fish completions
+  gobuild completion fish > ~/.config/fish/completions/gobuild.fish`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE:      runCompletion,
+}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	for _, c := range []*cobra.Command{templatesInfoCmd, templatesVersionsCmd, templatesValidateCmd} {
+		c.ValidArgsFunction = completeTemplateNames
+	}
+
+	rootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	}
+	return errors.NewUsageError("unsupported shell "+args[0]+" (expected bash, zsh or fish)", nil)
+}
+
+// templateNames lists the templates projects can be created from, or
+// nothing when the templates directory cannot be loaded.
+func templateNames() []string {
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return nil
+	}
+	return registry.ProjectNames()
+}
+
+// splitTemplateName splits "react-typescript" or "react/typescript" into
+// framework and variant.
+func splitTemplateName(name string) (string, string) {
+	if i := strings.IndexAny(name, "-/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+func completeTemplateNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd != templatesValidateCmd && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return templateNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeFrameworks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	seen := make(map[string]bool)
+	var frameworks []string
+	for _, name := range templateNames() {
+		fw, _ := splitTemplateName(name)
+		if !seen[fw] {
+			seen[fw] = true
+			frameworks = append(frameworks, fw)
+		}
+	}
+	sort.Strings(frameworks)
+	return frameworks, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeTemplateVariants(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var variants []string
+	for _, name := range templateNames() {
+		fw, variant := splitTemplateName(name)
+		if variant != "" && (framework == "" || fw == framework) {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Strings(variants)
+	return variants, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTemplateVersions completes the versions of the template selected
+// by --framework and --template, from versions.json when the template has
+// one and template.json otherwise.
+func completeTemplateVersions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if framework == "" || templateName == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	name := framework + "-" + templateName
+	t, err := registry.Get(name)
+	if err != nil {
+		if t, err = registry.Get(framework + "/" + templateName); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		name = t.Name
+	}
+
+	versions, err := version.NewVersionManager(registry.BasePath).GetAllVersions(name)
+	if err != nil || len(versions) == 0 {
+		return []string{t.Version}, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		names = append(names, versions[i].String())
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
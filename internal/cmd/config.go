@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/config"
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "********"
+
+var configShowSecrets bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the effective configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the merged configuration and where each value came from",
+	Long: `Show the configuration after merging built-in defaults, config/default.yaml,
+config/<env>.yaml and the --config file, as every other command loads it.
+
+Every key is annotated with the layer that set it. Values whose key or shape
+looks like a credential are redacted unless --show-secrets is given.
+
+The text and yaml formats print the configuration as YAML with the source of
+each value as a comment; json prints a list of key, value and source.`,
+	Example: `  # Show the production configuration
+  gobuild config show --env production
+
+  # Machine readable output
+  gobuild config show --output json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+func init() {
+	configShowCmd.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "print secret values instead of redacting them")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	settings, err := config.Explain(cfgFile, env)
+	if err != nil {
+		return errors.NewConfigError("failed to load configuration", err)
+	}
+	if !configShowSecrets {
+		for i, s := range settings {
+			if config.LooksSecret(s.Key, s.Value) {
+				settings[i].Value = redacted
+			}
+		}
+	}
+
+	if outputFormat == outputJSON {
+		return printJSON(settings)
+	}
+
+	doc, err := settingsYAML(settings)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(doc)
+}
+
+// settingsYAML nests dotted keys into a YAML document and records each
+// value's source as a line comment.
+func settingsYAML(settings []config.Setting) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings {
+		parent := root
+		parts := strings.Split(s.Key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			parent = childMapping(parent, part)
+		}
+
+		value := &yaml.Node{}
+		if err := value.Encode(s.Value); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", s.Key, err)
+		}
+		value.LineComment = "from " + s.Source
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}
+		parent.Content = append(parent.Content, key, value)
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil
+}
+
+// childMapping returns the mapping stored under key in parent, creating it
+// if needed.
+func childMapping(parent *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			return parent.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child
+}
@@ -5,13 +5,15 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/skbhati199/go-web-build/internal/errors"
 	templateengine "github.com/skbhati199/go-web-build/internal/template-engine"
 	"github.com/spf13/cobra"
 )
 
 var (
-	framework    string
-	templateName string
+	framework       string
+	templateName    string
+	templateVersion string
 )
 
 func init() {
@@ -31,33 +33,50 @@ func init() {
 	// Add flags
 	createCmd.Flags().StringVarP(&framework, "framework", "f", "", "Framework to use (react, vue, next)")
 	createCmd.Flags().StringVarP(&templateName, "template", "t", "", "Template to use (javascript, typescript)")
+	createCmd.Flags().StringVar(&templateVersion, "template-version", "", "Template version (defaults to the latest)")
 
 	// Mark required flags
 	createCmd.MarkFlagRequired("framework")
 
+	createCmd.RegisterFlagCompletionFunc("framework", completeFrameworks)
+	createCmd.RegisterFlagCompletionFunc("template", completeTemplateVariants)
+	createCmd.RegisterFlagCompletionFunc("template-version", completeTemplateVersions)
+
 	rootCmd.AddCommand(createCmd)
 }
 
+// createResult is the structured output of create.
+type createResult struct {
+	Name      string `json:"name"`
+	Framework string `json:"framework"`
+	Template  string `json:"template"`
+	Version   string `json:"version,omitempty"`
+	Path      string `json:"path"`
+}
+
 func runCreate(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("project name is required")
+		return errors.NewUsageError("project name is required", nil)
 	}
 
 	projectName := args[0]
 
-	// Enable debug output
-	fmt.Printf("Creating project: %s\nFramework: %s\nTemplate: %s\n", projectName, framework, templateName)
+	progressf("Creating project: %s\nFramework: %s\nTemplate: %s\n", projectName, framework, templateName)
 
-	// Create template manager with proper path handling
-	templatesDir := filepath.Join("/Users/sonukumar/go-web-build", "templates")
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	templatesDir := cfg.Templates.Directory
 
 	// Try both directory structures
-	templatePath := filepath.Join(templatesDir, fmt.Sprintf("%s-%s", framework, templateName))
+	name := fmt.Sprintf("%s-%s", framework, templateName)
+	templatePath := filepath.Join(templatesDir, name)
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
 		// Try nested structure
 		templatePath = filepath.Join(templatesDir, framework, fmt.Sprintf("react-%s", templateName))
 		if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-			return fmt.Errorf("template not found: %s-%s", framework, templateName)
+			return errors.NewTemplateError(fmt.Sprintf("template not found: %s in %s", name, templatesDir), nil)
 		}
 	}
 
@@ -65,11 +84,21 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	manager := templateengine.NewManager(templatesDir)
 
 	// Create project with specified template
-	err := manager.CreateProject(projectName, framework, templateName, "", nil)
+	err = manager.CreateProject(projectName, framework, templateName, templateVersion, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create project: %w", err)
+		return errors.NewTemplateError("failed to create project", err)
 	}
 
-	fmt.Printf("Successfully created project %s\n", projectName)
-	return nil
+	path, _ := filepath.Abs(projectName)
+	result := createResult{
+		Name:      projectName,
+		Framework: framework,
+		Template:  name,
+		Version:   templateVersion,
+		Path:      path,
+	}
+	return printResult(result, func() error {
+		fmt.Printf("Successfully created project %s\n", projectName)
+		return nil
+	})
 }
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/pkg/serverless"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deployTarget   string
+	deployManifest string
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Deploy serverless functions",
+	Long: `Deploy the functions and triggers declared in the "deploy" section of
+gobuild.yaml to a serverless provider.
+
+Manifest example:
+  deploy:
+    provider:
+      type: aws
+      region: us-east-1
+      defaults:
+        runtime: nodejs18.x
+        memory: 128
+        timeout: 10
+    functions:
+      - name: api
+        handler: index.handler
+        code_path: functions/api
+    triggers:
+      - type: http
+        function: api
+        properties:
+          path: /api`,
+	Example: `  # Deploy everything in gobuild.yaml
+  gobuild deploy --target aws
+
+  # Show the status of the deployed functions as JSON
+  gobuild deploy status --output json
+
+  # Remove a single function
+  gobuild deploy remove api`,
+	Args: cobra.NoArgs,
+	RunE: runDeploy,
+}
+
+var deployRemoveCmd = &cobra.Command{
+	Use:   "remove [function...]",
+	Short: "Remove deployed functions",
+	Long:  `Remove the named functions, or every function in the manifest when none are given.`,
+	RunE:  runDeployRemove,
+}
+
+var deployStatusCmd = &cobra.Command{
+	Use:   "status [function...]",
+	Short: "Show deployment status",
+	Long:  `Show the status of the named functions, or of every function in the manifest when none are given.`,
+	RunE:  runDeployStatus,
+}
+
+func init() {
+	deployCmd.PersistentFlags().StringVarP(&deployTarget, "target", "t", "", "provider to deploy to, overrides deploy.provider.type")
+	deployCmd.PersistentFlags().StringVarP(&deployManifest, "file", "f", "gobuild.yaml", "deploy manifest")
+
+	deployCmd.AddCommand(deployRemoveCmd)
+	deployCmd.AddCommand(deployStatusCmd)
+	rootCmd.AddCommand(deployCmd)
+}
+
+// newDeployer loads the manifest and configures the provider.
+func newDeployer() (*serverless.Deployer, *serverless.Config, error) {
+	config, err := serverless.LoadConfig(deployManifest)
+	if err != nil {
+		return nil, nil, errors.NewConfigError("failed to load deploy manifest", err)
+	}
+	if deployTarget != "" {
+		config.ProviderConfig.Type = deployTarget
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, errors.NewValidationError("invalid deploy manifest", err)
+	}
+
+	deployer, err := serverless.NewDeployer(*config)
+	if err != nil {
+		return nil, nil, errors.NewSystemError("failed to initialize deployer", err)
+	}
+	return deployer, config, nil
+}
+
+func runDeploy(cmd *cobra.Command, args []string) error {
+	deployer, config, err := newDeployer()
+	if err != nil {
+		return err
+	}
+
+	progressf("Deploying %d function(s) to %s\n", len(config.Functions), config.ProviderConfig.Type)
+	result, err := deployer.Deploy(cmd.Context())
+	if err != nil {
+		return errors.NewSystemError("deployment failed", err)
+	}
+
+	return printResult(result, func() error {
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "TYPE\tNAME")
+		for _, resource := range result.Resources {
+			fmt.Fprintf(w, "%s\t%s\n", resource.Type, resource.Name)
+		}
+		w.Flush()
+		if result.Endpoint != "" {
+			fmt.Printf("\nEndpoint: %s\n", result.Endpoint)
+		}
+		fmt.Printf("Version:  %s\n", result.Version)
+		return nil
+	})
+}
+
+func runDeployRemove(cmd *cobra.Command, args []string) error {
+	deployer, config, err := newDeployer()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = config.FunctionNames()
+	}
+
+	type removal struct {
+		Name    string `json:"name"`
+		Removed bool   `json:"removed"`
+		Error   string `json:"error,omitempty"`
+	}
+	results := make([]removal, 0, len(names))
+	failed := 0
+	for _, name := range names {
+		r := removal{Name: name, Removed: true}
+		if err := deployer.Remove(cmd.Context(), name); err != nil {
+			r.Removed, r.Error = false, err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	err = printResult(results, func() error {
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "FUNCTION\tRESULT")
+		for _, r := range results {
+			status := "removed"
+			if !r.Removed {
+				status = "failed: " + r.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\n", r.Name, status)
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.NewSystemError(fmt.Sprintf("failed to remove %d of %d function(s)", failed, len(names)), nil)
+	}
+	return nil
+}
+
+func runDeployStatus(cmd *cobra.Command, args []string) error {
+	deployer, config, err := newDeployer()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = config.FunctionNames()
+	}
+
+	statuses := make(map[string]*serverless.DeploymentStatus, len(names))
+	for _, name := range names {
+		status, err := deployer.GetStatus(cmd.Context(), name)
+		if err != nil {
+			return errors.NewSystemError(fmt.Sprintf("failed to get status of %s", name), err)
+		}
+		statuses[name] = status
+	}
+
+	return printResult(statuses, func() error {
+		return printDeployStatus(names, statuses)
+	})
+}
+
+func printDeployStatus(names []string, statuses map[string]*serverless.DeploymentStatus) error {
+	w := newTable(os.Stdout)
+	fmt.Fprintln(w, "FUNCTION\tSTATE\tRESOURCE\tTYPE\tRESOURCE STATE\tURL\tUPDATED")
+	for _, name := range names {
+		status := statuses[name]
+		updated := status.UpdatedAt.Format(time.RFC3339)
+		if len(status.Resources) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t%s\n", name, status.State, updated)
+		}
+		for _, resource := range status.Resources {
+			url := resource.URL
+			if url == "" {
+				url = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, status.State, resource.Name, resource.Type, resource.State, url, updated)
+		}
+		if status.LastError != "" {
+			fmt.Fprintf(w, "%s\terror: %s\n", name, strings.TrimSpace(status.LastError))
+		}
+	}
+	return w.Flush()
+}
@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"os/exec"
+	"runtime"
+
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/dev"
+	"github.com/skbhati199/go-web-build/internal/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +37,7 @@ Server Options:
 
   # Start with API proxy
   gobuild dev --proxy /api:http://localhost:8000`,
+	RunE: runDev,
 }
 
 func init() {
@@ -39,7 +46,71 @@ func init() {
 	devCmd.Flags().BoolP("https", "S", false, "enable HTTPS")
 	devCmd.Flags().StringP("proxy", "P", "", "API proxy configuration")
 	devCmd.Flags().BoolP("open", "o", false, "open in browser")
-	devCmd.Flags().BoolP("hot", "h", true, "enable hot reload")
+	devCmd.Flags().Bool("hot", true, "enable hot reload")
 
 	rootCmd.AddCommand(devCmd)
 }
+
+func runDev(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	opts := dev.Options{
+		Host: cfg.Server.Host,
+		Port: cfg.Server.Port,
+		Build: builder.Options{
+			Mode:      "development",
+			Config:    cfgFile,
+			BaseDir:   ".",
+			SourceMap: cfg.Build.SourceMap,
+			Jobs:      cfg.Build.ParallelJobs,
+			HTML:      cfg.Build.HTML,
+
+			Environment:    cfg.Environment,
+			EnvPrefixes:    cfg.Build.Env.Prefixes,
+			EnvDeclaration: cfg.Build.Env.Declaration,
+		},
+		Log: progressWriter(),
+	}
+	if cfg.Build.Cache {
+		opts.Build.CacheDir = cfg.Build.CacheDir
+	}
+	if flags.Changed("host") || opts.Host == "" {
+		opts.Host, _ = flags.GetString("host")
+	}
+	if flags.Changed("port") || opts.Port == 0 {
+		opts.Port, _ = flags.GetInt("port")
+	}
+	opts.HTTPS, _ = flags.GetBool("https")
+	opts.Proxy, _ = flags.GetString("proxy")
+	opts.Hot, _ = flags.GetBool("hot")
+
+	server := dev.NewServer(opts)
+	progressf("Development server running at %s (press Ctrl-C to stop)\n", server.URL())
+
+	if open, _ := flags.GetBool("open"); open {
+		if err := openBrowser(server.URL()); err != nil {
+			progressf("Could not open browser: %v\n", err)
+		}
+	}
+
+	if err := server.Run(cmd.Context()); err != nil {
+		return errors.NewSystemError("development server failed", err)
+	}
+	progressf("Development server stopped\n")
+	return nil
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/skbhati199/go-web-build/internal/doctor"
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the environment and project",
+	Long: `Check the external tools gobuild relies on, the loaded configuration, the
+templates directory and the current project's package.json and lockfile.
+
+Every problem comes with a suggested fix. The command exits non-zero when a
+blocking problem is found.`,
+	Example: `  # Run all checks
+  gobuild doctor
+
+  # Check a specific configuration
+  gobuild doctor --config ./gobuild.yaml --env production`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	results := doctor.Run(cmd.Context(), doctor.Options{
+		ConfigFile: cfgFile,
+		Env:        env,
+		ProjectDir: ".",
+	})
+
+	warnings, failures := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case doctor.StatusWarn:
+			warnings++
+		case doctor.StatusFail:
+			failures++
+		}
+	}
+
+	result := map[string]interface{}{
+		"results":  results,
+		"warnings": warnings,
+		"failures": failures,
+	}
+	err := printResult(result, func() error {
+		printDoctorResults(results)
+		fmt.Printf("\n%d checks, %d warnings, %d blocking problems\n", len(results), warnings, failures)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if doctor.HasFailures(results) {
+		return errors.NewValidationError(fmt.Sprintf("doctor found %d blocking problem(s)", failures), nil)
+	}
+	return nil
+}
+
+func printDoctorResults(results []doctor.Result) {
+	category := ""
+	for _, r := range results {
+		if r.Category != category {
+			if category != "" {
+				fmt.Println()
+			}
+			category = r.Category
+			fmt.Println(category)
+		}
+		fmt.Printf("  [%-4s] %-20s %s\n", r.Status, r.Name, r.Detail)
+		if r.Fix != "" {
+			fmt.Printf("         %-20s fix: %s\n", "", r.Fix)
+		}
+	}
+}
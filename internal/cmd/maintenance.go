@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/pkg/maintenance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	maintenanceFile    string
+	maintenanceProject string
+	maintenanceReports string
+	maintenanceFormat  string
+	maintenanceOut     string
+	maintenanceChecks  []string
+	maintenanceNoSave  bool
+	maintenanceApply   bool
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Check dependencies, vulnerabilities and build performance",
+	Long: `Run the maintenance checks configured in config/maintenance.yaml:
+
+  dependencies  outdated npm packages (npm outdated)
+  security      npm audit and, for Go modules, govulncheck
+  performance   a production build measured against the performance
+                thresholds
+
+run only reports what it finds unless --apply is given, which installs
+non-breaking dependency updates and runs npm audit fix. schedule does the same
+when auto_update and auto_patch are set; both are off by default.
+
+Reports are saved under <cache_dir>/maintenance and kept for
+metrics_retention.`,
+	Example: `  # Run every check once and print a Markdown report
+  gobuild maintenance run
+
+  # Install non-breaking updates and security patches as well
+  gobuild maintenance run --apply
+
+  # Only scan for vulnerabilities, writing an HTML report
+  gobuild maintenance run --checks security --format html --out report.html
+
+  # Keep running checks on their configured schedules
+  gobuild maintenance schedule
+
+  # Render the last saved report as JSON
+  gobuild maintenance report --format json`,
+}
+
+var maintenanceRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the maintenance checks once",
+	Long:  `Run the maintenance checks once and render the report. Nothing in the project is changed unless --apply is given. Exits non-zero if a check failed or critical vulnerabilities were found.`,
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceRun,
+}
+
+var maintenanceScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run the maintenance checks on their schedules in the foreground",
+	Long: `Run every check immediately, then dependencies every check_interval,
+performance every monitor_interval and security on scan_schedule, until
+interrupted. Each run is saved as a report.`,
+	Args: cobra.NoArgs,
+	RunE: runMaintenanceSchedule,
+}
+
+var maintenanceReportCmd = &cobra.Command{
+	Use:   "report [file]",
+	Short: "Render a saved maintenance report",
+	Long:  `Render the given report file, or the most recent saved report when none is given.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runMaintenanceReport,
+}
+
+func init() {
+	maintenanceCmd.PersistentFlags().StringVarP(&maintenanceFile, "file", "f", "config/maintenance.yaml", "maintenance configuration")
+	maintenanceCmd.PersistentFlags().StringVarP(&maintenanceProject, "project", "p", ".", "project directory")
+	maintenanceCmd.PersistentFlags().StringVar(&maintenanceReports, "reports", "", "report directory (defaults to <cache_dir>/maintenance)")
+
+	for _, c := range []*cobra.Command{maintenanceRunCmd, maintenanceReportCmd} {
+		c.Flags().StringVar(&maintenanceFormat, "format", "", "report format (markdown, html, json); follows --output when unset")
+		c.Flags().StringVar(&maintenanceOut, "out", "", "write the report to a file instead of stdout")
+	}
+	maintenanceRunCmd.Flags().StringSliceVar(&maintenanceChecks, "checks", nil, "checks to run (dependencies, security, performance); all by default")
+	maintenanceRunCmd.Flags().BoolVar(&maintenanceNoSave, "no-save", false, "do not save the report")
+	maintenanceRunCmd.Flags().BoolVar(&maintenanceApply, "apply", false, "install non-breaking dependency updates and security patches")
+
+	maintenanceCmd.AddCommand(maintenanceRunCmd)
+	maintenanceCmd.AddCommand(maintenanceScheduleCmd)
+	maintenanceCmd.AddCommand(maintenanceReportCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+}
+
+// maintenanceLogger adapts zerolog to maintenance.Logger. It writes to
+// stderr so reports on stdout stay machine readable.
+type maintenanceLogger struct {
+	log zerolog.Logger
+}
+
+func newMaintenanceLogger() maintenanceLogger {
+	return maintenanceLogger{log: zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.Kitchen}).With().Timestamp().Logger()}
+}
+
+func (l maintenanceLogger) Info(msg string, args ...interface{}) {
+	l.log.Info().Fields(args).Msg(msg)
+}
+
+func (l maintenanceLogger) Error(msg string, args ...interface{}) {
+	l.log.Error().Fields(args).Msg(msg)
+}
+
+// newMaintenanceScheduler wires the maintenance managers to the project.
+// configure, if not nil, adjusts the loaded configuration first.
+func newMaintenanceScheduler(configure func(*maintenance.Config)) (*maintenance.MaintenanceScheduler, *maintenance.Config, error) {
+	mcfg, err := maintenance.LoadConfig(maintenanceFile)
+	if err != nil {
+		return nil, nil, errors.NewConfigError("failed to load maintenance configuration", err)
+	}
+	if configure != nil {
+		configure(mcfg)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger := newMaintenanceLogger()
+	dir := maintenanceProject
+
+	var notifier maintenance.SecurityNotifier
+	if mcfg.Security.NotifyCritical {
+		notifier = maintenance.LogNotifier{Logger: logger, CriticalOnly: true}
+	}
+	scanner := maintenance.MultiScanner{
+		maintenance.NPMAuditScanner{Dir: dir},
+		maintenance.GovulncheckScanner{Dir: dir},
+	}
+	collector := maintenance.BuildCollector{Options: builder.Options{
+		Mode:      "production",
+		Config:    cfgFile,
+		BaseDir:   dir,
+		Minify:    cfg.Build.Minify,
+		SourceMap: cfg.Build.SourceMap,
+		Jobs:      cfg.Build.ParallelJobs,
+
+		Environment: cfg.Environment,
+		EnvPrefixes: cfg.Build.Env.Prefixes,
+	}}
+
+	scheduler := maintenance.NewMaintenanceScheduler(
+		maintenance.NewDependencyManager(dir, logger),
+		maintenance.NewSecurityManager(scanner, maintenance.NPMPatcher{Dir: dir}, notifier, logger),
+		maintenance.NewPerformanceMonitor(collector, logger),
+		*mcfg,
+		logger,
+	)
+	return scheduler, mcfg, nil
+}
+
+// reportDir returns --reports or <cache_dir>/maintenance.
+func reportDir() (string, error) {
+	if maintenanceReports != "" {
+		return maintenanceReports, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	cacheDir := cfg.Build.CacheDir
+	if cacheDir == "" {
+		cacheDir = ".cache"
+	}
+	return filepath.Join(cacheDir, "maintenance"), nil
+}
+
+// reportFormat returns --format, defaulting to JSON under --output json and
+// Markdown otherwise.
+func reportFormat() (string, error) {
+	format := maintenanceFormat
+	if format == "" {
+		format = maintenance.FormatMarkdown
+		if outputFormat == outputJSON {
+			format = maintenance.FormatJSON
+		}
+	}
+	switch format {
+	case maintenance.FormatMarkdown, maintenance.FormatHTML, maintenance.FormatJSON:
+		return format, nil
+	}
+	return "", errors.NewUsageError(fmt.Sprintf("unsupported report format %q (expected markdown, html or json)", format), nil)
+}
+
+// saveReport stores r and drops reports older than the retention period.
+func saveReport(r *maintenance.Report, retention time.Duration) (string, error) {
+	dir, err := reportDir()
+	if err != nil {
+		return "", err
+	}
+	path, err := maintenance.SaveReport(dir, r)
+	if err != nil {
+		return "", errors.NewSystemError("failed to save maintenance report", err)
+	}
+	if _, err := maintenance.PruneReports(dir, retention); err != nil {
+		return "", errors.NewSystemError("failed to prune old maintenance reports", err)
+	}
+	return path, nil
+}
+
+// writeReport renders r to --out, or stdout. Without --format, --output
+// yaml prints the report like any other command result.
+func writeReport(r *maintenance.Report, format string) error {
+	if maintenanceFormat == "" && maintenanceOut == "" && outputFormat == outputYAML {
+		return printResult(r, nil)
+	}
+
+	var w io.Writer = os.Stdout
+	if maintenanceOut != "" {
+		f, err := os.Create(maintenanceOut)
+		if err != nil {
+			return errors.NewSystemError("failed to create report file", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := maintenance.Render(w, r, format); err != nil {
+		return errors.NewSystemError("failed to render report", err)
+	}
+	if maintenanceOut != "" {
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", maintenanceOut)
+	}
+	return nil
+}
+
+func runMaintenanceRun(cmd *cobra.Command, args []string) error {
+	format, err := reportFormat()
+	if err != nil {
+		return err
+	}
+	checks := make([]maintenance.Check, 0, len(maintenanceChecks))
+	for _, name := range maintenanceChecks {
+		check, err := maintenance.ParseCheck(name)
+		if err != nil {
+			return errors.NewValidationError(err.Error(), nil)
+		}
+		checks = append(checks, check)
+	}
+
+	scheduler, mcfg, err := newMaintenanceScheduler(func(c *maintenance.Config) {
+		// A single run only changes the project when asked to.
+		c.Dependencies.AutoUpdate = maintenanceApply
+		c.Security.AutoPatch = maintenanceApply
+	})
+	if err != nil {
+		return err
+	}
+	report := scheduler.RunOnce(cmd.Context(), checks...)
+
+	if !maintenanceNoSave {
+		if _, err := saveReport(report, time.Duration(mcfg.Performance.MetricsRetention)); err != nil {
+			return err
+		}
+	}
+	if err := writeReport(report, format); err != nil {
+		return err
+	}
+	if len(report.Errors) > 0 {
+		return errors.NewValidationError(fmt.Sprintf("maintenance found problems: %s", report.ErrorSummary()), nil)
+	}
+	return nil
+}
+
+func runMaintenanceSchedule(cmd *cobra.Command, args []string) error {
+	scheduler, mcfg, err := newMaintenanceScheduler(nil)
+	if err != nil {
+		return err
+	}
+	logger := newMaintenanceLogger()
+
+	scheduler.OnReport(func(r *maintenance.Report) {
+		path, err := saveReport(r, time.Duration(mcfg.Performance.MetricsRetention))
+		if err != nil {
+			logger.Error("Failed to save report", "error", err)
+			return
+		}
+		logger.Info("Maintenance run finished", "checks", r.Checks, "updates", len(r.Updates),
+			"vulnerabilities", len(r.Vulnerabilities), "problems", len(r.Errors), "report", path)
+	})
+
+	logger.Info("Maintenance scheduler started", "check_interval", mcfg.Dependencies.CheckInterval.String(),
+		"monitor_interval", mcfg.Performance.MonitorInterval.String(), "scan_schedule", mcfg.Security.ScanSchedule)
+	if err := scheduler.Start(cmd.Context()); err != nil && cmd.Context().Err() == nil {
+		return errors.NewSystemError("maintenance scheduler stopped", err)
+	}
+	logger.Info("Maintenance scheduler stopped")
+	return nil
+}
+
+func runMaintenanceReport(cmd *cobra.Command, args []string) error {
+	format, err := reportFormat()
+	if err != nil {
+		return err
+	}
+
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		dir, err := reportDir()
+		if err != nil {
+			return err
+		}
+		if path, err = maintenance.LatestReport(dir); err != nil {
+			return errors.NewSystemError("failed to list maintenance reports", err)
+		}
+		if path == "" {
+			return errors.NewValidationError(fmt.Sprintf("no maintenance reports in %s; run gobuild maintenance run first", dir), nil)
+		}
+	}
+
+	report, err := maintenance.LoadReport(path)
+	if err != nil {
+		return errors.NewSystemError("failed to load maintenance report", err)
+	}
+	return writeReport(report, format)
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by the global --output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFormat is the value of the global --output flag.
+var outputFormat = outputText
+
+func validateOutput(format string) error {
+	switch format {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q (expected %s, %s or %s)", format, outputText, outputJSON, outputYAML)
+}
+
+// structuredOutput reports whether --output selects a machine readable
+// format.
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// printResult writes v as JSON or YAML when --output asks for it, and calls
+// text otherwise.
+func printResult(v interface{}, text func() error) error {
+	switch outputFormat {
+	case outputJSON:
+		return printJSON(v)
+	case outputYAML:
+		return printYAML(os.Stdout, v)
+	}
+	return text()
+}
+
+// progressf prints a human readable progress message. With structured output
+// it goes to stderr so stdout only carries the result.
+func progressf(format string, args ...interface{}) {
+	fmt.Fprintf(progressWriter(), format, args...)
+}
+
+// progressWriter returns where progress messages go: stdout for text output
+// and stderr when stdout carries a structured result.
+func progressWriter() io.Writer {
+	if structuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printYAML writes v as YAML with the same keys and key order as its JSON
+// encoding, so both formats honour the json struct tags.
+func printYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow and quoting styles yaml.v3 keeps from JSON
+// input. Strings that would read as another type stay quoted.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+// errorResult is the structured form of a failed command.
+type errorResult struct {
+	Error struct {
+		Type    errors.ErrorType `json:"type"`
+		Message string           `json:"message"`
+		Cause   string           `json:"cause,omitempty"`
+	} `json:"error"`
+}
+
+// printError reports err on stderr, as an errorResult with structured
+// output and as a message otherwise. Errors that are not an AppError are
+// system errors, unless cobra returned them before running a command, such
+// as unknown commands; argument and flag errors are usage errors already.
+func printError(err error, cmdPath string) {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		if started {
+			appErr = errors.NewSystemError(err.Error(), nil)
+		} else {
+			appErr = errors.NewUsageError(err.Error(), nil)
+		}
+	}
+
+	if structuredOutput() {
+		var result errorResult
+		result.Error.Type = appErr.Type
+		result.Error.Message = appErr.Message
+		if appErr.Err != nil {
+			result.Error.Cause = appErr.Err.Error()
+		}
+
+		var buf bytes.Buffer
+		if outputFormat == outputYAML {
+			printYAML(&buf, result)
+		} else {
+			enc := json.NewEncoder(&buf)
+			enc.SetIndent("", "  ")
+			enc.Encode(result)
+		}
+		os.Stderr.Write(buf.Bytes())
+		return
+	}
+
+	if appErr.Err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", appErr.Message, appErr.Err)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", appErr.Message)
+	}
+	if appErr.Type == errors.ErrorTypeUsage {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmdPath)
+	}
+}
+
+// newTable returns a writer that aligns tab separated columns. Callers must
+// Flush it.
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/preview"
+	"github.com/spf13/cobra"
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview [build-dir]",
+	Short: "Serve the production build locally",
+	Long: `Serve build-dir (build.out_dir by default) the way a CDN would, to test a
+production build without deploying it.
+
+  • Precompressed .br and .gz siblings are served when the client accepts them
+  • Files listed in asset-manifest.json are cached as immutable, HTML is
+    never cached
+  • ETag, If-None-Match and Range requests are supported
+  • Unknown routes fall back to index.html for client side routing`,
+	Example: `  # Build and preview
+  gobuild build && gobuild preview
+
+  # Serve over HTTPS with your own certificate
+  gobuild preview --https --cert localhost.pem --key localhost-key.pem`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPreview,
+}
+
+func init() {
+	previewCmd.Flags().IntP("port", "p", 4173, "preview server port")
+	previewCmd.Flags().StringP("host", "H", "localhost", "host address")
+	previewCmd.Flags().BoolP("https", "S", false, "serve over HTTPS")
+	previewCmd.Flags().String("cert", "", "TLS certificate file (a self-signed certificate is used if unset)")
+	previewCmd.Flags().String("key", "", "TLS key file")
+	previewCmd.Flags().Bool("no-spa", false, "answer 404 for unknown routes instead of serving index.html")
+	previewCmd.Flags().Bool("open", false, "open in browser")
+	previewCmd.Flags().BoolP("quiet", "q", false, "do not log requests")
+
+	rootCmd.AddCommand(previewCmd)
+}
+
+func runPreview(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	opts := preview.Options{}
+	opts.Host, _ = flags.GetString("host")
+	opts.Port, _ = flags.GetInt("port")
+	opts.HTTPS, _ = flags.GetBool("https")
+	opts.CertFile, _ = flags.GetString("cert")
+	opts.KeyFile, _ = flags.GetString("key")
+	noSPA, _ := flags.GetBool("no-spa")
+	opts.SPA = !noSPA
+	if quiet, _ := flags.GetBool("quiet"); !quiet {
+		opts.AccessLog = os.Stderr
+	}
+	if (opts.CertFile != "" || opts.KeyFile != "") && !opts.HTTPS {
+		return errors.NewUsageError("--cert and --key require --https", nil)
+	}
+
+	if len(args) == 1 {
+		opts.Dir = args[0]
+	} else {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		opts.Dir = cfg.Build.OutDir
+	}
+	if info, err := os.Stat(opts.Dir); err != nil || !info.IsDir() {
+		return errors.NewValidationError(fmt.Sprintf("build directory %s does not exist; run gobuild build first", opts.Dir), nil)
+	}
+
+	server := preview.NewServer(opts)
+	progressf("Serving %s at %s (press Ctrl-C to stop)\n", opts.Dir, server.URL())
+	if open, _ := flags.GetBool("open"); open {
+		if err := openBrowser(server.URL()); err != nil {
+			progressf("Could not open browser: %v\n", err)
+		}
+	}
+
+	if err := server.Run(cmd.Context()); err != nil {
+		return errors.NewSystemError("preview server failed", err)
+	}
+	progressf("Preview server stopped\n")
+	return nil
+}
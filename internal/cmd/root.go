@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/skbhati199/go-web-build/internal/config"
+	"github.com/skbhati199/go-web-build/internal/errors"
 	"github.com/skbhati199/go-web-build/internal/recovery"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +17,9 @@ var (
 	cfg     *config.Config
 	debug   bool
 	env     string
+	// started is set once cobra has parsed the command line and runs a
+	// command. Errors before that are in the command line.
+	started bool
 )
 
 var rootCmd = &cobra.Command{
@@ -37,19 +46,92 @@ For detailed documentation, visit: https://github.com/skbhati199/go-web-build`,
   gobuild build --mode production --minify
 
   # Deploy your application
-  gobuild deploy --target aws`,
+  gobuild deploy --target aws
+
+  # Machine readable results for scripts and CI
+  gobuild templates list --output json
+
+Output:
+  --output text|json|yaml selects the format of command results. With json
+  and yaml, stdout carries only the result; progress messages go to stderr,
+  and failures are written to stderr as {"error": {"type", "message",
+  "cause"}} where type is one of VALIDATION, CONFIG, TEMPLATE, BUILD,
+  SYSTEM or USAGE.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		started = true
+		if err := validateOutput(outputFormat); err != nil {
+			// Report this error itself as text; the format is unusable.
+			outputFormat = outputText
+			return errors.NewUsageError(err.Error(), nil)
+		}
+		return nil
+	},
 }
 
 func Execute() error {
 	recovery := recovery.NewRecoveryHandler(debug)
 
+	// Commands receive a context that is cancelled on Ctrl-C so long running
+	// work such as builds and servers can stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	wrapArgs(rootCmd)
 	return recovery.WrapHandler(func() error {
-		return rootCmd.Execute()
+		executed, err := rootCmd.ExecuteContextC(ctx)
+		if err != nil {
+			path := rootCmd.CommandPath()
+			if executed != nil {
+				path = executed.CommandPath()
+			}
+			printError(err, path)
+		}
+		return err
 	})()
 }
 
+// wrapArgs turns argument validation failures of every command into usage
+// errors so they are reported with a type like any other error.
+func wrapArgs(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return errors.NewUsageError(err.Error(), nil)
+			}
+			return nil
+		}
+	}
+	for _, child := range cmd.Commands() {
+		wrapArgs(child)
+	}
+}
+
+// loadConfig loads the configuration selected by --config and --env. The
+// result is cached for the rest of the invocation.
+func loadConfig() (*config.Config, error) {
+	if cfg != nil {
+		return cfg, nil
+	}
+	loaded, err := config.LoadConfig(cfgFile, env)
+	if err != nil {
+		return nil, errors.NewConfigError("failed to load configuration", err)
+	}
+	cfg = loaded
+	return cfg, nil
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug mode")
 	rootCmd.PersistentFlags().StringVar(&env, "env", "", "environment (development, staging, production)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "output format (text, json, yaml)")
+	rootCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{outputText, outputJSON, outputYAML}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errors.NewUsageError(err.Error(), nil)
+	})
 }
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sourcemapStore   string
+	sourcemapRelease string
+
+	resolveSourceDir string
+	resolveContext   int
+)
+
+var sourcemapCmd = &cobra.Command{
+	Use:   "sourcemap",
+	Short: "Store source maps per release and symbolicate stack traces",
+	Long: `Source maps are stored gzip-compressed under <store>/<release>, named by
+content hash, with an index mapping each generated file to its map.`,
+	Example: `  # Store the maps of a production build for release 1.4.0
+  gobuild sourcemap store dist --release 1.4.0
+
+  # Symbolicate a stack trace copied from the browser console
+  pbpaste | gobuild sourcemap resolve --release 1.4.0
+
+  # Resolve a trace saved to a file, as JSON
+  gobuild sourcemap resolve error.txt --release 1.4.0 --output json`,
+}
+
+var sourcemapStoreCmd = &cobra.Command{
+	Use:   "store [build-dir]",
+	Short: "Store the source maps of a build for a release",
+	Long: `Store the source maps referenced by the sourceMappingURL comments of the
+scripts and stylesheets in build-dir (build.out_dir by default). Each map is
+indexed under the generated file's path relative to build-dir.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSourcemapStore,
+}
+
+var sourcemapResolveCmd = &cobra.Command{
+	Use:   "resolve [trace-file]",
+	Short: "Map a minified stack trace back to original sources",
+	Long: `Read a stack trace from trace-file, or stdin, and print every frame with its
+original source location and the surrounding code. Frames are matched to
+stored maps by the file name or URL in the trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSourcemapResolve,
+}
+
+func init() {
+	sourcemapCmd.PersistentFlags().StringVar(&sourcemapStore, "store", filepath.Join("build", "sourcemaps"), "source map storage directory")
+	sourcemapCmd.PersistentFlags().StringVarP(&sourcemapRelease, "release", "r", "", "release version (defaults to the package.json version)")
+
+	sourcemapResolveCmd.Flags().StringVar(&resolveSourceDir, "source-dir", ".", "directory to read original sources from when the map does not embed them")
+	sourcemapResolveCmd.Flags().IntVarP(&resolveContext, "context", "C", 2, "lines of code to show around each frame")
+
+	sourcemapCmd.AddCommand(sourcemapStoreCmd)
+	sourcemapCmd.AddCommand(sourcemapResolveCmd)
+	rootCmd.AddCommand(sourcemapCmd)
+}
+
+// releaseVersion returns --release, then the package.json version. When
+// resolving, a store holding a single release is also accepted.
+func releaseVersion(allowSingleStored bool) (string, error) {
+	if sourcemapRelease != "" {
+		return sourcemapRelease, nil
+	}
+
+	if data, err := os.ReadFile("package.json"); err == nil {
+		var pkg struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+			return pkg.Version, nil
+		}
+	}
+
+	if allowSingleStored {
+		versions, err := sourcemap.Versions(sourcemapStore)
+		if err != nil {
+			return "", errors.NewSystemError("failed to list stored releases", err)
+		}
+		if len(versions) == 1 {
+			return versions[0], nil
+		}
+		if len(versions) > 1 {
+			return "", errors.NewValidationError(fmt.Sprintf("several releases are stored (%s); pass --release", strings.Join(versions, ", ")), nil)
+		}
+	}
+	return "", errors.NewValidationError("no release version; pass --release", nil)
+}
+
+func runSourcemapStore(cmd *cobra.Command, args []string) error {
+	release, err := releaseVersion(false)
+	if err != nil {
+		return err
+	}
+
+	dir := ""
+	if len(args) == 1 {
+		dir = args[0]
+	} else {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		dir = cfg.Build.OutDir
+	}
+
+	handler := sourcemap.NewSourceMapHandler(sourcemapStore, release, true)
+	var stored []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".js" && ext != ".css") {
+			return nil
+		}
+
+		mapPath, err := referencedSourceMap(path)
+		if err != nil || mapPath == "" {
+			return err
+		}
+		data, err := os.ReadFile(mapPath)
+		if err != nil {
+			return fmt.Errorf("failed to read source map of %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if err := handler.StoreSourceMap(filepath.ToSlash(rel), data); err != nil {
+			return fmt.Errorf("failed to store source map of %s: %w", rel, err)
+		}
+		stored = append(stored, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return errors.NewSystemError("failed to store source maps", err)
+	}
+	if len(stored) == 0 {
+		return errors.NewValidationError(fmt.Sprintf("no files with a sourceMappingURL found in %s; build with source maps enabled", dir), nil)
+	}
+
+	storeDir := filepath.Join(sourcemapStore, release)
+	result := map[string]interface{}{
+		"release": release,
+		"dir":     storeDir,
+		"files":   stored,
+	}
+	return printResult(result, func() error {
+		for _, file := range stored {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Printf("Stored %d source maps for release %s in %s\n", len(stored), release, storeDir)
+		return nil
+	})
+}
+
+// referencedSourceMap returns the path of the external map named by the
+// last sourceMappingURL comment of file, or "" if there is none.
+func referencedSourceMap(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	url := sourcemap.MappingURL(string(data))
+	if url == "" || strings.HasPrefix(url, "data:") {
+		return "", nil
+	}
+	return filepath.Join(filepath.Dir(file), filepath.FromSlash(url)), nil
+}
+
+func runSourcemapResolve(cmd *cobra.Command, args []string) error {
+	release, err := releaseVersion(true)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return errors.NewSystemError("failed to open stack trace", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	resolver := sourcemap.NewResolver(sourcemap.NewSourceMapHandler(sourcemapStore, release, true))
+	resolver.SourceDir = resolveSourceDir
+	resolver.ContextLines = resolveContext
+	frames, err := resolver.ResolveTrace(in)
+	if err != nil {
+		return errors.NewSystemError("failed to read stack trace", err)
+	}
+
+	result := map[string]interface{}{
+		"release": release,
+		"frames":  frames,
+	}
+	return printResult(result, func() error {
+		printFrames(release, frames)
+		return nil
+	})
+}
+
+func printFrames(release string, frames []sourcemap.ResolvedFrame) {
+	unresolved := 0
+	for _, f := range frames {
+		if f.Original == nil {
+			fmt.Println(f.Raw)
+			if f.Error != "" {
+				fmt.Printf("      (unresolved: %s)\n", f.Error)
+				unresolved++
+			}
+			continue
+		}
+
+		name := f.Function
+		if name == "" {
+			name = f.Original.Name
+		}
+		location := fmt.Sprintf("%s:%d:%d", f.Original.Source, f.Original.Line, f.Original.Column)
+		if name != "" {
+			fmt.Printf("    at %s (%s)\n", name, location)
+		} else {
+			fmt.Printf("    at %s\n", location)
+		}
+		printContext(f)
+	}
+	if unresolved > 0 {
+		fmt.Fprintf(os.Stderr, "%d frame(s) could not be resolved against release %s\n", unresolved, release)
+	}
+}
+
+// printContext prints the code around a resolved frame with a caret under
+// the original column.
+func printContext(f sourcemap.ResolvedFrame) {
+	if len(f.Context) == 0 {
+		return
+	}
+	width := len(fmt.Sprint(f.Context[len(f.Context)-1].Line))
+	for _, line := range f.Context {
+		marker := " "
+		if line.Current {
+			marker = ">"
+		}
+		fmt.Printf("      %s %*d | %s\n", marker, width, line.Line, line.Text)
+		if line.Current && f.Original.Column > 0 {
+			// Keep tabs so the caret lines up with the source above it.
+			indent := make([]byte, 0, f.Original.Column-1)
+			for i := 0; i < f.Original.Column-1 && i < len(line.Text); i++ {
+				if line.Text[i] == '\t' {
+					indent = append(indent, '\t')
+				} else {
+					indent = append(indent, ' ')
+				}
+			}
+			fmt.Printf("        %*s | %s^\n", width, "", indent)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	"github.com/skbhati199/go-web-build/internal/template-engine/validation"
+	"github.com/skbhati199/go-web-build/internal/template-engine/version"
+	"github.com/skbhati199/go-web-build/templates"
+	"github.com/spf13/cobra"
+)
+
+var templatesDir string
+
+var templatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"template", "tpl"},
+	Short:   "Inspect and validate project templates",
+	Long: `Inspect the project templates available to "gobuild create".
+
+Templates are directories containing a template.json, either directly under
+the templates directory or nested as framework/variant. Feature overlays,
+whose template.json sets "overlay", are not listed; apply them to a project
+with "gobuild add".`,
+	Example: `  # List templates
+  gobuild templates list
+
+  # Show versions and release channels as JSON
+  gobuild templates versions react-typescript --output json
+
+  # Validate every template
+  gobuild templates validate`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplatesList,
+}
+
+var templatesInfoCmd = &cobra.Command{
+	Use:   "info <template>",
+	Short: "Show template metadata",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplatesInfo,
+}
+
+var templatesVersionsCmd = &cobra.Command{
+	Use:   "versions <template>",
+	Short: "Show published versions and release channels",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplatesVersions,
+}
+
+var templatesValidateCmd = &cobra.Command{
+	Use:   "validate [template...]",
+	Short: "Validate templates",
+	Long:  `Validate the named templates, or all templates when none are given. Exits non-zero if any template is invalid.`,
+	RunE:  runTemplatesValidate,
+}
+
+func init() {
+	templatesCmd.PersistentFlags().StringVarP(&templatesDir, "dir", "d", "", "templates directory (defaults to templates.directory from config)")
+
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesInfoCmd)
+	templatesCmd.AddCommand(templatesVersionsCmd)
+	templatesCmd.AddCommand(templatesValidateCmd)
+	rootCmd.AddCommand(templatesCmd)
+}
+
+// loadTemplateRegistry resolves the templates directory and loads every
+// template in it.
+func loadTemplateRegistry() (*templates.TemplateRegistry, error) {
+	dir := templatesDir
+	if dir == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		dir = cfg.Templates.Directory
+	}
+
+	registry := templates.NewRegistry(dir)
+	if err := registry.LoadTemplates(); err != nil {
+		return nil, errors.NewTemplateError(fmt.Sprintf("failed to load templates from %s", dir), err)
+	}
+	return registry, nil
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return err
+	}
+
+	list := make([]*templates.Template, 0, len(registry.Templates))
+	for _, name := range registry.ProjectNames() {
+		list = append(list, registry.Templates[name])
+	}
+
+	return printResult(list, func() error {
+		if len(list) == 0 {
+			fmt.Printf("No templates found in %s\n", registry.BasePath)
+			return nil
+		}
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "NAME\tVERSION\tDESCRIPTION")
+		for _, t := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, t.Version, t.Description)
+		}
+		return w.Flush()
+	})
+}
+
+func runTemplatesInfo(cmd *cobra.Command, args []string) error {
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return err
+	}
+	t, err := registry.Get(args[0])
+	if err != nil {
+		return errors.NewTemplateError("unknown template", err)
+	}
+
+	var metadata struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		Scripts         map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(t.Config, &metadata); err != nil {
+		return errors.NewTemplateError(fmt.Sprintf("invalid metadata for %s", t.Name), err)
+	}
+
+	return printResult(json.RawMessage(t.Config), func() error {
+		w := newTable(os.Stdout)
+		fmt.Fprintf(w, "Name:\t%s\n", t.Name)
+		fmt.Fprintf(w, "Version:\t%s\n", t.Version)
+		fmt.Fprintf(w, "Description:\t%s\n", t.Description)
+		fmt.Fprintf(w, "Author:\t%s\n", t.Author)
+		fmt.Fprintf(w, "Path:\t%s\n", t.Path)
+		w.Flush()
+
+		printSection("Files", t.Files)
+		printSection("Dependencies", formatPairs(metadata.Dependencies))
+		printSection("Dev dependencies", formatPairs(metadata.DevDependencies))
+		printSection("Scripts", formatPairs(metadata.Scripts))
+		return nil
+	})
+}
+
+func runTemplatesVersions(cmd *cobra.Command, args []string) error {
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return err
+	}
+	t, err := registry.Get(args[0])
+	if err != nil {
+		return errors.NewTemplateError("unknown template", err)
+	}
+
+	manager := version.NewVersionManager(registry.BasePath)
+	versions, err := manager.GetAllVersions(t.Name)
+	if err != nil {
+		return errors.NewTemplateError(fmt.Sprintf("failed to read versions of %s", t.Name), err)
+	}
+	// Release channels are only declared in versions.json.
+	var latest, stable string
+	if _, err := os.Stat(filepath.Join(t.Path, "versions.json")); err == nil {
+		latest, stable, err = manager.GetChannels(t.Name)
+		if err != nil {
+			return errors.NewTemplateError(fmt.Sprintf("failed to read release channels of %s", t.Name), err)
+		}
+	}
+
+	type versionInfo struct {
+		Version  string                 `json:"version"`
+		Path     string                 `json:"path,omitempty"`
+		Channels []string               `json:"channels,omitempty"`
+		Metadata map[string]interface{} `json:"metadata,omitempty"`
+	}
+	infos := make([]versionInfo, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		info := versionInfo{Version: v.String(), Path: v.Path, Metadata: v.Metadata}
+		if sameVersion(v, latest) {
+			info.Channels = append(info.Channels, "latest")
+		}
+		if sameVersion(v, stable) {
+			info.Channels = append(info.Channels, "stable")
+		}
+		infos = append(infos, info)
+	}
+
+	result := map[string]interface{}{
+		"template": t.Name,
+		"latest":   latest,
+		"stable":   stable,
+		"versions": infos,
+	}
+	return printResult(result, func() error {
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "VERSION\tCHANNELS\tPATH")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", info.Version, strings.Join(info.Channels, ","), info.Path)
+		}
+		return w.Flush()
+	})
+}
+
+func runTemplatesValidate(cmd *cobra.Command, args []string) error {
+	registry, err := loadTemplateRegistry()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = registry.Names()
+	}
+
+	validator := validation.NewTemplateValidator(registry.BasePath)
+	reports := make([]*validation.Report, 0, len(names))
+	invalid := 0
+	for _, name := range names {
+		if _, err := registry.Get(name); err != nil {
+			return errors.NewTemplateError("unknown template", err)
+		}
+		report := validator.ValidateTemplateReport(name)
+		if !report.Valid() {
+			invalid++
+		}
+		reports = append(reports, report)
+	}
+
+	err = printResult(reports, func() error {
+		for _, report := range reports {
+			status := "valid"
+			if !report.Valid() {
+				status = fmt.Sprintf("%d issue(s)", len(report.Issues))
+			}
+			fmt.Printf("%s: %s\n", report.Template, status)
+			for _, file := range report.Files {
+				mark := "ok"
+				if !file.OK {
+					mark = "missing"
+				}
+				fmt.Printf("  %-8s %s\n", mark, file.Path)
+			}
+			for _, issue := range report.Issues {
+				if issue.Check == "files" && len(report.Files) > 0 {
+					// Already shown in the file list above.
+					continue
+				}
+				fmt.Printf("  [%s] %s\n", issue.Check, issue.Message)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if invalid > 0 {
+		return errors.NewValidationError(fmt.Sprintf("%d of %d template(s) failed validation", invalid, len(reports)), nil)
+	}
+	return nil
+}
+
+func sameVersion(v *version.TemplateVersion, channel string) bool {
+	if channel == "" {
+		return false
+	}
+	parsed, err := version.NewTemplateVersion(channel)
+	return err == nil && parsed.Version.Equal(v.Version)
+}
+
+func printSection(title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("\n%s:\n", title)
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+func formatPairs(pairs map[string]string) []string {
+	lines := make([]string, 0, len(pairs))
+	for key, value := range pairs {
+		lines = append(lines, key+" "+value)
+	}
+	sort.Strings(lines)
+	return lines
+}
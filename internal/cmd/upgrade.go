@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/skbhati199/go-web-build/internal/errors"
+	templateengine "github.com/skbhati199/go-web-build/internal/template-engine"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [project-dir]",
+	Short: "Upgrade a project to a newer template version",
+	Long: `Apply the changes between the template version a project was created from
+and a newer one.
+
+Files you have not touched are updated. Files you edited are merged line by
+line; where both you and the template changed the same lines, the file is
+left with conflict markers to resolve by hand. The project's template and
+version are read from ` + templateengine.RecordFile + `.`,
+	Example: `  # See what upgrading to the latest version would change
+  gobuild upgrade --dry-run
+
+  # Upgrade another project to a specific version
+  gobuild upgrade ./myapp --to 1.2.0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUpgrade,
+}
+
+func init() {
+	upgradeCmd.Flags().String("to", "", "template version or constraint to upgrade to (defaults to the latest)")
+	upgradeCmd.Flags().Bool("dry-run", false, "show the changes without writing them")
+
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.NewValidationError(fmt.Sprintf("project directory %s does not exist", dir), nil)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	opts := templateengine.UpgradeOptions{}
+	opts.Version, _ = cmd.Flags().GetString("to")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	manager := templateengine.NewManager(cfg.Templates.Directory)
+	result, err := manager.Upgrade(dir, opts)
+	if err != nil {
+		return errors.NewTemplateError("upgrade failed", err)
+	}
+
+	err = printResult(result, func() error {
+		if result.From == result.To {
+			fmt.Printf("%s is already at %s\n", result.Template, result.To)
+			return nil
+		}
+		verb := "Upgraded"
+		if result.DryRun {
+			verb = "Would upgrade"
+		}
+		fmt.Printf("%s %s from %s to %s\n", verb, result.Template, result.From, result.To)
+		if len(result.Files) == 0 {
+			fmt.Println("No files changed")
+			return nil
+		}
+		fmt.Println()
+		w := newTable(os.Stdout)
+		fmt.Fprintln(w, "ACTION\tPATH\tNOTE")
+		for _, f := range result.Files {
+			note := f.Note
+			if f.Conflicts > 0 {
+				note = fmt.Sprintf("%d conflicting region(s)", f.Conflicts)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", f.Action, f.Path, note)
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	if n := result.Conflicts(); n > 0 && !result.DryRun {
+		return errors.NewValidationError(fmt.Sprintf("%d file(s) have conflicts; resolve the conflict markers and review the changes", n), nil)
+	}
+	return nil
+}
@@ -20,11 +20,85 @@ type ServerConfig struct {
 }
 
 type BuildConfig struct {
-	OutDir    string `mapstructure:"out_dir" validate:"required"`
-	SourceMap bool   `mapstructure:"source_map"`
-	Minify    bool   `mapstructure:"minify"`
-	Cache     bool   `mapstructure:"cache"`
-	CacheDir  string `mapstructure:"cache_dir" validate:"required_if=Cache true"`
+	OutDir    string       `mapstructure:"out_dir" validate:"required"`
+	SourceMap bool         `mapstructure:"source_map"`
+	Minify    bool         `mapstructure:"minify"`
+	Cache     bool         `mapstructure:"cache"`
+	CacheDir  string       `mapstructure:"cache_dir" validate:"required_if=Cache true"`
+	Naming    NamingConfig `mapstructure:"naming"`
+	Assets    AssetsConfig `mapstructure:"assets"`
+	// HTML lists the HTML entries; empty uses public/index.html.
+	HTML []string  `mapstructure:"html"`
+	Env  EnvConfig `mapstructure:"env"`
+	// ParallelJobs bounds concurrent per-file work; 0 uses one job per CPU.
+	ParallelJobs int `mapstructure:"parallel_jobs" validate:"min=0"`
+	// Compression writes precompressed siblings of production outputs.
+	Compression CompressionConfig `mapstructure:"compression"`
+	// Splitting splits scripts at import() expressions.
+	Splitting SplittingConfig `mapstructure:"splitting"`
+	// TreeShaking drops unused exports and modules from production builds.
+	TreeShaking bool `mapstructure:"tree_shaking"`
+}
+
+// SplittingConfig controls code splitting. Every import() target gets a
+// chunk of its own, and modules several chunks need move to shared chunks.
+type SplittingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MinSize is the smallest shared chunk in bytes; smaller shared code is
+	// copied into each chunk instead.
+	MinSize int `mapstructure:"min_size" validate:"min=0"`
+	// MaxRequests bounds the files loaded for one chunk; 0 is unlimited.
+	MaxRequests int `mapstructure:"max_requests" validate:"min=0"`
+}
+
+// AssetsConfig controls how imported assets are processed.
+type AssetsConfig struct {
+	Images ImagesConfig `mapstructure:"images"`
+}
+
+// ImagesConfig controls the optimization of imported PNG and JPEG images
+// in production builds.
+type ImagesConfig struct {
+	Optimize bool `mapstructure:"optimize"`
+	// Quality is the JPEG quality; 0 uses the default.
+	Quality       int  `mapstructure:"quality" validate:"min=0,max=100"`
+	StripMetadata bool `mapstructure:"strip_metadata"`
+	// Responsive writes resized variants of Widths pixels for srcset.
+	Responsive bool  `mapstructure:"responsive"`
+	Widths     []int `mapstructure:"widths" validate:"dive,min=1"`
+}
+
+// CompressionConfig selects the precompressed encodings and which outputs
+// get them.
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Algorithm is gzip, brotli or all.
+	Algorithm string `mapstructure:"algorithm" validate:"omitempty,oneof=gzip brotli all"`
+	// Level is the compression level; 0 uses the best level.
+	Level int `mapstructure:"level" validate:"min=0,max=11"`
+	// MinSize skips smaller files, in bytes.
+	MinSize int64 `mapstructure:"min_size" validate:"min=0"`
+	// MinRatio drops compressed files larger than this fraction of the
+	// original.
+	MinRatio float64 `mapstructure:"min_ratio" validate:"min=0,max=1"`
+}
+
+// NamingConfig holds the output name patterns of production builds, such as
+// "assets/[name].[hash:8][ext]". Empty patterns use the builder defaults.
+type NamingConfig struct {
+	Entry string `mapstructure:"entry"`
+	Chunk string `mapstructure:"chunk"`
+	CSS   string `mapstructure:"css"`
+	Asset string `mapstructure:"asset"`
+}
+
+// EnvConfig selects the environment variables exposed to client code from
+// the .env files and the process environment.
+type EnvConfig struct {
+	// Prefixes mark public variables; empty uses PUBLIC_ and REACT_APP_.
+	Prefixes []string `mapstructure:"prefixes"`
+	// Declaration is the TypeScript declaration file generated for them.
+	Declaration string `mapstructure:"declaration"`
 }
 
 type TemplateConfig struct {
@@ -33,43 +107,79 @@ type TemplateConfig struct {
 }
 
 func LoadConfig(configPath string, env string) (*Config, error) {
+	v, _, err := load(configPath, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// load merges the defaults, config/default, the config of env and the
+// custom config file, in that order. It also returns the layer that set the
+// final value of every key: SourceDefault or the path of a config file.
+func load(configPath string, env string) (*viper.Viper, map[string]string, error) {
 	v := viper.New()
+	sources := make(map[string]string)
 
 	// Set defaults
 	setDefaults(v)
+	for _, key := range v.AllKeys() {
+		sources[key] = SourceDefault
+	}
+	merge := func(layer *viper.Viper, source string) error {
+		if err := v.MergeConfigMap(layer.AllSettings()); err != nil {
+			return err
+		}
+		for _, key := range layer.AllKeys() {
+			sources[key] = source
+		}
+		return nil
+	}
 
 	// Load base config
-	v.AddConfigPath("config")
-	v.SetConfigName("default")
-
-	if err := v.ReadInConfig(); err != nil {
+	base := viper.New()
+	base.AddConfigPath("config")
+	base.SetConfigName("default")
+	if err := base.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading base config: %w", err)
+			return nil, nil, fmt.Errorf("error reading base config: %w", err)
 		}
+	} else if err := merge(base, displayPath(base.ConfigFileUsed())); err != nil {
+		return nil, nil, fmt.Errorf("error reading base config: %w", err)
 	}
 
 	// Load environment specific config
 	if env != "" {
-		v.SetConfigName(env)
-		if err := v.MergeInConfig(); err != nil {
-			return nil, fmt.Errorf("error loading %s config: %w", env, err)
+		envConfig := viper.New()
+		envConfig.AddConfigPath("config")
+		envConfig.SetConfigName(env)
+		if err := envConfig.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("error loading %s config: %w", env, err)
+		}
+		if err := merge(envConfig, displayPath(envConfig.ConfigFileUsed())); err != nil {
+			return nil, nil, fmt.Errorf("error loading %s config: %w", env, err)
 		}
 	}
 
 	// Custom config overrides
 	if configPath != "" {
-		v.SetConfigFile(configPath)
-		if err := v.MergeInConfig(); err != nil {
-			return nil, fmt.Errorf("error merging custom config: %w", err)
+		custom := viper.New()
+		custom.SetConfigFile(configPath)
+		if err := custom.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("error merging custom config: %w", err)
+		}
+		if err := merge(custom, configPath); err != nil {
+			return nil, nil, fmt.Errorf("error merging custom config: %w", err)
 		}
 	}
 
-	var config Config
-	if err := v.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
-	}
-
-	return &config, nil
+	return v, sources, nil
 }
 
 func setDefaults(v *viper.Viper) {
@@ -82,6 +192,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("build.minify", true)
 	v.SetDefault("build.cache", true)
 	v.SetDefault("build.cache_dir", ".cache")
+	v.SetDefault("build.env.declaration", "src/env.d.ts")
+	v.SetDefault("build.assets.images.strip_metadata", true)
+	v.SetDefault("build.assets.images.widths", []int{480, 960, 1440})
+	v.SetDefault("build.compression.algorithm", "all")
+	v.SetDefault("build.compression.min_size", 1024)
+	v.SetDefault("build.compression.min_ratio", 0.9)
+	v.SetDefault("build.tree_shaking", true)
+	v.SetDefault("build.splitting.min_size", 20000)
+	v.SetDefault("build.splitting.max_requests", 6)
 	v.SetDefault("templates.directory", "templates")
 	v.SetDefault("templates.cache", true)
 }
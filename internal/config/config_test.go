@@ -0,0 +1,29 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestShippedConfigs(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "config", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No shipped config files found (error %v)", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			v := viper.New()
+			setDefaults(v)
+			v.SetConfigFile(file)
+			if err := v.ReadInConfig(); err != nil {
+				t.Fatalf("Failed to read %s: %v", file, err)
+			}
+			var cfg Config
+			if err := v.Unmarshal(&cfg); err != nil {
+				t.Fatalf("Failed to decode %s: %v", file, err)
+			}
+		})
+	}
+}
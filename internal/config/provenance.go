@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SourceDefault marks values that come from setDefaults.
+const SourceDefault = "default"
+
+// Setting is one leaf key of the effective configuration and the layer that
+// set it: SourceDefault or the path of a configuration file.
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// Explain loads the configuration like LoadConfig and reports for every
+// key which layer set its final value. Settings are sorted by key.
+func Explain(configPath string, env string) ([]Setting, error) {
+	v, sources, err := load(configPath, env)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	result := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, Setting{Key: key, Value: v.Get(key), Source: sources[key]})
+	}
+	return result, nil
+}
+
+// displayPath shortens a path found by viper's search to be relative to the
+// working directory.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+var (
+	secretKeyPattern   = regexp.MustCompile(`(?i)(secret|passw(or)?d|token|credential|private|api_?key|access_?key|auth($|[._]))`)
+	secretValuePattern = regexp.MustCompile(`^((AKIA|ASIA)[A-Z0-9]{16}|gh[pousr]_[A-Za-z0-9]{20,}|sk_(live|test)_[A-Za-z0-9]+|xox[abpr]-[A-Za-z0-9-]+|eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+)$|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+)
+
+// LooksSecret reports whether a setting probably holds a credential, either
+// because of its key name or because the value has the shape of a well known
+// token format.
+func LooksSecret(key string, value interface{}) bool {
+	if secretKeyPattern.MatchString(key) {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && secretValuePattern.MatchString(s)
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder/naming"
 )
 
 type Validator struct {
@@ -43,6 +45,23 @@ func (v *Validator) validateBuild(build BuildConfig) {
 	if build.Cache && build.CacheDir == "" {
 		v.errors = append(v.errors, "cache directory is required when cache is enabled")
 	}
+	if build.ParallelJobs < 0 {
+		v.errors = append(v.errors, "parallel jobs must not be negative")
+	}
+	for _, prefix := range build.Env.Prefixes {
+		if prefix == "" {
+			v.errors = append(v.errors, "environment variable prefixes must not be empty, since that would expose every variable")
+			break
+		}
+	}
+	for _, pattern := range []string{build.Naming.Entry, build.Naming.Chunk, build.Naming.CSS, build.Naming.Asset} {
+		if pattern == "" {
+			continue
+		}
+		if err := naming.Validate(pattern); err != nil {
+			v.errors = append(v.errors, err.Error())
+		}
+	}
 }
 
 func (v *Validator) validateTemplates(templates TemplateConfig) {
@@ -2,6 +2,7 @@ package hotreload
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/websocket"
 )
@@ -10,6 +11,8 @@ type Server struct {
 	addr     string
 	upgrader websocket.Upgrader
 	clients  map[*websocket.Conn]bool
+	mu       sync.Mutex
+	server   *http.Server
 }
 
 func NewServer(addr string) *Server {
@@ -24,12 +27,34 @@ func NewServer(addr string) *Server {
 	}
 }
 
+// Start serves the websocket endpoint on its own address. Use HandleWS to
+// mount the endpoint on an existing server instead.
 func (s *Server) Start() error {
-	http.HandleFunc("/ws", s.handleWS)
-	return http.ListenAndServe(s.addr, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.HandleWS)
+	s.server = &http.Server{Addr: s.addr, Handler: mux}
+	return s.server.ListenAndServe()
+}
+
+// Close disconnects all clients and stops the server started by Start.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	for client := range s.clients {
+		client.Close()
+		delete(s.clients, client)
+	}
+	s.mu.Unlock()
+
+	if s.server != nil {
+		return s.server.Close()
+	}
+	return nil
 }
 
 func (s *Server) Notify(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for client := range s.clients {
 		if err := client.WriteJSON(event); err != nil {
 			client.Close()
@@ -38,10 +63,28 @@ func (s *Server) Notify(event Event) {
 	}
 }
 
-func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+// HandleWS upgrades the request and registers the connection for
+// notifications until the client goes away.
+func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
+
+	s.mu.Lock()
 	s.clients[conn] = true
+	s.mu.Unlock()
+
+	// Clients never send anything; reading only detects disconnects.
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				break
+			}
+		}
+		s.mu.Lock()
+		delete(s.clients, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
 }
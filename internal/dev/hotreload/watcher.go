@@ -37,12 +37,25 @@ func NewFileWatcher(debounce time.Duration) (*FileWatcher, error) {
 	}, nil
 }
 
+// ignoredDirs are never watched. They are large and change for reasons that
+// do not affect the build.
+var ignoredDirs = map[string]bool{
+	"node_modules": true,
+	".git":         true,
+	".cache":       true,
+}
+
+// Watch adds dir and its subdirectories, skipping dependency and VCS
+// directories.
 func (fw *FileWatcher) Watch(dir string) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		if info.IsDir() {
+			if path != dir && ignoredDirs[info.Name()] {
+				return filepath.SkipDir
+			}
 			return fw.watcher.Add(path)
 		}
 		return nil
@@ -66,25 +79,48 @@ func (fw *FileWatcher) Errors() <-chan error {
 	return fw.errors
 }
 
+// run coalesces bursts of changes: an event is emitted once no further
+// change has arrived for the debounce interval, carrying the last change.
 func (fw *FileWatcher) run() {
 	timer := time.NewTimer(0)
 	<-timer.C
 
+	var pending *Event
 	for {
 		select {
-		case event := <-fw.watcher.Events:
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					fw.Watch(event.Name)
+				}
+			}
+			pending = &Event{
+				Path: event.Name,
+				Op:   event.Op,
+			}
 			timer.Reset(fw.debounce)
+		case <-timer.C:
+			if pending == nil {
+				continue
+			}
 			select {
-			case <-timer.C:
-				fw.events <- Event{
-					Path: event.Name,
-					Op:   event.Op,
-				}
+			case fw.events <- *pending:
+			case <-fw.done:
+				return
+			}
+			pending = nil
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case fw.errors <- err:
 			case <-fw.done:
 				return
 			}
-		case err := <-fw.watcher.Errors:
-			fw.errors <- err
 		case <-fw.done:
 			return
 		}
@@ -0,0 +1,322 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/dev/hotreload"
+)
+
+// reloadScript connects to the hot reload endpoint and reloads the page
+// whenever the server announces a change. It reconnects quietly if the
+// server restarts.
+const reloadScript = `<script>
+(function () {
+  var protocol = location.protocol === "https:" ? "wss:" : "ws:";
+  function connect() {
+    var socket = new WebSocket(protocol + "//" + location.host + "/ws");
+    socket.onmessage = function () { location.reload(); };
+    socket.onclose = function () { setTimeout(connect, 1000); };
+  }
+  connect();
+})();
+</script>
+`
+
+type Options struct {
+	Host string
+	Port int
+	// HTTPS serves over TLS with a self-signed certificate.
+	HTTPS bool
+	// Proxy forwards a path prefix to another server, written as
+	// "/api:http://localhost:8000".
+	Proxy string
+	// Hot enables file watching and live reload.
+	Hot bool
+	// Build configures the builds the server runs. OutDir is managed by the
+	// server.
+	Build builder.Options
+	// Log receives status messages about builds and file changes. Defaults
+	// to os.Stdout.
+	Log io.Writer
+}
+
+// Server builds the project into a temporary directory, serves the result and
+// rebuilds when sources change.
+type Server struct {
+	opts    Options
+	builder *builder.Builder
+	reload  *hotreload.Server
+
+	mu     sync.RWMutex
+	outDir string
+}
+
+func NewServer(opts Options) *Server {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+	return &Server{
+		opts:    opts,
+		builder: builder.New(),
+		reload:  hotreload.NewServer(addr),
+	}
+}
+
+// URL returns the address the server listens on.
+func (s *Server) URL() string {
+	scheme := "http"
+	if s.opts.HTTPS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port)))
+}
+
+// Run serves until ctx is cancelled, then shuts down the HTTP server, the
+// watcher and all live reload connections.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.rebuild(ctx); err != nil {
+		s.logf("Build failed: %v\n", err)
+	}
+	defer s.cleanup()
+
+	handler, err := s.handler()
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port)),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if s.opts.HTTPS {
+		cert, err := SelfSignedCertificate(s.opts.Host)
+		if err != nil {
+			return fmt.Errorf("failed to create certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	if s.opts.Hot {
+		watcher, err := s.watch(ctx)
+		if err != nil {
+			return err
+		}
+		defer watcher.Stop()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if s.opts.HTTPS {
+			errCh <- srv.ListenAndServeTLS("", "")
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("dev server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.reload.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down dev server: %w", err)
+	}
+	return nil
+}
+
+// watch rebuilds and notifies clients whenever a source file changes.
+func (s *Server) watch(ctx context.Context) (*hotreload.FileWatcher, error) {
+	watcher, err := hotreload.NewFileWatcher(100 * time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := s.opts.Build.BaseDir
+	for _, dir := range []string{"src", "public"} {
+		full := filepath.Join(baseDir, dir)
+		if _, err := os.Stat(full); err != nil {
+			continue
+		}
+		if err := watcher.Watch(full); err != nil {
+			watcher.Stop()
+			return nil, fmt.Errorf("failed to watch %s: %w", full, err)
+		}
+	}
+	watcher.Start()
+
+	go func() {
+		for {
+			select {
+			case event := <-watcher.Events():
+				rel, _ := filepath.Rel(baseDir, event.Path)
+				s.logf("Change detected: %s\n", rel)
+				if err := s.rebuild(ctx); err != nil {
+					s.logf("Build failed: %v\n", err)
+					continue
+				}
+				s.reload.Notify(event)
+			case err := <-watcher.Errors():
+				s.logf("Watcher error: %v\n", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return watcher, nil
+}
+
+// rebuild builds into a fresh directory and swaps it in once complete, so
+// requests never see a half-written build.
+func (s *Server) rebuild(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "gobuild-dev-")
+	if err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	opts := s.opts.Build
+	opts.OutDir = dir
+	result, err := s.builder.Run(ctx, opts)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	s.mu.Lock()
+	previous := s.outDir
+	s.outDir = dir
+	s.mu.Unlock()
+
+	if previous != "" {
+		os.RemoveAll(previous)
+	}
+	s.logf("Built %d modules in %s\n", result.Modules, result.Duration.Round(time.Millisecond))
+	return nil
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.opts.Log, format, args...)
+}
+
+func (s *Server) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.outDir != "" {
+		os.RemoveAll(s.outDir)
+		s.outDir = ""
+	}
+}
+
+func (s *Server) handler() (http.Handler, error) {
+	mux := http.NewServeMux()
+	if s.opts.Hot {
+		mux.HandleFunc("/ws", s.reload.HandleWS)
+	}
+	if s.opts.Proxy != "" {
+		prefix, proxy, err := newProxy(s.opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		mux.Handle(prefix, proxy)
+	}
+	mux.HandleFunc("/", s.serveFile)
+	return mux, nil
+}
+
+// serveFile serves the current build. Unknown paths without an extension
+// fall back to index.html so client side routing works.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	root := s.outDir
+	s.mu.RUnlock()
+	if root == "" {
+		http.Error(w, "build failed, see terminal output", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	file := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Stat(file)
+	switch {
+	case err == nil && info.IsDir():
+		file = filepath.Join(file, "index.html")
+	case err != nil && path.Ext(name) == "":
+		file = filepath.Join(root, "index.html")
+	}
+
+	if strings.HasSuffix(file, ".html") {
+		s.serveHTML(w, r, file)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, r, file)
+}
+
+func (s *Server) serveHTML(w http.ResponseWriter, r *http.Request, file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if s.opts.Hot {
+		data = injectReloadScript(data)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
+
+// injectReloadScript inserts the reload client before </body>, or appends it
+// when the document has no body end tag.
+func injectReloadScript(html []byte) []byte {
+	idx := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if idx < 0 {
+		return append(html, reloadScript...)
+	}
+	result := make([]byte, 0, len(html)+len(reloadScript))
+	result = append(result, html[:idx]...)
+	result = append(result, reloadScript...)
+	return append(result, html[idx:]...)
+}
+
+// newProxy parses "/prefix:http://target" into a reverse proxy.
+func newProxy(spec string) (string, http.Handler, error) {
+	prefix, target, ok := strings.Cut(spec, ":")
+	if !ok || !strings.HasPrefix(prefix, "/") {
+		return "", nil, fmt.Errorf("invalid proxy %q, expected /prefix:http://host:port", spec)
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Host == "" {
+		return "", nil, fmt.Errorf("invalid proxy target %q", target)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, proxy, nil
+}
@@ -0,0 +1,124 @@
+package dev
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/dev/hotreload"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "before body end", html: "<html><BODY>app</BODY></html>", want: "app" + reloadScript + "</BODY>"},
+		{name: "appended without body", html: "<p>app</p>", want: "<p>app</p>" + reloadScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(injectReloadScript([]byte(tt.html)))
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("injectReloadScript(%q) = %q", tt.html, got)
+			}
+		})
+	}
+}
+
+func TestNewProxy(t *testing.T) {
+	prefix, _, err := newProxy("/api:http://localhost:8000")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prefix != "/api/" {
+		t.Errorf("Expected prefix /api/, got %s", prefix)
+	}
+
+	if _, _, err := newProxy("api"); err == nil {
+		t.Error("Expected error for malformed proxy")
+	}
+}
+
+func TestRebuildOnChange(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "src", "index.js")
+	if err := os.MkdirAll(filepath.Dir(index), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(index, []byte("console.log('before');\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer(Options{Hot: true, Build: builder.Options{Mode: "development", BaseDir: dir}, Log: io.Discard})
+	if err := s.rebuild(ctx); err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	defer s.cleanup()
+	first := s.outDir
+
+	handler, err := s.handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	defer s.reload.Close()
+	watcher, err := s.watch(ctx)
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	defer watcher.Stop()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to /ws: %v", err)
+	}
+	defer conn.Close()
+	// Give the server a moment to register the client.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(index, []byte("console.log('after');\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var event hotreload.Event
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("Expected a reload message: %v", err)
+	}
+	if event.Path != index {
+		t.Errorf("Reload for %s, want %s", event.Path, index)
+	}
+
+	s.mu.RLock()
+	current := s.outDir
+	s.mu.RUnlock()
+	if current == first {
+		t.Error("Expected the rebuild to be swapped in from a new directory")
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("Expected the previous build to be removed, got %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/assets/main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "after") || strings.Contains(string(body), "before") {
+		t.Errorf("Expected the rebuilt script to be served, got:\n%s", body)
+	}
+}
@@ -0,0 +1,265 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/config"
+	"github.com/skbhati199/go-web-build/templates"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	// StatusFail marks a blocking problem.
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a single check. Fix is an actionable suggestion
+// shown for failed and warned checks.
+type Result struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Detail   string `json:"detail"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+// Tool is an external binary some part of gobuild shells out to.
+type Tool struct {
+	Name        string
+	VersionArgs []string
+	UsedBy      string
+	Install     string
+	// Required tools fail the check when missing; the others only warn.
+	Required bool
+}
+
+// Tools lists the external binaries gobuild invokes.
+var Tools = []Tool{
+	{Name: "npm", VersionArgs: []string{"--version"}, UsedBy: "dependency installation and audits", Install: "install Node.js from https://nodejs.org", Required: true},
+	{Name: "git", VersionArgs: []string{"--version"}, UsedBy: "project initialisation", Install: "install git from https://git-scm.com"},
+	{Name: "govulncheck", VersionArgs: []string{"-version"}, UsedBy: "maintenance security scans", Install: "go install golang.org/x/vuln/cmd/govulncheck@latest"},
+}
+
+// Options selects what Run inspects.
+type Options struct {
+	// ConfigFile and Env are passed to config.LoadConfig.
+	ConfigFile string
+	Env        string
+	// ProjectDir is where package.json is looked for.
+	ProjectDir string
+}
+
+// Run executes every check. It never fails itself; problems are reported
+// as results.
+func Run(ctx context.Context, opts Options) []Result {
+	var results []Result
+	for _, tool := range Tools {
+		results = append(results, CheckTool(ctx, tool))
+	}
+
+	cfg, result := CheckConfig(opts.ConfigFile, opts.Env)
+	results = append(results, result)
+	if cfg != nil {
+		results = append(results, CheckTemplates(cfg.Templates.Directory))
+	}
+
+	return append(results, CheckProject(opts.ProjectDir)...)
+}
+
+// HasFailures reports whether any result is blocking.
+func HasFailures(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTool looks the tool up on PATH and asks it for its version.
+func CheckTool(ctx context.Context, tool Tool) Result {
+	result := Result{Category: "tools", Name: tool.Name}
+
+	path, err := exec.LookPath(tool.Name)
+	if err != nil {
+		result.Status = StatusWarn
+		if tool.Required {
+			result.Status = StatusFail
+		}
+		result.Detail = fmt.Sprintf("not found on PATH (needed for %s)", tool.UsedBy)
+		result.Fix = tool.Install
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, tool.VersionArgs...).CombinedOutput()
+	if err != nil {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("found at %s but failed to report its version: %v", path, err)
+		result.Fix = "reinstall it: " + tool.Install
+		return result
+	}
+
+	result.Status = StatusOK
+	result.Detail = firstLine(string(out))
+	return result
+}
+
+// CheckConfig loads and validates the configuration.
+func CheckConfig(configFile, env string) (*config.Config, Result) {
+	result := Result{Category: "config", Name: "configuration"}
+
+	cfg, err := config.LoadConfig(configFile, env)
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = err.Error()
+		result.Fix = "check the --config path and that config/<env>.yaml exists for --env"
+		return nil, result
+	}
+	if err := config.NewValidator().Validate(cfg); err != nil {
+		result.Status = StatusFail
+		result.Detail = strings.ReplaceAll(err.Error(), "\n", "; ")
+		result.Fix = "correct the listed keys in your configuration file"
+		return cfg, result
+	}
+
+	result.Status = StatusOK
+	result.Detail = fmt.Sprintf("valid (%s environment)", cfg.Environment)
+	return cfg, result
+}
+
+// CheckTemplates verifies that the templates directory exists and contains
+// loadable templates.
+func CheckTemplates(dir string) Result {
+	result := Result{Category: "config", Name: "templates directory"}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("%s does not exist; gobuild create will not find templates", dir)
+		result.Fix = "set templates.directory to the directory holding your templates"
+		return result
+	}
+
+	registry := templates.NewRegistry(dir)
+	if err := registry.LoadTemplates(); err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("failed to load templates from %s: %v", dir, err)
+		result.Fix = "run gobuild templates validate to find the broken template"
+		return result
+	}
+	if len(registry.Templates) == 0 {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("%s contains no templates", dir)
+		result.Fix = "add template directories containing a template.json"
+		return result
+	}
+
+	result.Status = StatusOK
+	result.Detail = fmt.Sprintf("%s (%d templates)", dir, len(registry.Templates))
+	return result
+}
+
+// lockfiles maps lockfile names to the package manager that writes them.
+var lockfiles = []struct{ name, manager string }{
+	{"package-lock.json", "npm"},
+	{"yarn.lock", "yarn"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"npm-shrinkwrap.json", "npm"},
+}
+
+// CheckProject inspects package.json, the lockfile and installed
+// dependencies in dir.
+func CheckProject(dir string) []Result {
+	pkgPath := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(pkgPath)
+	if os.IsNotExist(err) {
+		return []Result{{
+			Category: "project",
+			Name:     "package.json",
+			Status:   StatusWarn,
+			Detail:   fmt.Sprintf("no package.json in %s", dir),
+			Fix:      "run doctor from your project root, or create a project with gobuild create",
+		}}
+	}
+	if err != nil {
+		return []Result{{Category: "project", Name: "package.json", Status: StatusFail, Detail: err.Error()}}
+	}
+
+	var pkg struct {
+		Name            string            `json:"name"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return []Result{{
+			Category: "project",
+			Name:     "package.json",
+			Status:   StatusFail,
+			Detail:   fmt.Sprintf("invalid JSON: %v", err),
+			Fix:      "fix the syntax error in package.json",
+		}}
+	}
+
+	results := []Result{{
+		Category: "project",
+		Name:     "package.json",
+		Status:   StatusOK,
+		Detail:   fmt.Sprintf("%s (%d dependencies)", pkg.Name, len(pkg.Dependencies)+len(pkg.DevDependencies)),
+	}}
+
+	lock := Result{Category: "project", Name: "lockfile"}
+	var found []string
+	for _, l := range lockfiles {
+		if _, err := os.Stat(filepath.Join(dir, l.name)); err == nil {
+			found = append(found, l.name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		lock.Status = StatusWarn
+		lock.Detail = "no lockfile; installs are not reproducible"
+		lock.Fix = "run npm install and commit package-lock.json"
+	case 1:
+		lock.Status = StatusOK
+		lock.Detail = found[0]
+	default:
+		lock.Status = StatusWarn
+		lock.Detail = fmt.Sprintf("several lockfiles found: %s", strings.Join(found, ", "))
+		lock.Fix = "keep only the lockfile of the package manager you use"
+	}
+	results = append(results, lock)
+
+	modules := Result{Category: "project", Name: "node_modules"}
+	if info, err := os.Stat(filepath.Join(dir, "node_modules")); err == nil && info.IsDir() {
+		modules.Status = StatusOK
+		modules.Detail = "installed"
+	} else if len(pkg.Dependencies)+len(pkg.DevDependencies) > 0 {
+		modules.Status = StatusFail
+		modules.Detail = "dependencies are not installed; builds cannot resolve packages"
+		modules.Fix = "run npm install"
+	} else {
+		modules.Status = StatusOK
+		modules.Detail = "no dependencies declared"
+	}
+	return append(results, modules)
+}
+
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
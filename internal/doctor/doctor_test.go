@@ -0,0 +1,62 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  map[string]Status
+	}{
+		{
+			name: "installed project",
+			files: map[string]string{
+				"package.json":              `{"name": "app", "dependencies": {"react": "^18.2.0"}}`,
+				"package-lock.json":         `{}`,
+				"node_modules/.placeholder": "",
+			},
+			want: map[string]Status{"package.json": StatusOK, "lockfile": StatusOK, "node_modules": StatusOK},
+		},
+		{
+			name: "missing install",
+			files: map[string]string{
+				"package.json": `{"name": "app", "dependencies": {"react": "^18.2.0"}}`,
+			},
+			want: map[string]Status{"package.json": StatusOK, "lockfile": StatusWarn, "node_modules": StatusFail},
+		},
+		{
+			name:  "invalid package.json",
+			files: map[string]string{"package.json": `{"name":`},
+			want:  map[string]Status{"package.json": StatusFail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", name, err)
+				}
+			}
+
+			results := CheckProject(dir)
+			if len(results) != len(tt.want) {
+				t.Fatalf("Expected %d results, got %+v", len(tt.want), results)
+			}
+			for _, r := range results {
+				if r.Status != tt.want[r.Name] {
+					t.Errorf("%s: expected %s, got %s (%s)", r.Name, tt.want[r.Name], r.Status, r.Detail)
+				}
+			}
+		})
+	}
+}
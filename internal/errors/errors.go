@@ -12,6 +12,9 @@ const (
 	ErrorTypeTemplate   ErrorType = "TEMPLATE"
 	ErrorTypeBuild      ErrorType = "BUILD"
 	ErrorTypeSystem     ErrorType = "SYSTEM"
+	// ErrorTypeUsage marks invalid command lines: unknown commands or flags
+	// and wrong argument counts.
+	ErrorTypeUsage ErrorType = "USAGE"
 )
 
 type AppError struct {
@@ -27,6 +30,11 @@ func (e *AppError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Type, e.Message)
 }
 
+// Unwrap returns the underlying error for errors.Is and errors.As.
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
 func NewValidationError(msg string, err error) *AppError {
 	return &AppError{
 		Type:    ErrorTypeValidation,
@@ -66,3 +74,11 @@ func NewSystemError(msg string, err error) *AppError {
 		Err:     err,
 	}
 }
+
+func NewUsageError(msg string, err error) *AppError {
+	return &AppError{
+		Type:    ErrorTypeUsage,
+		Message: msg,
+		Err:     err,
+	}
+}
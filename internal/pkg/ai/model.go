@@ -13,6 +13,24 @@ type Prediction struct {
 	Confidence    float64
 }
 
+// ParallelJobs returns the "parallel_jobs" build parameter, or 0 when it is
+// missing.
+func (p Prediction) ParallelJobs() int {
+	return parallelJobs(p.BuildParams)
+}
+
+// parallelJobs reads "parallel_jobs" from build parameters, which hold an
+// int when predicted and a float64 when decoded from JSON.
+func parallelJobs(params map[string]interface{}) int {
+	switch n := params["parallel_jobs"].(type) {
+	case int:
+		return max(n, 0)
+	case float64:
+		return max(int(n), 0)
+	}
+	return 0
+}
+
 type ResourceLimits struct {
 	CPU    int
 	Memory int64
@@ -74,9 +74,7 @@ func (r *randomForest) aggregatePredictions(predictions []Prediction) Prediction
 		totalMemory += float64(p.Resources.Memory)
 		totalDisk += float64(p.Resources.Disk)
 
-		if pj, ok := p.BuildParams["parallel_jobs"].(int); ok {
-			parallelJobs += pj
-		}
+		parallelJobs += p.ParallelJobs()
 	}
 
 	numPredictions := float64(len(predictions))
@@ -7,6 +7,12 @@ type OptimizedConfig struct {
 	Metadata        BuildMetadata
 }
 
+// ParallelJobs returns the "parallel_jobs" build parameter, or 0 when the
+// model did not suggest one. It maps onto build.parallel_jobs.
+func (c *OptimizedConfig) ParallelJobs() int {
+	return parallelJobs(c.BuildParameters)
+}
+
 type BuildMetadata struct {
 	Confidence      float64
 	Recommendations []string
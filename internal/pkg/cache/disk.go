@@ -0,0 +1,411 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	indexFile   = "index.json"
+	metricsFile = "metrics.json"
+	objectsDir  = "objects"
+
+	// maxRuns is how many per-build metric records are kept on disk.
+	maxRuns = 20
+)
+
+// Entry describes one object stored in a DiskCache. Hash is the SHA-256 of
+// the stored content and is checked on every read.
+type Entry struct {
+	Key        string    `json:"key"`
+	Hash       string    `json:"hash"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+// Run holds the cache metrics of a single build.
+type Run struct {
+	Time      time.Time `json:"time"`
+	Hits      int64     `json:"hits"`
+	Misses    int64     `json:"misses"`
+	Evictions int64     `json:"evictions"`
+}
+
+// Stats summarises the contents of a DiskCache.
+type Stats struct {
+	Dir        string `json:"dir"`
+	Entries    int    `json:"entries"`
+	TotalBytes int64  `json:"total_bytes"`
+	// Hits and Misses are summed over the recorded Runs.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Runs   []Run `json:"runs"`
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 when no
+// lookups were recorded.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// PruneOptions selects entries to remove. Entries older than MaxAge (by last
+// access) are removed first, then the least recently used entries until the
+// cache fits in MaxBytes. Zero values disable the respective limit.
+type PruneOptions struct {
+	MaxAge   time.Duration
+	MaxBytes int64
+	DryRun   bool
+}
+
+// Problem is an entry that failed verification.
+type Problem struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// DiskCache is a content-addressed build cache persisted under a directory.
+// The index and the metrics of the current run are written by Close.
+type DiskCache struct {
+	dir string
+
+	mu      sync.Mutex
+	index   map[string]*Entry
+	metrics CacheMetrics
+	dirty   bool
+}
+
+// OpenDiskCache opens the cache in dir. A missing directory is an empty
+// cache; it is created on the first write.
+func OpenDiskCache(dir string) (*DiskCache, error) {
+	c := &DiskCache{
+		dir:     dir,
+		index:   make(map[string]*Entry),
+		metrics: CacheMetrics{LastUpdated: time.Now()},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid cache index %s: %w", filepath.Join(dir, indexFile), err)
+	}
+	for _, e := range entries {
+		c.index[e.Key] = e
+		c.metrics.Size += e.Size
+	}
+	return c, nil
+}
+
+// Dir returns the cache directory.
+func (c *DiskCache) Dir() string {
+	return c.dir
+}
+
+// Get returns the content stored under key. Entries whose content no longer
+// matches the recorded hash are dropped and reported as misses.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.index[key]
+	if !ok {
+		c.metrics.Misses++
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.objectPath(key))
+	if err != nil || hashBytes(data) != entry.Hash {
+		c.removeLocked(entry)
+		c.metrics.Misses++
+		return nil, false
+	}
+
+	entry.AccessedAt = time.Now()
+	c.dirty = true
+	c.metrics.Hits++
+	return data, true
+}
+
+// Put stores data under key, replacing any previous content.
+func (c *DiskCache) Put(key string, data []byte) error {
+	path := c.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if old, ok := c.index[key]; ok {
+		c.metrics.Size -= old.Size
+	}
+	c.index[key] = &Entry{Key: key, Hash: hashBytes(data), Size: int64(len(data)), CreatedAt: now, AccessedAt: now}
+	c.metrics.Size += int64(len(data))
+	c.metrics.LastUpdated = now
+	c.dirty = true
+	return nil
+}
+
+// Entries returns all entries, least recently used first.
+func (c *DiskCache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sortedLocked()
+}
+
+// Metrics returns the metrics of the current run.
+func (c *DiskCache) Metrics() CacheMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metrics
+}
+
+// Stats reports the size of the cache and the metrics of recent runs.
+func (c *DiskCache) Stats() (Stats, error) {
+	runs, err := c.readRuns()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{Dir: c.dir, Entries: len(c.index), TotalBytes: c.metrics.Size, Runs: runs}
+	for _, r := range runs {
+		stats.Hits += r.Hits
+		stats.Misses += r.Misses
+	}
+	return stats, nil
+}
+
+// Prune removes entries according to opts and returns the removed entries.
+func (c *DiskCache) Prune(opts PruneOptions) ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed []Entry
+	remaining := c.metrics.Size
+	cutoff := time.Now().Add(-opts.MaxAge)
+	for _, e := range c.sortedLocked() {
+		expired := opts.MaxAge > 0 && e.AccessedAt.Before(cutoff)
+		overBudget := opts.MaxBytes > 0 && remaining > opts.MaxBytes
+		if !expired && !overBudget {
+			continue
+		}
+		removed = append(removed, e)
+		remaining -= e.Size
+	}
+
+	if opts.DryRun {
+		return removed, nil
+	}
+	for _, e := range removed {
+		c.removeLocked(c.index[e.Key])
+		c.metrics.Evictions++
+	}
+	return removed, c.saveLocked()
+}
+
+// Verify re-hashes every entry and returns those whose content is missing or
+// does not match. With repair set, the broken entries are removed.
+func (c *DiskCache) Verify(repair bool) ([]Problem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var problems []Problem
+	for _, e := range c.sortedLocked() {
+		data, err := os.ReadFile(c.objectPath(e.Key))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, Problem{Key: e.Key, Reason: "content missing"})
+		case err != nil:
+			problems = append(problems, Problem{Key: e.Key, Reason: err.Error()})
+		case int64(len(data)) != e.Size:
+			problems = append(problems, Problem{Key: e.Key, Reason: fmt.Sprintf("size %d, expected %d", len(data), e.Size)})
+		case hashBytes(data) != e.Hash:
+			problems = append(problems, Problem{Key: e.Key, Reason: "content hash mismatch"})
+		}
+	}
+
+	if !repair || len(problems) == 0 {
+		return problems, nil
+	}
+	for _, p := range problems {
+		c.removeLocked(c.index[p.Key])
+	}
+	return problems, c.saveLocked()
+}
+
+// Clear removes the files the cache keeps in its directory. Anything else
+// there is left alone, and so is the directory unless it ends up empty,
+// since it may be one the user chose that holds other files.
+func (c *DiskCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths := []string{filepath.Join(c.dir, indexFile), filepath.Join(c.dir, metricsFile), filepath.Join(c.dir, objectsDir)}
+	temps, _ := filepath.Glob(filepath.Join(c.dir, ".tmp-*"))
+	for _, path := range append(paths, temps...) {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	// Fails unless the directory is empty, which is fine.
+	os.Remove(c.dir)
+	c.index = make(map[string]*Entry)
+	c.metrics = CacheMetrics{LastUpdated: time.Now()}
+	c.dirty = false
+	return nil
+}
+
+// Close writes the index and, if the cache was used, records the metrics of
+// this run.
+func (c *DiskCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.metrics.Hits+c.metrics.Misses > 0 {
+		if err := c.appendRunLocked(); err != nil {
+			return err
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	return c.saveLocked()
+}
+
+func (c *DiskCache) objectPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, objectsDir, name[:2], name)
+}
+
+func (c *DiskCache) removeLocked(e *Entry) {
+	if e == nil {
+		return
+	}
+	os.Remove(c.objectPath(e.Key))
+	delete(c.index, e.Key)
+	c.metrics.Size -= e.Size
+	c.dirty = true
+}
+
+func (c *DiskCache) sortedLocked() []Entry {
+	entries := make([]Entry, 0, len(c.index))
+	for _, e := range c.index {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].AccessedAt.Equal(entries[j].AccessedAt) {
+			return entries[i].AccessedAt.Before(entries[j].AccessedAt)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+func (c *DiskCache) saveLocked() error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(c.sortedLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, indexFile), data); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *DiskCache) readRuns() ([]Run, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, metricsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache metrics: %w", err)
+	}
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("invalid cache metrics: %w", err)
+	}
+	return runs, nil
+}
+
+func (c *DiskCache) appendRunLocked() error {
+	runs, err := c.readRuns()
+	if err != nil {
+		// A corrupt history is not worth failing a build over.
+		runs = nil
+	}
+	runs = append(runs, Run{
+		Time:      time.Now(),
+		Hits:      c.metrics.Hits,
+		Misses:    c.metrics.Misses,
+		Evictions: c.metrics.Evictions,
+	})
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, metricsFile), data); err != nil {
+		return fmt.Errorf("failed to write cache metrics: %w", err)
+	}
+	c.metrics.Hits, c.metrics.Misses, c.metrics.Evictions = 0, 0, 0
+	return nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place so readers never observe partial content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := OpenDiskCache(dir)
+	if err != nil {
+		t.Fatalf("OpenDiskCache failed: %v", err)
+	}
+
+	if err := c.Put("a", []byte("alpha")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := c.Put("b", []byte("bravo!")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if data, ok := c.Get("a"); !ok || string(data) != "alpha" {
+		t.Errorf("Get(a) = %q, %v", data, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Expected miss for unknown key")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	c, err = OpenDiskCache(dir)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Entries != 2 || stats.TotalBytes != 11 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || len(stats.Runs) != 1 {
+		t.Errorf("Unexpected run metrics: %+v", stats)
+	}
+
+	if err := os.WriteFile(c.objectPath("b"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := c.Verify(true)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Key != "b" {
+		t.Errorf("Unexpected problems: %+v", problems)
+	}
+	if len(c.Entries()) != 1 {
+		t.Errorf("Expected corrupt entry to be removed, got %+v", c.Entries())
+	}
+}
+
+func TestDiskCachePrune(t *testing.T) {
+	c, err := OpenDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"old", "mid", "new"} {
+		if err := c.Put(key, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.index["old"].AccessedAt = time.Now().Add(-48 * time.Hour)
+	c.index["mid"].AccessedAt = time.Now().Add(-time.Hour)
+
+	removed, err := c.Prune(PruneOptions{MaxAge: 24 * time.Hour, DryRun: true})
+	if err != nil || len(removed) != 1 || removed[0].Key != "old" {
+		t.Fatalf("Dry run removed %+v, %v", removed, err)
+	}
+	if len(c.Entries()) != 3 {
+		t.Error("Dry run must not remove entries")
+	}
+
+	removed, err = c.Prune(PruneOptions{MaxBytes: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].Key != "old" || removed[1].Key != "mid" {
+		t.Errorf("Expected least recently used entries to be pruned, got %+v", removed)
+	}
+	if stats, _ := c.Stats(); stats.TotalBytes != 10 {
+		t.Errorf("Expected 10 bytes left, got %d", stats.TotalBytes)
+	}
+}
+
+func TestDiskCacheClearKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenDiskCache(dir)
+	if err != nil {
+		t.Fatalf("OpenDiskCache failed: %v", err)
+	}
+	if err := c.Put("a", []byte("alpha")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if data, err := os.ReadFile(other); err != nil || string(data) != "keep" {
+		t.Errorf("Expected unrelated file to survive, got %q, %v", data, err)
+	}
+	for _, name := range []string{indexFile, metricsFile, objectsDir} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	if len(c.Entries()) != 0 {
+		t.Errorf("Expected empty cache, got %+v", c.Entries())
+	}
+}
@@ -0,0 +1,53 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder"
+)
+
+// BuildCollector measures a production build of the project. The build is
+// written to a temporary directory so the project's output is untouched.
+// Runtime metrics need a browser and are left empty.
+type BuildCollector struct {
+	Options builder.Options
+}
+
+func (c BuildCollector) Collect(ctx context.Context) (*Metrics, error) {
+	outDir, err := os.MkdirTemp("", "gobuild-maintenance-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	opts := c.Options
+	opts.OutDir = outDir
+	if opts.Mode == "" {
+		opts.Mode = "production"
+	}
+	result, err := builder.New().Run(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build failed: %w", err)
+	}
+
+	metrics := &Metrics{Build: BuildMetrics{Duration: result.Duration.Seconds()}}
+	var bytes int64
+	for _, out := range result.Outputs {
+		switch strings.ToLower(filepath.Ext(out.Path)) {
+		case ".js":
+			metrics.Build.Chunks++
+			bytes += out.Size
+		case ".css":
+			bytes += out.Size
+		}
+	}
+	metrics.Build.BundleSize = bytes / 1024
+	if !opts.Minify {
+		metrics.Warnings = append(metrics.Warnings, "measured an unminified build; sizes are larger than in production")
+	}
+	return metrics, nil
+}
@@ -0,0 +1,145 @@
+package maintenance
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the "maintenance" section of config/maintenance.yaml.
+type Config struct {
+	Dependencies DependencyConfig  `yaml:"dependencies" json:"dependencies"`
+	Security     SecurityConfig    `yaml:"security" json:"security"`
+	Performance  PerformanceConfig `yaml:"performance" json:"performance"`
+}
+
+type DependencyConfig struct {
+	// AutoUpdate installs non-breaking updates after each scheduled check.
+	AutoUpdate      bool     `yaml:"auto_update" json:"auto_update"`
+	CheckInterval   Duration `yaml:"check_interval" json:"check_interval"`
+	NotifyOnUpdates bool     `yaml:"notify_on_updates" json:"notify_on_updates"`
+}
+
+type SecurityConfig struct {
+	// ScanSchedule is a five field cron expression.
+	ScanSchedule   string `yaml:"scan_schedule" json:"scan_schedule"`
+	AutoPatch      bool   `yaml:"auto_patch" json:"auto_patch"`
+	NotifyCritical bool   `yaml:"notify_critical" json:"notify_critical"`
+}
+
+type PerformanceConfig struct {
+	MonitorInterval Duration `yaml:"monitor_interval" json:"monitor_interval"`
+	// MetricsRetention is how long saved reports are kept.
+	MetricsRetention Duration `yaml:"metrics_retention" json:"metrics_retention"`
+	// AutoOptimize is accepted for forward compatibility; there is no
+	// automatic optimizer yet, so recommendations are only reported.
+	AutoOptimize bool `yaml:"auto_optimize" json:"auto_optimize"`
+	// Thresholds override the PerformanceMonitor defaults by name.
+	Thresholds map[string]float64 `yaml:"thresholds" json:"thresholds,omitempty"`
+}
+
+// DefaultConfig mirrors the maintenance.yaml shipped with gobuild.
+func DefaultConfig() Config {
+	return Config{
+		Dependencies: DependencyConfig{
+			CheckInterval:   Duration(24 * time.Hour),
+			NotifyOnUpdates: true,
+		},
+		Security: SecurityConfig{
+			ScanSchedule:   "0 0 * * *",
+			NotifyCritical: true,
+		},
+		Performance: PerformanceConfig{
+			MonitorInterval:  Duration(time.Hour),
+			MetricsRetention: Duration(30 * 24 * time.Hour),
+		},
+	}
+}
+
+// LoadConfig reads the maintenance section of path on top of DefaultConfig.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance config: %w", err)
+	}
+
+	file := struct {
+		Maintenance Config `yaml:"maintenance"`
+	}{Maintenance: DefaultConfig()}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	config := &file.Maintenance
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid maintenance config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks intervals and the scan schedule.
+func (c *Config) Validate() error {
+	var errs []string
+	if c.Dependencies.CheckInterval <= 0 {
+		errs = append(errs, "dependencies.check_interval must be positive")
+	}
+	if c.Performance.MonitorInterval <= 0 {
+		errs = append(errs, "performance.monitor_interval must be positive")
+	}
+	if c.Performance.MetricsRetention < 0 {
+		errs = append(errs, "performance.metrics_retention must not be negative")
+	}
+	if _, err := ParseSchedule(c.Security.ScanSchedule); err != nil {
+		errs = append(errs, fmt.Sprintf("security.scan_schedule: %v", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Duration is a time.Duration that also accepts a "d" suffix for days, as
+// in "30d".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := ParseDuration(node.Value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d Duration) String() string {
+	v := time.Duration(d)
+	if v >= 24*time.Hour && v%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", v/(24*time.Hour))
+	}
+	return v.String()
+}
+
+// ParseDuration parses time.ParseDuration values and whole or fractional
+// days such as "7d".
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -1,10 +1,13 @@
 package maintenance
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 )
 
@@ -25,13 +28,16 @@ func (m *DependencyManager) CheckUpdates(ctx context.Context) ([]Update, error)
 	cmd.Dir = m.projectPath
 
 	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+	if err != nil && !hasOutput(err, output) {
+		return nil, fmt.Errorf("npm outdated failed: %w", err)
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
 	}
 
 	var updates map[string]PackageInfo
 	if err := json.Unmarshal(output, &updates); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse npm outdated output: %w", err)
 	}
 
 	return m.processUpdates(updates), nil
@@ -44,8 +50,15 @@ func (m *DependencyManager) ApplySecurityPatches(ctx context.Context) error {
 }
 
 func (m *DependencyManager) processUpdates(updates map[string]PackageInfo) []Update {
+	names := make([]string, 0, len(updates))
+	for pkg := range updates {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+
 	var result []Update
-	for pkg, info := range updates {
+	for _, pkg := range names {
+		info := updates[pkg]
 		update := Update{
 			Package:     pkg,
 			FromVersion: info.Current,
@@ -54,11 +67,18 @@ func (m *DependencyManager) processUpdates(updates map[string]PackageInfo) []Upd
 			Breaking:    isBreakingChange(info.Current, info.Latest),
 		}
 		result = append(result, update)
-		m.logger.Info("Found update", "package", pkg, "from", info.Current, "to", info.Latest)
 	}
 	return result
 }
 
+// hasOutput reports whether err is a non-zero exit of a command that still
+// produced output. npm outdated and npm audit exit with status 1 when they
+// find something.
+func hasOutput(err error, output []byte) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && len(bytes.TrimSpace(output)) > 0
+}
+
 func determineUpdateType(current, latest string) string {
 	currentParts := strings.Split(strings.TrimPrefix(current, "v"), ".")
 	latestParts := strings.Split(strings.TrimPrefix(latest, "v"), ".")
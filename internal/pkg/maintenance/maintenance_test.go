@@ -0,0 +1,135 @@
+package maintenance
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance.yaml")
+	data := `maintenance:
+  dependencies:
+    auto_update: true
+    check_interval: 12h
+  security:
+    scan_schedule: "30 2 * * 1-5"
+  performance:
+    metrics_retention: 7d
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !config.Dependencies.AutoUpdate || time.Duration(config.Dependencies.CheckInterval) != 12*time.Hour {
+		t.Errorf("Unexpected dependency config: %+v", config.Dependencies)
+	}
+	if time.Duration(config.Performance.MetricsRetention) != 7*24*time.Hour {
+		t.Errorf("Expected 7d retention, got %s", config.Performance.MetricsRetention)
+	}
+	// Unset keys keep their defaults.
+	if time.Duration(config.Performance.MonitorInterval) != time.Hour || !config.Security.NotifyCritical {
+		t.Errorf("Defaults not applied: %+v", config)
+	}
+
+	if err := os.WriteFile(path, []byte("maintenance:\n  security:\n    scan_schedule: \"61 * * * *\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected error for invalid scan schedule")
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"0 0 * * *", "2024-03-10T15:04:00Z", "2024-03-11T00:00:00Z"},
+		{"*/15 * * * *", "2024-03-10T15:04:00Z", "2024-03-10T15:15:00Z"},
+		{"30 2 * * 1-5", "2024-03-08T03:00:00Z", "2024-03-11T02:30:00Z"}, // Friday -> Monday
+		{"0 9 1 * 0", "2024-03-02T10:00:00Z", "2024-03-03T09:00:00Z"},    // Sunday or the 1st
+		{"@monthly", "2024-01-31T12:00:00Z", "2024-02-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", tt.expr, err)
+		}
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		if got := s.Next(from).Format(time.RFC3339); got != tt.want {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestParseNPMAudit(t *testing.T) {
+	output := `{
+  "vulnerabilities": {
+    "lodash": {
+      "name": "lodash", "severity": "high",
+      "via": [{"source": 1, "title": "Prototype Pollution", "url": "https://github.com/advisories/GHSA-p6mc-m468-83gw"}],
+      "fixAvailable": {"name": "lodash", "version": "4.17.21", "isSemVerMajor": false}
+    },
+    "webpack": {
+      "name": "webpack", "severity": "critical",
+      "via": ["terser"],
+      "fixAvailable": true
+    }
+  }
+}`
+	vulns, err := parseNPMAudit([]byte(output))
+	if err != nil {
+		t.Fatalf("parseNPMAudit failed: %v", err)
+	}
+	if len(vulns) != 2 || vulns[0].Package != "webpack" {
+		t.Fatalf("Expected critical finding first, got %+v", vulns)
+	}
+	lodash := vulns[1]
+	if lodash.ID != "GHSA-p6mc-m468-83gw" || lodash.FixVersion != "lodash@4.17.21" || lodash.Description != "Prototype Pollution" {
+		t.Errorf("Unexpected lodash finding: %+v", lodash)
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := &Report{
+		GeneratedAt:     time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+		Checks:          AllChecks,
+		Updates:         []Update{{Package: "react", FromVersion: "17.0.2", ToVersion: "18.2.0", Type: "major", Breaking: true}},
+		Vulnerabilities: []Vulnerability{{ID: "GHSA-1", Package: "lodash", Severity: "high", Description: "a|b"}},
+		Performance: &PerformanceReport{
+			BuildMetrics:    BuildMetrics{Duration: 1.5, BundleSize: 2048, Chunks: 3},
+			Recommendations: []string{"Consider code splitting"},
+		},
+	}
+
+	var md bytes.Buffer
+	if err := Render(&md, report, FormatMarkdown); err != nil {
+		t.Fatalf("Render markdown failed: %v", err)
+	}
+	for _, want := range []string{"| react | 17.0.2 | 18.2.0 | major | yes |", `a\|b`, "| Bundle size | 2048 KB |", "- Consider code splitting"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := Render(&html, report, FormatHTML); err != nil {
+		t.Fatalf("Render html failed: %v", err)
+	}
+	if !strings.Contains(html.String(), `<tr class="high"><td>high</td><td>lodash</td>`) {
+		t.Errorf("HTML missing vulnerability row:\n%s", html.String())
+	}
+
+	if err := Render(&bytes.Buffer{}, report, "pdf"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -39,6 +39,7 @@ func (m *PerformanceMonitor) AnalyzePerformance(ctx context.Context) (*Performan
 		BuildMetrics:    metrics.Build,
 		RuntimeMetrics:  metrics.Runtime,
 		Recommendations: recommendations,
+		Warnings:        metrics.Warnings,
 		Timestamp:       time.Now(),
 	}, nil
 }
@@ -0,0 +1,325 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the outcome of one maintenance run.
+type Report struct {
+	GeneratedAt     time.Time          `json:"generated_at"`
+	Checks          []Check            `json:"checks"`
+	Updates         []Update           `json:"updates"`
+	Applied         []Update           `json:"applied,omitempty"`
+	Vulnerabilities []Vulnerability    `json:"vulnerabilities"`
+	Patched         bool               `json:"patched,omitempty"`
+	Performance     *PerformanceReport `json:"performance,omitempty"`
+	Errors          []CheckError       `json:"errors,omitempty"`
+}
+
+// CheckError records a check that could not complete.
+type CheckError struct {
+	Check   Check  `json:"check"`
+	Message string `json:"message"`
+}
+
+// ErrorSummary joins the messages of the failed checks.
+func (r *Report) ErrorSummary() string {
+	msgs := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		msgs[i] = fmt.Sprintf("%s: %s", e.Check, e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Ran reports whether check was part of the run.
+func (r *Report) Ran(check Check) bool {
+	for _, c := range r.Checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
+const reportPrefix = "report-"
+
+// SaveReport writes r as JSON into dir and returns the file path.
+func SaveReport(dir string, r *Report) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, reportPrefix+r.GeneratedAt.UTC().Format("20060102T150405.000Z")+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+// LoadReport reads a report written by SaveReport.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// LatestReport returns the path of the newest report in dir, or "" if there
+// is none.
+func LatestReport(dir string) (string, error) {
+	reports, err := listReports(dir)
+	if err != nil || len(reports) == 0 {
+		return "", err
+	}
+	return reports[len(reports)-1], nil
+}
+
+// PruneReports removes reports older than retention and returns how many
+// were removed. A zero retention keeps everything.
+func PruneReports(dir string, retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	reports, err := listReports(dir)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-retention)
+	removed := 0
+	for _, path := range reports {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove old report: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// listReports returns the report files in dir, oldest first. The timestamp
+// in the name sorts lexically.
+func listReports(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, reportPrefix+"*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Report formats accepted by Render.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Render writes r to w as Markdown, HTML or JSON.
+func Render(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown, "md":
+		return renderMarkdown(w, r)
+	case FormatHTML:
+		return htmlReport.Execute(w, r)
+	}
+	return fmt.Errorf("unsupported report format %q (expected markdown, html or json)", format)
+}
+
+func renderMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Maintenance report\n\nGenerated %s.\n", r.GeneratedAt.Format(time.RFC1123))
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\n## Problems\n\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "- **%s**: %s\n", e.Check, mdEscape(e.Message))
+		}
+	}
+
+	if r.Ran(CheckDependencies) {
+		b.WriteString("\n## Dependency updates\n\n")
+		if len(r.Updates) == 0 {
+			b.WriteString("All dependencies are up to date.\n")
+		} else {
+			b.WriteString("| Package | Current | Latest | Type | Breaking |\n|---|---|---|---|---|\n")
+			for _, u := range r.Updates {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", mdEscape(u.Package), u.FromVersion, u.ToVersion, u.Type, yesNo(u.Breaking))
+			}
+			if len(r.Applied) > 0 {
+				fmt.Fprintf(&b, "\n%d non-breaking update(s) were installed.\n", len(r.Applied))
+			}
+		}
+	}
+
+	if r.Ran(CheckSecurity) {
+		b.WriteString("\n## Vulnerabilities\n\n")
+		if len(r.Vulnerabilities) == 0 {
+			b.WriteString("No known vulnerabilities.\n")
+		} else {
+			b.WriteString("| Severity | Package | ID | Description | Fix |\n|---|---|---|---|---|\n")
+			for _, v := range r.Vulnerabilities {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", v.Severity, mdEscape(v.Package), mdEscape(v.ID), mdEscape(v.Description), mdEscape(v.FixVersion))
+			}
+			if r.Patched {
+				b.WriteString("\nSecurity patches were applied.\n")
+			}
+		}
+	}
+
+	if p := r.Performance; p != nil {
+		b.WriteString("\n## Performance\n\n| Metric | Value |\n|---|---|\n")
+		fmt.Fprintf(&b, "| Build time | %.2fs |\n| Bundle size | %d KB |\n| Chunks | %d |\n", p.BuildMetrics.Duration, p.BuildMetrics.BundleSize, p.BuildMetrics.Chunks)
+		if p.RuntimeMetrics != (RuntimeMetrics{}) {
+			fmt.Fprintf(&b, "| Load time | %.2fs |\n| First paint | %.2fs |\n| Time to interactive | %.2fs |\n", p.RuntimeMetrics.LoadTime, p.RuntimeMetrics.FirstPaint, p.RuntimeMetrics.Interactive)
+		}
+		for _, title := range []struct {
+			name  string
+			items []string
+		}{{"Recommendations", p.Recommendations}, {"Warnings", p.Warnings}} {
+			if len(title.items) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", title.name)
+			for _, item := range title.items {
+				fmt.Fprintf(&b, "- %s\n", mdEscape(item))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var mdReplacer = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func mdEscape(s string) string {
+	return mdReplacer.Replace(s)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ran":   func(r *Report, c string) bool { return r.Ran(Check(c)) },
+	"yesno": yesNo,
+	"lower": strings.ToLower,
+	"date":  func(t time.Time) string { return t.Format(time.RFC1123) },
+	"hasRuntime": func(m RuntimeMetrics) bool {
+		return m != (RuntimeMetrics{})
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Maintenance report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 960px; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1rem; }
+th, td { border: 1px solid #ddd; padding: .4rem .6rem; text-align: left; }
+th { background: #f5f5f5; }
+.critical { color: #b00020; font-weight: bold; }
+.high { color: #d9480f; }
+.problem { background: #fff4f4; border-left: 4px solid #b00020; padding: .5rem 1rem; }
+</style>
+</head>
+<body>
+<h1>Maintenance report</h1>
+<p>Generated {{date .GeneratedAt}}.</p>
+{{- if .Errors}}
+<h2>Problems</h2>
+{{- range .Errors}}
+<p class="problem"><strong>{{.Check}}</strong>: {{.Message}}</p>
+{{- end}}
+{{- end}}
+{{- if ran . "dependencies"}}
+<h2>Dependency updates</h2>
+{{- if .Updates}}
+<table>
+<tr><th>Package</th><th>Current</th><th>Latest</th><th>Type</th><th>Breaking</th></tr>
+{{- range .Updates}}
+<tr><td>{{.Package}}</td><td>{{.FromVersion}}</td><td>{{.ToVersion}}</td><td>{{.Type}}</td><td>{{yesno .Breaking}}</td></tr>
+{{- end}}
+</table>
+{{- if .Applied}}
+<p>{{len .Applied}} non-breaking update(s) were installed.</p>
+{{- end}}
+{{- else}}
+<p>All dependencies are up to date.</p>
+{{- end}}
+{{- end}}
+{{- if ran . "security"}}
+<h2>Vulnerabilities</h2>
+{{- if .Vulnerabilities}}
+<table>
+<tr><th>Severity</th><th>Package</th><th>ID</th><th>Description</th><th>Fix</th></tr>
+{{- range .Vulnerabilities}}
+<tr class="{{lower .Severity}}"><td>{{.Severity}}</td><td>{{.Package}}</td><td>{{.ID}}</td><td>{{.Description}}</td><td>{{.FixVersion}}</td></tr>
+{{- end}}
+</table>
+{{- if .Patched}}
+<p>Security patches were applied.</p>
+{{- end}}
+{{- else}}
+<p>No known vulnerabilities.</p>
+{{- end}}
+{{- end}}
+{{- with .Performance}}
+<h2>Performance</h2>
+<table>
+<tr><th>Metric</th><th>Value</th></tr>
+<tr><td>Build time</td><td>{{printf "%.2f" .BuildMetrics.Duration}}s</td></tr>
+<tr><td>Bundle size</td><td>{{.BuildMetrics.BundleSize}} KB</td></tr>
+<tr><td>Chunks</td><td>{{.BuildMetrics.Chunks}}</td></tr>
+{{- if hasRuntime .RuntimeMetrics}}
+<tr><td>Load time</td><td>{{printf "%.2f" .RuntimeMetrics.LoadTime}}s</td></tr>
+<tr><td>First paint</td><td>{{printf "%.2f" .RuntimeMetrics.FirstPaint}}s</td></tr>
+<tr><td>Time to interactive</td><td>{{printf "%.2f" .RuntimeMetrics.Interactive}}s</td></tr>
+{{- end}}
+</table>
+{{- if .Recommendations}}
+<h3>Recommendations</h3>
+<ul>
+{{- range .Recommendations}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Warnings}}
+<h3>Warnings</h3>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,243 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NPMAuditScanner reports vulnerabilities found by "npm audit" in a project
+// with a package.json.
+type NPMAuditScanner struct {
+	Dir string
+}
+
+// npmAudit is the subset of the npm 7+ "npm audit --json" report we use.
+type npmAudit struct {
+	Vulnerabilities map[string]struct {
+		Name     string            `json:"name"`
+		Severity string            `json:"severity"`
+		Via      []json.RawMessage `json:"via"`
+		// FixAvailable is either a bool or an object naming the fix.
+		FixAvailable json.RawMessage `json:"fixAvailable"`
+	} `json:"vulnerabilities"`
+}
+
+type npmAdvisory struct {
+	Source interface{} `json:"source"`
+	Title  string      `json:"title"`
+	URL    string      `json:"url"`
+}
+
+func (s NPMAuditScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	if _, err := os.Stat(filepath.Join(s.Dir, "package.json")); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "npm", "audit", "--json")
+	cmd.Dir = s.Dir
+	output, err := cmd.Output()
+	if err != nil && !hasOutput(err, output) {
+		return nil, fmt.Errorf("npm audit failed: %w", err)
+	}
+	return parseNPMAudit(output)
+}
+
+func parseNPMAudit(output []byte) ([]Vulnerability, error) {
+	var audit npmAudit
+	if err := json.Unmarshal(output, &audit); err != nil {
+		return nil, fmt.Errorf("failed to parse npm audit output: %w", err)
+	}
+
+	var vulns []Vulnerability
+	for name, entry := range audit.Vulnerabilities {
+		vuln := Vulnerability{Package: name, Severity: entry.Severity}
+
+		// "via" lists advisories, or the names of vulnerable dependencies
+		// that make this package vulnerable transitively.
+		var via []string
+		for _, raw := range entry.Via {
+			var advisory npmAdvisory
+			if json.Unmarshal(raw, &advisory) == nil && advisory.Title != "" {
+				if vuln.ID == "" {
+					vuln.ID = path.Base(advisory.URL)
+					vuln.Description = advisory.Title
+				}
+				continue
+			}
+			var dep string
+			if json.Unmarshal(raw, &dep) == nil {
+				via = append(via, dep)
+			}
+		}
+		if vuln.ID == "" {
+			vuln.ID = name
+			vuln.Description = "vulnerable through " + strings.Join(via, ", ")
+		}
+
+		var fix struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(entry.FixAvailable, &fix) == nil && fix.Version != "" {
+			vuln.FixVersion = fix.Name + "@" + fix.Version
+		}
+		vulns = append(vulns, vuln)
+	}
+	sortVulnerabilities(vulns)
+	return vulns, nil
+}
+
+// GovulncheckScanner reports vulnerabilities in Go code that is actually
+// called, as found by govulncheck. Projects without a go.mod, or machines
+// without govulncheck, yield no findings.
+type GovulncheckScanner struct {
+	Dir string
+}
+
+type govulnMessage struct {
+	OSV *struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module   string `json:"module"`
+			Function string `json:"function"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+func (s GovulncheckScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	if _, err := os.Stat(filepath.Join(s.Dir, "go.mod")); os.IsNotExist(err) {
+		return nil, nil
+	}
+	bin, err := exec.LookPath("govulncheck")
+	if err != nil {
+		return nil, nil
+	}
+
+	cmd := exec.CommandContext(ctx, bin, "-json", "./...")
+	cmd.Dir = s.Dir
+	output, err := cmd.Output()
+	if err != nil && !hasOutput(err, output) {
+		return nil, fmt.Errorf("govulncheck failed: %w", err)
+	}
+	return parseGovulncheck(output)
+}
+
+func parseGovulncheck(output []byte) ([]Vulnerability, error) {
+	summaries := make(map[string]string)
+	found := make(map[string]*Vulnerability)
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var msg govulnMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+
+		switch {
+		case msg.OSV != nil:
+			summaries[msg.OSV.ID] = msg.OSV.Summary
+		case msg.Finding != nil && len(msg.Finding.Trace) > 0:
+			// Findings without a function are only imported, not called.
+			if msg.Finding.Trace[0].Function == "" || found[msg.Finding.OSV] != nil {
+				continue
+			}
+			found[msg.Finding.OSV] = &Vulnerability{
+				ID:         msg.Finding.OSV,
+				Package:    msg.Finding.Trace[0].Module,
+				Severity:   "unknown",
+				FixVersion: msg.Finding.FixedVersion,
+			}
+		}
+	}
+
+	vulns := make([]Vulnerability, 0, len(found))
+	for id, vuln := range found {
+		vuln.Description = summaries[id]
+		vulns = append(vulns, *vuln)
+	}
+	sortVulnerabilities(vulns)
+	return vulns, nil
+}
+
+// MultiScanner merges the findings of several scanners.
+type MultiScanner []VulnerabilityScanner
+
+func (m MultiScanner) Scan(ctx context.Context) ([]Vulnerability, error) {
+	var all []Vulnerability
+	for _, scanner := range m {
+		vulns, err := scanner.Scan(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, vulns...)
+	}
+	sortVulnerabilities(all)
+	return all, nil
+}
+
+// NPMPatcher applies fixes with "npm audit fix".
+type NPMPatcher struct {
+	Dir string
+}
+
+func (p NPMPatcher) ApplyPatches(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "npm", "audit", "fix")
+	cmd.Dir = p.Dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("npm audit fix failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// LogNotifier reports vulnerabilities through a Logger. With CriticalOnly
+// set, less severe findings are not reported.
+type LogNotifier struct {
+	Logger       Logger
+	CriticalOnly bool
+}
+
+func (n LogNotifier) NotifyVulnerability(vuln Vulnerability) error {
+	if n.CriticalOnly && !strings.EqualFold(vuln.Severity, "critical") {
+		return nil
+	}
+	n.Logger.Error("Vulnerability found", "id", vuln.ID, "package", vuln.Package, "severity", vuln.Severity)
+	return nil
+}
+
+var severityRank = map[string]int{"critical": 0, "high": 1, "moderate": 2, "medium": 2, "low": 3, "info": 4}
+
+// sortVulnerabilities orders by severity, then package and ID.
+func sortVulnerabilities(vulns []Vulnerability) {
+	rank := func(v Vulnerability) int {
+		if r, ok := severityRank[strings.ToLower(v.Severity)]; ok {
+			return r
+		}
+		return len(severityRank)
+	}
+	sort.Slice(vulns, func(i, j int) bool {
+		a, b := vulns[i], vulns[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.ID < b.ID
+	})
+}
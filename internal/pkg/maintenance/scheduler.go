@@ -6,76 +6,206 @@ import (
 	"time"
 )
 
+// Check names one part of a maintenance run.
+type Check string
+
+const (
+	CheckDependencies Check = "dependencies"
+	CheckSecurity     Check = "security"
+	CheckPerformance  Check = "performance"
+)
+
+// AllChecks lists every check in the order they run.
+var AllChecks = []Check{CheckDependencies, CheckSecurity, CheckPerformance}
+
+// ParseCheck validates a check name.
+func ParseCheck(name string) (Check, error) {
+	for _, c := range AllChecks {
+		if string(c) == name {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown check %q (expected dependencies, security or performance)", name)
+}
+
 type MaintenanceScheduler struct {
 	dependency  *DependencyManager
+	security    *SecurityManager
 	performance *PerformanceMonitor
-	interval    time.Duration
+	config      Config
 	logger      Logger
+	onReport    func(*Report)
 }
 
-func NewMaintenanceScheduler(dm *DependencyManager, pm *PerformanceMonitor, logger Logger) *MaintenanceScheduler {
+func NewMaintenanceScheduler(dm *DependencyManager, sm *SecurityManager, pm *PerformanceMonitor, config Config, logger Logger) *MaintenanceScheduler {
+	if len(config.Performance.Thresholds) > 0 {
+		pm.UpdateThresholds(config.Performance.Thresholds)
+	}
 	return &MaintenanceScheduler{
 		dependency:  dm,
+		security:    sm,
 		performance: pm,
-		interval:    24 * time.Hour,
+		config:      config,
 		logger:      logger,
 	}
 }
 
+// OnReport registers a function called with the report of every run
+// started by Start.
+func (s *MaintenanceScheduler) OnReport(fn func(*Report)) {
+	s.onReport = fn
+}
+
+// Start runs every check once and then each check on its own schedule until
+// ctx is cancelled: dependencies every check_interval, performance every
+// monitor_interval and security on scan_schedule.
 func (s *MaintenanceScheduler) Start(ctx context.Context) error {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	schedule, err := ParseSchedule(s.config.Security.ScanSchedule)
+	if err != nil {
+		return fmt.Errorf("invalid security.scan_schedule: %w", err)
+	}
 
-	// Run initial maintenance
-	if err := s.runMaintenance(ctx); err != nil {
-		s.logError("Initial maintenance failed", err)
+	now := time.Now()
+	next := map[Check]time.Time{
+		CheckDependencies: now,
+		CheckSecurity:     now,
+		CheckPerformance:  now,
 	}
 
 	for {
+		due, at := s.dueChecks(next)
+		timer := time.NewTimer(time.Until(at))
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			return ctx.Err()
-		case <-ticker.C:
-			if err := s.runMaintenance(ctx); err != nil {
-				s.logError("Scheduled maintenance failed", err)
+		case <-timer.C:
+		}
+
+		s.emit(s.RunOnce(ctx, due...))
+
+		now := time.Now()
+		for _, check := range due {
+			switch check {
+			case CheckDependencies:
+				next[check] = now.Add(time.Duration(s.config.Dependencies.CheckInterval))
+			case CheckPerformance:
+				next[check] = now.Add(time.Duration(s.config.Performance.MonitorInterval))
+			case CheckSecurity:
+				next[check] = schedule.Next(now)
 			}
 		}
 	}
 }
 
-func (s *MaintenanceScheduler) runMaintenance(ctx context.Context) error {
-	// Check for dependency updates
+// dueChecks returns the checks scheduled at the earliest time, in AllChecks
+// order.
+func (s *MaintenanceScheduler) dueChecks(next map[Check]time.Time) ([]Check, time.Time) {
+	var earliest time.Time
+	for _, check := range AllChecks {
+		if t := next[check]; !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	var due []Check
+	for _, check := range AllChecks {
+		if t := next[check]; !t.IsZero() && !t.After(earliest) {
+			due = append(due, check)
+		}
+	}
+	return due, earliest
+}
+
+func (s *MaintenanceScheduler) emit(report *Report) {
+	if len(report.Errors) > 0 {
+		s.logError("Scheduled maintenance reported problems", fmt.Errorf("%s", report.ErrorSummary()))
+	}
+	if s.onReport != nil {
+		s.onReport(report)
+	}
+}
+
+// RunOnce runs the given checks, or all of them when none are given. A
+// failing check is recorded in the report and does not stop the others.
+func (s *MaintenanceScheduler) RunOnce(ctx context.Context, checks ...Check) *Report {
+	if len(checks) == 0 {
+		checks = AllChecks
+	}
+	report := &Report{GeneratedAt: time.Now(), Checks: checks}
+
+	for _, check := range checks {
+		var err error
+		switch check {
+		case CheckDependencies:
+			err = s.runDependencies(ctx, report)
+		case CheckSecurity:
+			err = s.runSecurity(ctx, report)
+		case CheckPerformance:
+			err = s.runPerformance(ctx, report)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, CheckError{Check: check, Message: err.Error()})
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return report
+}
+
+func (s *MaintenanceScheduler) runDependencies(ctx context.Context, report *Report) error {
 	updates, err := s.dependency.CheckUpdates(ctx)
 	if err != nil {
 		return fmt.Errorf("dependency check failed: %w", err)
 	}
+	report.Updates = updates
 
-	if len(updates) > 0 {
+	if len(updates) > 0 && s.config.Dependencies.NotifyOnUpdates {
 		s.logger.Info("Found dependency updates", "count", len(updates))
-		if err := s.dependency.ApplySecurityPatches(ctx); err != nil {
+		for _, u := range updates {
+			s.logger.Info("Dependency update available", "package", u.Package, "from", u.FromVersion, "to", u.ToVersion, "type", u.Type)
+		}
+	}
+
+	if s.config.Dependencies.AutoUpdate {
+		if err := s.dependency.UpdateDependencies(ctx, updates); err != nil {
+			return fmt.Errorf("dependency update failed: %w", err)
+		}
+		for _, u := range updates {
+			if !u.Breaking {
+				report.Applied = append(report.Applied, u)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MaintenanceScheduler) runSecurity(ctx context.Context, report *Report) error {
+	vulns, scanErr := s.security.ScanVulnerabilities(ctx)
+	report.Vulnerabilities = vulns
+
+	if len(vulns) > 0 && s.config.Security.AutoPatch {
+		if err := s.security.ApplySecurityPatches(ctx); err != nil {
 			return fmt.Errorf("security patch failed: %w", err)
 		}
+		report.Patched = true
 	}
+	return scanErr
+}
 
-	// Run performance analysis
-	report, err := s.performance.AnalyzePerformance(ctx)
+func (s *MaintenanceScheduler) runPerformance(ctx context.Context, report *Report) error {
+	perf, err := s.performance.AnalyzePerformance(ctx)
 	if err != nil {
 		return fmt.Errorf("performance analysis failed: %w", err)
 	}
+	report.Performance = perf
 
-	if len(report.Recommendations) > 0 {
-		s.logger.Info("Performance recommendations available", "count", len(report.Recommendations))
+	if len(perf.Recommendations) > 0 {
+		s.logger.Info("Performance recommendations available", "count", len(perf.Recommendations))
 	}
-
 	return nil
 }
 
-func (s *MaintenanceScheduler) SetInterval(duration time.Duration) {
-	if duration > 0 {
-		s.interval = duration
-	}
-}
-
 func (s *MaintenanceScheduler) logError(msg string, err error) {
 	s.logger.Error(msg, "error", err)
 }
@@ -3,7 +3,6 @@ package maintenance
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
@@ -23,49 +22,29 @@ func NewSecurityManager(scanner VulnerabilityScanner, patcher SecurityPatcher, n
 	}
 }
 
-func (s *SecurityManager) ScanVulnerabilities(ctx context.Context) error {
-	// Run Go vulnerability check
-	goCmd := exec.CommandContext(ctx, "govulncheck", "./...")
-	_, err := goCmd.CombinedOutput()
-	if err != nil {
-		s.logger.Error("Go vulnerability check failed", "error", err)
-		return fmt.Errorf("go vulnerability check failed: %w", err)
-	}
-
-	// Run npm audit
-	npmCmd := exec.CommandContext(ctx, "npm", "audit", "--json")
-	_, err = npmCmd.CombinedOutput()
-	if err != nil {
-		s.logger.Error("NPM audit failed", "error", err)
-		return fmt.Errorf("npm audit failed: %w", err)
-	}
-
-	// Process vulnerabilities through scanner
+// ScanVulnerabilities runs the scanner and notifies about every finding.
+// The findings are returned even when the error reports critical ones.
+func (s *SecurityManager) ScanVulnerabilities(ctx context.Context) ([]Vulnerability, error) {
 	vulns, err := s.scanner.Scan(ctx)
 	if err != nil {
-		return fmt.Errorf("vulnerability scan failed: %w", err)
+		s.logger.Error("Vulnerability scan failed", "error", err)
+		return nil, fmt.Errorf("vulnerability scan failed: %w", err)
 	}
 
-	// Notify about found vulnerabilities
-	for _, vuln := range vulns {
-		if err := s.notifier.NotifyVulnerability(vuln); err != nil {
-			s.logger.Error("Failed to notify vulnerability", "id", vuln.ID, "error", err)
+	if s.notifier != nil {
+		for _, vuln := range vulns {
+			if err := s.notifier.NotifyVulnerability(vuln); err != nil {
+				s.logger.Error("Failed to notify vulnerability", "id", vuln.ID, "error", err)
+			}
 		}
 	}
 
-	return s.processVulnerabilities(vulns)
+	return vulns, s.processVulnerabilities(vulns)
 }
 
 func (s *SecurityManager) ApplySecurityPatches(ctx context.Context) error {
-	// Apply npm security fixes
-	npmCmd := exec.CommandContext(ctx, "npm", "audit", "fix")
-	if output, err := npmCmd.CombinedOutput(); err != nil {
-		s.logger.Error("NPM security fix failed", "error", err, "output", string(output))
-		return fmt.Errorf("npm security fix failed: %w", err)
-	}
-
-	// Apply patches through patcher
 	if err := s.patcher.ApplyPatches(ctx); err != nil {
+		s.logger.Error("Security patching failed", "error", err)
 		return fmt.Errorf("failed to apply security patches: %w", err)
 	}
 
@@ -18,30 +18,31 @@ type PackageInfo struct {
 }
 
 type Update struct {
-	Package     string
-	FromVersion string
-	ToVersion   string
-	Type        string // major, minor, patch
-	Breaking    bool
+	Package     string `json:"package"`
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+	Type        string `json:"type"` // major, minor, patch
+	Breaking    bool   `json:"breaking"`
 }
 
 type PerformanceReport struct {
-	BuildMetrics    BuildMetrics
-	RuntimeMetrics  RuntimeMetrics
-	Recommendations []string
-	Timestamp       time.Time
+	BuildMetrics    BuildMetrics   `json:"build"`
+	RuntimeMetrics  RuntimeMetrics `json:"runtime"`
+	Recommendations []string       `json:"recommendations"`
+	Warnings        []string       `json:"warnings,omitempty"`
+	Timestamp       time.Time      `json:"timestamp"`
 }
 
 type BuildMetrics struct {
-	Duration   float64
-	BundleSize int64
-	Chunks     int
+	Duration   float64 `json:"duration_seconds"`
+	BundleSize int64   `json:"bundle_size_kb"` // KB
+	Chunks     int     `json:"chunks"`
 }
 
 type RuntimeMetrics struct {
-	LoadTime    float64
-	FirstPaint  float64
-	Interactive float64
+	LoadTime    float64 `json:"load_time_seconds"`
+	FirstPaint  float64 `json:"first_paint_seconds"`
+	Interactive float64 `json:"interactive_seconds"`
 }
 
 type MetricsCollector interface {
@@ -67,9 +68,9 @@ type SecurityNotifier interface {
 }
 
 type Vulnerability struct {
-	ID          string
-	Package     string
-	Severity    string
-	Description string
-	FixVersion  string
+	ID          string `json:"id"`
+	Package     string `json:"package"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	FixVersion  string `json:"fix_version,omitempty"`
 }
@@ -2,10 +2,19 @@ package optimization
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/builder/compress"
+	"github.com/skbhati199/go-web-build/internal/builder/css"
+	"github.com/skbhati199/go-web-build/internal/builder/html"
+	"github.com/skbhati199/go-web-build/internal/builder/js"
+	"github.com/skbhati199/go-web-build/internal/builder/sourcemap"
 )
 
 type defaultOptimizer struct {
@@ -44,7 +53,7 @@ func (d *defaultOptimizer) Optimize(ctx context.Context, config OptimizationConf
 
 	// Apply compression if enabled
 	if config.Performance.Compression.Enable {
-		if err := d.compressAssets(config.Performance.Compression); err != nil {
+		if err := d.compressAssets(ctx, config.Performance.Compression); err != nil {
 			return fmt.Errorf("failed to compress assets: %w", err)
 		}
 	}
@@ -62,66 +71,207 @@ func (d *defaultOptimizer) Optimize(ctx context.Context, config OptimizationConf
 func (d *defaultOptimizer) minifyAssets(config OptimizationConfig) error {
 	fmt.Println("Minifying assets...")
 
-	// Example: Minify JavaScript files
-	jsFiles, err := filepath.Glob(filepath.Join(d.config.OutputDir, "*.js"))
-	if err != nil {
-		return fmt.Errorf("failed to find JS files: %w", err)
+	if d.config.MinifyJS {
+		if err := d.minifyScripts(); err != nil {
+			return err
+		}
+	}
+	if err := d.minifyStyles(); err != nil {
+		return err
 	}
+	if d.config.MinifyHTML {
+		return d.minifyDocuments()
+	}
+	return nil
+}
 
-	for _, file := range jsFiles {
-		cmd := exec.Command("uglifyjs", file, "-o", file, "--compress", "--mangle")
-		if err := cmd.Run(); err != nil {
+// minifyScripts minifies every script under OutputDir in place and writes
+// its source map. A map the script already links to is chained through the
+// minification, so it keeps pointing at the original sources; other
+// scripts get a map of their unminified code.
+func (d *defaultOptimizer) minifyScripts() error {
+	return filepath.WalkDir(d.config.OutputDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !isScript(file) {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		code := string(data)
+		minified, segments, err := js.Minify(code, js.MinifyOptions{})
+		if err != nil {
 			return fmt.Errorf("failed to minify JS file %s: %w", file, err)
 		}
-	}
 
-	// Example: Minify CSS files
-	cssFiles, err := filepath.Glob(filepath.Join(d.config.OutputDir, "*.css"))
+		name := filepath.Base(file)
+		g := sourcemap.NewGenerator(name)
+		source := g.AddSource(name, code)
+		for _, seg := range segments {
+			m := sourcemap.Mapping{GeneratedLine: seg.GeneratedLine, GeneratedColumn: seg.GeneratedColumn, Source: source, OriginalLine: seg.OriginalLine, OriginalColumn: seg.OriginalColumn, Name: -1}
+			if seg.Name != "" {
+				m.Name = g.AddName(seg.Name)
+			}
+			g.AddMapping(m)
+		}
+		sm := g.SourceMap(true)
+
+		url, mapFile := name+".map", file+".map"
+		if ref := sourcemap.MappingURL(code); isLocalURL(ref) {
+			url, mapFile = ref, filepath.Join(filepath.Dir(file), filepath.FromSlash(ref))
+			if strings.HasPrefix(ref, "/") {
+				mapFile = filepath.Join(d.config.OutputDir, filepath.FromSlash(ref))
+			}
+			if sm, err = chainSourceMap(mapFile, sm); err != nil {
+				return fmt.Errorf("failed to chain source map of %s: %w", file, err)
+			}
+		}
+
+		mapData, err := json.Marshal(sm)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(mapFile, mapData, 0644); err != nil {
+			return err
+		}
+		return os.WriteFile(file, []byte(minified+"\n//# sourceMappingURL="+url+"\n"), 0644)
+	})
+}
+
+// chainSourceMap maps sm through the source map in file, if there is one.
+func chainSourceMap(file string, sm *sourcemap.SourceMap) (*sourcemap.SourceMap, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return sm, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to find CSS files: %w", err)
+		return nil, err
+	}
+	var input sourcemap.SourceMap
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	consumer, err := sourcemap.NewConsumer(&input)
+	if err != nil {
+		return nil, err
+	}
+	chained, err := consumer.Chain(sm)
+	if err != nil {
+		return nil, err
+	}
+	chained.File = input.File
+	return chained, nil
+}
+
+func isScript(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".js", ".mjs", ".cjs":
+		return true
 	}
+	return false
+}
+
+// isLocalURL reports whether a sourceMappingURL names a file of the build
+// rather than another site or inline data.
+func isLocalURL(ref string) bool {
+	return ref != "" && !strings.Contains(ref, "://") && !strings.HasPrefix(ref, "data:") && !strings.HasPrefix(ref, "//")
+}
 
-	for _, file := range cssFiles {
-		cmd := exec.Command("cleancss", "-o", file, file)
-		if err := cmd.Run(); err != nil {
+// minifyStyles minifies every stylesheet under OutputDir in place.
+func (d *defaultOptimizer) minifyStyles() error {
+	return filepath.WalkDir(d.config.OutputDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(file), ".css") {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		minified, _, err := css.Minify(string(data))
+		if err != nil {
 			return fmt.Errorf("failed to minify CSS file %s: %w", file, err)
 		}
-	}
+		return os.WriteFile(file, []byte(minified), 0644)
+	})
+}
 
-	return nil
+// minifyDocuments minifies every HTML file under OutputDir in place.
+func (d *defaultOptimizer) minifyDocuments() error {
+	return filepath.WalkDir(d.config.OutputDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(file), ".html") {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, []byte(html.Minify(string(data))), 0644)
+	})
 }
 
-func (d *defaultOptimizer) compressAssets(config CompressionConfig) error {
+// compressAssets writes gzip and Brotli siblings of the files under
+// OutputDir and reports the ones kept.
+func (d *defaultOptimizer) compressAssets(ctx context.Context, config CompressionConfig) error {
 	fmt.Println("Compressing assets...")
 
-	// Example: Compress files using gzip
-	files, err := filepath.Glob(filepath.Join(d.config.OutputDir, "*"))
+	var files []string
+	err := filepath.WalkDir(d.config.OutputDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(d.config.OutputDir, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to find files: %w", err)
 	}
 
-	for _, file := range files {
-		if strings.HasSuffix(file, ".gz") {
-			continue
-		}
-
-		cmd := exec.Command("gzip", "-k", "-f", file)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to compress file %s: %w", file, err)
-		}
+	results, err := compress.Files(ctx, d.config.OutputDir, files, compress.Options{
+		Algorithm: config.Algorithm,
+		Level:     config.Level,
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		fmt.Printf("  %s: %d -> %d bytes\n", r.Path, r.Original, r.Size)
 	}
-
 	return nil
 }
 
+// splitCode reports the chunks of the build. Splitting happens while
+// bundling, when build.splitting is enabled, since it changes how modules
+// are linked; the output cannot be split afterwards.
 func (d *defaultOptimizer) splitCode(config OptimizationConfig) error {
 	fmt.Println("Splitting code...")
 
-	// Example: Use Webpack for code splitting
-	cmd := exec.Command("webpack", "--config", "webpack.config.js", "--mode", "production")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to split code: %w", err)
+	data, err := os.ReadFile(filepath.Join(d.config.OutputDir, builder.ManifestFile))
+	if os.IsNotExist(err) {
+		fmt.Println("  No asset manifest; chunks are split by production builds with build.splitting enabled")
+		return nil
 	}
-
+	if err != nil {
+		return err
+	}
+	var manifest builder.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", builder.ManifestFile, err)
+	}
+	entries, chunks := 0, 0
+	for name, entry := range manifest {
+		if !strings.HasSuffix(name, ".js") {
+			continue
+		}
+		if entry.IsEntry {
+			entries++
+		} else {
+			chunks++
+		}
+	}
+	fmt.Printf("  %d entry chunk(s), %d async or shared chunk(s)\n", entries, chunks)
 	return nil
 }
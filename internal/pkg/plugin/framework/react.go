@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/builder/html"
 	"github.com/skbhati199/go-web-build/internal/pkg/optimization"
 )
 
@@ -97,25 +102,111 @@ func (r *ReactOptimizer) Cleanup(ctx context.Context) error {
 	return nil
 }
 
+// enablePrefetching adds <link rel="prefetch"> tags for the chunks loaded by
+// import() to the HTML pages of the build in OutputDir, so browsers fetch
+// them while idle. The chunks are read from the asset manifest of a
+// production build.
 func (r *ReactOptimizer) enablePrefetching(projectDir string) error {
-	// This is a custom feature not in the core optimizer
-	// Implement prefetching for React components
+	outDir := r.config.OutputDir
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(projectDir, outDir)
+	}
 
-	// Example: Add prefetch webpack plugin to the webpack config
-	webpackConfigPath := filepath.Join(projectDir, "webpack.config.js")
+	data, err := os.ReadFile(filepath.Join(outDir, builder.ManifestFile))
+	if err != nil {
+		return fmt.Errorf("no asset manifest, run a production build first: %w", err)
+	}
+	var manifest builder.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", builder.ManifestFile, err)
+	}
+	files := asyncChunkFiles(manifest)
+	if len(files) == 0 {
+		return nil
+	}
+
+	return filepath.WalkDir(outDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+		return addPrefetchLinks(path, outDir, files)
+	})
+}
 
-	// Check if webpack config exists
-	if _, err := exec.Command("test", "-f", webpackConfigPath).Output(); err != nil {
-		return fmt.Errorf("webpack config not found: %w", err)
+// asyncChunkFiles returns the files of the chunks reachable through import()
+// from the entries of manifest and of the chunks those import, sorted.
+func asyncChunkFiles(manifest builder.Manifest) []string {
+	seen := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		entry, ok := manifest[name]
+		if !ok || seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, dep := range append(append([]string{}, entry.Imports...), entry.DynamicImports...) {
+			visit(dep)
+		}
+	}
+	for _, entry := range manifest {
+		if entry.IsEntry {
+			for _, name := range entry.DynamicImports {
+				visit(name)
+			}
+		}
 	}
 
-	// Add prefetching plugin (this is a simplified example)
-	cmd := exec.Command("npx", "webpack-cli", "--config", webpackConfigPath, "--prefetch")
-	cmd.Dir = projectDir
+	var files []string
+	for name := range seen {
+		if !manifest[name].IsEntry {
+			files = append(files, manifest[name].File)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// addPrefetchLinks inserts prefetch links for files, given relative to
+// outDir, before </head> of the page at path. Links already present are not
+// added again. Precompressed copies of the page would be stale, so they are
+// removed.
+func addPrefetchLinks(path, outDir string, files []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc := string(data)
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to enable prefetching: %s: %w", output, err)
+	var links strings.Builder
+	for _, file := range files {
+		href, err := filepath.Rel(filepath.Dir(path), filepath.Join(outDir, file))
+		if err != nil {
+			return err
+		}
+		link := fmt.Sprintf(`<link rel="prefetch" href="%s">`, filepath.ToSlash(href))
+		if !strings.Contains(doc, link) {
+			links.WriteString(link)
+		}
+	}
+	if links.Len() == 0 {
+		return nil
 	}
 
+	pos := len(doc)
+	for _, tag := range html.Tags(doc) {
+		if tag.Name == "head" && tag.Closing {
+			pos = tag.Start
+			break
+		}
+	}
+	doc = doc[:pos] + links.String() + doc[pos:]
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		return err
+	}
+	for _, ext := range []string{".gz", ".br"} {
+		if err := os.Remove(path + ext); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	return nil
 }
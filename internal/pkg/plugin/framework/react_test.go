@@ -0,0 +1,55 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnablePrefetching(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `{
+  "assets/main.js": {"file": "assets/main.1a2b3c4d.js", "is_entry": true, "dynamic_imports": ["assets/page.js"]},
+  "assets/page.js": {"file": "assets/page.5e6f7a8b.js", "imports": ["assets/shared.js"]},
+  "assets/shared.js": {"file": "assets/shared.9c0d1e2f.js"}
+}`
+	files := map[string]string{
+		"dist/asset-manifest.json": manifest,
+		"dist/index.html":          "<html><head><title>App</title></head><body></body></html>",
+		"dist/index.html.gz":       "stale",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &ReactOptimizer{}
+	if err := r.Init(context.Background(), json.RawMessage(`{"prefetching": true}`)); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.enablePrefetching(dir); err != nil {
+			t.Fatalf("enablePrefetching failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<title>App</title><link rel="prefetch" href="assets/page.5e6f7a8b.js"><link rel="prefetch" href="assets/shared.9c0d1e2f.js"></head>`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("Expected prefetch links once before </head>, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dist/index.html.gz")); !os.IsNotExist(err) {
+		t.Errorf("Expected stale precompressed page to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package serverless
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifest mirrors the "deploy" section of gobuild.yaml:
+//
+//	deploy:
+//	  provider:
+//	    type: aws
+//	    region: us-east-1
+//	    credentials:
+//	      accessKey: ${AWS_ACCESS_KEY_ID}
+//	    defaults:
+//	      runtime: nodejs18.x
+//	      memory: 128
+//	      timeout: 10
+//	  functions:
+//	    - name: api
+//	      handler: index.handler
+//	      code_path: functions/api
+//	  triggers:
+//	    - type: http
+//	      function: api
+//	      properties:
+//	        path: /api
+//
+// yaml.v3 is used rather than viper because viper lowercases map keys, which
+// would mangle environment variable names and credential keys.
+type manifest struct {
+	Deploy struct {
+		Provider struct {
+			Type        string            `yaml:"type"`
+			Region      string            `yaml:"region"`
+			Credentials map[string]string `yaml:"credentials"`
+			Defaults    struct {
+				Runtime     string            `yaml:"runtime"`
+				Memory      int               `yaml:"memory"`
+				Timeout     int               `yaml:"timeout"`
+				Environment map[string]string `yaml:"environment"`
+			} `yaml:"defaults"`
+		} `yaml:"provider"`
+		TemplatesDir string `yaml:"templates_dir"`
+		Functions    []struct {
+			Name        string            `yaml:"name"`
+			Runtime     string            `yaml:"runtime"`
+			Memory      int               `yaml:"memory"`
+			Timeout     int               `yaml:"timeout"`
+			Environment map[string]string `yaml:"environment"`
+			Template    string            `yaml:"template"`
+			Handler     string            `yaml:"handler"`
+			CodePath    string            `yaml:"code_path"`
+		} `yaml:"functions"`
+		Triggers []struct {
+			Type       string                 `yaml:"type"`
+			Function   string                 `yaml:"function"`
+			Properties map[string]interface{} `yaml:"properties"`
+		} `yaml:"triggers"`
+	} `yaml:"deploy"`
+}
+
+// LoadConfig reads the deploy section of a gobuild.yaml manifest. Provider
+// defaults are applied to functions that do not set their own values, and
+// ${VAR} references in credentials and environment values are expanded.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deploy manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse deploy manifest %s: %w", path, err)
+	}
+	d := m.Deploy
+
+	config := &Config{
+		ProviderConfig: ProviderConfig{
+			Type:        d.Provider.Type,
+			Region:      d.Provider.Region,
+			Credentials: expandValues(d.Provider.Credentials),
+			FunctionOpts: FunctionOptions{
+				Runtime:     d.Provider.Defaults.Runtime,
+				MemorySize:  d.Provider.Defaults.Memory,
+				Timeout:     d.Provider.Defaults.Timeout,
+				Environment: expandValues(d.Provider.Defaults.Environment),
+			},
+		},
+		TemplatesDir: d.TemplatesDir,
+	}
+	if config.TemplatesDir == "" {
+		config.TemplatesDir = "templates"
+	}
+
+	defaults := config.ProviderConfig.FunctionOpts
+	for _, f := range d.Functions {
+		function := FunctionConfig{
+			Name:        f.Name,
+			Runtime:     f.Runtime,
+			Memory:      f.Memory,
+			Timeout:     f.Timeout,
+			Environment: make(map[string]string),
+			Template:    f.Template,
+			Handler:     f.Handler,
+			CodePath:    f.CodePath,
+		}
+		if function.Runtime == "" {
+			function.Runtime = defaults.Runtime
+		}
+		if function.Memory == 0 {
+			function.Memory = defaults.MemorySize
+		}
+		if function.Timeout == 0 {
+			function.Timeout = defaults.Timeout
+		}
+		for key, value := range defaults.Environment {
+			function.Environment[key] = value
+		}
+		for key, value := range expandValues(f.Environment) {
+			function.Environment[key] = value
+		}
+		config.Functions = append(config.Functions, function)
+	}
+
+	for _, t := range d.Triggers {
+		config.Triggers = append(config.Triggers, TriggerConfig{
+			Type:       t.Type,
+			Function:   t.Function,
+			Properties: t.Properties,
+		})
+	}
+
+	return config, nil
+}
+
+// Validate checks that the configuration can be deployed.
+func (c *Config) Validate() error {
+	if c.ProviderConfig.Type == "" {
+		return fmt.Errorf("deploy provider type is required")
+	}
+	if len(c.Functions) == 0 {
+		return fmt.Errorf("no functions configured for deployment")
+	}
+
+	names := make(map[string]bool)
+	for _, function := range c.Functions {
+		if function.Name == "" {
+			return fmt.Errorf("function name is required")
+		}
+		if names[function.Name] {
+			return fmt.Errorf("duplicate function %s", function.Name)
+		}
+		if function.CodePath == "" && function.Template == "" {
+			return fmt.Errorf("function %s needs a code_path or template", function.Name)
+		}
+		names[function.Name] = true
+	}
+
+	for _, trigger := range c.Triggers {
+		if trigger.Type == "" {
+			return fmt.Errorf("trigger for %s has no type", trigger.Function)
+		}
+		if !names[trigger.Function] {
+			return fmt.Errorf("trigger %s references unknown function %s", trigger.Type, trigger.Function)
+		}
+	}
+	return nil
+}
+
+// FunctionNames lists the configured functions in manifest order.
+func (c *Config) FunctionNames() []string {
+	names := make([]string, 0, len(c.Functions))
+	for _, function := range c.Functions {
+		names = append(names, function.Name)
+	}
+	return names
+}
+
+func expandValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	expanded := make(map[string]string, len(values))
+	for key, value := range values {
+		expanded[key] = os.ExpandEnv(value)
+	}
+	return expanded
+}
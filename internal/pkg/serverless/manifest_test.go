@@ -0,0 +1,75 @@
+package serverless
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TEST_DEPLOY_KEY", "secret")
+
+	manifest := `environment: production
+deploy:
+  provider:
+    type: aws
+    region: eu-west-1
+    credentials:
+      accessKey: ${TEST_DEPLOY_KEY}
+    defaults:
+      runtime: nodejs18.x
+      memory: 256
+      environment:
+        LOG_LEVEL: info
+  functions:
+    - name: api
+      handler: index.handler
+      code_path: functions/api
+      timeout: 30
+      environment:
+        API_URL: https://example.com
+  triggers:
+    - type: http
+      function: api
+      properties:
+        path: /api
+`
+	path := filepath.Join(t.TempDir(), "gobuild.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if got := config.ProviderConfig.Credentials["accessKey"]; got != "secret" {
+		t.Errorf("Expected expanded credential, got %q", got)
+	}
+
+	fn := config.Functions[0]
+	if fn.Runtime != "nodejs18.x" || fn.Memory != 256 || fn.Timeout != 30 {
+		t.Errorf("Defaults not applied: %+v", fn)
+	}
+	if fn.Environment["LOG_LEVEL"] != "info" || fn.Environment["API_URL"] != "https://example.com" {
+		t.Errorf("Unexpected environment: %v", fn.Environment)
+	}
+	if len(config.Triggers) != 1 || config.Triggers[0].Properties["path"] != "/api" {
+		t.Errorf("Unexpected triggers: %+v", config.Triggers)
+	}
+}
+
+func TestValidateUnknownTriggerFunction(t *testing.T) {
+	config := &Config{
+		ProviderConfig: ProviderConfig{Type: "aws"},
+		Functions:      []FunctionConfig{{Name: "api", CodePath: "functions/api"}},
+		Triggers:       []TriggerConfig{{Type: "http", Function: "worker"}},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for trigger referencing unknown function")
+	}
+}
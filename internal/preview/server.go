@@ -0,0 +1,476 @@
+// Package preview serves a production build the way a CDN would: with
+// precompressed variants, long-lived caching for the hashed assets listed in
+// the asset manifest and validators for conditional and range requests.
+package preview
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/builder"
+	"github.com/skbhati199/go-web-build/internal/dev"
+)
+
+type Options struct {
+	Host string
+	Port int
+	// Dir is the build output directory to serve.
+	Dir string
+	// SPA serves index.html for unknown paths that look like client side
+	// routes instead of answering 404.
+	SPA bool
+	// HTTPS serves over TLS, with CertFile and KeyFile when both are set
+	// and a self-signed certificate otherwise.
+	HTTPS    bool
+	CertFile string
+	KeyFile  string
+	// AccessLog receives one line per request. Nil disables logging.
+	AccessLog io.Writer
+}
+
+// Server serves a build directory.
+type Server struct {
+	opts     Options
+	etags    sync.Map // file path -> etagEntry
+	manifest manifestFiles
+}
+
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func NewServer(opts Options) *Server {
+	return &Server{opts: opts}
+}
+
+// URL returns the address the server listens on.
+func (s *Server) URL() string {
+	scheme := "http"
+	if s.opts.HTTPS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port)))
+}
+
+// Run serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	if info, err := os.Stat(s.opts.Dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("build directory %s does not exist", s.opts.Dir)
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port)),
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if s.opts.HTTPS {
+		cert, err := s.certificate()
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if s.opts.HTTPS {
+			errCh <- srv.ListenAndServeTLS("", "")
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("preview server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down preview server: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) certificate() (tls.Certificate, error) {
+	if s.opts.CertFile != "" || s.opts.KeyFile != "" {
+		if s.opts.CertFile == "" || s.opts.KeyFile == "" {
+			return tls.Certificate{}, fmt.Errorf("both a certificate and a key file are required")
+		}
+		cert, err := tls.LoadX509KeyPair(s.opts.CertFile, s.opts.KeyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		return cert, nil
+	}
+	cert, err := dev.SelfSignedCertificate(s.opts.Host)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// ServeHTTP serves a file of the build directory.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	encoding := s.serve(rec, r)
+	if s.opts.AccessLog != nil {
+		if encoding != "" {
+			encoding = " (" + encoding + ")"
+		}
+		fmt.Fprintf(s.opts.AccessLog, "%s %s %d %s%s\n", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Microsecond), encoding)
+	}
+}
+
+// serve writes the response and returns the content encoding it used.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) string {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return ""
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	file, status := s.resolve(name, r)
+	if file == "" {
+		http.NotFound(w, r)
+		return ""
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", ContentType(file))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", CacheControl(file, s.immutable(file)))
+
+	served, encoding := negotiate(file, r.Header.Get("Accept-Encoding"), header)
+	f, err := os.Open(served)
+	if err != nil {
+		http.Error(w, "failed to open file", http.StatusInternalServerError)
+		return ""
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return ""
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	if status != http.StatusOK {
+		// Fallback pages are not cacheable resources of their own, so
+		// conditional and range handling does not apply.
+		w.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			io.Copy(w, f)
+		}
+		return encoding
+	}
+
+	etag, err := s.etag(served, info, f)
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return ""
+	}
+	header.Set("ETag", etag)
+	http.ServeContent(w, r, file, info.ModTime(), f)
+	return encoding
+}
+
+// resolve maps a URL path to a file. Directories serve their index.html.
+// Unknown routes serve index.html when SPA fallback applies, or 404.html
+// when the build has one.
+func (s *Server) resolve(name string, r *http.Request) (string, int) {
+	root := s.opts.Dir
+	file := filepath.Join(root, filepath.FromSlash(name))
+	if info, err := os.Stat(file); err == nil {
+		if !info.IsDir() {
+			return file, http.StatusOK
+		}
+		index := filepath.Join(file, "index.html")
+		if isFile(index) {
+			return index, http.StatusOK
+		}
+	}
+
+	if s.opts.SPA && isRoute(name, r) {
+		if index := filepath.Join(root, "index.html"); isFile(index) {
+			return index, http.StatusOK
+		}
+	}
+	if notFound := filepath.Join(root, "404.html"); isFile(notFound) {
+		return notFound, http.StatusNotFound
+	}
+	return "", http.StatusNotFound
+}
+
+// isRoute reports whether a request for a missing path is a page navigation
+// rather than a request for a missing asset.
+func isRoute(name string, r *http.Request) bool {
+	if ext := path.Ext(name); ext != "" && ext != ".html" {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// etag returns a strong validator derived from the content of f, cached
+// until the file changes.
+func (s *Server) etag(file string, info os.FileInfo, f io.ReadSeeker) (string, error) {
+	if cached, ok := s.etags.Load(file); ok {
+		entry := cached.(etagEntry)
+		if entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			return entry.etag, nil
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+	s.etags.Store(file, etagEntry{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// contentTypes overrides mime.TypeByExtension, whose answers depend on the
+// host's mime database, for the file types a build produces.
+var contentTypes = map[string]string{
+	".html":        "text/html; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".css":         "text/css; charset=utf-8",
+	".json":        "application/json",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".txt":         "text/plain; charset=utf-8",
+	".xml":         "application/xml",
+	".svg":         "image/svg+xml",
+	".png":         "image/png",
+	".jpg":         "image/jpeg",
+	".jpeg":        "image/jpeg",
+	".gif":         "image/gif",
+	".webp":        "image/webp",
+	".avif":        "image/avif",
+	".ico":         "image/x-icon",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".ttf":         "font/ttf",
+	".otf":         "font/otf",
+	".wasm":        "application/wasm",
+	".mp4":         "video/mp4",
+	".webm":        "video/webm",
+	".mp3":         "audio/mpeg",
+	".pdf":         "application/pdf",
+}
+
+// ContentType returns the Content-Type for a file name.
+func ContentType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// CacheControl returns the caching policy for a file: HTML is revalidated on
+// every request, immutable assets are cached for a year and everything else
+// is revalidated once stale.
+func CacheControl(name string, immutable bool) string {
+	switch {
+	case strings.HasSuffix(name, ".html"):
+		return "no-cache"
+	case immutable:
+		return "public, max-age=31536000, immutable"
+	}
+	return "public, max-age=0, must-revalidate"
+}
+
+// immutable reports whether file, a path in the build directory, is listed
+// in the build's asset manifest. Those files are named by their content, so
+// a changed file gets a new URL.
+func (s *Server) immutable(file string) bool {
+	rel, err := filepath.Rel(s.opts.Dir, file)
+	if err != nil {
+		return false
+	}
+	return s.manifest.load(filepath.Join(s.opts.Dir, builder.ManifestFile))[filepath.ToSlash(rel)]
+}
+
+// manifestFiles caches the set of files listed in an asset manifest. The
+// manifest is read again when it changes, so rebuilding while the server
+// runs is picked up.
+type manifestFiles struct {
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	files   map[string]bool
+}
+
+func (m *manifestFiles) load(path string) map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		m.files = nil
+		return nil
+	}
+	if m.files != nil && info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return m.files
+	}
+	m.files = nil
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var manifest builder.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	m.files, m.modTime, m.size = manifestFileSet(manifest), info.ModTime(), info.Size()
+	return m.files
+}
+
+// manifestFileSet returns the output files of a manifest, their source maps
+// and their resized image variants. Srcset URLs carry the public path, which
+// is found from the candidate for the image itself.
+func manifestFileSet(manifest builder.Manifest) map[string]bool {
+	files := make(map[string]bool)
+	for _, entry := range manifest {
+		if entry == nil || entry.File == "" {
+			continue
+		}
+		files[entry.File] = true
+		if entry.Map != "" {
+			files[entry.Map] = true
+		}
+		var urls []string
+		prefix, found := "", false
+		for _, candidate := range strings.Split(entry.Srcset, ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) == 0 {
+				continue
+			}
+			urls = append(urls, fields[0])
+			if strings.HasSuffix(fields[0], entry.File) {
+				prefix, found = strings.TrimSuffix(fields[0], entry.File), true
+			}
+		}
+		if !found {
+			continue
+		}
+		for _, url := range urls {
+			if strings.HasPrefix(url, prefix) {
+				files[strings.TrimPrefix(url, prefix)] = true
+			}
+		}
+	}
+	return files
+}
+
+// encodings are the precompressed variants the server looks for, in order of
+// preference.
+var encodings = []struct {
+	name, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// negotiate picks the best precompressed sibling of file the client
+// accepts. It returns the path to serve and the content encoding, "" for
+// the identity encoding.
+func negotiate(file, acceptEncoding string, header http.Header) (string, string) {
+	accepted := parseAcceptEncoding(acceptEncoding)
+	varies := false
+	for _, enc := range encodings {
+		if !isFile(file + enc.ext) {
+			continue
+		}
+		varies = true
+		if accepted(enc.name) {
+			header.Add("Vary", "Accept-Encoding")
+			return file + enc.ext, enc.name
+		}
+	}
+	if varies {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	return file, ""
+}
+
+// parseAcceptEncoding returns a predicate reporting whether a coding is
+// acceptable, honouring q=0 and the * wildcard.
+func parseAcceptEncoding(value string) func(string) bool {
+	q := map[string]float64{}
+	for _, part := range strings.Split(value, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					weight = f
+				}
+			}
+		}
+		q[coding] = weight
+	}
+	return func(coding string) bool {
+		if w, ok := q[coding]; ok {
+			return w > 0
+		}
+		w, ok := q["*"]
+		return ok && w > 0
+	}
+}
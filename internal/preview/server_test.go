@@ -0,0 +1,140 @@
+package preview
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":                 "<html>app</html>",
+		"assets/main.3f2a9c1e.js":    "console.log('hello world')",
+		"assets/main.3f2a9c1e.js.br": "br-bytes",
+		"assets/main.3f2a9c1e.js.gz": "gz-bytes",
+		"robots.txt":                 "User-agent: *",
+		"asset-manifest.json":        `{"assets/main.js": {"file": "assets/main.3f2a9c1e.js"}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewServer(Options{Dir: dir, SPA: true})
+}
+
+func get(s *Server, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeNegotiatesPrecompressed(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		accept, encoding, body string
+	}{
+		{"gzip, deflate, br", "br", "br-bytes"},
+		{"gzip", "gzip", "gz-bytes"},
+		{"br;q=0, gzip", "gzip", "gz-bytes"},
+		{"", "", "console.log('hello world')"},
+	}
+	for _, tt := range tests {
+		rec := get(s, "/assets/main.3f2a9c1e.js", map[string]string{"Accept-Encoding": tt.accept})
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.body {
+			t.Errorf("Accept-Encoding %q: got %d %q", tt.accept, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.accept, got, tt.encoding)
+		}
+		if rec.Header().Get("Content-Type") != "text/javascript; charset=utf-8" || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Unexpected headers: %v", rec.Header())
+		}
+		if rec.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+			t.Errorf("Hashed asset Cache-Control = %q", rec.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+func TestServeConditionalAndRange(t *testing.T) {
+	s := newTestServer(t)
+
+	first := get(s, "/robots.txt", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" || first.Header().Get("Cache-Control") != "public, max-age=0, must-revalidate" {
+		t.Fatalf("Unexpected headers: %v", first.Header())
+	}
+	if rec := get(s, "/robots.txt", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: got %d, want 304", rec.Code)
+	}
+
+	rec := get(s, "/robots.txt", map[string]string{"Range": "bytes=0-9"})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "User-agent" {
+		t.Errorf("Range: got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeSPAFallback(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/users/42", map[string]string{"Accept": "text/html"})
+	if rec.Code != http.StatusOK || rec.Body.String() != "<html>app</html>" || rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Route fallback: got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if rec := get(s, "/assets/missing.js", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Missing asset: got %d, want 404", rec.Code)
+	}
+
+	s.opts.SPA = false
+	if rec := get(s, "/users/42", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Without SPA fallback: got %d, want 404", rec.Code)
+	}
+}
+
+func TestServeCachesManifestFilesAsImmutable(t *testing.T) {
+	s := newTestServer(t)
+	files := map[string]string{
+		"asset-manifest.json": `{
+  "assets/main.js": {"file": "assets/main.3f2a9c1e.js", "map": "sourcemaps/main.3f2a9c1e.js.map"},
+  "src/logo.png": {"file": "static/media/logo.79612083.png", "srcset": "/app/static/media/logo.79612083-480w.png 480w, /app/static/media/logo.79612083.png 1024w"}
+}`,
+		"sourcemaps/main.3f2a9c1e.js.map":     "{}",
+		"static/media/logo.79612083.png":      "png",
+		"static/media/logo.79612083-480w.png": "png",
+		"vendor/jquery.0123456789.js":         "jquery",
+	}
+	for name, content := range files {
+		path := filepath.Join(s.opts.Dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]bool{
+		"/assets/main.3f2a9c1e.js":             true,
+		"/sourcemaps/main.3f2a9c1e.js.map":     true,
+		"/static/media/logo.79612083.png":      true,
+		"/static/media/logo.79612083-480w.png": true,
+		// Looks hashed, but the build did not write it.
+		"/vendor/jquery.0123456789.js": false,
+		"/robots.txt":                  false,
+	}
+	for target, immutable := range tests {
+		got := get(s, target, nil).Header().Get("Cache-Control")
+		if (got == "public, max-age=31536000, immutable") != immutable {
+			t.Errorf("%s: Cache-Control = %q, want immutable %v", target, got, immutable)
+		}
+	}
+}
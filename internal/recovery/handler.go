@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime/debug"
 
+	"github.com/skbhati199/go-web-build/internal/errors"
 	"github.com/skbhati199/go-web-build/internal/logger"
 )
 
@@ -27,14 +28,14 @@ func (h *RecoveryHandler) Recover(err interface{}) error {
 		logger.Error(fmt.Errorf("%v", err), "An unexpected error occurred")
 	}
 
-	return fmt.Errorf("recovered from panic: %v", err)
+	return errors.NewSystemError("recovered from panic", fmt.Errorf("%v", err))
 }
 
 func (h *RecoveryHandler) WrapHandler(fn func() error) func() error {
-	return func() error {
+	return func() (err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				h.Recover(r)
+				err = h.Recover(r)
 			}
 		}()
 		return fn()
@@ -1,12 +1,14 @@
 package engine
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
-
 	"time"
+	"unicode/utf8"
 
 	"github.com/skbhati199/go-web-build/internal/template-engine/cache"
 	"github.com/skbhati199/go-web-build/internal/template-engine/variables"
@@ -35,6 +37,28 @@ func (e *TemplateEngine) RegisterVariable(name, defaultValue, description string
 }
 
 func (e *TemplateEngine) Generate(templateName string, data *TemplateData, outputDir string) error {
+	files, err := e.Render(templateName, data)
+	if err != nil {
+		return fmt.Errorf("failed to process template: %w", err)
+	}
+
+	for name, content := range files {
+		outputPath := filepath.Join(outputDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outputPath, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render processes every file of the template directory templateName,
+// relative to TemplatesDir, and returns the results keyed by slash separated
+// path. Text files have their variables substituted and a .tmpl suffix
+// removed; binary files are returned unchanged.
+func (e *TemplateEngine) Render(templateName string, data *TemplateData) (map[string][]byte, error) {
 	// Set up variables
 	e.variables.SetVariable("projectName", data.ProjectName)
 	e.variables.SetVariable("framework", data.Framework)
@@ -42,42 +66,36 @@ func (e *TemplateEngine) Generate(templateName string, data *TemplateData, outpu
 	for k, v := range data.Configuration {
 		e.variables.SetVariable(k, v)
 	}
+	values := e.variables.GetVariables()
 
-	// Process template with variables
-	if err := e.processTemplateWithVariables(templateName, outputDir); err != nil {
-		return fmt.Errorf("failed to process template: %w", err)
-	}
-
-	return nil
-}
-
-func (e *TemplateEngine) processTemplateWithVariables(templateName, outputDir string) error {
 	files, err := e.getTemplateFiles(templateName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	templatePath := filepath.Join(e.TemplatesDir, templateName)
+	rendered := make(map[string][]byte, len(files))
 	for _, file := range files {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return err
-		}
-
-		// Get values map from registered variables
-		values := e.variables.GetVariables()
-
-		processedContent, err := e.variables.ProcessContent(string(content), values)
+		content, err := os.ReadFile(filepath.Join(templatePath, file))
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		outputPath := filepath.Join(outputDir, filepath.Base(file))
-		if err := os.WriteFile(outputPath, []byte(processedContent), 0644); err != nil {
-			return err
+		if isText(content) {
+			processed, err := e.variables.ProcessContent(string(content), values)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			content = []byte(processed)
 		}
+		rendered[strings.TrimSuffix(filepath.ToSlash(file), ".tmpl")] = content
 	}
+	return rendered, nil
+}
 
-	return nil
+// isText reports whether content should have variables substituted.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
 }
 
 func (e *TemplateEngine) loadTemplate(name string) (*template.Template, error) {
@@ -97,6 +115,9 @@ func (e *TemplateEngine) loadTemplate(name string) (*template.Template, error) {
 	return tmpl, nil
 }
 
+// metadataFiles describe a template and are not part of generated projects.
+var metadataFiles = map[string]bool{"template.json": true, "versions.json": true}
+
 func (e *TemplateEngine) getTemplateFiles(templateName string) ([]string, error) {
 	var files []string
 	templatePath := filepath.Join(e.TemplatesDir, templateName)
@@ -105,11 +126,14 @@ func (e *TemplateEngine) getTemplateFiles(templateName string) ([]string, error)
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".tmpl" {
-			relPath, err := filepath.Rel(templatePath, path)
-			if err != nil {
-				return err
-			}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(templatePath, path)
+		if err != nil {
+			return err
+		}
+		if !metadataFiles[relPath] {
 			files = append(files, relPath)
 		}
 		return nil
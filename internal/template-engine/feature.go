@@ -0,0 +1,269 @@
+package templateengine
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/template-engine/engine"
+)
+
+// Features maps the feature names accepted by AddFeature to the overlay
+// templates that provide them.
+var Features = map[string]string{
+	"router":  "react-router",
+	"redux":   "react-redux",
+	"testing": "react-testing",
+}
+
+// FeatureNames returns the supported feature names in sorted order.
+func FeatureNames() []string {
+	names := make([]string, 0, len(Features))
+	for name := range Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// featureName resolves a feature or overlay name to the feature name.
+func featureName(name string) (string, error) {
+	name = strings.ToLower(name)
+	for feature, overlay := range Features {
+		if name == feature || name == overlay {
+			return feature, nil
+		}
+	}
+	return "", fmt.Errorf("unknown feature %q (available: %s)", name, strings.Join(FeatureNames(), ", "))
+}
+
+// FeatureOptions configure AddFeature.
+type FeatureOptions struct {
+	// Force replaces modified files and conflicting package.json entries.
+	Force bool
+	// DryRun reports the changes without writing them.
+	DryRun bool
+}
+
+// FeatureResult summarises adding a feature. When it has conflicts nothing
+// was written.
+type FeatureResult struct {
+	Feature  string       `json:"feature"`
+	Overlay  string       `json:"overlay"`
+	Version  string       `json:"version"`
+	Language string       `json:"language"`
+	DryRun   bool         `json:"dry_run"`
+	Recorded bool         `json:"recorded"`
+	Files    []FileChange `json:"files"`
+}
+
+// Conflicts returns the number of files that would overwrite local changes.
+func (r *FeatureResult) Conflicts() int {
+	return countConflicts(r.Files)
+}
+
+// AddFeature applies a feature overlay to the existing project in dir.
+// package.json is merged rather than replaced. Other files are only
+// replaced if they are still as generated by the project's template or a
+// previously added feature, unless opts.Force is set. The feature is added
+// to the project's record when it has one.
+func (m *Manager) AddFeature(dir, name string, opts FeatureOptions) (*FeatureResult, error) {
+	feature, err := featureName(name)
+	if err != nil {
+		return nil, err
+	}
+	overlay := Features[feature]
+
+	var record *Record
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(RecordFile))); err == nil {
+		if record, err = LoadRecord(dir); err != nil {
+			return nil, err
+		}
+	}
+	language, projectName := detectLanguage(dir), filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		projectName = filepath.Base(abs)
+	}
+	if record != nil {
+		if !strings.EqualFold(record.Framework, "react") {
+			return nil, fmt.Errorf("%s is a %s project; features are only available for react", dir, record.Framework)
+		}
+		language, projectName = strings.ToLower(record.Language), record.Project
+	}
+
+	v, err := m.versions.GetLatestVersion(overlay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s version: %w", overlay, err)
+	}
+	overlayPath := filepath.Join(overlay, v.Path, language)
+	if info, err := os.Stat(filepath.Join(m.engine.TemplatesDir, overlayPath)); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s does not support %s projects", overlay, language)
+	}
+
+	data := &engine.TemplateData{
+		ProjectName: projectName,
+		Framework:   "react",
+		Language:    language,
+		Version:     v.String(),
+	}
+	if record != nil {
+		data.Configuration = record.configuration()
+	}
+	files, err := m.engine.Render(overlayPath, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", overlay, err)
+	}
+	generated := m.generatedFiles(record, data)
+
+	result := &FeatureResult{
+		Feature:  feature,
+		Overlay:  overlay,
+		Version:  v.String(),
+		Language: language,
+		DryRun:   opts.DryRun,
+		Files:    []FileChange{},
+	}
+	writes := map[string][]byte{}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		change, content, err := applyOverlayFile(dir, name, files[name], generated[name], opts.Force)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			result.Files = append(result.Files, *change)
+			writes[name] = content
+		}
+	}
+
+	if opts.DryRun || result.Conflicts() > 0 {
+		return result, nil
+	}
+	for name, content := range writes {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	if record != nil {
+		if !contains(record.Features, feature) {
+			record.Features = append(record.Features, feature)
+			sort.Strings(record.Features)
+		}
+		record.UpdatedAt = time.Now().UTC()
+		if err := SaveRecord(dir, record); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", RecordFile, err)
+		}
+		result.Recorded = true
+	}
+	return result, nil
+}
+
+// generatedFiles renders the project's template and the features it already
+// has, so files that still match them can be replaced safely. Files
+// that cannot be rendered any more are treated as modified.
+func (m *Manager) generatedFiles(record *Record, data *engine.TemplateData) map[string][][]byte {
+	generated := map[string][][]byte{}
+	add := func(files map[string][]byte) {
+		for name, content := range files {
+			generated[name] = append(generated[name], content)
+		}
+	}
+	if record == nil {
+		return generated
+	}
+
+	if v, err := m.versions.GetVersion(record.Template, record.Version); err == nil {
+		base := *data
+		base.Version = v.String()
+		if files, err := m.engine.Render(filepath.Join(record.Template, v.Path), &base); err == nil {
+			add(files)
+		}
+	}
+	for _, feature := range record.Features {
+		overlay, ok := Features[feature]
+		if !ok {
+			continue
+		}
+		v, err := m.versions.GetLatestVersion(overlay)
+		if err != nil {
+			continue
+		}
+		previous := *data
+		previous.Version = v.String()
+		if files, err := m.engine.Render(filepath.Join(overlay, v.Path, data.Language), &previous); err == nil {
+			add(files)
+		}
+	}
+	return generated
+}
+
+// applyOverlayFile decides what adding one overlay file does to the
+// project, returning nil when the project already has it.
+func applyOverlayFile(dir, name string, content []byte, generated [][]byte, force bool) (*FileChange, []byte, error) {
+	ours, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return &FileChange{Path: name, Action: ActionAdded}, content, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if name == "package.json" {
+		merged, err := mergePackageJSON(ours, content, force)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case len(merged.Conflicts) > 0 && !force:
+			return &FileChange{Path: name, Action: ActionConflict, Note: "conflicting " + strings.Join(merged.Conflicts, ", ")}, nil, nil
+		case len(merged.Conflicts) > 0:
+			return &FileChange{Path: name, Action: ActionMerged, Note: "replaced " + strings.Join(merged.Conflicts, ", ")}, merged.Content, nil
+		case len(merged.Added) > 0:
+			return &FileChange{Path: name, Action: ActionMerged, Note: "added " + strings.Join(merged.Added, ", ")}, merged.Content, nil
+		}
+		return nil, nil, nil
+	}
+
+	if bytes.Equal(ours, content) {
+		return nil, nil, nil
+	}
+	for _, g := range generated {
+		if bytes.Equal(ours, g) {
+			return &FileChange{Path: name, Action: ActionUpdated}, content, nil
+		}
+	}
+	if force {
+		return &FileChange{Path: name, Action: ActionUpdated, Note: "local changes replaced"}, content, nil
+	}
+	return &FileChange{Path: name, Action: ActionConflict, Note: "modified locally"}, nil, nil
+}
+
+// detectLanguage guesses the language of a project without a record.
+func detectLanguage(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "tsconfig.json")); err == nil {
+		return "typescript"
+	}
+	return "javascript"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
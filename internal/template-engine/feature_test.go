@@ -0,0 +1,95 @@
+package templateengine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddFeature(t *testing.T) {
+	templatesDir := t.TempDir()
+	writeFiles(t, templatesDir, map[string]string{
+		"react-typescript/template.json":             `{"name": "react-typescript", "version": "1.0.0"}`,
+		"react-typescript/package.json":              "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"react\": \"^18.2.0\"\n  },\n  \"scripts\": {\n    \"start\": \"react-scripts start\"\n  }\n}\n",
+		"react-typescript/src/App.tsx":               "export default function App() {}\n",
+		"react-typescript/src/index.tsx":             "render(<App />);\n",
+		"react-router/template.json":                 `{"name": "react-router", "version": "1.0.0"}`,
+		"react-router/typescript/package.json":       `{"dependencies": {"react-router-dom": "^6.14.0"}, "scripts": {"routes": "list-routes"}}`,
+		"react-router/typescript/src/App.tsx":        "export default function App() { return <BrowserRouter />; }\n",
+		"react-router/typescript/src/pages/Home.tsx": "export default function Home() {}\n",
+		"react-redux/template.json":                  `{"name": "react-redux", "version": "1.0.0"}`,
+		"react-redux/typescript/package.json":        `{"dependencies": {"react": "^17.0.0", "react-redux": "^8.1.1"}}`,
+		"react-redux/typescript/src/index.tsx":       "render(<Provider><App /></Provider>);\n",
+	})
+
+	manager := NewManager(templatesDir)
+	project := filepath.Join(t.TempDir(), "app")
+	if err := manager.CreateProject(project, "react", "typescript", "", nil); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	if err := manager.CreateProject(filepath.Join(t.TempDir(), "overlay"), "react", "router", "", nil); err == nil || !strings.Contains(err.Error(), "feature overlay") {
+		t.Errorf("CreateProject(react-router) error = %v, want a feature overlay error", err)
+	}
+
+	// App.tsx is unchanged from the template, so the overlay may replace it.
+	result, err := manager.AddFeature(project, "router", FeatureOptions{})
+	if err != nil {
+		t.Fatalf("AddFeature(router) error = %v", err)
+	}
+	actions := map[string]string{}
+	for _, f := range result.Files {
+		actions[f.Path] = f.Action
+	}
+	want := map[string]string{"package.json": ActionMerged, "src/App.tsx": ActionUpdated, "src/pages/Home.tsx": ActionAdded}
+	for path, action := range want {
+		if actions[path] != action {
+			t.Errorf("%s: action = %q, want %q", path, actions[path], action)
+		}
+	}
+	pkg, _ := os.ReadFile(filepath.Join(project, "package.json"))
+	wantPkg := "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"react\": \"^18.2.0\",\n    \"react-router-dom\": \"^6.14.0\"\n  },\n  \"scripts\": {\n    \"start\": \"react-scripts start\",\n    \"routes\": \"list-routes\"\n  }\n}\n"
+	if string(pkg) != wantPkg {
+		t.Errorf("package.json =\n%s\nwant\n%s", pkg, wantPkg)
+	}
+
+	// Local edits and a conflicting dependency block redux without --force.
+	writeFiles(t, project, map[string]string{"src/index.tsx": "render(<App />); // mine\n"})
+	result, err = manager.AddFeature(project, "react-redux", FeatureOptions{})
+	if err != nil {
+		t.Fatalf("AddFeature(redux) error = %v", err)
+	}
+	if result.Conflicts() != 2 {
+		t.Errorf("AddFeature(redux) conflicts = %d, want 2: %+v", result.Conflicts(), result.Files)
+	}
+	if index, _ := os.ReadFile(filepath.Join(project, "src/index.tsx")); !strings.Contains(string(index), "mine") {
+		t.Errorf("Conflicting add overwrote src/index.tsx")
+	}
+
+	if _, err := manager.AddFeature(project, "redux", FeatureOptions{Force: true}); err != nil {
+		t.Fatalf("AddFeature(redux, force) error = %v", err)
+	}
+	pkg, _ = os.ReadFile(filepath.Join(project, "package.json"))
+	if !strings.Contains(string(pkg), `"react": "^17.0.0"`) || !strings.Contains(string(pkg), `"react-redux"`) {
+		t.Errorf("Forced merge did not update package.json:\n%s", pkg)
+	}
+
+	record, err := LoadRecord(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(record.Features, ",") != "redux,router" {
+		t.Errorf("Recorded features = %v, want [redux router]", record.Features)
+	}
+
+	// Adding a feature again is a no-op, even though its files now match a
+	// previous feature rather than the base template.
+	result, err = manager.AddFeature(project, "router", FeatureOptions{})
+	if err != nil || len(result.Files) != 0 {
+		t.Errorf("Re-adding router = %+v, %v; want no changes", result, err)
+	}
+
+	if _, err := manager.AddFeature(project, "mobx", FeatureOptions{}); err == nil {
+		t.Errorf("AddFeature(mobx) succeeded, want an error")
+	}
+}
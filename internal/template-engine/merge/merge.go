@@ -0,0 +1,223 @@
+// Package merge implements a line based three-way merge in the style of
+// diff3, used to carry template changes into files users have edited.
+package merge
+
+import (
+	"bytes"
+)
+
+// Labels name the three sides in conflict markers.
+type Labels struct {
+	Ours   string
+	Theirs string
+}
+
+// Result is the outcome of a merge. Content holds git style conflict
+// markers for every region changed differently on both sides.
+type Result struct {
+	Content   []byte
+	Conflicts int
+}
+
+// Merge applies the changes from base to theirs on top of ours.
+func Merge(base, ours, theirs []byte, labels Labels) Result {
+	o, a, b := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	matchA, matchB := matches(o, a), matches(o, b)
+
+	var out bytes.Buffer
+	conflicts := 0
+	emit := func(lines []string) {
+		for _, line := range lines {
+			out.WriteString(line)
+		}
+	}
+
+	io, ia, ib := 0, 0, 0
+	for io < len(o) || ia < len(a) || ib < len(b) {
+		// Stable run: base lines matched at the current position of both
+		// sides.
+		n := 0
+		for io+n < len(o) && matchA[io+n] == ia+n && matchB[io+n] == ib+n {
+			n++
+		}
+		if n > 0 {
+			emit(o[io : io+n])
+			io, ia, ib = io+n, ia+n, ib+n
+			continue
+		}
+
+		// Unstable chunk up to the next base line both sides kept.
+		no, na, nb := len(o), len(a), len(b)
+		for k := io; k < len(o); k++ {
+			if matchA[k] >= 0 && matchB[k] >= 0 {
+				no, na, nb = k, matchA[k], matchB[k]
+				break
+			}
+		}
+		chunkO, chunkA, chunkB := o[io:no], a[ia:na], b[ib:nb]
+		switch {
+		case equal(chunkA, chunkO):
+			emit(chunkB)
+		case equal(chunkB, chunkO), equal(chunkA, chunkB):
+			emit(chunkA)
+		default:
+			conflicts++
+			writeConflict(&out, chunkA, chunkB, labels)
+		}
+		io, ia, ib = no, na, nb
+	}
+	return Result{Content: out.Bytes(), Conflicts: conflicts}
+}
+
+func writeConflict(out *bytes.Buffer, ours, theirs []string, labels Labels) {
+	marker := func(m, label string) {
+		out.WriteString(m)
+		if label != "" {
+			out.WriteString(" " + label)
+		}
+		out.WriteString("\n")
+	}
+	block := func(lines []string) {
+		for _, line := range lines {
+			out.WriteString(line)
+		}
+		if n := len(lines); n > 0 && !hasNewline(lines[n-1]) {
+			out.WriteString("\n")
+		}
+	}
+
+	marker("<<<<<<<", labels.Ours)
+	block(ours)
+	marker("=======", "")
+	block(theirs)
+	marker(">>>>>>>", labels.Theirs)
+}
+
+func hasNewline(line string) bool {
+	return len(line) > 0 && line[len(line)-1] == '\n'
+}
+
+// SplitLines splits data after every newline, keeping line endings so the
+// lines concatenate back to data.
+func SplitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns, for every line of x, the index of the line of y it is
+// paired with in a shortest edit script, or -1 when it was deleted.
+func matches(x, y []string) []int {
+	m := make([]int, len(x))
+	for i := range m {
+		m[i] = -1
+	}
+
+	// Common prefix and suffix need no search.
+	start := 0
+	for start < len(x) && start < len(y) && x[start] == y[start] {
+		m[start] = start
+		start++
+	}
+	endX, endY := len(x), len(y)
+	for endX > start && endY > start && x[endX-1] == y[endY-1] {
+		endX--
+		endY--
+		m[endX] = endY
+	}
+
+	for _, p := range myers(x[start:endX], y[start:endY]) {
+		m[start+p[0]] = start + p[1]
+	}
+	return m
+}
+
+// myers returns the matched line pairs of a shortest edit script between x
+// and y, using Myers' O((N+M)D) algorithm.
+func myers(x, y []string) [][2]int {
+	n, m := len(x), len(y)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	max := n + m
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		// Only diagonals -d..d are read when backtracking through step d.
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+			if i >= n && j >= m {
+				return backtrack(trace, x, y, d)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved frontiers from the end of both inputs to
+// recover the diagonal moves, which are the matched lines.
+func backtrack(trace [][]int, x, y []string, d int) [][2]int {
+	var pairs [][2]int
+	i, j := len(x), len(y)
+	for ; d > 0; d-- {
+		v := trace[d] // v[d+k] is the frontier of diagonal k
+		k := i - j
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := v[d+prevK]
+		prevJ := prevI - prevK
+		for i > prevI && j > prevJ {
+			i--
+			j--
+			pairs = append(pairs, [2]int{i, j})
+		}
+		i, j = prevI, prevJ
+	}
+	for i > 0 && j > 0 {
+		i--
+		j--
+		pairs = append(pairs, [2]int{i, j})
+	}
+	for l, r := 0, len(pairs)-1; l < r; l, r = l+1, r-1 {
+		pairs[l], pairs[r] = pairs[r], pairs[l]
+	}
+	return pairs
+}
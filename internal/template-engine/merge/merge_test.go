@@ -0,0 +1,76 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	labels := Labels{Ours: "yours", Theirs: "template 1.1.0"}
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{"only theirs changed", base, "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n", 0},
+		{"only ours changed", "a\nb\nc\nD\ne\n", base, "a\nb\nc\nD\ne\n", 0},
+		{"both changed apart", "a\nb\nc\nD\ne\n", "A\nb\nc\nd\ne\nf\n", "A\nb\nc\nD\ne\nf\n", 0},
+		{"same change", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", 0},
+		{"insertions and deletions", "a\nc\nd\ne\n", "a\nb\nc\nd\nnew\ne\n", "a\nc\nd\nnew\ne\n", 0},
+		{
+			"conflict",
+			"a\nours\nc\nd\ne\n",
+			"a\ntheirs\nc\nd\ne\n",
+			"a\n<<<<<<< yours\nours\n=======\ntheirs\n>>>>>>> template 1.1.0\nc\nd\ne\n",
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge([]byte(base), []byte(tt.ours), []byte(tt.theirs), labels)
+			if string(got.Content) != tt.want || got.Conflicts != tt.conflicts {
+				t.Errorf("Merge() = %q with %d conflicts, want %q with %d", got.Content, got.Conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeNewFile(t *testing.T) {
+	// A file added by the template that the user also created conflicts as a
+	// whole.
+	got := Merge(nil, []byte("mine\n"), []byte("template\n"), Labels{})
+	if got.Conflicts != 1 || !strings.Contains(string(got.Content), "mine\n=======\ntemplate\n") {
+		t.Errorf("Unexpected merge: %q", got.Content)
+	}
+}
+
+func TestMatchesLongInput(t *testing.T) {
+	var x, y []string
+	for i := 0; i < 2000; i++ {
+		line := strings.Repeat("x", i%7) + "\n"
+		x = append(x, line)
+		if i%100 != 0 {
+			y = append(y, line)
+		}
+	}
+	m := matches(x, y)
+	matched := 0
+	last := -1
+	for _, j := range m {
+		if j < 0 {
+			continue
+		}
+		if j <= last {
+			t.Fatalf("Matches are not monotonic")
+		}
+		last = j
+		matched++
+	}
+	if matched != len(y) {
+		t.Errorf("Matched %d lines, want %d", matched, len(y))
+	}
+}
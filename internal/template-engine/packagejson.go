@@ -0,0 +1,166 @@
+package templateengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// jsonObject is a JSON object that keeps its key order, so rewriting a
+// user's package.json only changes the entries that were merged.
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+	o.keys, o.values = nil, map[string]json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		o.set(tok.(string), value)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(jsonString(key))
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *jsonObject) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// packageSections are the package.json objects merged entry by entry.
+// Dependency sections are kept sorted the way npm writes them.
+var packageSections = map[string]bool{
+	"dependencies":         true,
+	"devDependencies":      true,
+	"peerDependencies":     true,
+	"optionalDependencies": true,
+	"scripts":              false,
+}
+
+// packageMerge is the outcome of merging an overlay's package.json.
+type packageMerge struct {
+	Content   []byte
+	Added     []string
+	Conflicts []string
+}
+
+// mergePackageJSON adds the dependencies, scripts and other top level keys of
+// overlay to project. Entries the project already has with a different
+// value are conflicts, and are only replaced when force is set.
+func mergePackageJSON(project, overlay []byte, force bool) (*packageMerge, error) {
+	var dst, src jsonObject
+	if err := json.Unmarshal(project, &dst); err != nil {
+		return nil, fmt.Errorf("package.json: %w", err)
+	}
+	if err := json.Unmarshal(overlay, &src); err != nil {
+		return nil, fmt.Errorf("overlay package.json: %w", err)
+	}
+
+	result := &packageMerge{}
+	for _, key := range src.keys {
+		value := src.values[key]
+		sorted, section := packageSections[key]
+		existing, exists := dst.values[key]
+		if !exists {
+			dst.set(key, value)
+			result.Added = append(result.Added, key)
+			continue
+		}
+		if !section {
+			if !jsonEqual(existing, value) {
+				result.Conflicts = append(result.Conflicts, key)
+				if force {
+					dst.set(key, value)
+				}
+			}
+			continue
+		}
+
+		var into, from jsonObject
+		if err := json.Unmarshal(existing, &into); err != nil {
+			return nil, fmt.Errorf("package.json %s: %w", key, err)
+		}
+		if err := json.Unmarshal(value, &from); err != nil {
+			return nil, fmt.Errorf("overlay package.json %s: %w", key, err)
+		}
+		changed := false
+		for _, name := range from.keys {
+			current, ok := into.values[name]
+			switch {
+			case !ok:
+				result.Added = append(result.Added, key+"."+name)
+			case jsonEqual(current, from.values[name]):
+				continue
+			default:
+				result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s.%s (%s, overlay wants %s)", key, name, current, from.values[name]))
+				if !force {
+					continue
+				}
+			}
+			into.set(name, from.values[name])
+			changed = true
+		}
+		if changed {
+			if sorted {
+				sort.Strings(into.keys)
+			}
+			data, _ := into.MarshalJSON()
+			dst.set(key, data)
+		}
+	}
+
+	data, _ := dst.MarshalJSON()
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	result.Content = out.Bytes()
+	return result, nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y bytes.Buffer
+	if json.Compact(&x, a) != nil || json.Compact(&y, b) != nil {
+		return false
+	}
+	return bytes.Equal(x.Bytes(), y.Bytes())
+}
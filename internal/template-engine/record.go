@@ -0,0 +1,81 @@
+package templateengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RecordFile is where a generated project records the template it was
+// created from, relative to the project directory.
+const RecordFile = ".gobuild/template.json"
+
+// Record describes how a project was generated so it can be regenerated
+// with a newer template version.
+type Record struct {
+	Template  string            `json:"template"`
+	Project   string            `json:"project"`
+	Framework string            `json:"framework"`
+	Language  string            `json:"language"`
+	Version   string            `json:"version"`
+	Variables map[string]string `json:"variables,omitempty"`
+	Features  []string          `json:"features,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at,omitempty"`
+}
+
+// LoadRecord reads the record of the project in dir.
+func LoadRecord(dir string) (*Record, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(RecordFile)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s has no %s; it was not created by gobuild create", dir, RecordFile)
+		}
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", RecordFile, err)
+	}
+	if record.Template == "" || record.Version == "" {
+		return nil, fmt.Errorf("%s does not name a template and version", RecordFile)
+	}
+	return &record, nil
+}
+
+// SaveRecord writes the record of the project in dir.
+func SaveRecord(dir string, record *Record) error {
+	path := filepath.Join(dir, filepath.FromSlash(RecordFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// configuration converts recorded variables back to template configuration.
+func (r *Record) configuration() map[string]interface{} {
+	config := make(map[string]interface{}, len(r.Variables))
+	for k, v := range r.Variables {
+		config[k] = v
+	}
+	return config
+}
+
+// stringValues formats configuration values the way the variable manager
+// does, so recorded variables render identically.
+func stringValues(config map[string]interface{}) map[string]string {
+	if len(config) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(config))
+	for k, v := range config {
+		values[k] = fmt.Sprintf("%v", v)
+	}
+	return values
+}
@@ -2,7 +2,9 @@ package templateengine // Update package name
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/skbhati199/go-web-build/internal/template-engine/engine"
 	"github.com/skbhati199/go-web-build/internal/template-engine/validation"
@@ -31,6 +33,11 @@ func (m *Manager) CreateProject(name, framework, language, ver string, config ma
 	}
 
 	templateName := fmt.Sprintf("%s-%s", strings.ToLower(framework), strings.ToLower(language))
+	for feature, overlay := range Features {
+		if templateName == overlay {
+			return fmt.Errorf("%s is a feature overlay, not a project template; add it to a project with \"gobuild add %s\"", templateName, feature)
+		}
+	}
 
 	if err := m.validator.ValidateTemplate(templateName); err != nil {
 		return fmt.Errorf("invalid template combination: %w", err)
@@ -64,7 +71,20 @@ func (m *Manager) CreateProject(name, framework, language, ver string, config ma
 		Configuration: config,
 	}
 
-	return m.engine.Generate(templateName, data, name)
+	if err := m.engine.Generate(filepath.Join(templateName, templateVersion.Path), data, name); err != nil {
+		return err
+	}
+
+	record := &Record{
+		Template:  templateName,
+		Project:   name,
+		Framework: framework,
+		Language:  language,
+		Version:   templateVersion.Version.String(),
+		Variables: stringValues(config),
+		CreatedAt: time.Now().UTC(),
+	}
+	return SaveRecord(name, record)
 }
 
 func isValidFramework(framework string) bool {
@@ -0,0 +1,235 @@
+package templateengine
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/skbhati199/go-web-build/internal/template-engine/engine"
+	"github.com/skbhati199/go-web-build/internal/template-engine/merge"
+	"github.com/skbhati199/go-web-build/internal/template-engine/version"
+)
+
+// Actions reported for the files an upgrade touches.
+const (
+	ActionAdded    = "added"
+	ActionUpdated  = "updated"
+	ActionMerged   = "merged"
+	ActionConflict = "conflict"
+	ActionRemoved  = "removed"
+	ActionKept     = "kept"
+)
+
+// FileChange is what an upgrade did, or would do, to one file.
+type FileChange struct {
+	Path      string `json:"path"`
+	Action    string `json:"action"`
+	Conflicts int    `json:"conflicts,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// UpgradeResult summarises an upgrade. Files only lists files that changed
+// or need attention.
+type UpgradeResult struct {
+	Template string       `json:"template"`
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	DryRun   bool         `json:"dry_run"`
+	Files    []FileChange `json:"files"`
+}
+
+// Conflicts returns the number of files left with conflict markers or
+// unmerged binary changes.
+func (r *UpgradeResult) Conflicts() int {
+	return countConflicts(r.Files)
+}
+
+func countConflicts(files []FileChange) int {
+	n := 0
+	for _, f := range files {
+		if f.Action == ActionConflict {
+			n++
+		}
+	}
+	return n
+}
+
+// UpgradeOptions configure Upgrade.
+type UpgradeOptions struct {
+	// Version is a version or constraint to upgrade to. Empty selects the
+	// latest version.
+	Version string
+	// DryRun reports the changes without writing them.
+	DryRun bool
+}
+
+// Upgrade moves the project in dir to a newer version of the template it
+// was created from. Both template versions are rendered with the recorded
+// variables, and the difference between them is merged into the project
+// with a three-way merge, so local edits are kept.
+func (m *Manager) Upgrade(dir string, opts UpgradeOptions) (*UpgradeResult, error) {
+	record, err := LoadRecord(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := m.versions.GetVersion(record.Template, record.Version)
+	if err != nil {
+		return nil, fmt.Errorf("recorded template version is not available: %w", err)
+	}
+	var to *version.TemplateVersion
+	if opts.Version != "" {
+		to, err = m.versions.GetVersion(record.Template, opts.Version)
+	} else {
+		to, err = m.versions.GetLatestVersion(record.Template)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template version: %w", err)
+	}
+	if to.Version.LessThan(from.Version) {
+		return nil, fmt.Errorf("%s %s is older than the project's version %s", record.Template, to, from)
+	}
+
+	result := &UpgradeResult{Template: record.Template, From: from.String(), To: to.String(), DryRun: opts.DryRun, Files: []FileChange{}}
+	if to.Version.Equal(from.Version) {
+		return result, nil
+	}
+
+	data := &engine.TemplateData{
+		ProjectName:   record.Project,
+		Framework:     record.Framework,
+		Language:      record.Language,
+		Configuration: record.configuration(),
+	}
+	data.Version = from.String()
+	base, err := m.engine.Render(filepath.Join(record.Template, from.Path), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s %s: %w", record.Template, from, err)
+	}
+	data.Version = to.String()
+	theirs, err := m.engine.Render(filepath.Join(record.Template, to.Path), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s %s: %w", record.Template, to, err)
+	}
+
+	labels := merge.Labels{Ours: "local", Theirs: record.Template + " " + to.String()}
+	writes := map[string][]byte{}
+	var removals []string
+	for _, name := range unionKeys(base, theirs) {
+		if name == RecordFile {
+			continue
+		}
+		change, content, err := upgradeFile(dir, name, base[name], theirs[name], labels)
+		if err != nil {
+			return nil, err
+		}
+		if change == nil {
+			continue
+		}
+		result.Files = append(result.Files, *change)
+		switch {
+		case change.Action == ActionRemoved:
+			removals = append(removals, name)
+		case content != nil:
+			writes[change.Path] = content
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	for name, content := range writes {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range removals {
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	record.Version = to.String()
+	record.UpdatedAt = time.Now().UTC()
+	if err := SaveRecord(dir, record); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", RecordFile, err)
+	}
+	return result, nil
+}
+
+// upgradeFile decides how one file moves from base to theirs given the
+// project's copy. It returns nil when nothing changes, and the content to
+// write, if any. A nil base or theirs means the file does not exist in that
+// template version.
+func upgradeFile(dir, name string, base, theirs []byte, labels merge.Labels) (*FileChange, []byte, error) {
+	if base != nil && theirs != nil && bytes.Equal(base, theirs) {
+		return nil, nil, nil
+	}
+
+	ours, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	switch {
+	case theirs == nil:
+		// Removed from the template.
+		if !exists {
+			return nil, nil, nil
+		}
+		if bytes.Equal(ours, base) {
+			return &FileChange{Path: name, Action: ActionRemoved}, nil, nil
+		}
+		return &FileChange{Path: name, Action: ActionKept, Note: "removed from the template but edited locally"}, nil, nil
+
+	case !exists:
+		if base != nil {
+			return &FileChange{Path: name, Action: ActionKept, Note: "changed in the template but deleted locally"}, nil, nil
+		}
+		return &FileChange{Path: name, Action: ActionAdded}, theirs, nil
+
+	case bytes.Equal(ours, theirs):
+		return nil, nil, nil
+
+	case base != nil && bytes.Equal(ours, base):
+		return &FileChange{Path: name, Action: ActionUpdated}, theirs, nil
+	}
+
+	if !isText(ours) || !isText(theirs) {
+		// Binary files cannot be merged; leave the local copy and put the
+		// template's next to it.
+		return &FileChange{Path: name + ".new", Action: ActionConflict, Note: "binary file changed in the template and locally; the template version was written to " + name + ".new"}, theirs, nil
+	}
+
+	merged := merge.Merge(base, ours, theirs, labels)
+	if merged.Conflicts > 0 {
+		return &FileChange{Path: name, Action: ActionConflict, Conflicts: merged.Conflicts}, merged.Content, nil
+	}
+	return &FileChange{Path: name, Action: ActionMerged}, merged.Content, nil
+}
+
+func isText(content []byte) bool {
+	return !bytes.ContainsRune(content, 0)
+}
+
+func unionKeys(a, b map[string][]byte) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+package templateengine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	templatesDir := t.TempDir()
+	writeFiles(t, filepath.Join(templatesDir, "react-typescript"), map[string]string{
+		"template.json": `{"name": "react-typescript", "version": "1.1.0", "framework": "react", "language": "typescript"}`,
+
+		"v1.0.0/src/App.tsx":     "import React from 'react';\n\nexport default function App() {\n  return <h1>Hello</h1>;\n}\n",
+		"v1.0.0/src/index.css":   "body {\n  margin: 0;\n}\n",
+		"v1.0.0/tsconfig.json":   "{\n  \"strict\": false\n}\n",
+		"v1.0.0/src/legacy.ts":   "export {};\n",
+		"v1.0.0/src/removed.ts":  "export {};\n",
+		"v1.0.0/public/logo.png": "\x89PNG\x00v1",
+
+		"v1.1.0/src/App.tsx":     "import React from 'react';\nimport './index.css';\n\nexport default function App() {\n  return <h1>Hello</h1>;\n}\n",
+		"v1.1.0/src/index.css":   "body {\n  margin: 0;\n  font-family: sans-serif;\n}\n",
+		"v1.1.0/tsconfig.json":   "{\n  \"strict\": true\n}\n",
+		"v1.1.0/.eslintrc":       "{}\n",
+		"v1.1.0/public/logo.png": "\x89PNG\x00v2",
+	})
+
+	manager := NewManager(templatesDir)
+	project := filepath.Join(t.TempDir(), "app")
+	if err := manager.CreateProject(project, "react", "typescript", "1.0.0", nil); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	// Local edits: one that merges cleanly, two that conflict, and one to a
+	// file the template removes.
+	writeFiles(t, project, map[string]string{
+		"public/logo.png": "\x89PNG\x00local",
+		"src/App.tsx":     "import React from 'react';\n\nexport default function App() {\n  return <h1>Hello, world</h1>;\n}\n",
+		"tsconfig.json":   "{\n  \"strict\": \"local\"\n}\n",
+		"src/removed.ts":  "export const kept = true;\n",
+	})
+
+	dry, err := manager.Upgrade(project, UpgradeOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Upgrade(dry run) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".eslintrc")); !os.IsNotExist(err) {
+		t.Errorf("Dry run wrote files")
+	}
+
+	result, err := manager.Upgrade(project, UpgradeOptions{})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if result.From != "1.0.0" || result.To != "1.1.0" {
+		t.Errorf("Upgrade() from %s to %s, want 1.0.0 to 1.1.0", result.From, result.To)
+	}
+	if len(dry.Files) != len(result.Files) {
+		t.Errorf("Dry run reported %d changes, upgrade made %d", len(dry.Files), len(result.Files))
+	}
+
+	want := map[string]string{
+		".eslintrc":           ActionAdded,
+		"src/App.tsx":         ActionMerged,
+		"src/index.css":       ActionUpdated,
+		"src/legacy.ts":       ActionRemoved,
+		"src/removed.ts":      ActionKept,
+		"tsconfig.json":       ActionConflict,
+		"public/logo.png.new": ActionConflict,
+	}
+	got := map[string]string{}
+	for _, f := range result.Files {
+		got[f.Path] = f.Action
+	}
+	for path, action := range want {
+		if got[path] != action {
+			t.Errorf("%s: action = %q, want %q", path, got[path], action)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Upgrade() changed %v, want %v", got, want)
+	}
+
+	app, _ := os.ReadFile(filepath.Join(project, "src/App.tsx"))
+	if !strings.Contains(string(app), "import './index.css';") || !strings.Contains(string(app), "Hello, world") {
+		t.Errorf("App.tsx not merged:\n%s", app)
+	}
+	tsconfig, _ := os.ReadFile(filepath.Join(project, "tsconfig.json"))
+	if !strings.Contains(string(tsconfig), "<<<<<<< local") || !strings.Contains(string(tsconfig), ">>>>>>> react-typescript 1.1.0") {
+		t.Errorf("tsconfig.json has no conflict markers:\n%s", tsconfig)
+	}
+	logo, _ := os.ReadFile(filepath.Join(project, "public/logo.png"))
+	logoNew, _ := os.ReadFile(filepath.Join(project, "public/logo.png.new"))
+	if string(logo) != "\x89PNG\x00local" || string(logoNew) != "\x89PNG\x00v2" {
+		t.Errorf("Binary conflict wrote logo.png = %q, logo.png.new = %q", logo, logoNew)
+	}
+	if _, err := os.Stat(filepath.Join(project, "src/legacy.ts")); !os.IsNotExist(err) {
+		t.Errorf("src/legacy.ts was not removed")
+	}
+
+	record, err := LoadRecord(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Version != "1.1.0" || record.UpdatedAt.IsZero() {
+		t.Errorf("Record not updated: %+v", record)
+	}
+
+	again, err := manager.Upgrade(project, UpgradeOptions{})
+	if err != nil || len(again.Files) != 0 {
+		t.Errorf("Second Upgrade() = %+v, %v; want no changes", again, err)
+	}
+}
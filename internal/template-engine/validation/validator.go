@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/skbhati199/go-web-build/internal/template-engine/version"
 )
 
 type TemplateMetadata struct {
@@ -80,3 +83,122 @@ func (v *TemplateValidator) validateSyntax(templateName string, files []string)
 	}
 	return nil
 }
+
+// Issue is a single problem found while validating a template.
+type Issue struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// Report collects every problem found in a template. Unlike ValidateTemplate
+// it does not stop at the first failure.
+type Report struct {
+	Template string `json:"template"`
+	// Version is the template version whose files were checked, the one
+	// projects are created from by default.
+	Version  string            `json:"version,omitempty"`
+	Metadata *TemplateMetadata `json:"metadata,omitempty"`
+	Files    []FileCheck       `json:"files"`
+	Issues   []Issue           `json:"issues"`
+}
+
+// FileCheck records whether a file listed in template.json is usable.
+type FileCheck struct {
+	Path  string `json:"path"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Valid reports whether no issues were found.
+func (r *Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *Report) addIssue(check, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateTemplateReport checks template metadata, the listed and required
+// files of the latest version and, when present, versions.json.
+func (v *TemplateValidator) ValidateTemplateReport(templateName string) *Report {
+	report := &Report{Template: templateName, Files: []FileCheck{}, Issues: []Issue{}}
+
+	metadata, err := v.loadMetadata(templateName)
+	if err != nil {
+		report.addIssue("metadata", "failed to load template.json: %v", err)
+		return report
+	}
+	report.Metadata = metadata
+
+	if metadata.Name == "" {
+		report.addIssue("metadata", "name is missing")
+	}
+	if _, err := semver.NewVersion(metadata.Version); err != nil {
+		report.addIssue("metadata", "version %q is not valid semver", metadata.Version)
+	}
+	if len(metadata.Files) == 0 {
+		report.addIssue("files", "template.json lists no files")
+	}
+
+	// Files are listed relative to the version directory, as projects are
+	// generated from it.
+	latest, err := version.NewVersionManager(v.templatesDir).GetLatestVersion(templateName)
+	if err != nil {
+		report.addIssue("versions", "%v", err)
+		return report
+	}
+	report.Version = latest.String()
+	templatePath := filepath.Join(v.templatesDir, templateName, latest.Path)
+	seen := make(map[string]bool)
+	for _, file := range append(append([]string{}, metadata.Files...), metadata.Required...) {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		check := FileCheck{Path: file, OK: true}
+		if _, err := os.ReadFile(filepath.Join(templatePath, file)); err != nil {
+			check.OK = false
+			check.Error = err.Error()
+			report.addIssue("files", "%s is missing or unreadable", file)
+		}
+		report.Files = append(report.Files, check)
+	}
+
+	v.checkVersions(templateName, report)
+	return report
+}
+
+// checkVersions validates versions.json if the template has one.
+func (v *TemplateValidator) checkVersions(templateName string, report *Report) {
+	if _, err := os.Stat(filepath.Join(v.templatesDir, templateName, "versions.json")); os.IsNotExist(err) {
+		return
+	}
+
+	manager := version.NewVersionManager(v.templatesDir)
+	versions, err := manager.GetAllVersions(templateName)
+	if err != nil {
+		report.addIssue("versions", "%v", err)
+		return
+	}
+	latest, stable, err := manager.GetChannels(templateName)
+	if err != nil {
+		report.addIssue("versions", "%v", err)
+		return
+	}
+
+	declared := make(map[string]bool, len(versions))
+	for _, tv := range versions {
+		declared[tv.String()] = true
+	}
+	channels := []struct{ name, value string }{{"latest", latest}, {"stable", stable}}
+	for _, channel := range channels {
+		if channel.value == "" {
+			continue
+		}
+		parsed, err := semver.NewVersion(channel.value)
+		if err != nil || !declared[parsed.String()] {
+			report.addIssue("versions", "%s channel points to undeclared version %q", channel.name, channel.value)
+		}
+	}
+}
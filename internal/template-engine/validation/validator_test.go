@@ -0,0 +1,36 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateTemplateReportUsesVersionPath(t *testing.T) {
+	templatesDir := filepath.Join("..", "..", "..", "templates")
+	report := NewTemplateValidator(templatesDir).ValidateTemplateReport("react-javascript")
+
+	if report.Version != "1.0.0" {
+		t.Errorf("Version = %q, want 1.0.0", report.Version)
+	}
+	if len(report.Files) == 0 {
+		t.Fatalf("Expected file checks, got issues %+v", report.Issues)
+	}
+	for _, file := range report.Files {
+		_, err := os.Stat(filepath.Join(templatesDir, "react-javascript", "v1.0.0", file.Path))
+		if exists := err == nil; file.OK != exists {
+			t.Errorf("%s: OK = %v, but exists in v1.0.0 = %v", file.Path, file.OK, exists)
+		}
+	}
+	for _, path := range []string{"src/App.jsx", "components/Layout.jsx"} {
+		found := false
+		for _, file := range report.Files {
+			if file.Path == path {
+				found = file.OK
+			}
+		}
+		if !found {
+			t.Errorf("Expected %s to be found in the version directory", path)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 )
@@ -41,29 +42,45 @@ func NewVersionManager(templatesDir string) *VersionManager {
 	}
 }
 
-func (vm *VersionManager) GetAllVersions(templateName string) ([]*TemplateVersion, error) {
+// versionsFile is the versions.json document stored in a template directory.
+type versionsFile struct {
+	Versions []struct {
+		Version     string                 `json:"version"`
+		Path        string                 `json:"path"`
+		ReleaseDate string                 `json:"releaseDate"`
+		IsLatest    bool                   `json:"isLatest"`
+		Features    []string               `json:"features"`
+		Metadata    map[string]interface{} `json:"metadata"`
+	} `json:"versions"`
+	Latest string `json:"latest"`
+	Stable string `json:"stable"`
+}
+
+func (vm *VersionManager) readVersionsFile(templateName string) (*versionsFile, error) {
 	versionsPath := filepath.Join(vm.templatesDir, templateName, "versions.json")
 	data, err := os.ReadFile(versionsPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read versions file: %w", err)
 	}
 
-	var versionData struct {
-		Versions []struct {
-			Version     string                 `json:"version"`
-			Path        string                 `json:"path"`
-			ReleaseDate string                 `json:"releaseDate"`
-			IsLatest    bool                   `json:"isLatest"`
-			Features    []string               `json:"features"`
-			Metadata    map[string]interface{} `json:"metadata"`
-		} `json:"versions"`
-		Latest string `json:"latest"`
-		Stable string `json:"stable"`
-	}
-
+	var versionData versionsFile
 	if err := json.Unmarshal(data, &versionData); err != nil {
 		return nil, fmt.Errorf("failed to parse versions: %w", err)
 	}
+	return &versionData, nil
+}
+
+// GetAllVersions returns the versions of a template, oldest first. Without
+// versions.json they are the v<semver> subdirectories of the template, or
+// the template directory itself at the version in template.json.
+func (vm *VersionManager) GetAllVersions(templateName string) ([]*TemplateVersion, error) {
+	if _, err := os.Stat(filepath.Join(vm.templatesDir, templateName, "versions.json")); os.IsNotExist(err) {
+		return vm.discoverVersions(templateName)
+	}
+	versionData, err := vm.readVersionsFile(templateName)
+	if err != nil {
+		return nil, err
+	}
 
 	var versions []*TemplateVersion
 	for _, v := range versionData.Versions {
@@ -83,6 +100,60 @@ func (vm *VersionManager) GetAllVersions(templateName string) ([]*TemplateVersio
 	return versions, nil
 }
 
+func (vm *VersionManager) discoverVersions(templateName string) ([]*TemplateVersion, error) {
+	templateDir := filepath.Join(vm.templatesDir, templateName)
+	entries, err := os.ReadDir(templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", templateName, err)
+	}
+
+	var versions []*TemplateVersion
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "v") {
+			continue
+		}
+		version, err := NewTemplateVersion(entry.Name()[1:])
+		if err != nil {
+			continue
+		}
+		version.Path = entry.Name()
+		versions = append(versions, version)
+	}
+
+	if len(versions) == 0 {
+		data, err := os.ReadFile(filepath.Join(templateDir, "template.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template metadata: %w", err)
+		}
+		var metadata struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse template metadata: %w", err)
+		}
+		version, err := NewTemplateVersion(metadata.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template version %q: %w", metadata.Version, err)
+		}
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version.LessThan(versions[j].Version)
+	})
+	return versions, nil
+}
+
+// GetChannels returns the versions published on the latest and stable
+// channels. Either may be empty when versions.json does not declare it.
+func (vm *VersionManager) GetChannels(templateName string) (latest, stable string, err error) {
+	versionData, err := vm.readVersionsFile(templateName)
+	if err != nil {
+		return "", "", err
+	}
+	return versionData.Latest, versionData.Stable, nil
+}
+
 func (vm *VersionManager) GetVersion(templateName, version string) (*TemplateVersion, error) {
 	versions, err := vm.GetAllVersions(templateName)
 	if err != nil {
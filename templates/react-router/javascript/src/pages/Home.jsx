@@ -0,0 +1,16 @@
+import React from 'react';
+import { Link } from 'react-router-dom';
+
+function Home() {
+  return (
+    <main>
+      <h1>{{projectName}}</h1>
+      <p>
+        Add routes in <code>src/App</code>. <Link to="/missing">A broken link</Link> shows the
+        not-found page.
+      </p>
+    </main>
+  );
+}
+
+export default Home;
@@ -0,0 +1,13 @@
+import React from 'react';
+import { Link } from 'react-router-dom';
+
+function NotFound() {
+  return (
+    <main>
+      <h1>Page not found</h1>
+      <Link to="/">Back to the home page</Link>
+    </main>
+  );
+}
+
+export default NotFound;
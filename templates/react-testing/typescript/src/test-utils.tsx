@@ -0,0 +1,19 @@
+import React, { ReactElement } from 'react';
+import { render, RenderOptions } from '@testing-library/react';
+import userEvent from '@testing-library/user-event';
+
+// Wrap components in the app's providers here so every test renders them
+// the same way the app does.
+function Providers({ children }: { children: React.ReactNode }) {
+  return <>{children}</>;
+}
+
+function customRender(ui: ReactElement, options?: Omit<RenderOptions, 'wrapper'>) {
+  return {
+    user: userEvent.setup(),
+    ...render(ui, { wrapper: Providers, ...options }),
+  };
+}
+
+export * from '@testing-library/react';
+export { customRender as render };
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 type TemplateRegistry struct {
@@ -15,13 +16,21 @@ type TemplateRegistry struct {
 type Template struct {
 	Name        string          `json:"name"`
 	Version     string          `json:"version"`
+	Description string          `json:"description,omitempty"`
+	Author      string          `json:"author,omitempty"`
 	Path        string          `json:"path"`
 	Config      json.RawMessage `json:"config"`
 	Files       []string        `json:"files"`
 	Variables   map[string]any  `json:"variables"`
 	Validations map[string]any  `json:"validations"`
+	// Overlay marks a feature overlay, which is applied to an existing
+	// project by "gobuild add" rather than used to create one.
+	Overlay bool `json:"overlay,omitempty"`
 }
 
+// metadataFile marks a directory as a template.
+const metadataFile = "template.json"
+
 func NewRegistry(basePath string) *TemplateRegistry {
 	return &TemplateRegistry{
 		Templates: make(map[string]*Template),
@@ -37,44 +46,40 @@ func (r *TemplateRegistry) Register(name string, template *Template) error {
 	return nil
 }
 
-// Update how templates are registered to match your directory structure
+// LoadTemplates registers every directory containing a template.json. Both
+// flat layouts ("react-typescript") and nested framework/variant layouts
+// ("react/typescript") are supported; the template name is the path relative
+// to BasePath.
 func (r *TemplateRegistry) LoadTemplates() error {
-	// Instead of looking for templates like "react-typescript"
-	// Look for them in nested directories like "react/typescript"
-
-	// Example implementation:
-	frameworks, err := os.ReadDir(r.BasePath)
+	entries, err := os.ReadDir(r.BasePath)
 	if err != nil {
 		return err
 	}
 
-	for _, framework := range frameworks {
-		if !framework.IsDir() {
+	for _, entry := range entries {
+		if !entry.IsDir() {
 			continue
 		}
 
-		variants, err := os.ReadDir(filepath.Join(r.BasePath, framework.Name()))
-		if err != nil {
+		dir := filepath.Join(r.BasePath, entry.Name())
+		if isTemplateDir(dir) {
+			if err := r.loadTemplate(entry.Name(), dir); err != nil {
+				return err
+			}
 			continue
 		}
 
+		variants, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
 		for _, variant := range variants {
-			if !variant.IsDir() {
+			variantDir := filepath.Join(dir, variant.Name())
+			if !variant.IsDir() || !isTemplateDir(variantDir) {
 				continue
 			}
-
-			templatePath := filepath.Join(r.BasePath, framework.Name(), variant.Name())
-			// Load template from this path...
-
-			// Example:
-			template := &Template{
-				Name:    fmt.Sprintf("%s/%s", framework.Name(), variant.Name()),
-				Version: "1.0.0",
-				Path:    templatePath,
-				// ... other fields
-			}
-
-			if err := r.Register(template.Name, template); err != nil {
+			name := fmt.Sprintf("%s/%s", entry.Name(), variant.Name())
+			if err := r.loadTemplate(name, variantDir); err != nil {
 				return err
 			}
 		}
@@ -82,3 +87,59 @@ func (r *TemplateRegistry) LoadTemplates() error {
 
 	return nil
 }
+
+// Names returns the registered template names in sorted order.
+func (r *TemplateRegistry) Names() []string {
+	names := make([]string, 0, len(r.Templates))
+	for name := range r.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProjectNames returns the sorted names of the templates projects can be
+// created from, leaving out feature overlays.
+func (r *TemplateRegistry) ProjectNames() []string {
+	names := make([]string, 0, len(r.Templates))
+	for _, name := range r.Names() {
+		if !r.Templates[name].Overlay {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Get returns a registered template.
+func (r *TemplateRegistry) Get(name string) (*Template, error) {
+	template, ok := r.Templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return template, nil
+}
+
+func (r *TemplateRegistry) loadTemplate(name, dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return fmt.Errorf("failed to read %s metadata: %w", name, err)
+	}
+
+	template := &Template{}
+	if err := json.Unmarshal(data, template); err != nil {
+		return fmt.Errorf("failed to parse %s metadata: %w", name, err)
+	}
+	template.Name = name
+	template.Path = dir
+	template.Config = data
+	if template.Version == "" {
+		template.Version = "1.0.0"
+	}
+
+	return r.Register(name, template)
+}
+
+func isTemplateDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, metadataFile))
+	return err == nil && !info.IsDir()
+}